@@ -17,7 +17,9 @@ package cli
 
 import (
 	"github.com/sigstore/cosign/cmd/cosign/cli/attach"
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/spf13/cobra"
 )
 
@@ -58,11 +60,28 @@ func attachSBOM() *cobra.Command {
 	o := &options.AttachSBOMOptions{}
 
 	cmd := &cobra.Command{
-		Use:     "sbom",
-		Short:   "Attach sbom to the supplied container image",
-		Example: "  cosign attach sbom <image uri>",
-		Args:    cobra.ExactArgs(1),
+		Use:   "sbom",
+		Short: "Attach sbom to the supplied container image",
+		Example: `  cosign attach sbom <image uri>
+
+  # attach an SPDX SBOM as a signed in-toto attestation
+  cosign attach sbom --sbom sbom.spdx --type spdx --wrap-dsse --key cosign.key <image uri>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.WrapDSSE {
+				ko := sign.KeyOpts{
+					KeyRef:                   o.Key,
+					PassFunc:                 generate.GetPass,
+					FulcioURL:                o.Fulcio.URL,
+					IDToken:                  o.Fulcio.IdentityToken,
+					InsecureSkipFulcioVerify: o.Fulcio.InsecureSkipFulcioVerify,
+					RekorURL:                 o.Rekor.URL,
+					OIDCIssuer:               o.OIDC.Issuer,
+					OIDCClientID:             o.OIDC.ClientID,
+					OIDCClientSecret:         o.OIDC.ClientSecret,
+				}
+				return attach.SBOMAttestationCmd(cmd.Context(), ko, o.Registry, o.SBOM, o.SBOMType, args[0])
+			}
 			mediaType, err := o.MediaType()
 			if err != nil {
 				return err
@@ -22,13 +22,18 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sigstore/cosign/cmd/cosign/cli/attest"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
 	"github.com/sigstore/cosign/pkg/oci/static"
+	"github.com/sigstore/cosign/pkg/sbom"
+	ctypes "github.com/sigstore/cosign/pkg/types"
 )
 
 func SBOMCmd(ctx context.Context, regOpts options.RegistryOptions, sbomRef string, sbomType types.MediaType, imageRef string) error {
@@ -60,6 +65,86 @@ func SBOMCmd(ctx context.Context, regOpts options.RegistryOptions, sbomRef strin
 	return remote.Write(dstRef, img, regOpts.GetRegistryClientOpts(ctx)...)
 }
 
+// SBOMAttestationCmd wraps the SBOM at sbomRef in an in-toto statement of the given
+// type, signs it as a DSSE envelope and attaches it to imageRef as an attestation.
+func SBOMAttestationCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOptions, sbomRef string, sbomType string, imageRef string) error {
+	switch sbomType {
+	case options.PredicateSPDX, options.PredicateCycloneDX:
+	default:
+		return fmt.Errorf("SBOM type %q cannot be wrapped in an attestation, expected (spdx|cyclonedx)", sbomType)
+	}
+
+	b, err := sbomBytes(sbomRef)
+	if err != nil {
+		return err
+	}
+
+	// AttestCmd reads the predicate from a file, which sbomRef need not be.
+	f, err := os.CreateTemp("", "cosign-sbom-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Attaching SBOM for [%s] as a signed %s attestation.\n", imageRef, sbomType)
+	return attest.AttestCmd(ctx, ko, regOpts, imageRef, "", false, f.Name(), false, sbomType, false, 0, 0, "")
+}
+
+// GenerateSBOMCmd generates an SPDX SBOM of the dpkg and rpm packages installed in
+// imageRef and attaches it to the image, as a signed attestation if wrapDSSE is set.
+// If outputPath is set, the SBOM is also written there.
+func GenerateSBOMCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOptions, imageRef string, wrapDSSE bool, outputPath string) error {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return err
+	}
+	img, err := remote.Image(ref, regOpts.GetRegistryClientOpts(ctx)...)
+	if err != nil {
+		return err
+	}
+	// Attach the SBOM to the image it describes, rather than to an index ref resolves to.
+	h, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	digestRef := ref.Context().Digest(h.String())
+
+	inv, err := sbom.Scan(img)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Found %d packages in [%s].\n", len(inv.Packages), digestRef.Name())
+	b, err := sbom.SPDX(ref.Context().Name(), h, inv, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		f, err := os.CreateTemp("", "cosign-sbom-*.spdx.json")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		f.Close()
+		outputPath = f.Name()
+	}
+	if err := os.WriteFile(outputPath, b, 0600); err != nil {
+		return err
+	}
+
+	if wrapDSSE {
+		return SBOMAttestationCmd(ctx, ko, regOpts, outputPath, options.PredicateSPDX, digestRef.Name())
+	}
+	return SBOMCmd(ctx, regOpts, outputPath, ctypes.SPDXJSONMediaType, digestRef.Name())
+}
+
 func sbomBytes(sbomRef string) ([]byte, error) {
 	// sbomRef can be "-", a string or a file.
 	switch signatureType(sbomRef) {
@@ -39,6 +39,9 @@ func Attest() *cobra.Command {
   # attach an attestation to a container image with a local key pair file
   cosign attest --predicate <FILE> --type <TYPE> --key cosign.key <IMAGE>
 
+  # attach an attestation read from standard input
+  cat <FILE> | cosign attest --predicate - --type <TYPE> --key cosign.key <IMAGE>
+
   # attach an attestation to a container image with a key pair stored in Azure Key Vault
   cosign attest --predicate <FILE> --type <TYPE> --key azurekms://[VAULT_NAME][VAULT_URI]/[KEY] <IMAGE>
 
@@ -69,9 +72,13 @@ func Attest() *cobra.Command {
 				OIDCClientID:             o.OIDC.ClientID,
 				OIDCClientSecret:         o.OIDC.ClientSecret,
 			}
+			// Standard input can only be read once.
+			if o.Predicate.Path == "-" && len(args) > 1 {
+				return errors.New("--predicate - can only be used with a single image")
+			}
 			for _, img := range args {
 				if err := attest.AttestCmd(cmd.Context(), ko, o.Registry, img, o.Cert, o.NoUpload,
-					o.Predicate.Path, o.Force, o.Predicate.Type, o.Replace, o.Timeout); err != nil {
+					o.Predicate.Path, o.Force, o.Predicate.Type, o.Replace, o.Timeout, o.Predicate.MaxSize, o.Predicate.Schema); err != nil {
 					return errors.Wrapf(err, "signing %s", img)
 				}
 			}
@@ -21,12 +21,14 @@ import (
 	_ "crypto/sha256" // for `crypto.SHA256`
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
 
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
@@ -46,9 +48,16 @@ import (
 	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
 )
 
+// DefaultMaxPredicateSize is the largest predicate AttestCmd reads when no
+// other limit is given.
+const DefaultMaxPredicateSize = 64 << 20
+
 type tlogUploadFn func(*client.Rekor, []byte) (*models.LogEntryAnon, error)
 
 func uploadToTlog(ctx context.Context, sv *sign.SignerVerifier, rekorURL string, upload tlogUploadFn) (*cbundle.RekorBundle, error) {
+	if err := sv.CheckTlogUpload(); err != nil {
+		return nil, err
+	}
 	var rekorBytes []byte
 	// Upload the cert or the public key, depending on what we have
 	if sv.Cert != nil {
@@ -75,7 +84,8 @@ func uploadToTlog(ctx context.Context, sv *sign.SignerVerifier, rekorURL string,
 
 //nolint
 func AttestCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOptions, imageRef string, certPath string,
-	noUpload bool, predicatePath string, force bool, predicateType string, replace bool, timeout time.Duration) error {
+	noUpload bool, predicatePath string, force bool, predicateType string, replace bool, timeout time.Duration,
+	maxPredicateSize int64, predicateSchema string) error {
 	// A key file or token is required unless we're in experimental mode!
 	if options.EnableExperimental() {
 		if options.NOf(ko.KeyRef, ko.Sk) > 1 {
@@ -117,6 +127,16 @@ func AttestCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOpt
 	// each access.
 	ref = digest // nolint
 
+	predicate, err := readPredicate(predicatePath, maxPredicateSize)
+	if err != nil {
+		return err
+	}
+	if predicateSchema != "" {
+		if err := validatePredicate(predicate, predicateSchema); err != nil {
+			return err
+		}
+	}
+
 	sv, err := sign.SignerFromKeyOpts(ctx, certPath, ko)
 	if err != nil {
 		return errors.Wrap(err, "getting signer")
@@ -125,15 +145,8 @@ func AttestCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOpt
 	wrapped := dsse.WrapSigner(sv, types.IntotoPayloadType)
 	dd := cremote.NewDupeDetector(sv)
 
-	fmt.Fprintln(os.Stderr, "Using payload from:", predicatePath)
-	predicate, err := os.Open(predicatePath)
-	if err != nil {
-		return err
-	}
-	defer predicate.Close()
-
 	sh, err := attestation.GenerateStatement(attestation.GenerateOpts{
-		Predicate: predicate,
+		Predicate: bytes.NewReader(predicate),
 		Type:      predicateType,
 		Digest:    h.Hex,
 		Repo:      digest.Repository.String(),
@@ -187,7 +200,7 @@ func AttestCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOpt
 	}
 
 	if replace {
-		ro := cremote.NewReplaceOp(predicateURI)
+		ro := cremote.NewReplaceOp(predicateURI, sv)
 		signOpts = append(signOpts, mutate.WithReplaceOp(ro))
 	}
 
@@ -200,3 +213,58 @@ func AttestCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOpt
 	// Publish the attestations associated with this entity
 	return ociremote.WriteAttestations(digest.Repository, newSE, ociremoteOpts...)
 }
+
+// readPredicate reads the predicate at path, or from stdin if path is "-",
+// refusing predicates larger than maxSize bytes. A maxSize of zero or less
+// means DefaultMaxPredicateSize.
+func readPredicate(path string, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxPredicateSize
+	}
+
+	var r io.Reader
+	if path == "-" {
+		fmt.Fprintln(os.Stderr, "Using payload from: standard input")
+		r = os.Stdin
+	} else {
+		fmt.Fprintln(os.Stderr, "Using payload from:", path)
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// Read one byte past the limit so an oversized predicate can be told apart
+	// from one that is exactly maxSize bytes.
+	b, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading predicate")
+	}
+	if int64(len(b)) > maxSize {
+		return nil, fmt.Errorf("predicate is larger than the maximum of %d bytes", maxSize)
+	}
+	return b, nil
+}
+
+// validatePredicate checks predicate against the JSON Schema at schemaPath,
+// printing each violation to stderr.
+func validatePredicate(predicate []byte, schemaPath string) error {
+	schema, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return errors.Wrap(err, "reading predicate schema")
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewBytesLoader(predicate))
+	if err != nil {
+		return errors.Wrap(err, "validating predicate against schema")
+	}
+	if !result.Valid() {
+		fmt.Fprintf(os.Stderr, "The predicate does not match the schema %s:\n", schemaPath)
+		for _, e := range result.Errors() {
+			fmt.Fprintf(os.Stderr, "- %s\n", e)
+		}
+		return fmt.Errorf("predicate failed validation with %d errors", len(result.Errors()))
+	}
+	return nil
+}
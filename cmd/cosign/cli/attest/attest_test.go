@@ -0,0 +1,92 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// pipeStdin replaces os.Stdin with a pipe fed with contents for the rest of the test.
+func pipeStdin(t *testing.T, contents string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		w.WriteString(contents) //nolint: errcheck
+		w.Close()
+	}()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+}
+
+func TestReadPredicateFromStdin(t *testing.T) {
+	predicate := `{ "builder": { "id": "2" }, "recipe": {} }`
+	pipeStdin(t, predicate)
+
+	got, err := readPredicate("-", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != predicate {
+		t.Errorf("readPredicate() = %q, want %q", got, predicate)
+	}
+}
+
+func TestReadPredicateTooLarge(t *testing.T) {
+	predicate := `{ "builder": { "id": "2" }, "recipe": {} }`
+	pipeStdin(t, predicate)
+
+	if _, err := readPredicate("-", int64(len(predicate)-1)); err == nil {
+		t.Error("expected error reading a predicate over the size limit")
+	}
+
+	// A predicate exactly at the limit is accepted.
+	path := filepath.Join(t.TempDir(), "predicate.json")
+	if err := os.WriteFile(path, []byte(predicate), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPredicate(path, int64(len(predicate))); err != nil {
+		t.Errorf("readPredicate() returned error for a predicate at the size limit: %v", err)
+	}
+}
+
+func TestValidatePredicate(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schema, []byte(`{
+  "type": "object",
+  "properties": {"builder": {"type": "object", "required": ["id"]}},
+  "required": ["builder"]
+}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validatePredicate([]byte(`{ "builder": { "id": "2" }, "recipe": {} }`), schema); err != nil {
+		t.Errorf("validatePredicate() = %v", err)
+	}
+	for _, predicate := range []string{`{ "recipe": {} }`, `{ "builder": {} }`, `not json`} {
+		if err := validatePredicate([]byte(predicate), schema); err == nil {
+			t.Errorf("validatePredicate(%s) succeeded, want an error", predicate)
+		}
+	}
+}
@@ -72,6 +72,7 @@ func New() *cobra.Command {
 	cmd.AddCommand(Copy())
 	cmd.AddCommand(Dockerfile())
 	cmd.AddCommand(Download())
+	cmd.AddCommand(Env())
 	cmd.AddCommand(Generate())
 	cmd.AddCommand(GenerateKeyPair())
 	cmd.AddCommand(ImportKeyPair())
@@ -89,6 +90,7 @@ func New() *cobra.Command {
 	cmd.AddCommand(Verify())
 	cmd.AddCommand(VerifyAttestation())
 	cmd.AddCommand(VerifyBlob())
+	cmd.AddCommand(VerifyBlobAttestation())
 	cmd.AddCommand(Triangulate())
 	cmd.AddCommand(Version())
 
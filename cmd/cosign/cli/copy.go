@@ -28,11 +28,16 @@ func Copy() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "copy",
 		Short: "Copy the supplied container image and signatures.",
+		Long: `Copy the supplied container image and signatures. With --all, the image's
+attestations and SBOM are copied as well.`,
 		Example: `  cosign copy <source image> <destination image>
 
   # copy a container image and its signatures
   cosign copy example.com/src:latest example.com/dest:latest
 
+  # copy a container image with its signatures, attestations and SBOM
+  cosign copy --all example.com/src:latest example.com/dest:latest
+
   # copy the signatures only
   cosign copy --sig-only example.com/src example.com/dest
 
@@ -41,7 +46,7 @@ func Copy() *cobra.Command {
 
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return copy.CopyCmd(cmd.Context(), o.Registry, args[0], args[1], o.SignatureOnly, o.Force)
+			return copy.CopyCmd(cmd.Context(), o.Registry, args[0], args[1], o.SignatureOnly, o.All, o.Force)
 		},
 	}
 
@@ -16,18 +16,25 @@ package copy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
 )
 
 // CopyCmd implements the logic to copy the supplied container image and signatures.
+// With copyAll, the image's attestations and SBOM are copied too, if it has them.
 // nolint
-func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstImg string, sigOnly, force bool) error {
+func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstImg string, sigOnly, copyAll, force bool) error {
+	if sigOnly && copyAll {
+		return errors.New("--sig-only and --all cannot be used together")
+	}
 	srcRef, err := name.ParseReference(srcImg)
 	if err != nil {
 		return err
@@ -49,6 +56,19 @@ func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstIm
 		return err
 	}
 
+	if copyAll {
+		for _, tagFn := range []func(name.Reference, ...ociremote.Option) (name.Tag, error){ociremote.AttestationTag, ociremote.SBOMTag} {
+			srcTag, err := tagFn(srcRef, ociremote.WithRemoteOptions(remoteOpts...))
+			if err != nil {
+				return err
+			}
+			// Not every image has attestations or an SBOM.
+			if err := copyImage(srcTag, dstRepoRef.Tag(srcTag.Identifier()), force, remoteOpts...); err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+	}
+
 	if !sigOnly {
 		return copyImage(srcRef, dstRef, force, remoteOpts...)
 	}
@@ -56,6 +76,11 @@ func CopyCmd(ctx context.Context, regOpts options.RegistryOptions, srcImg, dstIm
 	return nil
 }
 
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
+
 func descriptorsEqual(a, b *v1.Descriptor) bool {
 	if a == nil || b == nil {
 		return a == nil && b == nil
@@ -17,6 +17,8 @@ package cli
 
 import (
 	"github.com/sigstore/cosign/cmd/cosign/cli/dockerfile"
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/sigstore/cosign/cmd/cosign/cli/verify"
 	"github.com/spf13/cobra"
 
@@ -30,12 +32,58 @@ func Dockerfile() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		dockerfileSign(),
 		dockerfileVerify(),
 	)
 
 	return cmd
 }
 
+func dockerfileSign() *cobra.Command {
+	o := &options.SignDockerfileOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Sign a Dockerfile, embedding the signature in it as a LABEL",
+		Long: `Sign the SHA-256 digest of a Dockerfile's contents and append the base64-encoded
+signature to it as a sigstore.signature LABEL, or write it to a sidecar file.
+
+Any existing sigstore.signature LABEL is replaced, and is not covered by the signature.`,
+		Example: `  cosign dockerfile sign --key <key path>|<kms uri> [--file <path/to/Dockerfile>]
+
+  # sign the Dockerfile in the current directory with a local key pair file
+  cosign dockerfile sign --key cosign.key
+
+  # sign a Dockerfile, writing the signature to <path/to/Dockerfile>.sig
+  cosign dockerfile sign --key cosign.key --file <path/to/Dockerfile> --sidecar
+
+  # sign a Dockerfile with a key pair stored in Google Cloud KMS
+  cosign dockerfile sign --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] --file <path/to/Dockerfile>
+
+  # verify the signature
+  cosign dockerfile verify --dockerfile-signature --key cosign.pub <path/to/Dockerfile>`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !options.OneOf(o.Key, o.SecurityKey.Use) {
+				return &options.KeyParseError{}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ko := sign.KeyOpts{
+				KeyRef:   o.Key,
+				PassFunc: generate.GetPass,
+				Sk:       o.SecurityKey.Use,
+				Slot:     o.SecurityKey.Slot,
+			}
+			return dockerfile.SignDockerfileCmd(cmd.Context(), ko, o.File, o.Sidecar)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
 func dockerfileVerify() *cobra.Command {
 	o := &options.VerifyDockerfileOptions{}
 
@@ -54,6 +102,9 @@ Shell-like variables in the Dockerfile's FROM lines will be substituted with val
   # only verify the base image (the last FROM image)
   cosign dockerfile verify --base-image-only <path/to/Dockerfile>
 
+  # verify the signature 'cosign dockerfile sign' made on the Dockerfile itself
+  cosign dockerfile verify --dockerfile-signature --key cosign.pub <path/to/Dockerfile>
+
   # additionally verify specified annotations
   cosign dockerfile verify -a key1=val1 -a key2=val2 <path/to/Dockerfile>
 
@@ -79,22 +130,47 @@ Shell-like variables in the Dockerfile's FROM lines will be substituted with val
   cosign dockerfile verify --key hashivault://[KEY] <path/to/Dockerfile>`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.DockerfileSignature {
+				ko := sign.KeyOpts{
+					KeyRef: o.Key,
+					Sk:     o.SecurityKey.Use,
+					Slot:   o.SecurityKey.Slot,
+				}
+				return dockerfile.VerifyDockerfileSignatureCmd(cmd.Context(), ko, args[0])
+			}
 			annotations, err := o.AnnotationsMap()
 			if err != nil {
 				return err
 			}
+			requiredAnnotations, err := options.ParseSignatureAnnotations(o.RequireAnnotations)
+			if err != nil {
+				return err
+			}
 			v := &dockerfile.VerifyDockerfileCommand{
 				VerifyCommand: verify.VerifyCommand{
-					RegistryOptions: o.Registry,
-					CheckClaims:     o.CheckClaims,
-					KeyRef:          o.Key,
-					CertEmail:       o.CertEmail,
-					Sk:              o.SecurityKey.Use,
-					Slot:            o.SecurityKey.Slot,
-					Output:          o.Output,
-					RekorURL:        o.Rekor.URL,
-					Attachment:      o.Attachment,
-					Annotations:     annotations,
+					RegistryOptions:      o.Registry,
+					CheckClaims:          o.CheckClaims,
+					KeyRef:               o.Key,
+					CertEmail:            o.CertEmail,
+					CertOidcIssuerRegexp: o.CertOidcIssuerRegexp,
+					TSACertChain:         o.TSACertChain,
+					TUFRoot:              o.TUFRoot,
+					TUFMirror:            o.TUFMirror,
+					Offline:              o.Offline,
+					MaxWorkers:           o.MaxWorkers,
+					Sk:                   o.SecurityKey.Use,
+					Slot:                 o.SecurityKey.Slot,
+					Output:               o.Output,
+					OutputFormat:         o.OutputFormat,
+					RekorURL:             o.Rekor.URL,
+					Attachment:           o.Attachment,
+					Annotations:          annotations,
+					RequiredAnnotations:  requiredAnnotations,
+					RequireIdentity:      o.RequireIdentity,
+					SearchRekor:          o.SearchRekor,
+					Threshold:            o.Threshold,
+					AdditionalKeyRefs:    o.AdditionalKeys,
+					ExperimentalOCI2:     o.ExperimentalOCI2,
 				},
 				BaseOnly: o.BaseImageOnly,
 			}
@@ -103,6 +179,8 @@ Shell-like variables in the Dockerfile's FROM lines will be substituted with val
 	}
 
 	o.AddFlags(cmd)
+	// The images come from the input file, so --images-file doesn't apply.
+	_ = cmd.Flags().MarkHidden("images-file")
 
 	return cmd
 }
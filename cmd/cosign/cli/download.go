@@ -32,6 +32,7 @@ func Download() *cobra.Command {
 		downloadSignature(),
 		downloadSBOM(),
 		downloadAttestation(),
+		downloadConfig(),
 	)
 
 	return cmd
@@ -56,15 +57,18 @@ func downloadSignature() *cobra.Command {
 }
 
 func downloadSBOM() *cobra.Command {
-	o := &options.RegistryOptions{}
+	o := &options.DownloadSBOMOptions{}
 
 	cmd := &cobra.Command{
-		Use:     "sbom",
-		Short:   "Download SBOMs from the supplied container image",
-		Example: "  cosign download sbom <image uri>",
-		Args:    cobra.ExactArgs(1),
+		Use:   "sbom",
+		Short: "Download SBOMs from the supplied container image",
+		Example: `  cosign download sbom <image uri>
+
+  # download an SBOM, reading it as CycloneDX JSON whatever its media type
+  cosign download sbom --format cyclonedx-json <image uri>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := download.SBOMCmd(cmd.Context(), *o, args[0], cmd.OutOrStdout())
+			_, err := download.SBOMCmd(cmd.Context(), o.Registry, o.Format, args[0], cmd.OutOrStdout())
 			return err
 		},
 	}
@@ -74,6 +78,27 @@ func downloadSBOM() *cobra.Command {
 	return cmd
 }
 
+func downloadConfig() *cobra.Command {
+	o := &options.DownloadConfigOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Download the config of the supplied container image",
+		Example: `  cosign download config <image uri>
+
+  # write the config to a file
+  cosign download config --output config.json <image uri>`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return download.ConfigCmd(cmd.Context(), o.Registry, args[0], o.Output, cmd.OutOrStdout())
+		},
+	}
+
+	o.AddFlags(cmd)
+
+	return cmd
+}
+
 func downloadAttestation() *cobra.Command {
 	o := &options.RegistryOptions{}
 
@@ -0,0 +1,67 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+)
+
+// ConfigCmd fetches the config of the image imageRef, without any of its layers, and
+// writes it as indented JSON to out, or to the file outputPath if it is set.
+func ConfigCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef, outputPath string, out io.Writer) error {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return err
+	}
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return err
+	}
+	img, err := ociremote.SignedImage(ref, ociremoteOpts...)
+	if err != nil {
+		return err
+	}
+	config, err := img.RawConfigFile()
+	if err != nil {
+		return errors.Wrap(err, "fetching image config")
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, config, "", "  "); err != nil {
+		return errors.Wrap(err, "formatting image config")
+	}
+	buf.WriteByte('\n')
+
+	if outputPath != "" {
+		if err := os.WriteFile(outputPath, buf.Bytes(), 0600); err != nil {
+			return errors.Wrap(err, "writing image config")
+		}
+		fmt.Fprintln(os.Stderr, "Wrote image config to", outputPath)
+		return nil
+	}
+	_, err = out.Write(buf.Bytes())
+	return err
+}
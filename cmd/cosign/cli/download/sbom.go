@@ -16,17 +16,34 @@
 package download
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+	ctypes "github.com/sigstore/cosign/pkg/types"
 )
 
-func SBOMCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef string, out io.Writer) ([]string, error) {
+// SBOMCmd writes the SBOM attached to imageRef to out, pretty-printed according to
+// format, and returns it as stored. With options.SBOMFormatAuto the format is
+// detected from the SBOM's media type.
+func SBOMCmd(ctx context.Context, regOpts options.RegistryOptions, format, imageRef string, out io.Writer) ([]string, error) {
+	switch format {
+	case options.SBOMFormatAuto, options.SBOMFormatSPDXJSON, options.SBOMFormatSPDXTagValue,
+		options.SBOMFormatCycloneDXJSON, options.SBOMFormatCycloneDXXML:
+	default:
+		return nil, fmt.Errorf("unknown SBOM format: %q, expected (auto|spdx-json|spdx-tv|cyclonedx-json|cyclonedx-xml)", format)
+	}
+
 	ref, err := name.ParseReference(imageRef)
 	if err != nil {
 		return nil, err
@@ -61,8 +78,55 @@ func SBOMCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef stri
 		return nil, err
 	}
 
+	if format == options.SBOMFormatAuto {
+		format = detectSBOMFormat(mt, sbom)
+	}
+	pretty, err := formatSBOM(format, sbom)
+	if err != nil {
+		return nil, err
+	}
+
 	sboms = append(sboms, string(sbom))
-	fmt.Fprintln(out, string(sbom))
+	fmt.Fprintln(out, string(pretty))
 
 	return sboms, nil
 }
+
+// detectSBOMFormat returns the format of an SBOM of media type mt, or "" if it is
+// not one we know. CycloneDX SBOMs attached without a +json or +xml suffix are told
+// apart by their content.
+func detectSBOMFormat(mt types.MediaType, sbom []byte) string {
+	switch {
+	case mt == ctypes.SPDXJSONMediaType, mt == ctypes.SPDXMediaType+"+json":
+		return options.SBOMFormatSPDXJSON
+	case mt == ctypes.SPDXMediaType:
+		return options.SBOMFormatSPDXTagValue
+	case strings.HasPrefix(string(mt), ctypes.CycloneDXMediaType):
+		switch {
+		case strings.HasSuffix(string(mt), "+json"):
+			return options.SBOMFormatCycloneDXJSON
+		case strings.HasSuffix(string(mt), "+xml"):
+			return options.SBOMFormatCycloneDXXML
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(sbom), []byte("<")) {
+			return options.SBOMFormatCycloneDXXML
+		}
+		return options.SBOMFormatCycloneDXJSON
+	}
+	return ""
+}
+
+// formatSBOM pretty-prints the JSON formats. The tag-value and XML formats, and
+// SBOMs of unknown format, are returned as they are.
+func formatSBOM(format string, sbom []byte) ([]byte, error) {
+	switch format {
+	case options.SBOMFormatSPDXJSON, options.SBOMFormatCycloneDXJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, sbom, "", "  "); err != nil {
+			return nil, errors.Wrapf(err, "parsing SBOM as %s", format)
+		}
+		return buf.Bytes(), nil
+	default:
+		return sbom, nil
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	ctypes "github.com/sigstore/cosign/pkg/types"
+)
+
+func TestDetectSBOMFormat(t *testing.T) {
+	tests := []struct {
+		mt   types.MediaType
+		sbom string
+		want string
+	}{
+		{ctypes.SPDXJSONMediaType, `{}`, options.SBOMFormatSPDXJSON},
+		{ctypes.SPDXMediaType, "SPDXVersion: SPDX-2.2", options.SBOMFormatSPDXTagValue},
+		{ctypes.CycloneDXMediaType, `{"bomFormat":"CycloneDX"}`, options.SBOMFormatCycloneDXJSON},
+		{ctypes.CycloneDXMediaType, ` <?xml version="1.0"?><bom/>`, options.SBOMFormatCycloneDXXML},
+		{ctypes.CycloneDXMediaType + "+xml", `{}`, options.SBOMFormatCycloneDXXML},
+		{ctypes.SyftMediaType, `{}`, ""},
+	}
+	for _, tt := range tests {
+		if got := detectSBOMFormat(tt.mt, []byte(tt.sbom)); got != tt.want {
+			t.Errorf("detectSBOMFormat(%s, %q) = %q, want %q", tt.mt, tt.sbom, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSBOM(t *testing.T) {
+	got, err := formatSBOM(options.SBOMFormatCycloneDXJSON, []byte(`{"bomFormat":"CycloneDX"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"bomFormat\": \"CycloneDX\"\n}"; string(got) != want {
+		t.Errorf("formatSBOM() = %q, want %q", got, want)
+	}
+
+	if _, err := formatSBOM(options.SBOMFormatSPDXJSON, []byte("SPDXVersion: SPDX-2.2")); err == nil {
+		t.Error("expected an error for a tag-value SBOM read as JSON")
+	}
+
+	tv := "SPDXVersion: SPDX-2.2\n"
+	if got, err := formatSBOM(options.SBOMFormatSPDXTagValue, []byte(tv)); err != nil || string(got) != tv {
+		t.Errorf("formatSBOM() = %q, %v, want it unchanged", got, err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/pkg/cosign/tuf"
+	"github.com/sigstore/cosign/pkg/oci"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+	"github.com/sigstore/cosign/pkg/providers/spiffe"
+)
+
+// envVar is an environment variable that changes how cosign behaves.
+type envVar struct {
+	name        string
+	description string
+	// defaultValue is what cosign uses when the variable is unset, if anything.
+	defaultValue func() string
+	// secret variables are redacted unless --no-redact is passed.
+	secret bool
+}
+
+func defaultTUFRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = ""
+	}
+	return filepath.Join(home, ".sigstore", "root")
+}
+
+func constant(s string) func() string {
+	return func() string { return s }
+}
+
+var envVars = []envVar{
+	{name: options.ExperimentalEnv, description: "enables experimental features, such as keyless signing", defaultValue: constant("false")},
+	{name: "COSIGN_PASSWORD", description: "password for encrypted private keys", secret: true},
+	{name: ociremote.RepoOverrideEnvKey, description: "repository to store signatures and attestations in"},
+	{name: oci.DockerMediaTypesEnv, description: "use Docker media types instead of OCI media types", defaultValue: constant("false")},
+	{name: tuf.TufRootEnv, description: "directory of the cached TUF metadata and targets", defaultValue: defaultTUFRoot},
+	{name: tuf.SigstoreNoCache, description: "keep TUF metadata in memory instead of caching it on disk", defaultValue: constant("false")},
+	{name: "SIGSTORE_ROOT_FILE", description: "PEM file of Fulcio roots to use instead of the TUF targets"},
+	{name: "SIGSTORE_CT_LOG_PUBLIC_KEY_FILE", description: "CT log public key to use instead of the TUF targets"},
+	{name: spiffe.SocketPathEnvKey, description: "SPIFFE workload API socket used to obtain an OIDC token", defaultValue: constant("/tmp/spire-agent/public/api.sock")},
+	{name: "GITHUB_TOKEN", description: "token for storing keys as GitHub secrets", secret: true},
+	{name: "GITLAB_TOKEN", description: "token for storing keys as GitLab variables", secret: true},
+	{name: "GITLAB_HOST", description: "GitLab server to store keys on", defaultValue: constant("https://gitlab.com")},
+	{name: "GOOGLE_SERVICE_ACCOUNT_NAME", description: "service account to impersonate for a Google OIDC token"},
+}
+
+func Env() *cobra.Command {
+	o := &options.EnvOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Prints the environment variables cosign recognizes and their values.",
+		Long: `Prints the environment variables cosign recognizes and their values.
+
+Variables that are not set are shown as <unset>, along with the value cosign uses
+instead, if any. The values of secrets are redacted unless --no-redact is passed.`,
+		Example: "  cosign env [--no-redact]",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return EnvCmd(cmd.OutOrStdout(), o.NoRedact)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+// EnvCmd writes the name, value and description of each environment variable
+// cosign recognizes to out. Secrets are redacted unless noRedact is set.
+func EnvCmd(out io.Writer, noRedact bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, v := range envVars {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.name, envValue(v, noRedact), v.description)
+	}
+	return w.Flush()
+}
+
+func envValue(v envVar, noRedact bool) string {
+	val, ok := os.LookupEnv(v.name)
+	switch {
+	case !ok && v.defaultValue != nil:
+		return fmt.Sprintf("<unset> (default: %s)", v.defaultValue())
+	case !ok:
+		return "<unset>"
+	case v.secret && !noRedact:
+		return "<redacted>"
+	default:
+		return val
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnvCmd(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "hunter2")
+	t.Setenv("COSIGN_REPOSITORY", "example.com/sigs")
+	t.Setenv("COSIGN_EXPERIMENTAL", "")
+
+	lines := func(noRedact bool) map[string]string {
+		var out bytes.Buffer
+		if err := EnvCmd(&out, noRedact); err != nil {
+			t.Fatal(err)
+		}
+		m := map[string]string{}
+		for _, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			fields := strings.Fields(l)
+			m[fields[0]] = l
+		}
+		return m
+	}
+
+	got := lines(false)
+	if len(got) != len(envVars) {
+		t.Errorf("got %d variables, want %d", len(got), len(envVars))
+	}
+	if l := got["COSIGN_PASSWORD"]; !strings.Contains(l, "<redacted>") || strings.Contains(l, "hunter2") {
+		t.Errorf("COSIGN_PASSWORD should be redacted: %q", l)
+	}
+	if l := got["COSIGN_REPOSITORY"]; !strings.Contains(l, "example.com/sigs") {
+		t.Errorf("COSIGN_REPOSITORY value missing: %q", l)
+	}
+	if l := got["COSIGN_EXPERIMENTAL"]; strings.Contains(l, "<unset>") {
+		t.Errorf("COSIGN_EXPERIMENTAL is set to the empty string, not unset: %q", l)
+	}
+
+	if l := lines(true)["COSIGN_PASSWORD"]; !strings.Contains(l, "hunter2") {
+		t.Errorf("--no-redact should print COSIGN_PASSWORD: %q", l)
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	v := envVar{name: "COSIGN_TEST_ENV_VALUE", defaultValue: constant("false")}
+	if got, want := envValue(v, false), "<unset> (default: false)"; got != want {
+		t.Errorf("envValue() = %q, want %q", got, want)
+	}
+	v.defaultValue = nil
+	if got, want := envValue(v, false), "<unset>"; got != want {
+		t.Errorf("envValue() = %q, want %q", got, want)
+	}
+	t.Setenv(v.name, "")
+	if got := envValue(v, false); got != "" {
+		t.Errorf("envValue() = %q for a variable set to the empty string", got)
+	}
+}
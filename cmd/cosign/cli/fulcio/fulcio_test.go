@@ -16,6 +16,7 @@
 package fulcio
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -113,7 +114,7 @@ func TestGetCertForOauthID(t *testing.T) {
 				err: tc.tokenGetterErr,
 			}
 
-			resp, err := getCertForOauthID(testKey, tscp, &tf, "", "")
+			resp, err := getCertForOauthID(context.Background(), testKey, tscp, &tf, "", "")
 
 			if err != nil {
 				if !tc.expectErr {
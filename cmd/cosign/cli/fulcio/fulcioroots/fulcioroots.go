@@ -63,21 +63,25 @@ func initRoots() (*x509.CertPool, error) {
 			return nil, errors.New("error creating root cert pool")
 		}
 	} else {
-		tuf, err := tuf.NewFromEnv(context.Background())
+		tufClient, err := tuf.NewFromEnv(context.Background())
 		if err != nil {
 			return nil, errors.Wrap(err, "initializing tuf")
 		}
-		defer tuf.Close()
+		defer tufClient.Close()
 		// Retrieve from the embedded or cached TUF root. If expired, a network
 		// call is made to update the root.
 		rootFound := false
 		for _, fulcioTarget := range []string{fulcioTargetStr, fulcioV1TargetStr} {
-			b, err := tuf.GetTarget(fulcioTarget)
-			if err == nil {
-				rootFound = true
-				if !cp.AppendCertsFromPEM(b) {
-					return nil, errors.New("error creating root cert pool")
-				}
+			b, err := tufClient.GetTarget(fulcioTarget)
+			if errors.Is(err, tuf.ErrTargetNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading %s", fulcioTarget)
+			}
+			rootFound = true
+			if !cp.AppendCertsFromPEM(b) {
+				return nil, errors.New("error creating root cert pool")
 			}
 		}
 		if !rootFound {
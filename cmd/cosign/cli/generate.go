@@ -18,8 +18,10 @@ package cli
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/sigstore/cosign/cmd/cosign/cli/attach"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 )
 
 func Generate() *cobra.Command {
@@ -52,6 +54,44 @@ to sign payloads with your own tooling or algorithms.`,
 		},
 	}
 
+	o.AddFlags(cmd)
+	cmd.AddCommand(generateSBOM())
+	return cmd
+}
+
+func generateSBOM() *cobra.Command {
+	o := &options.GenerateSBOMOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sbom",
+		Short: "Generates an SPDX SBOM of the packages installed in the supplied container image and attaches it",
+		Long: `Generates an SPDX SBOM of the packages recorded in the dpkg and rpm databases of the
+supplied container image, and attaches it to the image as "cosign attach sbom" does.
+The SBOM describes, and is attached to, the image manifest digest.`,
+		Example: `  cosign generate sbom <image uri>
+
+  # generate an SBOM, keep a copy and attach it
+  cosign generate sbom --output-sbom sbom.spdx.json <IMAGE>
+
+  # generate an SBOM and attach it as a signed in-toto attestation
+  cosign generate sbom --wrap-dsse --key cosign.key <IMAGE>`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ko := sign.KeyOpts{
+				KeyRef:                   o.Key,
+				PassFunc:                 generate.GetPass,
+				FulcioURL:                o.Fulcio.URL,
+				IDToken:                  o.Fulcio.IdentityToken,
+				InsecureSkipFulcioVerify: o.Fulcio.InsecureSkipFulcioVerify,
+				RekorURL:                 o.Rekor.URL,
+				OIDCIssuer:               o.OIDC.Issuer,
+				OIDCClientID:             o.OIDC.ClientID,
+				OIDCClientSecret:         o.OIDC.ClientSecret,
+			}
+			return attach.GenerateSBOMCmd(cmd.Context(), ko, o.Registry, args[0], o.WrapDSSE, o.Output)
+		},
+	}
+
 	o.AddFlags(cmd)
 	return cmd
 }
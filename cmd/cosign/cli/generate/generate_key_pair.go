@@ -16,6 +16,7 @@
 package generate
 
 import (
+	"bufio"
 	"context"
 	"crypto"
 	"fmt"
@@ -40,7 +41,10 @@ var (
 )
 
 // nolint
-func GenerateKeyPairCmd(ctx context.Context, kmsVal string, args []string) error {
+func GenerateKeyPairCmd(ctx context.Context, kmsVal, keyType string, args []string) error {
+	if keyType != cosign.ECDSAKeyType && (kmsVal != "" || len(args) > 0) {
+		return fmt.Errorf("--key-type %s is only supported for key pairs written to disk", keyType)
+	}
 	if kmsVal != "" {
 		k, err := kms.Get(ctx, kmsVal, crypto.SHA256)
 		if err != nil {
@@ -80,7 +84,7 @@ func GenerateKeyPairCmd(ctx context.Context, kmsVal string, args []string) error
 		return fmt.Errorf("undefined provider: %s", provider)
 	}
 
-	keys, err := cosign.GenerateKeyPair(GetPass)
+	keys, err := cosign.GenerateKeyPairWithType(keyType, GetPass)
 	if err != nil {
 		return err
 	}
@@ -116,6 +120,30 @@ func GetPass(confirm bool) ([]byte, error) {
 	return read()
 }
 
+// PassFromFile returns a cosign.PassFunc that reads the password from the file
+// at path, without any trailing newline.
+func PassFromFile(path string) cosign.PassFunc {
+	return func(bool) ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading password file")
+		}
+		return []byte(strings.TrimRight(string(b), "\r\n")), nil
+	}
+}
+
+// PassFromReader returns a cosign.PassFunc that reads the password from the
+// first line of r.
+func PassFromReader(r io.Reader) cosign.PassFunc {
+	return func(bool) ([]byte, error) {
+		line, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, errors.Wrap(err, "reading password")
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+}
+
 func readPasswordFn(confirm bool) func() ([]byte, error) {
 	pw, ok := os.LookupEnv("COSIGN_PASSWORD")
 	switch {
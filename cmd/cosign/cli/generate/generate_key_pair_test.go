@@ -17,6 +17,8 @@ package generate
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -45,3 +47,38 @@ func TestReadPasswordFn_envEmptyVal(t *testing.T) {
 		t.Fatalf("expected empty string; got %q", string(b))
 	}
 }
+
+func TestPassFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("foo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := PassFromFile(path)(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("foo", string(b)); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if _, err := PassFromFile(filepath.Join(t.TempDir(), "missing"))(false); err == nil {
+		t.Fatal("expected error for a missing password file")
+	}
+}
+
+func TestPassFromReader(t *testing.T) {
+	for in, want := range map[string]string{
+		"foo\nbar\n": "foo",
+		"foo\r\n":    "foo",
+		"foo":        "foo",
+		"":           "",
+	} {
+		b, err := PassFromReader(strings.NewReader(in))(false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(want, string(b)); diff != "" {
+			t.Errorf("%q: %s", in, diff)
+		}
+	}
+}
@@ -34,6 +34,12 @@ func GenerateKeyPair() *cobra.Command {
   # generate key-pair and write to cosign.key and cosign.pub files
   cosign generate-key-pair
 
+  # generate an ED25519 key-pair and write to cosign.key and cosign.pub files
+  cosign generate-key-pair --key-type ed25519
+
+  # generate an RSA key-pair that signs with RSASSA-PSS
+  cosign generate-key-pair --key-type rsa-pss
+
   # generate a key-pair in Azure Key Vault
   cosign generate-key-pair --kms azurekms://[VAULT_NAME][VAULT_URI]/[KEY]
 
@@ -63,7 +69,7 @@ CAVEATS:
   the COSIGN_PASSWORD environment variable to provide one.`,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return generate.GenerateKeyPairCmd(cmd.Context(), o.KMS, args)
+			return generate.GenerateKeyPairCmd(cmd.Context(), o.KMS, o.KeyType, args)
 		},
 	}
 
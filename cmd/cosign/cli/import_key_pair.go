@@ -34,6 +34,9 @@ func ImportKeyPair() *cobra.Command {
   # import PEM-encoded RSA or EC private key and write to import-cosign.key and import-cosign.pub files
   cosign import-key-pair --key <key path>
 
+  # import a PEM-encoded private key from stdin
+  COSIGN_PASSWORD=<password> cosign import-key-pair --key - < <key path>
+
 CAVEATS:
   This command interactively prompts for a password. You can use
   the COSIGN_PASSWORD environment variable to provide one.`,
@@ -17,6 +17,7 @@ package importkeypair
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -31,10 +32,26 @@ var (
 
 // nolint
 func ImportKeyPairCmd(ctx context.Context, keyVal string, args []string) error {
-
-	keys, err := cosign.ImportKeyPair(keyVal, GetPass)
-	if err != nil {
-		return err
+	var keys *cosign.KeysBytes
+	var err error
+	if keyVal == "-" {
+		// The password prompt also reads from stdin, so it has to come from the environment.
+		if _, ok := os.LookupEnv("COSIGN_PASSWORD"); !ok {
+			return errors.New("COSIGN_PASSWORD must be set when reading the private key from stdin")
+		}
+		kb, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		keys, err = cosign.ImportKeyPairFromPEM(kb, GetPass)
+		if err != nil {
+			return err
+		}
+	} else {
+		keys, err = cosign.ImportKeyPair(keyVal, GetPass)
+		if err != nil {
+			return err
+		}
 	}
 
 	if cosign.FileExists("import-cosign.key") {
@@ -34,23 +34,30 @@ The following options are used by default:
  - The current trusted Sigstore TUF root is embedded inside cosign at the time of release.
  - SigStore remote TUF repository is pulled from the GCS mirror at sigstore-tuf-root.
 
-To provide an out-of-band trusted initial root.json, use the -root flag with a file or URL reference.
+To provide an out-of-band trusted initial root.json, use the --root flag with a file or URL reference.
 This will enable you to point cosign to a separate TUF root.
 
-Any updated TUF repository will be written to $HOME/.sigstore/root/.
+Any updated TUF repository will be written to $HOME/.sigstore/root/, or to $TUF_ROOT if it is set.
+
+Initialization also downloads every target, so later commands can start without
+fetching anything. In a container image build, running 'cosign initialize' in a
+layer of its own lets that layer be cached.
 
 Trusted keys and certificate used in cosign verification (e.g. verifying Fulcio issued certificates
 with Fulcio root CA) are pulled form the trusted metadata.`,
-		Example: `cosign initialize -mirror <url> -out <file>
+		Example: `cosign initialize --mirror <url> --root <file>
 
 # initialize root with distributed root keys, default mirror, and default out path.
 cosign initialize
 
 # initialize with an out-of-band root key file, using the default mirror.
-cosign initialize -root <url>
+cosign initialize --root <url>
 
 # initialize with an out-of-band root key file and custom repository mirror.
-cosign initialize -mirror <url> -root <url>`,
+cosign initialize --mirror <url> --root <url>
+
+# warm the TUF cache in its own Dockerfile layer, ahead of signing or verifying
+RUN cosign initialize`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return initialize.DoInitialize(cmd.Context(), o.Root, o.Mirror)
 		},
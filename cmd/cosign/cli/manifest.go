@@ -42,13 +42,17 @@ func manifestVerify() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Verify all signatures of images specified in the manifest",
-		Long: `Verify all signature of images in a Kubernetes resource manifest by checking claims
-against the transparency log.`,
+		Long: `Verify all signature of images in a Kubernetes resource manifest, or in every manifest
+in a directory, by checking claims against the transparency log. Every image is verified,
+and the command fails if any of them does not verify.`,
 		Example: `  cosign manifest verify --key <key path>|<key url>|<kms uri> <path/to/manifest>
 
   # verify cosign claims and signing certificates on images in the manifest
   cosign manifest verify <path/to/my-deployment.yaml>
 
+  # verify images in all the manifests in a directory
+  cosign manifest verify --key cosign.pub <path/to/manifests/>
+
   # additionally verify specified annotations
   cosign manifest verify -a key1=val1 -a key2=val2 <path/to/my-deployment.yaml>
 
@@ -78,18 +82,35 @@ against the transparency log.`,
 			if err != nil {
 				return err
 			}
+			requiredAnnotations, err := options.ParseSignatureAnnotations(o.RequireAnnotations)
+			if err != nil {
+				return err
+			}
 			v := &manifest.VerifyManifestCommand{
 				VerifyCommand: verify.VerifyCommand{
-					RegistryOptions: o.Registry,
-					CheckClaims:     o.CheckClaims,
-					KeyRef:          o.Key,
-					CertEmail:       o.CertEmail,
-					Sk:              o.SecurityKey.Use,
-					Slot:            o.SecurityKey.Slot,
-					Output:          o.Output,
-					RekorURL:        o.Rekor.URL,
-					Attachment:      o.Attachment,
-					Annotations:     annotations,
+					RegistryOptions:      o.Registry,
+					CheckClaims:          o.CheckClaims,
+					KeyRef:               o.Key,
+					CertEmail:            o.CertEmail,
+					CertOidcIssuerRegexp: o.CertOidcIssuerRegexp,
+					TSACertChain:         o.TSACertChain,
+					TUFRoot:              o.TUFRoot,
+					TUFMirror:            o.TUFMirror,
+					Offline:              o.Offline,
+					MaxWorkers:           o.MaxWorkers,
+					Sk:                   o.SecurityKey.Use,
+					Slot:                 o.SecurityKey.Slot,
+					Output:               o.Output,
+					OutputFormat:         o.OutputFormat,
+					RekorURL:             o.Rekor.URL,
+					Attachment:           o.Attachment,
+					Annotations:          annotations,
+					RequiredAnnotations:  requiredAnnotations,
+					RequireIdentity:      o.RequireIdentity,
+					SearchRekor:          o.SearchRekor,
+					Threshold:            o.Threshold,
+					AdditionalKeyRefs:    o.AdditionalKeys,
+					ExperimentalOCI2:     o.ExperimentalOCI2,
 				},
 			}
 			return v.Exec(cmd.Context(), args)
@@ -97,6 +118,8 @@ against the transparency log.`,
 	}
 
 	o.AddFlags(cmd)
+	// The images come from the input file, so --images-file doesn't apply.
+	_ = cmd.Flags().MarkHidden("images-file")
 
 	return cmd
 }
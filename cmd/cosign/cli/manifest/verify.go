@@ -41,27 +41,77 @@ func (c *VerifyManifestCommand) Exec(ctx context.Context, args []string) error {
 		return flag.ErrHelp
 	}
 
-	manifestPath := args[0]
-
-	err := isExtensionAllowed(manifestPath)
+	images, err := getImagesFromPath(args[0])
 	if err != nil {
-		return errors.Wrap(err, "check if extension is valid")
+		return err
 	}
-	manifest, err := os.ReadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("could not read manifest: %w", err)
+	if len(images) == 0 {
+		return errors.New("no images found in manifest")
 	}
+	fmt.Fprintf(os.Stderr, "Extracted image(s): %s\n", strings.Join(images, ", "))
 
-	images, err := getImagesFromYamlManifest(manifest)
+	// Verify each image on its own, so one failure doesn't hide the others.
+	var failed []string
+	for _, img := range images {
+		if err := c.VerifyCommand.Exec(ctx, []string{img}); err != nil {
+			fmt.Fprintf(os.Stderr, "Verification failed for %s: %v\n", img, err)
+			failed = append(failed, img)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d images failed verification: %s", len(failed), len(images), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// getImagesFromPath returns the images referenced by the manifest at path or, if
+// path is a directory, by the manifests anywhere beneath it. Each image is
+// returned once, in the order it is first found.
+func getImagesFromPath(path string) ([]string, error) {
+	fi, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("unable to extract the container image references in the manifest %w", err)
+		return nil, fmt.Errorf("could not read manifest: %w", err)
 	}
-	if len(images) == 0 {
-		return errors.New("no images found in manifest")
+
+	var manifestPaths []string
+	if fi.IsDir() {
+		if err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && isExtensionAllowed(p) == nil {
+				manifestPaths = append(manifestPaths, p)
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("could not read manifests: %w", err)
+		}
+	} else {
+		if err := isExtensionAllowed(path); err != nil {
+			return nil, errors.Wrap(err, "check if extension is valid")
+		}
+		manifestPaths = []string{path}
 	}
-	fmt.Fprintf(os.Stderr, "Extracted image(s): %s\n", strings.Join(images, ", "))
 
-	return c.VerifyCommand.Exec(ctx, images)
+	var images []string
+	seen := map[string]bool{}
+	for _, p := range manifestPaths {
+		manifest, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read manifest: %w", err)
+		}
+		found, err := getImagesFromYamlManifest(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("unable to extract the container image references in the manifest %s: %w", p, err)
+		}
+		for _, img := range found {
+			if !seen[img] {
+				seen[img] = true
+				images = append(images, img)
+			}
+		}
+	}
+	return images, nil
 }
 
 // unionImagesKind is the union type that match PodSpec, PodSpecTemplate, and
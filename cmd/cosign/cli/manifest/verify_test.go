@@ -15,6 +15,8 @@
 package manifest
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -282,3 +284,43 @@ func TestGetImagesFromYamlManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestGetImagesFromPath(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"pod.yaml":             singleContainerManifest,
+		"nested/multi.yml":     multiContainerManifest,
+		"nested/notes.txt":     jobManifest,
+		"nested/deep/job.yaml": jobManifest,
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files are walked in lexical order and repeated images are only reported once.
+	got, err := getImagesFromPath(dir)
+	if err != nil {
+		t.Fatalf("getImagesFromPath returned error: %v", err)
+	}
+	want := []string{"python", "perl", "nginx:1.21.1", "ubuntu:21.10"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("getImagesFromPath returned %v, wanted %v", got, want)
+	}
+
+	got, err = getImagesFromPath(filepath.Join(dir, "pod.yaml"))
+	if err != nil {
+		t.Fatalf("getImagesFromPath returned error: %v", err)
+	}
+	if want := []string{"nginx:1.21.1"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("getImagesFromPath returned %v, wanted %v", got, want)
+	}
+
+	if _, err := getImagesFromPath(filepath.Join(dir, "nested", "notes.txt")); err == nil {
+		t.Error("expected error for a file without a yaml extension")
+	}
+}
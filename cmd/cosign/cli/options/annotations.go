@@ -46,6 +46,22 @@ func (o *AnnotationOptions) AnnotationsMap() (sigs.AnnotationsMap, error) {
 	return ann, nil
 }
 
+// ParseSignatureAnnotations parses key=value pairs into annotations of a signature layer.
+func ParseSignatureAnnotations(kvs []string) (map[string]string, error) {
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+	ann := make(map[string]string, len(kvs))
+	for _, a := range kvs {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("unable to parse annotation: %s", a)
+		}
+		ann[kv[0]] = kv[1]
+	}
+	return ann, nil
+}
+
 // AddFlags implements Interface
 func (o *AnnotationOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVarP(&o.Annotations, "annotations", "a", nil,
@@ -48,7 +48,13 @@ func (o *AttachSignatureOptions) AddFlags(cmd *cobra.Command) {
 type AttachSBOMOptions struct {
 	SBOM     string
 	SBOMType string
+	WrapDSSE bool
+	Key      string
 	Registry RegistryOptions
+
+	Fulcio FulcioOptions
+	Rekor  RekorOptions
+	OIDC   OIDCOptions
 }
 
 var _ Interface = (*AttachSBOMOptions)(nil)
@@ -56,12 +62,21 @@ var _ Interface = (*AttachSBOMOptions)(nil)
 // AddFlags implements Interface
 func (o *AttachSBOMOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
+	o.Fulcio.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.OIDC.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.SBOM, "sbom", "",
 		"path to the sbom, or {-} for stdin")
 
 	cmd.Flags().StringVar(&o.SBOMType, "type", "spdx",
 		"type of sbom (spdx|cyclonedx|syft)")
+
+	cmd.Flags().BoolVar(&o.WrapDSSE, "wrap-dsse", false,
+		"wrap the sbom in a signed in-toto attestation and attach it as an attestation (spdx|cyclonedx only)")
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the private key file, KMS URI or Kubernetes Secret, used with --wrap-dsse")
 }
 
 func (o *AttachSBOMOptions) MediaType() (types.MediaType, error) {
@@ -66,7 +66,7 @@ func (o *AttestOptions) AddFlags(cmd *cobra.Command) {
 		"if a multi-arch image is specified, additionally sign each discrete image")
 
 	cmd.Flags().BoolVarP(&o.Replace, "replace", "", false,
-		"")
+		"replace your existing attestations of the same predicate type instead of adding another")
 
 	cmd.Flags().DurationVar(&o.Timeout, "timeout", time.Second*30,
 		"HTTP Timeout defaults to 30 seconds")
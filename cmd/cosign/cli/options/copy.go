@@ -22,6 +22,7 @@ import (
 // CopyOptions is the top level wrapper for the copy command.
 type CopyOptions struct {
 	SignatureOnly bool
+	All           bool
 	Force         bool
 	Registry      RegistryOptions
 }
@@ -35,6 +36,9 @@ func (o *CopyOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.SignatureOnly, "sig-only", false,
 		"only copy the image signature")
 
+	cmd.Flags().BoolVar(&o.All, "all", false,
+		"also copy the image attestations and SBOM")
+
 	cmd.Flags().BoolVarP(&o.Force, "force", "f", false,
 		"overwrite destination image(s), if necessary")
 }
@@ -0,0 +1,43 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// SignDockerfileOptions is the top level wrapper for the dockerfile sign command.
+type SignDockerfileOptions struct {
+	Key         string
+	File        string
+	Sidecar     bool
+	SecurityKey SecurityKeyOptions
+}
+
+var _ Interface = (*SignDockerfileOptions)(nil)
+
+// AddFlags implements Interface
+func (o *SignDockerfileOptions) AddFlags(cmd *cobra.Command) {
+	o.SecurityKey.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the private key file, KMS URI or Kubernetes Secret")
+
+	cmd.Flags().StringVarP(&o.File, "file", "f", "Dockerfile",
+		"path to the Dockerfile to sign")
+
+	cmd.Flags().BoolVar(&o.Sidecar, "sidecar", false,
+		"write the signature to <file>.sig instead of appending it to the Dockerfile as a LABEL")
+}
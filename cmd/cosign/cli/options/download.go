@@ -0,0 +1,60 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// SBOM formats understood by the download sbom command.
+const (
+	SBOMFormatAuto          = "auto"
+	SBOMFormatSPDXJSON      = "spdx-json"
+	SBOMFormatSPDXTagValue  = "spdx-tv"
+	SBOMFormatCycloneDXJSON = "cyclonedx-json"
+	SBOMFormatCycloneDXXML  = "cyclonedx-xml"
+)
+
+// DownloadSBOMOptions is the top level wrapper for the download sbom command.
+type DownloadSBOMOptions struct {
+	Format   string
+	Registry RegistryOptions
+}
+
+var _ Interface = (*DownloadSBOMOptions)(nil)
+
+// AddFlags implements Interface
+func (o *DownloadSBOMOptions) AddFlags(cmd *cobra.Command) {
+	o.Registry.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Format, "format", SBOMFormatAuto,
+		"format of the sbom, detected from its media type by default (auto|spdx-json|spdx-tv|cyclonedx-json|cyclonedx-xml)")
+}
+
+// DownloadConfigOptions is the top level wrapper for the download config command.
+type DownloadConfigOptions struct {
+	Output   string
+	Registry RegistryOptions
+}
+
+var _ Interface = (*DownloadConfigOptions)(nil)
+
+// AddFlags implements Interface
+func (o *DownloadConfigOptions) AddFlags(cmd *cobra.Command) {
+	o.Registry.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Output, "output", "",
+		"path to write the image config to, instead of standard output")
+}
@@ -32,3 +32,34 @@ func (o *GenerateOptions) AddFlags(cmd *cobra.Command) {
 	o.AnnotationOptions.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
 }
+
+// GenerateSBOMOptions is the top level wrapper for the generate sbom command.
+type GenerateSBOMOptions struct {
+	Output   string
+	WrapDSSE bool
+	Key      string
+	Registry RegistryOptions
+
+	Fulcio FulcioOptions
+	Rekor  RekorOptions
+	OIDC   OIDCOptions
+}
+
+var _ Interface = (*GenerateSBOMOptions)(nil)
+
+// AddFlags implements Interface
+func (o *GenerateSBOMOptions) AddFlags(cmd *cobra.Command) {
+	o.Registry.AddFlags(cmd)
+	o.Fulcio.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.OIDC.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Output, "output-sbom", "",
+		"write the generated SBOM to FILE as well as attaching it")
+
+	cmd.Flags().BoolVar(&o.WrapDSSE, "wrap-dsse", false,
+		"wrap the sbom in a signed in-toto attestation and attach it as an attestation")
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the private key file, KMS URI or Kubernetes Secret, used with --wrap-dsse")
+}
@@ -17,12 +17,16 @@ package options
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/sigstore/cosign/pkg/cosign"
 )
 
 // GenerateKeyPairOptions is the top level wrapper for the generate-key-pair command.
 type GenerateKeyPairOptions struct {
 	// KMS Key Management Service
 	KMS string
+	// KeyType is the type of key to generate on disk.
+	KeyType string
 }
 
 var _ Interface = (*GenerateKeyPairOptions)(nil)
@@ -31,4 +35,7 @@ var _ Interface = (*GenerateKeyPairOptions)(nil)
 func (o *GenerateKeyPairOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.KMS, "kms", "",
 		"create key pair in KMS service to use for signing")
+
+	cmd.Flags().StringVar(&o.KeyType, "key-type", cosign.ECDSAKeyType,
+		"type of key pair to generate (ecdsa|ed25519|rsa-pss)")
 }
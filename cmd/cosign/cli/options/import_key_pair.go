@@ -30,5 +30,5 @@ var _ Interface = (*ImportKeyPairOptions)(nil)
 // AddFlags implements Interface
 func (o *ImportKeyPairOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Key, "key", "",
-		"import key pair to use for signing")
+		"import key pair to use for signing, or {-} to read it from stdin")
 }
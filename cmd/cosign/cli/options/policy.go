@@ -86,3 +86,53 @@ func (o *PolicySignOptions) AddFlags(cmd *cobra.Command) {
 	o.Rekor.AddFlags(cmd)
 	o.OIDC.AddFlags(cmd)
 }
+
+// PolicySignClusterImagePolicyOptions is the top level wrapper for the policy-sign-cip command.
+type PolicySignClusterImagePolicyOptions struct {
+	Key         string
+	OutFile     string
+	SecurityKey SecurityKeyOptions
+	Fulcio      FulcioOptions
+	Rekor       RekorOptions
+	OIDC        OIDCOptions
+}
+
+var _ Interface = (*PolicySignClusterImagePolicyOptions)(nil)
+
+// AddFlags implements Interface
+func (o *PolicySignClusterImagePolicyOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the private key file, KMS URI or Kubernetes Secret")
+
+	cmd.Flags().StringVar(&o.OutFile, "out", "",
+		"write the signed policy to FILE instead of stdout")
+
+	o.SecurityKey.AddFlags(cmd)
+	o.Fulcio.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.OIDC.AddFlags(cmd)
+}
+
+// PolicyVerifyClusterImagePolicyOptions is the top level wrapper for the policy-verify-cip command.
+type PolicyVerifyClusterImagePolicyOptions struct {
+	Key            string
+	CertIdentity   string
+	CertOidcIssuer string
+	Rekor          RekorOptions
+}
+
+var _ Interface = (*PolicyVerifyClusterImagePolicyOptions)(nil)
+
+// AddFlags implements Interface
+func (o *PolicyVerifyClusterImagePolicyOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret")
+
+	cmd.Flags().StringVar(&o.CertIdentity, "certificate-identity", "",
+		"the email or URI the signing certificate must name, required without --key")
+
+	cmd.Flags().StringVar(&o.CertOidcIssuer, "certificate-oidc-issuer", "",
+		"the OIDC issuer that must have authenticated the signer of the certificate, required without --key")
+
+	o.Rekor.AddFlags(cmd)
+}
@@ -28,20 +28,22 @@ import (
 )
 
 const (
-	PredicateCustom = "custom"
-	PredicateSLSA   = "slsaprovenance"
-	PredicateSPDX   = "spdx"
-	PredicateLink   = "link"
-	PredicateVuln   = "vuln"
+	PredicateCustom    = "custom"
+	PredicateSLSA      = "slsaprovenance"
+	PredicateSPDX      = "spdx"
+	PredicateCycloneDX = "cyclonedx"
+	PredicateLink      = "link"
+	PredicateVuln      = "vuln"
 )
 
 // PredicateTypeMap is the mapping between the predicate `type` option to predicate URI.
 var PredicateTypeMap = map[string]string{
-	PredicateCustom: attestation.CosignCustomProvenanceV01,
-	PredicateSLSA:   slsa.PredicateSLSAProvenance,
-	PredicateSPDX:   in_toto.PredicateSPDX,
-	PredicateLink:   in_toto.PredicateLinkV1,
-	PredicateVuln:   attestation.CosignVulnProvenanceV01,
+	PredicateCustom:    attestation.CosignCustomProvenanceV01,
+	PredicateSLSA:      slsa.PredicateSLSAProvenance,
+	PredicateSPDX:      in_toto.PredicateSPDX,
+	PredicateCycloneDX: attestation.CycloneDXBOM,
+	PredicateLink:      in_toto.PredicateLinkV1,
+	PredicateVuln:      attestation.CosignVulnProvenanceV01,
 }
 
 // PredicateOptions is the wrapper for predicate related options.
@@ -54,7 +56,7 @@ var _ Interface = (*PredicateOptions)(nil)
 // AddFlags implements Interface
 func (o *PredicateOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Type, "type", "custom",
-		"specify a predicate type (slsaprovenance|link|spdx|vuln|custom) or an URI")
+		"specify a predicate type (slsaprovenance|link|spdx|cyclonedx|vuln|custom) or an URI")
 }
 
 // ParsePredicateType parses the predicate `type` flag passed into a predicate URI, or validates `type` is a valid URI.
@@ -72,7 +74,9 @@ func ParsePredicateType(t string) (string, error) {
 // PredicateLocalOptions is the wrapper for predicate related options.
 type PredicateLocalOptions struct {
 	PredicateOptions
-	Path string
+	Path    string
+	MaxSize int64
+	Schema  string
 }
 
 var _ Interface = (*PredicateLocalOptions)(nil)
@@ -82,7 +86,13 @@ func (o *PredicateLocalOptions) AddFlags(cmd *cobra.Command) {
 	o.PredicateOptions.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Path, "predicate", "",
-		"path to the predicate file.")
+		"path to the predicate file, or '-' to read it from standard input.")
+
+	cmd.Flags().Int64Var(&o.MaxSize, "max-predicate-size", 64<<20,
+		"maximum size in bytes of the predicate")
+
+	cmd.Flags().StringVar(&o.Schema, "predicate-schema", "",
+		"path to a JSON Schema the predicate must validate against before it is attested")
 }
 
 // PredicateRemoteOptions is the wrapper for remote predicate related options.
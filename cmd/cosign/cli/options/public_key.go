@@ -23,6 +23,7 @@ import (
 type PublicKeyOptions struct {
 	Key         string
 	SecurityKey SecurityKeyOptions
+	FromCert    string
 	OutFile     string
 }
 
@@ -35,6 +36,9 @@ func (o *PublicKeyOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Key, "key", "",
 		"path to the private key file, KMS URI or Kubernetes Secret")
 
+	cmd.Flags().StringVar(&o.FromCert, "from-cert", "",
+		"path to a PEM-encoded certificate to extract the public key from")
+
 	cmd.Flags().StringVar(&o.OutFile, "outfile", "",
 		"path to a payload file to use rather than generating one")
 }
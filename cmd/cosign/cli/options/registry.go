@@ -48,7 +48,14 @@ func (o *RegistryOptions) AddFlags(cmd *cobra.Command) {
 }
 
 func (o *RegistryOptions) ClientOpts(ctx context.Context) ([]ociremote.Option, error) {
-	opts := []ociremote.Option{ociremote.WithRemoteOptions(o.GetRegistryClientOpts(ctx)...)}
+	opts := []ociremote.Option{
+		ociremote.WithRemoteOptions(o.GetRegistryClientOpts(ctx)...),
+		ociremote.WithKeychain(o.keychain(ctx)),
+		ociremote.WithContext(ctx),
+	}
+	if t := o.transport(); t != nil {
+		opts = append(opts, ociremote.WithTransport(t))
+	}
 	if o.RefOpts.TagPrefix != "" {
 		opts = append(opts, ociremote.WithPrefix(o.RefOpts.TagPrefix))
 	}
@@ -66,20 +73,31 @@ func (o *RegistryOptions) GetRegistryClientOpts(ctx context.Context) []remote.Op
 	opts := []remote.Option{
 		remote.WithContext(ctx),
 		remote.WithUserAgent(UserAgent()),
+		remote.WithAuthFromKeychain(o.keychain(ctx)),
+	}
+
+	if t := o.transport(); t != nil {
+		opts = append(opts, remote.WithTransport(t))
+	}
+	return opts
+}
+
+// transport returns the transport to talk to registries with, or nil for the default.
+func (o *RegistryOptions) transport() http.RoundTripper {
+	if o != nil && o.AllowInsecure {
+		return &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} // #nosec G402
 	}
+	return nil
+}
 
+// keychain returns the keychain to authenticate to registries with.
+func (o *RegistryOptions) keychain(ctx context.Context) authn.Keychain {
 	if o.KubernetesKeychain {
 		kc, err := k8schain.NewNoClient(ctx)
 		if err != nil {
 			panic(err.Error())
 		}
-		opts = append(opts, remote.WithAuthFromKeychain(kc))
-	} else {
-		opts = append(opts, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		return kc
 	}
-
-	if o != nil && o.AllowInsecure {
-		opts = append(opts, remote.WithTransport(&http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}})) // #nosec G402
-	}
-	return opts
+	return authn.DefaultKeychain
 }
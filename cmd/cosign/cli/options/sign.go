@@ -21,16 +21,30 @@ import (
 
 // SignOptions is the top level wrapper for the sign command.
 type SignOptions struct {
-	Key               string
-	Cert              string
-	Upload            bool
-	Output            string // deprecated: TODO remove when the output flag is fully deprecated
-	OutputSignature   string // TODO: this should be the root output file arg.
-	OutputCertificate string
-	PayloadPath       string
-	Force             bool
-	Recursive         bool
-	Attachment        string
+	Key                string
+	Cert               string
+	Upload             bool
+	Output             string // deprecated: TODO remove when the output flag is fully deprecated
+	OutputSignature    string // TODO: this should be the root output file arg.
+	OutputCertificate  string
+	OutputSignaturePEM string
+	PayloadPath        string
+	Force              bool
+	Recursive          bool
+	Attachment         string
+	TSAServerURL       string
+	OCILayoutPath      string
+	AllTags            bool
+	FilterRegexp       string
+	Parallelism        int
+	PasswordFile       string
+	PasswordStdin      bool
+	AuditLogPath       string
+	SigAnnotations     []string
+	ContainerIdentity  string
+	ArtifactType       string
+	ExperimentalOCI2   bool
+	PrintPayloadHash   bool
 
 	Rekor       RekorOptions
 	Fulcio      FulcioOptions
@@ -66,6 +80,9 @@ func (o *SignOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.OutputCertificate, "output-certificate", "",
 		"write the certificate to FILE")
 
+	cmd.Flags().StringVar(&o.OutputSignaturePEM, "output-signature-pem", "",
+		"write the raw signature to FILE as a PEM block, followed by the signing certificate if there is one")
+
 	cmd.Flags().StringVar(&o.PayloadPath, "payload", "",
 		"path to a payload file to use rather than generating one")
 
@@ -77,4 +94,43 @@ func (o *SignOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.Attachment, "attachment", "",
 		"related image attachment to sign (sbom), default none")
+
+	cmd.Flags().StringVar(&o.TSAServerURL, "timestamp-server-url", "",
+		"url of an RFC 3161 timestamp authority used to timestamp the signature")
+
+	cmd.Flags().StringVar(&o.OCILayoutPath, "oci-layout-path", "",
+		"write the signed image and its signatures to an OCI image layout at this path instead of pushing the signature")
+
+	cmd.Flags().BoolVar(&o.AllTags, "all-tags", false,
+		"treat each argument as a repository and sign every tag in it")
+
+	cmd.Flags().StringVar(&o.FilterRegexp, "filter-regexp", "",
+		"with --all-tags, only sign tags matching this regular expression")
+
+	cmd.Flags().IntVar(&o.Parallelism, "parallelism", 4,
+		"with --all-tags, the number of tags to sign concurrently")
+
+	cmd.Flags().StringVar(&o.PasswordFile, "password-file", "",
+		"read the private key password from this file instead of COSIGN_PASSWORD or a prompt")
+
+	cmd.Flags().BoolVar(&o.PasswordStdin, "password-stdin", false,
+		"read the private key password from the first line of stdin instead of COSIGN_PASSWORD or a prompt")
+
+	cmd.Flags().StringVar(&o.AuditLogPath, "audit-log", "",
+		"append a JSON line describing each signature to FILE once it is stored")
+
+	cmd.Flags().StringSliceVar(&o.SigAnnotations, "annotation", nil,
+		"key=value annotation to set on the signature in the signature manifest, unlike --annotations which are signed; may be repeated")
+
+	cmd.Flags().StringVar(&o.ContainerIdentity, "sign-container-identity", "",
+		"image reference to sign as the identity of the container, in the containerIdentity field of the payload, for 'cosign verify --require-container-identity' to check")
+
+	cmd.Flags().StringVar(&o.ArtifactType, "artifact-type", "",
+		"type of artifact being signed: 'helm' checks that each image is a Helm chart, signs the chart's name and version in the helmChart field of the payload, and attaches an attestation of them with the cosign.sigstore.dev/attestation/helm-chart/v1 predicate type")
+
+	cmd.Flags().BoolVar(&o.ExperimentalOCI2, "experimental-oci2", false,
+		"store the signature as an OCI v1.1 referrer of the image instead of under the signature tag, falling back to the referrers tag on registries without the referrers API")
+
+	cmd.Flags().BoolVar(&o.PrintPayloadHash, "print-payload-hash", false,
+		"print the SHA-256 hash of each signing payload to stderr before signing it, for an out-of-band audit log")
 }
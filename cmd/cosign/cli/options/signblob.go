@@ -29,6 +29,9 @@ type SignBlobOptions struct {
 	Output            string // deprecated: TODO remove when the output flag is fully deprecated
 	OutputSignature   string // TODO: this should be the root output file arg.
 	OutputCertificate string
+	Signature         string
+	Certificate       string
+	BundlePath        string
 	SecurityKey       SecurityKeyOptions
 	Fulcio            FulcioOptions
 	Rekor             RekorOptions
@@ -62,6 +65,15 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.OutputCertificate, "output-certificate", "",
 		"write the certificate to FILE")
 
+	cmd.Flags().StringVar(&o.Signature, "signature", "",
+		"write the base64-encoded signature to FILE")
+
+	cmd.Flags().StringVar(&o.Certificate, "certificate", "",
+		"write the PEM-encoded signing certificate to FILE")
+
+	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
+		"write a Sigstore bundle holding the signature, certificate and transparency log entry to FILE; supersedes --signature and --certificate")
+
 	cmd.Flags().DurationVar(&o.Timeout, "timeout", time.Second*30,
 		"HTTP Timeout defaults to 30 seconds")
 }
@@ -22,6 +22,7 @@ import (
 // TriangulateOptions is the top level wrapper for the triangulate command.
 type TriangulateOptions struct {
 	Type     string
+	Check    bool
 	Registry RegistryOptions
 }
 
@@ -33,4 +34,7 @@ func (o *TriangulateOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.Type, "type", "signature",
 		"related attachment to triangulate (attestation|sbom|signature), default signature")
+
+	cmd.Flags().BoolVar(&o.Check, "check", false,
+		"check whether the registry stores the attachment at the located reference and print its digest")
 }
@@ -33,8 +33,11 @@ func (o *UploadBlobOptions) AddFlags(cmd *cobra.Command) {
 	o.Registry.AddFlags(cmd)
 	o.Files.AddFlags(cmd)
 
+	cmd.Flags().StringVar(&o.ContentType, "media-type", "",
+		"media type of the uploaded layers, e.g. application/vnd.wasm.content.layer.v1+wasm. detected from their contents if unset")
+
 	cmd.Flags().StringVar(&o.ContentType, "ct", "",
-		"content type to set")
+		"content type to set, an alias for --media-type")
 }
 
 // UploadWASMOptions is the top level wrapper for the `upload wasm` command.
@@ -21,14 +21,28 @@ import (
 
 // VerifyOptions is the top level wrapper for the `verify` command.
 type VerifyOptions struct {
-	Key          string
-	Cert         string
-	CertEmail    string // TODO: merge into fulcio option as read mode?
-	CheckClaims  bool
-	Attachment   string
-	Output       string
-	SignatureRef string
-	LocalImage   bool
+	Key                  string
+	AdditionalKeys       []string
+	Cert                 string
+	CertEmail            string // TODO: merge into fulcio option as read mode?
+	CertOidcIssuerRegexp string
+	CheckClaims          bool
+	Attachment           string
+	Output               string
+	OutputFormat         string
+	SignatureRef         string
+	LocalImage           bool
+	TSACertChain         string
+	TUFRoot              string
+	TUFMirror            string
+	Offline              bool
+	MaxWorkers           int
+	ImagesFile           string
+	RequireAnnotations   []string
+	SearchRekor          bool
+	Threshold            int
+	RequireIdentity      bool
+	ExperimentalOCI2     bool
 
 	SecurityKey SecurityKeyOptions
 	Rekor       RekorOptions
@@ -57,8 +71,11 @@ func (o *VerifyOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.CertEmail, "cert-email", "",
 		"the email expected in a valid fulcio cert")
 
+	cmd.Flags().StringVar(&o.CertOidcIssuerRegexp, "certificate-oidc-issuer-regexp", "",
+		"a regular expression that the OIDC issuer in a valid fulcio cert must match")
+
 	cmd.Flags().BoolVar(&o.CheckClaims, "check-claims", true,
-		"whether to check the claims found")
+		"whether to check the claims found; if false, only check that a signature verifies and stop at the first that does")
 
 	cmd.Flags().StringVar(&o.Attachment, "attachment", "",
 		"related image attachment to sign (sbom), default none")
@@ -66,11 +83,50 @@ func (o *VerifyOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Output, "output", "o", "json",
 		"output format for the signing image information (json|text)")
 
+	cmd.Flags().StringVar(&o.OutputFormat, "output-format", "",
+		"write all verified signatures as a single document in this format (json), in place of --output")
+
 	cmd.Flags().StringVar(&o.SignatureRef, "signature", "",
 		"signature content or path or remote URL")
 
 	cmd.Flags().BoolVar(&o.LocalImage, "local-image", false,
 		"whether the specified image is a path to an image saved locally via 'cosign save'")
+
+	cmd.Flags().StringVar(&o.TSACertChain, "timestamp-certificate-chain", "",
+		"path to a PEM file of the RFC 3161 timestamp authority's certificate chain; if set, signatures must carry a timestamp that verifies against it")
+
+	cmd.Flags().StringVar(&o.TUFMirror, "tuf-mirror", "",
+		"GCS bucket or HTTP(S) base URL of a Sigstore TUF repository whose Fulcio roots and log keys to verify against. it is fetched into memory, leaving the local TUF cache untouched")
+
+	cmd.Flags().StringVar(&o.TUFRoot, "tuf-root", "",
+		"path or URL of the trusted initial root for --tuf-mirror. defaults to the embedded root")
+
+	cmd.Flags().BoolVar(&o.Offline, "offline", false,
+		"verify the transparency log inclusion of each signature from its Rekor bundle alone, without contacting Rekor; fails for signatures without a bundle")
+
+	cmd.Flags().IntVar(&o.MaxWorkers, "max-workers", 1,
+		"the maximum number of images to verify concurrently")
+
+	cmd.Flags().StringVar(&o.ImagesFile, "images-file", "",
+		"path to a file of image references to verify, one per line, in addition to any given as arguments; lines starting with # are ignored")
+
+	cmd.Flags().StringSliceVar(&o.RequireAnnotations, "require-annotation", nil,
+		"key=value annotation a signature must carry in the signature manifest, as set by 'cosign sign --annotation'; may be repeated")
+
+	cmd.Flags().BoolVar(&o.SearchRekor, "search-rekor", false,
+		"if an image has no signatures attached, search Rekor for entries whose artifact hash is the image's manifest digest and verify those instead")
+
+	cmd.Flags().StringSliceVar(&o.AdditionalKeys, "additional-key", nil,
+		"path to a further public key file, KMS URI or Kubernetes Secret that signatures may verify with besides --key; may be repeated")
+
+	cmd.Flags().IntVar(&o.Threshold, "threshold", 1,
+		"the minimum number of distinct signers with a valid signature on an image; each of --key and --additional-key counts as one signer, as does each certificate identity and issuer")
+
+	cmd.Flags().BoolVar(&o.RequireIdentity, "require-container-identity", false,
+		"require each signature to name the image being verified as its container identity, as set by 'cosign sign --sign-container-identity'")
+
+	cmd.Flags().BoolVar(&o.ExperimentalOCI2, "experimental-oci2", false,
+		"fetch signatures stored as OCI v1.1 referrers of the image, as by 'cosign sign --experimental-oci2', instead of from the signature tag")
 }
 
 // VerifyAttestationOptions is the top level wrapper for the `verify attestation` command.
@@ -86,6 +142,8 @@ type VerifyAttestationOptions struct {
 	Predicate   PredicateRemoteOptions
 	Policies    []string
 	LocalImage  bool
+
+	CertIdentityRegexp string
 }
 
 var _ Interface = (*VerifyAttestationOptions)(nil)
@@ -112,13 +170,17 @@ func (o *VerifyAttestationOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&o.LocalImage, "local-image", false,
 		"whether the specified image is a path to an image saved locally via 'cosign save'")
+
+	cmd.Flags().StringVar(&o.CertIdentityRegexp, "certificate-identity-regexp", "",
+		"a regular expression that an email or URI subject alternative name in a valid fulcio cert must match")
 }
 
 // VerifyBlobOptions is the top level wrapper for the `verify blob` command.
 type VerifyBlobOptions struct {
-	Key       string
-	Cert      string
-	Signature string
+	Key        string
+	Cert       string
+	Signature  string
+	BundlePath string
 
 	SecurityKey SecurityKeyOptions
 	Rekor       RekorOptions
@@ -141,12 +203,49 @@ func (o *VerifyBlobOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.Signature, "signature", "",
 		"signature content or path or remote URL")
+
+	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
+		"path to a Sigstore bundle (.sigstore) holding the signature, certificate and tlog entry, as written by other Sigstore clients")
+}
+
+// VerifyBlobAttestationOptions is the top level wrapper for the `verify-blob-attestation` command.
+type VerifyBlobAttestationOptions struct {
+	Key           string
+	Cert          string
+	Signature     string
+	PredicateType string
+
+	SecurityKey SecurityKeyOptions
+	Rekor       RekorOptions
+	Registry    RegistryOptions
+}
+
+var _ Interface = (*VerifyBlobAttestationOptions)(nil)
+
+// AddFlags implements Interface
+func (o *VerifyBlobAttestationOptions) AddFlags(cmd *cobra.Command) {
+	o.SecurityKey.AddFlags(cmd)
+	o.Rekor.AddFlags(cmd)
+	o.Registry.AddFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Key, "key", "",
+		"path to the public key file, KMS URI or Kubernetes Secret")
+
+	cmd.Flags().StringVar(&o.Cert, "cert", "",
+		"path to the public certificate, which must chain up to the Fulcio roots and have been valid when the attestation was logged in Rekor")
+
+	cmd.Flags().StringVar(&o.Signature, "signature", "",
+		"path or remote URL to the attestation, a DSSE envelope or one envelope per line")
+
+	cmd.Flags().StringVar(&o.PredicateType, "predicate-type", "",
+		"only verify attestations of this predicate type (slsaprovenance|link|spdx|cyclonedx|vuln|custom) or an URI")
 }
 
 // VerifyBlobOptions is the top level wrapper for the `verify blob` command.
 type VerifyDockerfileOptions struct {
 	VerifyOptions
-	BaseImageOnly bool
+	BaseImageOnly       bool
+	DockerfileSignature bool
 }
 
 var _ Interface = (*VerifyDockerfileOptions)(nil)
@@ -157,4 +256,7 @@ func (o *VerifyDockerfileOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&o.BaseImageOnly, "base-image-only", false,
 		"only verify the base image (the last FROM image in the Dockerfile)")
+
+	cmd.Flags().BoolVar(&o.DockerfileSignature, "dockerfile-signature", false,
+		"verify the signature 'cosign dockerfile sign' made on the Dockerfile itself, instead of the signatures on its images")
 }
@@ -0,0 +1,90 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/policy"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+)
+
+func signClusterImagePolicy() *cobra.Command {
+	o := &options.PolicySignClusterImagePolicyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sign-cip",
+		Short: "sign a ClusterImagePolicy manifest.",
+		Long:  "sign-cip signs the canonical JSON of a policy-controller ClusterImagePolicy manifest\nand stores the signature in the cosign.dev/signed-policy annotation.",
+		Example: `  cosign policy sign-cip --key <key path>|<kms uri> [--out <path>] <manifest>
+
+  # sign a ClusterImagePolicy with a local key pair file
+  cosign policy sign-cip --key cosign.key --out signed-policy.json policy.yaml
+
+  # sign a ClusterImagePolicy with Google sign-in (experimental)
+  COSIGN_EXPERIMENTAL=1 cosign policy sign-cip policy.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ko := sign.KeyOpts{
+				KeyRef:                   o.Key,
+				PassFunc:                 generate.GetPass,
+				Sk:                       o.SecurityKey.Use,
+				Slot:                     o.SecurityKey.Slot,
+				FulcioURL:                o.Fulcio.URL,
+				IDToken:                  o.Fulcio.IdentityToken,
+				InsecureSkipFulcioVerify: o.Fulcio.InsecureSkipFulcioVerify,
+				RekorURL:                 o.Rekor.URL,
+				OIDCIssuer:               o.OIDC.Issuer,
+				OIDCClientID:             o.OIDC.ClientID,
+				OIDCClientSecret:         o.OIDC.ClientSecret,
+			}
+			return policy.SignClusterImagePolicyCmd(cmd.Context(), ko, args[0], o.OutFile)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func verifyClusterImagePolicy() *cobra.Command {
+	o := &options.PolicyVerifyClusterImagePolicyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify-cip",
+		Short: "verify a signed ClusterImagePolicy manifest.",
+		Long:  "verify-cip checks the cosign.dev/signed-policy annotation of a ClusterImagePolicy manifest\nagainst the rest of the manifest.",
+		Example: `  cosign policy verify-cip [--key <key path>|<kms uri>] [--certificate-identity <identity> --certificate-oidc-issuer <issuer>] <manifest>
+
+  # verify a ClusterImagePolicy signed with a local key pair
+  cosign policy verify-cip --key cosign.pub signed-policy.json
+
+  # verify a ClusterImagePolicy signed with a Fulcio certificate
+  cosign policy verify-cip --certificate-identity user@example.com --certificate-oidc-issuer https://accounts.google.com signed-policy.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ko := sign.KeyOpts{
+				KeyRef:   o.Key,
+				RekorURL: o.Rekor.URL,
+			}
+			return policy.VerifyClusterImagePolicyCmd(cmd.Context(), ko, o.CertIdentity, o.CertOidcIssuer, args[0])
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
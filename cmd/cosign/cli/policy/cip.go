@@ -0,0 +1,297 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
+	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+const (
+	// ClusterImagePolicyKind is the kind of the policy-controller CRD we sign.
+	ClusterImagePolicyKind = "ClusterImagePolicy"
+
+	// SignatureAnnotation holds the base64 encoded signature over the canonicalized policy.
+	SignatureAnnotation = "cosign.dev/signed-policy"
+	// CertificateAnnotation holds the base64 encoded PEM certificate of a keyless signer.
+	CertificateAnnotation = "cosign.dev/signed-policy-certificate"
+)
+
+// SignClusterImagePolicyCmd signs the ClusterImagePolicy manifest at manifestPath and writes it,
+// with the signature stored in its annotations, to outputPath or stdout.
+func SignClusterImagePolicyCmd(ctx context.Context, ko sign.KeyOpts, manifestPath, outputPath string) error {
+	obj, err := loadClusterImagePolicy(manifestPath)
+	if err != nil {
+		return err
+	}
+	payload, err := canonicalPolicy(obj)
+	if err != nil {
+		return err
+	}
+
+	sv, err := sign.SignerFromKeyOpts(ctx, "", ko)
+	if err != nil {
+		return err
+	}
+	defer sv.Close()
+
+	sig, err := sv.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "signing policy")
+	}
+
+	annotations := map[string]string{
+		SignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+	}
+	if len(sv.Cert) > 0 {
+		// Fulcio certificates expire minutes after they are issued, so the signature
+		// is only verifiable with the time Rekor records it at.
+		rekorClient, err := rekor.NewClient(ko.RekorURL)
+		if err != nil {
+			return err
+		}
+		entry, err := cosign.TLogUpload(ctx, rekorClient, sig, payload, sv.Cert)
+		if err != nil {
+			return errors.Wrap(err, "uploading policy signature to the transparency log")
+		}
+		fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
+		annotations[CertificateAnnotation] = base64.StdEncoding.EncodeToString(sv.Cert)
+	}
+	setAnnotations(obj, annotations)
+
+	out, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	if outputPath == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(outputPath, out, 0600); err != nil {
+		return errors.Wrapf(err, "writing %s", outputPath)
+	}
+	fmt.Fprintln(os.Stderr, "Signed policy written to", outputPath)
+	return nil
+}
+
+// VerifyClusterImagePolicyCmd verifies the signature stored in the annotations of the
+// ClusterImagePolicy manifest at manifestPath, either with ko.KeyRef or, if it is empty,
+// with the embedded certificate. A certificate must chain to the Fulcio roots, name
+// certIdentity and certOIDCIssuer, and have been valid when the signature was entered
+// in the Rekor transparency log at ko.RekorURL.
+func VerifyClusterImagePolicyCmd(ctx context.Context, ko sign.KeyOpts, certIdentity, certOIDCIssuer, manifestPath string) error {
+	obj, err := loadClusterImagePolicy(manifestPath)
+	if err != nil {
+		return err
+	}
+	annotations := getAnnotations(obj)
+	b64sig, ok := annotations[SignatureAnnotation]
+	if !ok {
+		return fmt.Errorf("policy has no %s annotation", SignatureAnnotation)
+	}
+	sig, err := base64.StdEncoding.DecodeString(b64sig)
+	if err != nil {
+		return errors.Wrap(err, "decoding signature")
+	}
+	payload, err := canonicalPolicy(obj)
+	if err != nil {
+		return err
+	}
+
+	var verifier signature.Verifier
+	if ko.KeyRef != "" {
+		verifier, err = sigs.PublicKeyFromKeyRef(ctx, ko.KeyRef)
+		if err != nil {
+			return errors.Wrap(err, "loading public key")
+		}
+	} else {
+		b64cert, ok := annotations[CertificateAnnotation]
+		if !ok {
+			return fmt.Errorf("a key is required to verify a policy without a %s annotation", CertificateAnnotation)
+		}
+		if certIdentity == "" || certOIDCIssuer == "" {
+			return errors.New("--certificate-identity and --certificate-oidc-issuer are required to verify a policy signed with a certificate")
+		}
+		pemCert, err := base64.StdEncoding.DecodeString(b64cert)
+		if err != nil {
+			return errors.Wrap(err, "decoding certificate")
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pemCert)
+		if err != nil {
+			return err
+		}
+		if len(certs) == 0 {
+			return errors.New("no certificate found in policy annotation")
+		}
+		cert := certs[0]
+		if err := cosign.TrustedCert(cert, fulcio.GetRoots()); err != nil {
+			return err
+		}
+		if err := checkCertIdentity(cert, certIdentity, certOIDCIssuer); err != nil {
+			return err
+		}
+		if err := checkCertTlogEntry(ctx, ko.RekorURL, cert, b64sig, payload, pemCert); err != nil {
+			return err
+		}
+		verifier, err = signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload), signatureoptions.WithContext(ctx)); err != nil {
+		return errors.Wrap(err, "verifying policy signature")
+	}
+	fmt.Fprintf(os.Stderr, "Verified policy %s\n", manifestPath)
+	return nil
+}
+
+// checkCertIdentity checks that one of cert's email or URI subject alternative names is
+// identity and that Fulcio recorded issuer as the OIDC issuer that authenticated it.
+func checkCertIdentity(cert *x509.Certificate, identity, issuer string) error {
+	if got := sigs.CertIssuerExtension(cert); got != issuer {
+		return fmt.Errorf("%w: certificate OIDC issuer %q is not %q", cosign.ErrIdentityMismatch, got, issuer)
+	}
+	for _, em := range cert.EmailAddresses {
+		if em == identity {
+			return nil
+		}
+	}
+	for _, u := range cert.URIs {
+		if u.String() == identity {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: certificate does not name %q", cosign.ErrIdentityMismatch, identity)
+}
+
+// checkCertTlogEntry checks that Rekor has a verified entry for the policy signature and
+// that cert was valid at the time it was integrated into the log.
+func checkCertTlogEntry(ctx context.Context, rekorURL string, cert *x509.Certificate, b64sig string, payload, pemCert []byte) error {
+	rekorClient, err := rekor.NewClient(rekorURL)
+	if err != nil {
+		return err
+	}
+	uuid, index, err := cosign.FindTlogEntry(ctx, rekorClient, b64sig, payload, pemCert)
+	if err != nil {
+		return errors.Wrap(err, "finding the policy signature in the transparency log")
+	}
+	fmt.Fprintf(os.Stderr, "tlog entry verified with uuid: %q index: %d\n", uuid, index)
+	e, err := cosign.GetTlogEntry(ctx, rekorClient, uuid)
+	if err != nil {
+		return err
+	}
+	return cosign.CheckExpiry(cert, time.Unix(*e.IntegratedTime, 0))
+}
+
+func loadClusterImagePolicy(manifestPath string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(filepath.Clean(manifestPath))
+	if err != nil {
+		return nil, err
+	}
+	j, err := yaml.ToJSON(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting manifest to JSON")
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(j, &obj); err != nil {
+		return nil, errors.Wrap(err, "parsing manifest")
+	}
+	if kind, _ := obj["kind"].(string); kind != ClusterImagePolicyKind {
+		return nil, fmt.Errorf("expected kind %s, got %q", ClusterImagePolicyKind, kind)
+	}
+	return obj, nil
+}
+
+// canonicalPolicy returns the canonical JSON of the policy without the signature annotations.
+func canonicalPolicy(obj map[string]interface{}) ([]byte, error) {
+	annotations := getAnnotations(obj)
+	delete(annotations, SignatureAnnotation)
+	delete(annotations, CertificateAnnotation)
+
+	unsigned := map[string]interface{}{}
+	for k, v := range obj {
+		unsigned[k] = v
+	}
+	metadata := map[string]interface{}{}
+	if m, ok := obj["metadata"].(map[string]interface{}); ok {
+		for k, v := range m {
+			metadata[k] = v
+		}
+	}
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	} else {
+		delete(metadata, "annotations")
+	}
+	unsigned["metadata"] = metadata
+
+	b, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	return jsoncanonicalizer.Transform(b)
+}
+
+func getAnnotations(obj map[string]interface{}) map[string]string {
+	annotations := map[string]string{}
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	raw, _ := metadata["annotations"].(map[string]interface{})
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			annotations[k] = s
+		}
+	}
+	return annotations
+}
+
+func setAnnotations(obj map[string]interface{}, annotations map[string]string) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	raw, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		raw = map[string]interface{}{}
+		metadata["annotations"] = raw
+	}
+	for k, v := range annotations {
+		raw[k] = v
+	}
+}
@@ -0,0 +1,137 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign"
+)
+
+const testPolicy = `apiVersion: cosign.sigstore.dev/v1alpha1
+kind: ClusterImagePolicy
+metadata:
+  name: image-policy
+  annotations:
+    owner: security
+spec:
+  images:
+  - glob: "gcr.io/example/*"
+    authorities:
+    - key:
+        data: |
+          -----BEGIN PUBLIC KEY-----
+          -----END PUBLIC KEY-----
+`
+
+func pass(s string) cosign.PassFunc {
+	return func(_ bool) ([]byte, error) {
+		return []byte(s), nil
+	}
+}
+
+func TestSignVerifyClusterImagePolicy(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	keys, err := cosign.GenerateKeyPair(pass("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKey, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pubKey := filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(pubKey, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := filepath.Join(td, "policy.yaml")
+	if err := os.WriteFile(manifest, []byte(testPolicy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signed := filepath.Join(td, "signed.json")
+	ko := sign.KeyOpts{KeyRef: privKey, PassFunc: pass("hello")}
+	if err := SignClusterImagePolicyCmd(ctx, ko, manifest, signed); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyClusterImagePolicyCmd(ctx, sign.KeyOpts{KeyRef: pubKey}, "", "", signed); err != nil {
+		t.Fatalf("verifying signed policy: %v", err)
+	}
+
+	// Existing annotations must survive signing.
+	obj, err := loadClusterImagePolicy(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getAnnotations(obj)["owner"]; got != "security" {
+		t.Errorf("owner annotation = %q, want %q", got, "security")
+	}
+
+	// Any change to the policy invalidates the signature.
+	b, err := os.ReadFile(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(td, "tampered.json")
+	if err := os.WriteFile(tampered, bytes.Replace(b, []byte("gcr.io/example/*"), []byte("*"), 1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyClusterImagePolicyCmd(ctx, sign.KeyOpts{KeyRef: pubKey}, "", "", tampered); err == nil {
+		t.Error("expected error verifying tampered policy, got nil")
+	}
+
+	// An unsigned policy cannot be verified.
+	if err := VerifyClusterImagePolicyCmd(ctx, sign.KeyOpts{KeyRef: pubKey}, "", "", manifest); err == nil {
+		t.Error("expected error verifying unsigned policy, got nil")
+	}
+}
+
+func TestVerifyClusterImagePolicyKeylessRequiresIdentity(t *testing.T) {
+	td := t.TempDir()
+	signed := filepath.Join(td, "signed.yaml")
+	policy := strings.Replace(testPolicy, "    owner: security\n",
+		"    owner: security\n    "+SignatureAnnotation+": c2ln\n    "+CertificateAnnotation+": Y2VydA==\n", 1)
+	if err := os.WriteFile(signed, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, tc := range []struct {
+		identity, issuer string
+	}{{"", ""}, {"user@example.com", ""}, {"", "https://accounts.google.com"}} {
+		err := VerifyClusterImagePolicyCmd(ctx, sign.KeyOpts{}, tc.identity, tc.issuer, signed)
+		if err == nil || !strings.Contains(err.Error(), "--certificate-identity") {
+			t.Errorf("VerifyClusterImagePolicyCmd(%q, %q) = %v, want an error requiring the identity flags", tc.identity, tc.issuer, err)
+		}
+	}
+}
+
+func TestLoadClusterImagePolicyWrongKind(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "pod.yaml")
+	if err := os.WriteFile(f, []byte("apiVersion: v1\nkind: Pod\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadClusterImagePolicy(f); err == nil {
+		t.Error("expected error loading a non-ClusterImagePolicy manifest, got nil")
+	}
+}
@@ -69,6 +69,8 @@ func Policy() *cobra.Command {
 	cmd.AddCommand(
 		initPolicy(),
 		signPolicy(),
+		signClusterImagePolicy(),
+		verifyClusterImagePolicy(),
 	)
 
 	return cmd
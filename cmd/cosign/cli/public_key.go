@@ -18,6 +18,7 @@ package cli
 import (
 	"os"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
@@ -51,14 +52,17 @@ func PublicKey() *cobra.Command {
   # extract public key from Hashicorp Vault KMS
   cosign public-key --key hashivault://[KEY]
 
+  # extract public key from a certificate, such as one issued by Fulcio
+  cosign public-key --from-cert <CERTIFICATE FILE>
+
   # extract public key from GitLab with project name
   cosign verify --key gitlab://[OWNER]/[PROJECT_NAME] <IMAGE>
 
   # extract public key from GitLab with project id
   cosign verify --key gitlab://[PROJECT_ID] <IMAGE>`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if !options.OneOf(o.Key, o.SecurityKey.Use) {
-				return &options.KeyParseError{}
+			if !options.OneOf(o.Key, o.SecurityKey.Use, o.FromCert) {
+				return errors.New("exactly one of: key reference (--key), certificate (--from-cert) or hardware token (--sk) must be provided")
 			}
 			return nil
 		},
@@ -79,9 +83,10 @@ func PublicKey() *cobra.Command {
 				writer.Writer = os.Stdout
 			}
 			pk := publickey.Pkopts{
-				KeyRef: o.Key,
-				Sk:     o.SecurityKey.Use,
-				Slot:   o.SecurityKey.Slot,
+				KeyRef:  o.Key,
+				CertRef: o.FromCert,
+				Sk:      o.SecurityKey.Use,
+				Slot:    o.SecurityKey.Slot,
 			}
 			return publickey.GetPublicKey(cmd.Context(), pk, writer, generate.GetPass)
 		},
@@ -17,6 +17,7 @@ package publickey
 
 import (
 	"context"
+	"crypto"
 	"fmt"
 	"io"
 	"os"
@@ -27,6 +28,7 @@ import (
 	"github.com/sigstore/cosign/pkg/cosign/pivkey"
 	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
 	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
 )
@@ -37,9 +39,10 @@ type NamedWriter struct {
 }
 
 type Pkopts struct {
-	KeyRef string
-	Sk     bool
-	Slot   string
+	KeyRef  string
+	CertRef string
+	Sk      bool
+	Slot    string
 }
 
 func GetPublicKey(ctx context.Context, opts Pkopts, writer NamedWriter, pf cosign.PassFunc) error {
@@ -55,6 +58,23 @@ func GetPublicKey(ctx context.Context, opts Pkopts, writer NamedWriter, pf cosig
 			defer pkcs11Key.Close()
 		}
 		k = s
+	case opts.CertRef != "":
+		pemBytes, err := os.ReadFile(opts.CertRef)
+		if err != nil {
+			return err
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pemBytes)
+		if err != nil {
+			return errors.Wrap(err, "parsing certificate")
+		}
+		if len(certs) == 0 {
+			return errors.Errorf("no certificate found in %s", opts.CertRef)
+		}
+		v, err := signature.LoadVerifier(certs[0].PublicKey, crypto.SHA256)
+		if err != nil {
+			return errors.Wrap(err, "loading certificate public key")
+		}
+		k = v
 	case opts.Sk:
 		sk, err := pivkey.GetKeyWithSlot(opts.Slot)
 		if err != nil {
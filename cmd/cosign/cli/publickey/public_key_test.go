@@ -18,12 +18,18 @@ package publickey
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/x509"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
 func pass(s string) cosign.PassFunc {
@@ -64,6 +70,47 @@ func TestPublicKeyLocation(t *testing.T) {
 	}
 }
 
+// Test getting the public key from a certificate.
+func TestPublicKeyFromCert(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := cryptoutils.MarshalCertificateToPEM(&x509.Certificate{Raw: der})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(f, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := GetPublicKey(context.Background(), Pkopts{CertRef: f}, NamedWriter{"", &out}, pass("")); err != nil {
+		t.Fatal(err)
+	}
+	want, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("got %s, want %s", out.Bytes(), want)
+	}
+
+	if err := GetPublicKey(context.Background(), Pkopts{CertRef: filepath.Join(t.TempDir(), "missing.pem")}, NamedWriter{"", &out}, pass("")); err == nil {
+		t.Error("expected error for a missing certificate")
+	}
+}
+
 // Tests failure with bad private key.
 func TestPublicKeyBadPrivateKey(t *testing.T) {
 	ctx := context.Background()
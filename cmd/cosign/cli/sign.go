@@ -17,6 +17,7 @@ package cli
 
 import (
 	"flag"
+	"os"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -24,6 +25,7 @@ import (
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign"
 )
 
 func Sign() *cobra.Command {
@@ -41,12 +43,21 @@ func Sign() *cobra.Command {
   # sign a container image with a local key pair file
   cosign sign --key cosign.key <IMAGE>
 
+  # sign a container image with a local key pair file, reading its password from a file
+  cosign sign --key cosign.key --password-file <PASSWORD FILE> <IMAGE>
+
   # sign a multi-arch container image AND all referenced, discrete images
   cosign sign --key cosign.key --recursive <MULTI-ARCH IMAGE>
 
+  # sign every tag in a repository matching a regular expression, four at a time
+  cosign sign --key cosign.key --all-tags --filter-regexp '^v[0-9]+' --parallelism 4 <REPOSITORY>
+
   # sign a container image and add annotations
   cosign sign --key cosign.key -a key1=value1 -a key2=value2 <IMAGE>
 
+  # sign a container image and annotate the signature in the signature manifest, without signing the annotation
+  cosign sign --key cosign.key --annotation pipeline-run=1234 <IMAGE>
+
   # sign a container image with a key pair stored in Azure Key Vault
   cosign sign --key azurekms://[VAULT_NAME][VAULT_URI]/[KEY] <IMAGE>
 
@@ -62,6 +73,18 @@ func Sign() *cobra.Command {
   # sign a container image with a key pair stored in a Kubernetes secret
   cosign sign --key k8s://[NAMESPACE]/[KEY] <IMAGE>
 
+  # sign a container image with a key stored in a PKCS11 token (requires cosign built with -tags=pkcs11key)
+  cosign sign --key "pkcs11:token=[TOKEN];object=[KEY]?module-path=[MODULE_PATH]" <IMAGE>
+
+  # sign a container image and timestamp the signature with an RFC 3161 timestamp authority
+  cosign sign --key cosign.key --timestamp-server-url https://freetsa.org/tsr <IMAGE>
+
+  # sign a container image and write it, with its signature, to an OCI image layout instead of pushing
+  cosign sign --key cosign.key --oci-layout-path <DIRECTORY> <IMAGE>
+
+  # keyless sign a container image and also write the signature and certificate as PEM to a file, e.g. for openssl
+  COSIGN_EXPERIMENTAL=1 cosign sign --output-signature-pem <FILE> <IMAGE>
+
   # sign a container in a registry which does not fully support OCI media types
   COSIGN_DOCKER_MEDIA_TYPES=1 cosign sign --key cosign.key legacy-registry.example.com/my/image`,
 		Args: cobra.MinimumNArgs(1),
@@ -72,9 +95,13 @@ func Sign() *cobra.Command {
 			default:
 				return flag.ErrHelp
 			}
+			passFunc, err := signPassFunc(o)
+			if err != nil {
+				return err
+			}
 			ko := sign.KeyOpts{
 				KeyRef:                   o.Key,
-				PassFunc:                 generate.GetPass,
+				PassFunc:                 passFunc,
 				Sk:                       o.SecurityKey.Use,
 				Slot:                     o.SecurityKey.Slot,
 				FulcioURL:                o.Fulcio.URL,
@@ -84,12 +111,31 @@ func Sign() *cobra.Command {
 				OIDCIssuer:               o.OIDC.Issuer,
 				OIDCClientID:             o.OIDC.ClientID,
 				OIDCClientSecret:         o.OIDC.ClientSecret,
+				TSAServerURL:             o.TSAServerURL,
+				AuditLogPath:             o.AuditLogPath,
+				ContainerIdentity:        o.ContainerIdentity,
+				ArtifactType:             o.ArtifactType,
+				ExperimentalOCI2:         o.ExperimentalOCI2,
+				PrintPayloadHash:         o.PrintPayloadHash,
 			}
 			annotationsMap, err := o.AnnotationsMap()
 			if err != nil {
 				return err
 			}
-			if err := sign.SignCmd(cmd.Context(), ko, o.Registry, annotationsMap.Annotations, args, o.Cert, o.Upload, o.OutputSignature, o.OutputCertificate, o.PayloadPath, o.Force, o.Recursive, o.Attachment); err != nil {
+			ko.SignatureAnnotations, err = options.ParseSignatureAnnotations(o.SigAnnotations)
+			if err != nil {
+				return err
+			}
+			if o.AllTags {
+				if o.OutputSignature != "" || o.OutputCertificate != "" || o.OutputSignaturePEM != "" || o.OCILayoutPath != "" {
+					return errors.New("--all-tags cannot be used with --output-signature, --output-certificate, --output-signature-pem or --oci-layout-path")
+				}
+				if err := sign.SignAllTagsCmd(cmd.Context(), ko, o.Registry, annotationsMap.Annotations, args, o.FilterRegexp, o.Parallelism, o.Cert, o.Upload, o.PayloadPath, o.Force, o.Recursive, o.Attachment); err != nil {
+					return errors.Wrapf(err, "signing tags of %v", args)
+				}
+				return nil
+			}
+			if err := sign.SignCmd(cmd.Context(), ko, o.Registry, annotationsMap.Annotations, args, o.Cert, o.Upload, o.OutputSignature, o.OutputCertificate, o.OutputSignaturePEM, o.PayloadPath, o.Force, o.Recursive, o.Attachment, o.OCILayoutPath); err != nil {
 				if o.Attachment == "" {
 					return errors.Wrapf(err, "signing %v", args)
 				}
@@ -101,3 +147,18 @@ func Sign() *cobra.Command {
 	o.AddFlags(cmd)
 	return cmd
 }
+
+// signPassFunc returns how to get the private key password: from --password-file
+// or --password-stdin if one is given, and otherwise from COSIGN_PASSWORD or a prompt.
+func signPassFunc(o *options.SignOptions) (cosign.PassFunc, error) {
+	switch {
+	case o.PasswordFile != "" && o.PasswordStdin:
+		return nil, errors.New("--password-file and --password-stdin cannot be used together")
+	case o.PasswordFile != "":
+		return generate.PassFromFile(o.PasswordFile), nil
+	case o.PasswordStdin:
+		return generate.PassFromReader(os.Stdin), nil
+	default:
+		return generate.GetPass, nil
+	}
+}
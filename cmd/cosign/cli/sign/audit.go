@@ -0,0 +1,91 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/sigstore/cosign/pkg/oci"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+)
+
+// auditLogEntry is a line of the --audit-log file.
+type auditLogEntry struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Image          string    `json:"image"`
+	Digest         string    `json:"digest"`
+	SignerIdentity string    `json:"signer_identity,omitempty"`
+	RekorLogID     string    `json:"rekor_log_id,omitempty"`
+	RekorLogIndex  *int64    `json:"rekor_log_index,omitempty"`
+}
+
+// auditLogMu serializes appends from the concurrent signing of --all-tags.
+var auditLogMu sync.Mutex
+
+// appendAuditLog appends a line recording the signature sig over digest to the
+// file at path. The image is signed whether or not this succeeds, so failures
+// are only reported as a warning.
+func appendAuditLog(path string, digest name.Digest, sig oci.Signature, ko KeyOpts) {
+	if path == "" {
+		return
+	}
+	if err := writeAuditLogEntry(path, newAuditLogEntry(digest, sig, ko)); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: unable to write to the audit log %s: %v\n", path, err)
+	}
+}
+
+func newAuditLogEntry(digest name.Digest, sig oci.Signature, ko KeyOpts) auditLogEntry {
+	e := auditLogEntry{
+		Timestamp: time.Now().UTC(),
+		Image:     digest.Context().Name(),
+		Digest:    digest.DigestStr(),
+	}
+	// The identity is the certificate subject when there is a certificate,
+	// and otherwise the key the image was signed with.
+	if cert, err := sig.Cert(); err == nil && cert != nil {
+		e.SignerIdentity = sigs.CertSubject(cert)
+	} else if ko.KeyRef != "" {
+		e.SignerIdentity = ko.KeyRef
+	}
+	if b, err := sig.Bundle(); err == nil && b != nil {
+		e.RekorLogID = b.Payload.LogID
+		e.RekorLogIndex = &b.Payload.LogIndex
+	}
+	return e
+}
+
+func writeAuditLogEntry(path string, e auditLogEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	auditLogMu.Lock()
+	defer auditLogMu.Unlock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
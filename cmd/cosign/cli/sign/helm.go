@@ -0,0 +1,174 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/attestation"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/mutate"
+	"github.com/sigstore/cosign/pkg/oci/static"
+	"github.com/sigstore/cosign/pkg/types"
+)
+
+const (
+	// ArtifactTypeHelm makes SignCmd check that each image is a Helm chart,
+	// sign the chart's metadata in the payload under HelmChartKey, and attach
+	// an attestation of it with the attestation.HelmChartV01 predicate type.
+	ArtifactTypeHelm = "helm"
+
+	// HelmChartKey is the payload annotation holding the name and version of
+	// a Helm chart signed with ArtifactTypeHelm.
+	HelmChartKey = "helmChart"
+)
+
+// validateArtifactType returns an error if artifactType is not a type SignCmd supports.
+func validateArtifactType(artifactType string) error {
+	switch artifactType {
+	case "", ArtifactTypeHelm:
+		return nil
+	default:
+		return fmt.Errorf("unsupported artifact type %q, expected %q", artifactType, ArtifactTypeHelm)
+	}
+}
+
+// withHelmChart checks that se is a Helm chart and returns the chart's metadata,
+// along with a copy of annotations that also holds it under HelmChartKey.
+func withHelmChart(se oci.SignedEntity, annotations map[string]interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	img, ok := se.(oci.SignedImage)
+	if !ok {
+		return nil, nil, errors.New("not a Helm chart: expected an image, not an image index")
+	}
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "getting manifest")
+	}
+	raw, err := img.RawConfigFile()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "getting config")
+	}
+	chart, err := helmChart(string(m.Config.MediaType), raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	withChart := make(map[string]interface{}, len(annotations)+1)
+	for k, v := range annotations {
+		withChart[k] = v
+	}
+	withChart[HelmChartKey] = chart
+	return withChart, chart, nil
+}
+
+// attestHelmChart attaches an attestation of chart, the metadata of the Helm
+// chart at digest, to se and pushes it. The attestation is signed with sv and,
+// like the chart's signature, uploaded to the transparency log if force or the
+// user asks for it.
+func attestHelmChart(ctx context.Context, digest name.Digest, chart map[string]interface{}, ko KeyOpts,
+	regOpts options.RegistryOptions, force bool, dd mutate.DupeDetector, sv *SignerVerifier, se oci.SignedEntity, pusher cremote.Pusher) error {
+	st, err := attestation.NewStatement(digest.String(), attestation.HelmChartV01, chart)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	signedPayload, err := dsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "signing")
+	}
+
+	opts := []static.Option{static.WithLayerMediaType(types.DssePayloadType)}
+	if sv.Cert != nil {
+		opts = append(opts, static.WithCertChain(sv.Cert, sv.Chain))
+	}
+	if ShouldUploadToTlog(ctx, digest, force, ko.RekorURL) {
+		if err := sv.CheckTlogUpload(); err != nil {
+			return err
+		}
+		rClient, err := rekor.NewClient(ko.RekorURL)
+		if err != nil {
+			return err
+		}
+		pemBytes, err := sv.Bytes(ctx)
+		if err != nil {
+			return err
+		}
+		entry, err := cosign.TLogUploadInTotoAttestation(ctx, rClient, signedPayload, pemBytes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
+		opts = append(opts, static.WithBundle(bundle.EntryToBundle(entry)))
+	}
+	att, err := static.NewAttestation(signedPayload, opts...)
+	if err != nil {
+		return err
+	}
+
+	newSE, err := mutate.AttachAttestationToEntity(se, att, mutate.WithDupeDetector(dd))
+	if err != nil {
+		return err
+	}
+	walkOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "constructing client options")
+	}
+	fmt.Fprintln(os.Stderr, "Pushing Helm chart attestation to:", digest.Repository)
+	return pusher.WriteAttestations(digest.Repository, newSE, walkOpts...)
+}
+
+// helmChart returns the name and version, and app version if there is one, of
+// the Helm chart with the given config media type and config.
+func helmChart(configMediaType string, config []byte) (map[string]interface{}, error) {
+	if configMediaType != types.HelmConfigMediaType {
+		return nil, fmt.Errorf("not a Helm chart: config media type is %q, expected %q", configMediaType, types.HelmConfigMediaType)
+	}
+	var meta struct {
+		Name       string `json:"name"`
+		Version    string `json:"version"`
+		AppVersion string `json:"appVersion"`
+	}
+	if err := json.Unmarshal(config, &meta); err != nil {
+		return nil, errors.Wrap(err, "parsing Helm chart metadata")
+	}
+	if meta.Name == "" || meta.Version == "" {
+		return nil, errors.New("no name or version in the Helm chart metadata")
+	}
+	chart := map[string]interface{}{
+		"name":    meta.Name,
+		"version": meta.Version,
+	}
+	if meta.AppVersion != "" {
+		chart["appVersion"] = meta.AppVersion
+	}
+	return chart, nil
+}
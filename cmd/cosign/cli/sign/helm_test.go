@@ -0,0 +1,192 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/attestation"
+	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/oci/signed"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/cosign/pkg/types"
+)
+
+func TestHelmChart(t *testing.T) {
+	config := []byte(`{"name":"nginx","version":"1.2.3","appVersion":"1.21","apiVersion":"v2"}`)
+	got, err := helmChart(types.HelmConfigMediaType, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"name": "nginx", "version": "1.2.3", "appVersion": "1.21"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("helmChart() = %v, want %v", got, want)
+	}
+
+	if _, err := helmChart("application/vnd.oci.image.config.v1+json", config); err == nil {
+		t.Error("expected an error for a non-Helm config media type")
+	}
+	if _, err := helmChart(types.HelmConfigMediaType, []byte(`{"name":"nginx"}`)); err == nil {
+		t.Error("expected an error for a chart without a version")
+	}
+}
+
+func TestWithHelmChartNotAChart(t *testing.T) {
+	img, err := random.Image(300 /* bytes */, 1 /* layers */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := withHelmChart(signed.Image(img), nil); err == nil {
+		t.Error("expected an error for an image that is not a Helm chart")
+	}
+}
+
+func TestValidateArtifactType(t *testing.T) {
+	for _, at := range []string{"", ArtifactTypeHelm} {
+		if err := validateArtifactType(at); err != nil {
+			t.Errorf("validateArtifactType(%q) = %v", at, err)
+		}
+	}
+	if err := validateArtifactType("wasm"); err == nil {
+		t.Error("expected an error for an unsupported artifact type")
+	}
+}
+
+// helmChartImage is a Helm chart with no layers, as pushed to an OCI registry.
+type helmChartImage struct {
+	config []byte
+}
+
+func (h *helmChartImage) RawConfigFile() ([]byte, error) { return h.config, nil }
+
+func (h *helmChartImage) MediaType() (ggcrtypes.MediaType, error) {
+	return ggcrtypes.OCIManifestSchema1, nil
+}
+
+func (h *helmChartImage) RawManifest() ([]byte, error) {
+	return json.Marshal(v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     ggcrtypes.OCIManifestSchema1,
+		Config: v1.Descriptor{
+			MediaType: types.HelmConfigMediaType,
+			Size:      int64(len(h.config)),
+			Digest:    v1.Hash{Algorithm: "sha256", Hex: fmt.Sprintf("%x", sha256.Sum256(h.config))},
+		},
+		Layers: []v1.Descriptor{},
+	})
+}
+
+func (h *helmChartImage) LayerByDigest(v1.Hash) (partial.CompressedLayer, error) {
+	return nil, errors.New("no layers")
+}
+
+func TestSignCmdHelmAttestation(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	keys, err := cosign.GenerateKeyPair(passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKeyPath := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pubKeyPath := filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(pubKeyPath, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := partial.CompressedToImage(&helmChartImage{config: []byte(`{"name":"nginx","version":"1.2.3"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference("registry.example.com/charts/nginx:1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := cremote.NewFakeRegistry()
+	if err := reg.Add(ref, signed.Image(img)); err != nil {
+		t.Fatal(err)
+	}
+
+	ko := KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc, ArtifactType: ArtifactTypeHelm}
+	if err := signCmd(ctx, reg, reg, ko, options.RegistryOptions{}, nil, []string{ref.String()}, "", true, "", "", "", "", false, false, "", "", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := sigs.PublicKeyFromKeyRef(ctx, pubKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	co := &cosign.CheckOpts{Puller: reg, SigVerifier: verifier}
+	atts, _, err := cosign.VerifyImageAttestations(ctx, ref.Context().Digest(h.String()), co)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(atts) != 1 {
+		t.Fatalf("got %d verified attestations, want 1", len(atts))
+	}
+	payload, err := atts[0].Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env dsse.Envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st in_toto.Statement
+	if err := json.Unmarshal(decoded, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.PredicateType != attestation.HelmChartV01 {
+		t.Errorf("predicate type = %q, want %q", st.PredicateType, attestation.HelmChartV01)
+	}
+	want := map[string]interface{}{"name": "nginx", "version": "1.2.3"}
+	if !reflect.DeepEqual(st.Predicate, want) {
+		t.Errorf("predicate = %v, want %v", st.Predicate, want)
+	}
+	if len(st.Subject) != 1 || st.Subject[0].Digest["sha256"] != h.Hex {
+		t.Errorf("subject = %v, want the chart digest %s", st.Subject, h)
+	}
+}
@@ -18,14 +18,17 @@ package sign
 import (
 	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/rsa"
-	"crypto/x509"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -40,16 +43,18 @@ import (
 	ifulcio "github.com/sigstore/cosign/internal/pkg/cosign/fulcio"
 	ipayload "github.com/sigstore/cosign/internal/pkg/cosign/payload"
 	irekor "github.com/sigstore/cosign/internal/pkg/cosign/rekor"
+	itsa "github.com/sigstore/cosign/internal/pkg/cosign/tsa"
 	"github.com/sigstore/cosign/pkg/cosign"
-	"github.com/sigstore/cosign/pkg/cosign/pivkey"
-	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
 	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/cosign/signer"
 	"github.com/sigstore/cosign/pkg/oci"
 	ociempty "github.com/sigstore/cosign/pkg/oci/empty"
+	"github.com/sigstore/cosign/pkg/oci/layout"
 	"github.com/sigstore/cosign/pkg/oci/mutate"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
 	"github.com/sigstore/cosign/pkg/oci/walk"
-	providers "github.com/sigstore/cosign/pkg/providers/all"
+	"github.com/sigstore/cosign/pkg/providers"
+	_ "github.com/sigstore/cosign/pkg/providers/all"
 	sigs "github.com/sigstore/cosign/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
@@ -96,7 +101,99 @@ func GetAttachedImageRef(ref name.Reference, attachment string, opts ...ociremot
 
 // nolint
 func SignCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, annotations map[string]interface{},
-	imgs []string, certPath string, upload bool, outputSignature, outputCertificate string, payloadPath string, force bool, recursive bool, attachment string) error {
+	imgs []string, certPath string, upload bool, outputSignature, outputCertificate, outputSignaturePEM string, payloadPath string, force bool, recursive bool, attachment string, ociLayoutPath string) error {
+	puller, pusher := registryFor(ko)
+	return signCmd(ctx, puller, pusher, ko, regOpts, annotations, imgs, certPath, upload, outputSignature, outputCertificate, outputSignaturePEM, payloadPath, force, recursive, attachment, ociLayoutPath, 1)
+}
+
+// registryFor returns where to fetch images from and store their signatures
+// when signing with ko.
+func registryFor(ko KeyOpts) (cremote.Puller, cremote.Pusher) {
+	if ko.ExperimentalOCI2 {
+		return cremote.Referrers, cremote.Referrers
+	}
+	return cremote.Registry, cremote.Registry
+}
+
+// SignAllTagsCmd signs every tag of each of the repositories in repos whose name
+// matches filter, signing up to parallelism tags at a time. Tags that point at
+// the same image are signed once, by digest.
+// nolint
+func SignAllTagsCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, annotations map[string]interface{},
+	repos []string, filter string, parallelism int, certPath string, upload bool, payloadPath string, force bool, recursive bool, attachment string) error {
+	re, err := regexp.Compile(filter)
+	if err != nil {
+		return errors.Wrap(err, "parsing tag filter")
+	}
+	var imgs []string
+	for _, r := range repos {
+		repo, err := name.NewRepository(r)
+		if err != nil {
+			return errors.Wrap(err, "parsing repository")
+		}
+		tags, err := remote.List(repo, regOpts.GetRegistryClientOpts(ctx)...)
+		if err != nil {
+			return errors.Wrapf(err, "listing tags of %s", repo)
+		}
+		tags = filterTags(tags, re)
+		if len(tags) == 0 {
+			continue
+		}
+		digests, err := uniqueDigests(repo, tags, regOpts.GetRegistryClientOpts(ctx)...)
+		if err != nil {
+			return err
+		}
+		imgs = append(imgs, digests...)
+	}
+	if len(imgs) == 0 {
+		return fmt.Errorf("no tags of %v match %q", repos, filter)
+	}
+	fmt.Fprintf(os.Stderr, "Signing %d images\n", len(imgs))
+	puller, pusher := registryFor(ko)
+	return signCmd(ctx, puller, pusher, ko, regOpts, annotations, imgs, certPath, upload, "", "", "", payloadPath, force, recursive, attachment, "", parallelism)
+}
+
+// uniqueDigests resolves tags in repo and returns a digest reference for each
+// distinct image, in the order of the first tag pointing at it. Signing tags
+// that share an image concurrently would otherwise race to write the same
+// signature manifest.
+func uniqueDigests(repo name.Repository, tags []string, opts ...remote.Option) ([]string, error) {
+	var digests []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		desc, err := remote.Head(repo.Tag(tag), opts...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving %s", repo.Tag(tag))
+		}
+		d := repo.Digest(desc.Digest.String()).String()
+		if !seen[d] {
+			seen[d] = true
+			digests = append(digests, d)
+		}
+	}
+	return digests, nil
+}
+
+// filterTags returns the tags matched by filter, skipping the tags cosign itself
+// uses to store signatures, attestations and SBOMs.
+func filterTags(tags []string, filter *regexp.Regexp) []string {
+	var out []string
+	for _, tag := range tags {
+		if strings.HasSuffix(tag, ".sig") || strings.HasSuffix(tag, ".att") || strings.HasSuffix(tag, ".sbom") {
+			continue
+		}
+		if filter.MatchString(tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// signCmd is SignCmd, fetching images with puller and publishing signatures with pusher,
+// and signing up to parallelism images at a time.
+// nolint
+func signCmd(ctx context.Context, puller cremote.Puller, pusher cremote.Pusher, ko KeyOpts, regOpts options.RegistryOptions, annotations map[string]interface{},
+	imgs []string, certPath string, upload bool, outputSignature, outputCertificate, outputSignaturePEM string, payloadPath string, force bool, recursive bool, attachment string, ociLayoutPath string, parallelism int) error {
 	if options.EnableExperimental() {
 		if options.NOf(ko.KeyRef, ko.Sk) > 1 {
 			return &options.KeyParseError{}
@@ -106,6 +203,12 @@ func SignCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, a
 			return &options.KeyParseError{}
 		}
 	}
+	if err := validateArtifactType(ko.ArtifactType); err != nil {
+		return err
+	}
+	if ociLayoutPath != "" && (len(imgs) != 1 || recursive) {
+		return errors.New("an OCI layout holds a single image: --oci-layout-path cannot be used with several images or --recursive")
+	}
 
 	// TODO: accept a timeout argument and uncomment the block below
 	// if timeout != 0 {
@@ -114,12 +217,11 @@ func SignCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, a
 	// 	defer cancelFn()
 	// }
 
-	sv, err := SignerFromKeyOpts(ctx, certPath, ko)
+	signers, err := newSignerSource(ctx, certPath, ko)
 	if err != nil {
 		return errors.Wrap(err, "getting signer")
 	}
-	defer sv.Close()
-	dd := cremote.NewDupeDetector(sv)
+	defer signers.Close()
 
 	var staticPayload []byte
 	if payloadPath != "" {
@@ -136,7 +238,7 @@ func SignCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, a
 		ErrDone = mutate.ErrSkipChildren
 	}
 
-	for _, inputImg := range imgs {
+	signImage := func(inputImg string) error {
 		ref, err := name.ParseReference(inputImg)
 		if err != nil {
 			return errors.Wrap(err, "parsing reference")
@@ -150,22 +252,36 @@ func SignCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, a
 			return fmt.Errorf("unable to resolve attachment %s for image %s", attachment, inputImg)
 		}
 
-		if digest, ok := ref.(name.Digest); ok && !recursive {
+		// Writing to an OCI layout, checking the artifact type, or referring to the
+		// image from its signature needs the image itself, not just its digest.
+		needsImage := ociLayoutPath != "" || ko.ArtifactType != "" || ko.ExperimentalOCI2
+		if digest, ok := ref.(name.Digest); ok && !recursive && !needsImage {
 			se, err := ociempty.SignedImage(ref)
 			if err != nil {
 				return errors.Wrap(err, "accessing image")
 			}
-			err = signDigest(ctx, digest, staticPayload, ko, regOpts, annotations, upload, outputSignature, outputCertificate, force, dd, sv, se)
+			sv, err := signers.get(ctx)
+			if err != nil {
+				return errors.Wrap(err, "getting signer")
+			}
+			err = signDigest(ctx, digest, staticPayload, ko, regOpts, annotations, upload, outputSignature, outputCertificate, outputSignaturePEM, ociLayoutPath, force, cremote.NewDupeDetector(sv), sv, se, pusher)
 			if err != nil {
 				return errors.Wrap(err, "signing digest")
 			}
-			continue
+			return nil
 		}
 
-		se, err := ociremote.SignedEntity(ref, opts...)
+		se, err := puller.SignedEntity(ref, opts...)
 		if err != nil {
 			return errors.Wrap(err, "accessing entity")
 		}
+		imgAnnotations := annotations
+		var chart map[string]interface{}
+		if ko.ArtifactType == ArtifactTypeHelm {
+			if imgAnnotations, chart, err = withHelmChart(se, annotations); err != nil {
+				return errors.Wrapf(err, "checking %s", inputImg)
+			}
+		}
 
 		if err := walk.SignedEntity(ctx, se, func(ctx context.Context, se oci.SignedEntity) error {
 			// Get the digest for this entity in our walk.
@@ -175,25 +291,91 @@ func SignCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, a
 			}
 			digest := ref.Context().Digest(d.String())
 
-			err = signDigest(ctx, digest, staticPayload, ko, regOpts, annotations, upload, outputSignature, outputCertificate, force, dd, sv, se)
+			sv, err := signers.get(ctx)
+			if err != nil {
+				return errors.Wrap(err, "getting signer")
+			}
+			dd := cremote.NewDupeDetector(sv)
+			err = signDigest(ctx, digest, staticPayload, ko, regOpts, imgAnnotations, upload, outputSignature, outputCertificate, outputSignaturePEM, ociLayoutPath, force, dd, sv, se, pusher)
 			if err != nil {
 				return errors.Wrap(err, "signing digest")
 			}
+			if chart != nil && upload && ociLayoutPath == "" {
+				if err := attestHelmChart(ctx, digest, chart, ko, regOpts, force, dd, sv, se, pusher); err != nil {
+					return errors.Wrap(err, "attesting Helm chart")
+				}
+			}
 			return ErrDone
 		}); err != nil {
 			return errors.Wrap(err, "recursively signing")
 		}
+		return nil
+	}
+
+	if parallelism <= 1 {
+		for _, inputImg := range imgs {
+			if err := signImage(inputImg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	sem := make(chan struct{}, parallelism)
+	for _, inputImg := range imgs {
+		inputImg := inputImg
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := signImage(inputImg); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", inputImg, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d images failed to sign:\n%s", len(errs), len(imgs), strings.Join(errs, "\n"))
 	}
 
 	return nil
 }
 
+// payloadHash returns the SHA-256 digest of payload, in the form sha256:<hex>.
+func payloadHash(payload []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+}
+
+// withContainerIdentity returns a copy of annotations that also names identity
+// under cosign.ContainerIdentityKey.
+func withContainerIdentity(annotations map[string]interface{}, identity string) map[string]interface{} {
+	withIdentity := make(map[string]interface{}, len(annotations)+1)
+	for k, v := range annotations {
+		withIdentity[k] = v
+	}
+	withIdentity[cosign.ContainerIdentityKey] = identity
+	return withIdentity
+}
+
 func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyOpts,
-	regOpts options.RegistryOptions, annotations map[string]interface{}, upload bool, outputSignature, outputCertificate string, force bool,
-	dd mutate.DupeDetector, sv *SignerVerifier, se oci.SignedEntity) error {
+	regOpts options.RegistryOptions, annotations map[string]interface{}, upload bool, outputSignature, outputCertificate, outputSignaturePEM, ociLayoutPath string, force bool,
+	dd mutate.DupeDetector, sv *SignerVerifier, se oci.SignedEntity, pusher cremote.Pusher) error {
 	var err error
 	// The payload can be passed to skip generation.
 	if len(payload) == 0 {
+		if ko.ContainerIdentity != "" {
+			annotations = withContainerIdentity(annotations, ko.ContainerIdentity)
+		}
 		payload, err = (&sigPayload.Cosign{
 			Image:       digest,
 			Annotations: annotations,
@@ -206,7 +388,13 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyO
 	var s icos.Signer
 	s = ipayload.NewSigner(sv)
 	s = ifulcio.NewSigner(s, sv.Cert, sv.Chain)
+	if ko.TSAServerURL != "" {
+		s = itsa.NewSigner(s, ko.TSAServerURL)
+	}
 	if ShouldUploadToTlog(ctx, digest, force, ko.RekorURL) {
+		if err := sv.CheckTlogUpload(); err != nil {
+			return err
+		}
 		rClient, err := rekor.NewClient(ko.RekorURL)
 		if err != nil {
 			return err
@@ -214,10 +402,19 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyO
 		s = irekor.NewSigner(s, rClient)
 	}
 
+	if ko.PrintPayloadHash {
+		fmt.Fprintf(os.Stderr, "Payload hash for %s: %s\n", digest, payloadHash(payload))
+	}
 	ociSig, _, err := s.Sign(ctx, bytes.NewReader(payload))
 	if err != nil {
 		return err
 	}
+	if len(ko.SignatureAnnotations) > 0 {
+		ociSig, err = annotateSignature(ociSig, ko.SignatureAnnotations)
+		if err != nil {
+			return err
+		}
+	}
 
 	b64sig, err := ociSig.Base64Signature()
 	if err != nil {
@@ -230,6 +427,12 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyO
 		}
 	}
 
+	if outputSignaturePEM != "" {
+		if err := writeSignaturePEM(outputSignaturePEM, b64sig, sv.Cert); err != nil {
+			return errors.Wrap(err, "create PEM signature file")
+		}
+	}
+
 	if outputCertificate != "" {
 		rekorBytes, err := sv.Bytes(ctx)
 		if err != nil {
@@ -244,6 +447,7 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyO
 	}
 
 	if !upload {
+		appendAuditLog(ko.AuditLogPath, digest, ociSig, ko)
 		return nil
 	}
 
@@ -253,6 +457,15 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyO
 		return err
 	}
 
+	if ociLayoutPath != "" {
+		fmt.Fprintln(os.Stderr, "Writing signed image to OCI layout:", ociLayoutPath)
+		if err := writeLayout(ociLayoutPath, newSE); err != nil {
+			return err
+		}
+		appendAuditLog(ko.AuditLogPath, digest, ociSig, ko)
+		return nil
+	}
+
 	// Publish the signatures associated with this entity
 	walkOpts, err := regOpts.ClientOpts(ctx)
 	if err != nil {
@@ -268,121 +481,65 @@ func signDigest(ctx context.Context, digest name.Digest, payload []byte, ko KeyO
 	}
 
 	// Publish the signatures associated with this entity
-	if err := ociremote.WriteSignatures(digest.Repository, newSE, walkOpts...); err != nil {
+	if err := pusher.WriteSignatures(digest.Repository, newSE, walkOpts...); err != nil {
 		return err
 	}
 
+	appendAuditLog(ko.AuditLogPath, digest, ociSig, ko)
 	return nil
 }
 
-func signerFromSecurityKey(keySlot string) (*SignerVerifier, error) {
-	sk, err := pivkey.GetKeyWithSlot(keySlot)
+// annotateSignature returns sig with the given annotations added to those it has. The
+// annotations cosign itself sets on a signature cannot be overridden.
+func annotateSignature(sig oci.Signature, annotations map[string]string) (oci.Signature, error) {
+	ann, err := sig.Annotations()
 	if err != nil {
 		return nil, err
 	}
-	sv, err := sk.SignerVerifier()
-	if err != nil {
-		sk.Close()
-		return nil, err
+	merged := make(map[string]string, len(ann)+len(annotations))
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	for k, v := range ann {
+		merged[k] = v
+	}
+	return mutate.Signature(sig, mutate.WithAnnotations(merged))
+}
+
+// writeLayout writes the signed entity and its signatures to an OCI image layout at path.
+func writeLayout(path string, se oci.SignedEntity) error {
+	switch obj := se.(type) {
+	case oci.SignedImage:
+		return layout.WriteSignedImage(path, obj)
+	case oci.SignedImageIndex:
+		return layout.WriteSignedImageIndex(path, obj)
+	default:
+		return fmt.Errorf("unsupported type: %T", se)
 	}
+}
 
-	// Handle the -cert flag.
-	// With PIV, we assume the certificate is in the same slot on the PIV
-	// token as the private key. If it's not there, show a warning to the
-	// user.
-	certFromPIV, err := sk.Certificate()
-	var pemBytes []byte
+func signerFromSecurityKey(keySlot string) (*SignerVerifier, error) {
+	sk, err := signer.FromSecurityKey(keySlot)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "warning: no x509 certificate retrieved from the PIV token")
-	} else {
-		pemBytes, err = cryptoutils.MarshalCertificateToPEM(certFromPIV)
-		if err != nil {
-			sk.Close()
-			return nil, err
-		}
+		return nil, err
 	}
-
 	return &SignerVerifier{
-		Cert:           pemBytes,
-		SignerVerifier: sv,
+		Cert:           sk.Cert,
+		SignerVerifier: sk.SignerVerifier,
 		close:          sk.Close,
 	}, nil
 }
 
 func signerFromKeyRef(ctx context.Context, certPath, keyRef string, passFunc cosign.PassFunc) (*SignerVerifier, error) {
-	k, err := sigs.SignerVerifierFromKeyRef(ctx, keyRef, passFunc)
-	if err != nil {
-		return nil, errors.Wrap(err, "reading key")
-	}
-
-	// Handle the -cert flag
-	// With PKCS11, we assume the certificate is in the same slot on the PKCS11
-	// token as the private key. If it's not there, show a warning to the
-	// user.
-	if pkcs11Key, ok := k.(*pkcs11key.Key); ok {
-		certFromPKCS11, _ := pkcs11Key.Certificate()
-		var pemBytes []byte
-		if certFromPKCS11 == nil {
-			fmt.Fprintln(os.Stderr, "warning: no x509 certificate retrieved from the PKCS11 token")
-		} else {
-			pemBytes, err = cryptoutils.MarshalCertificateToPEM(certFromPKCS11)
-			if err != nil {
-				pkcs11Key.Close()
-				return nil, err
-			}
-		}
-
-		return &SignerVerifier{
-			Cert:           pemBytes,
-			SignerVerifier: k,
-			close:          pkcs11Key.Close,
-		}, nil
-	}
-	certSigner := &SignerVerifier{
-		SignerVerifier: k,
-	}
-	if certPath == "" {
-		return certSigner, nil
-	}
-
-	certBytes, err := os.ReadFile(certPath)
+	k, err := signer.FromKeyRef(ctx, keyRef, certPath, passFunc)
 	if err != nil {
-		return nil, errors.Wrap(err, "read certificate")
-	}
-	// Handle PEM.
-	if bytes.HasPrefix(certBytes, []byte("-----")) {
-		decoded, _ := pem.Decode(certBytes)
-		if decoded.Type != "CERTIFICATE" {
-			return nil, fmt.Errorf("supplied PEM file is not a certificate: %s", certPath)
-		}
-		certBytes = decoded.Bytes
-	}
-	parsedCert, err := x509.ParseCertificate(certBytes)
-	if err != nil {
-		return nil, errors.Wrap(err, "parse x509 certificate")
-	}
-	pk, err := k.PublicKey()
-	if err != nil {
-		return nil, errors.Wrap(err, "get public key")
-	}
-	switch kt := parsedCert.PublicKey.(type) {
-	case *ecdsa.PublicKey:
-		if !kt.Equal(pk) {
-			return nil, errors.New("public key in certificate does not match that in the signing key")
-		}
-	case *rsa.PublicKey:
-		if !kt.Equal(pk) {
-			return nil, errors.New("public key in certificate does not match that in the signing key")
-		}
-	default:
-		return nil, fmt.Errorf("unsupported key type: %T", parsedCert.PublicKey)
-	}
-	pemBytes, err := cryptoutils.MarshalCertificateToPEM(parsedCert)
-	if err != nil {
-		return nil, errors.Wrap(err, "marshaling certificate to PEM")
+		return nil, err
 	}
-	certSigner.Cert = pemBytes
-	return certSigner, nil
+	return &SignerVerifier{
+		Cert:           k.Cert,
+		SignerVerifier: k.SignerVerifier,
+		close:          k.Close,
+	}, nil
 }
 
 func keylessSigner(ctx context.Context, ko KeyOpts) (*SignerVerifier, error) {
@@ -392,7 +549,7 @@ func keylessSigner(ctx context.Context, ko KeyOpts) (*SignerVerifier, error) {
 	}
 	tok := ko.IDToken
 	if providers.Enabled(ctx) {
-		tok, err = providers.Provide(ctx, "sigstore")
+		tok, err = ambientToken(ctx)
 		if err != nil {
 			return nil, errors.Wrap(err, "fetching ambient OIDC credentials")
 		}
@@ -417,6 +574,118 @@ func keylessSigner(ctx context.Context, ko KeyOpts) (*SignerVerifier, error) {
 	}, nil
 }
 
+// ambientTokenRefreshMargin is how long an ambient OIDC token must remain valid
+// for it to be exchanged with Fulcio without first being refreshed.
+const ambientTokenRefreshMargin = providers.CacheRefreshMargin
+
+var (
+	ambientProvidersOnce sync.Once
+	ambientProviders     []providers.ProviderWithExpiry
+)
+
+// cachedProviders returns the registered providers in priority order, each wrapped with
+// providers.WrapWithCache so that signing many artifacts in one process reuses tokens.
+func cachedProviders() []providers.ProviderWithExpiry {
+	ambientProvidersOnce.Do(func() {
+		for _, name := range providers.List() {
+			p, _ := providers.Get(name)
+			ambientProviders = append(ambientProviders, providers.WrapWithCache(p).(providers.ProviderWithExpiry))
+		}
+	})
+	return ambientProviders
+}
+
+// ambientToken fetches an OIDC token from the first enabled provider that furnishes
+// one. If the provider reports that the token expires within ambientTokenRefreshMargin,
+// it is refreshed before being returned.
+func ambientToken(ctx context.Context) (string, error) {
+	err := errors.New("no providers are enabled")
+	for _, p := range cachedProviders() {
+		if !p.Enabled(ctx) {
+			continue
+		}
+		var tok string
+		var exp time.Time
+		tok, exp, err = p.ProvideWithExpiry(ctx, "sigstore")
+		if err != nil {
+			continue
+		}
+		if exp.IsZero() || time.Until(exp) > ambientTokenRefreshMargin {
+			return tok, nil
+		}
+		fmt.Fprintf(os.Stderr, "Ambient OIDC token expires at %s, refreshing it...\n", exp.Format(time.RFC3339))
+		return p.Provide(ctx, "sigstore")
+	}
+	return "", err
+}
+
+// keylessCertRefreshMargin is how long a keyless signing certificate must remain
+// valid for it to be used to sign another image.
+const keylessCertRefreshMargin = time.Minute
+
+// signerSource hands out the signer for each image signed by signCmd. Fulcio
+// certificates are short-lived, so when signing many images with ambient
+// credentials, a certificate about to expire is replaced by a new one from Fulcio,
+// exchanging a freshly fetched token for it.
+type signerSource struct {
+	mu  sync.Mutex
+	sv  *SignerVerifier
+	all []*SignerVerifier
+	// renew returns a new keyless signer, or is nil if the signer can't be renewed.
+	renew func(context.Context) (*SignerVerifier, error)
+	now   func() time.Time
+}
+
+func newSignerSource(ctx context.Context, certPath string, ko KeyOpts) (*signerSource, error) {
+	sv, err := SignerFromKeyOpts(ctx, certPath, ko)
+	if err != nil {
+		return nil, err
+	}
+	s := &signerSource{sv: sv, all: []*SignerVerifier{sv}, now: time.Now}
+	// Only ambient credentials can be refreshed; an --identity-token is used once.
+	if !ko.Sk && ko.KeyRef == "" && providers.Enabled(ctx) {
+		s.renew = func(ctx context.Context) (*SignerVerifier, error) {
+			return keylessSigner(ctx, ko)
+		}
+	}
+	return s, nil
+}
+
+// get returns the signer to sign the next image with.
+func (s *signerSource) get(ctx context.Context) (*SignerVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.renew == nil || s.sv.Cert == nil {
+		return s.sv, nil
+	}
+	cert, err := cryptoutils.UnmarshalCertificatesFromPEM(s.sv.Cert)
+	if err != nil || len(cert) == 0 {
+		return nil, errors.New("parsing signing certificate")
+	}
+	if s.now().Add(keylessCertRefreshMargin).Before(cert[0].NotAfter) {
+		return s.sv, nil
+	}
+	fmt.Fprintf(os.Stderr, "Signing certificate expires at %s, getting a new one...\n", cert[0].NotAfter.Format(time.RFC3339))
+	sv, err := s.renew(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Images still being signed may hold the previous signer, so keep it open.
+	s.sv = sv
+	s.all = append(s.all, sv)
+	return sv, nil
+}
+
+// Close closes every signer handed out.
+func (s *signerSource) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sv := range s.all {
+		sv.Close()
+	}
+}
+
 func SignerFromKeyOpts(ctx context.Context, certPath string, ko KeyOpts) (*SignerVerifier, error) {
 	if ko.Sk {
 		return signerFromSecurityKey(ko.Slot)
@@ -431,6 +700,19 @@ func SignerFromKeyOpts(ctx context.Context, certPath string, ko KeyOpts) (*Signe
 	return keylessSigner(ctx, ko)
 }
 
+// writeSignaturePEM writes the base64 encoded signature b64sig to path as a PEM
+// block, followed by the PEM encoded certificate cert if there is one, so the
+// signature can be checked with standard tools like openssl.
+func writeSignaturePEM(path, b64sig string, cert []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(b64sig)
+	if err != nil {
+		return errors.Wrap(err, "decoding signature")
+	}
+	out := pem.EncodeToMemory(&pem.Block{Type: cosign.SignaturePemType, Bytes: sig})
+	out = append(out, cert...)
+	return os.WriteFile(path, out, 0600)
+}
+
 type SignerVerifier struct {
 	Cert  []byte
 	Chain []byte
@@ -444,6 +726,22 @@ func (c *SignerVerifier) Close() {
 	}
 }
 
+// CheckTlogUpload returns cosign.ErrRSAPSSTlog or cosign.ErrED25519Tlog if c
+// signs with an rsa-pss or ed25519 key, whose signatures Rekor cannot verify.
+func (c *SignerVerifier) CheckTlogUpload() error {
+	if _, ok := c.SignerVerifier.(*cosign.RSAPSSSignerVerifier); ok {
+		return cosign.ErrRSAPSSTlog
+	}
+	pub, err := c.PublicKey()
+	if err != nil {
+		return err
+	}
+	if _, ok := pub.(ed25519.PublicKey); ok {
+		return cosign.ErrED25519Tlog
+	}
+	return nil
+}
+
 func (c *SignerVerifier) Bytes(ctx context.Context) ([]byte, error) {
 	if c.Cert != nil {
 		fmt.Fprintf(os.Stderr, "using ephemeral certificate:\n%s\n", string(c.Cert))
@@ -18,6 +18,7 @@ package sign
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -30,6 +31,9 @@ import (
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
 	"github.com/sigstore/cosign/pkg/cosign"
+	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
 )
 
@@ -44,6 +48,27 @@ type KeyOpts struct {
 	OIDCIssuer       string
 	OIDCClientID     string
 	OIDCClientSecret string
+	TSAServerURL     string
+	BundlePath       string
+	AuditLogPath     string
+	// SignatureAnnotations are set on each signature in the signature manifest
+	// when signing an image.
+	SignatureAnnotations map[string]string
+	// ContainerIdentity, if set, is signed in the payload as the identity of the
+	// container when signing an image.
+	ContainerIdentity string
+	// ArtifactType, if set, is checked against each image when signing images;
+	// see ArtifactTypeHelm.
+	ArtifactType string
+	// ExperimentalOCI2 stores image signatures as OCI v1.1 referrers; see
+	// cremote.Referrers.
+	ExperimentalOCI2 bool
+	// PrintPayloadHash prints the SHA-256 digest of each image signature's
+	// payload to stderr before it is signed.
+	PrintPayloadHash bool
+	// VerifyHook, if set, is called with the outcome of each check made when
+	// verifying a blob or blob attestation; see cosign.CheckOpts.VerifyHook.
+	VerifyHook func(step string, passed bool, detail string)
 
 	// Modeled after InsecureSkipVerify in tls.Config, this disables
 	// verifying the SCT.
@@ -51,10 +76,12 @@ type KeyOpts struct {
 }
 
 // nolint
-func SignBlobCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, payloadPath string, b64 bool, outputSignature string, outputCertificate string, timeout time.Duration) ([]byte, error) {
+func SignBlobCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOptions, payloadPath string, b64 bool, outputSignature string, outputCertificate string,
+	signaturePath string, certificatePath string, timeout time.Duration) ([]byte, error) {
 	var payload []byte
 	var err error
 	var rekorBytes []byte
+	var rekorEntry *models.LogEntryAnon
 
 	if payloadPath == "-" {
 		payload, err = io.ReadAll(os.Stdin)
@@ -77,6 +104,12 @@ func SignBlobCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOption
 	}
 	defer sv.Close()
 
+	if options.EnableExperimental() {
+		if err := sv.CheckTlogUpload(); err != nil {
+			return nil, err
+		}
+	}
+
 	sig, err := sv.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
 	if err != nil {
 		return nil, errors.Wrap(err, "signing blob")
@@ -96,6 +129,34 @@ func SignBlobCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOption
 			return nil, err
 		}
 		fmt.Fprintln(os.Stderr, "tlog entry created with index:", *entry.LogIndex)
+		rekorEntry = entry
+	}
+
+	// The bundle carries the signature and certificate, so it replaces the separate files.
+	if ko.BundlePath != "" {
+		if signaturePath != "" || certificatePath != "" {
+			fmt.Fprintln(os.Stderr, "WARNING: --bundle supersedes --signature and --certificate, which are ignored")
+		}
+		if err := writeSigstoreBundle(ko.BundlePath, payload, sig, sv, rekorEntry); err != nil {
+			return nil, errors.Wrap(err, "create bundle file")
+		}
+		fmt.Printf("Bundle wrote in the file %s\n", ko.BundlePath)
+	} else {
+		if signaturePath != "" {
+			if err := os.WriteFile(signaturePath, []byte(base64.StdEncoding.EncodeToString(sig)), 0600); err != nil {
+				return nil, errors.Wrap(err, "create signature file")
+			}
+			fmt.Printf("Signature wrote in the file %s\n", signaturePath)
+		}
+		if certificatePath != "" {
+			if len(sv.Cert) == 0 {
+				return nil, errors.New("no certificate to write: signing with a key does not produce one")
+			}
+			if err := os.WriteFile(certificatePath, sv.Cert, 0600); err != nil {
+				return nil, errors.Wrap(err, "create certificate file")
+			}
+			fmt.Printf("Certificate wrote in the file %s\n", certificatePath)
+		}
 	}
 
 	if outputSignature != "" {
@@ -131,3 +192,24 @@ func SignBlobCmd(ctx context.Context, ko KeyOpts, regOpts options.RegistryOption
 
 	return sig, nil
 }
+
+// writeSigstoreBundle writes a Sigstore bundle for the signature sig over payload to path.
+func writeSigstoreBundle(path string, payload, sig []byte, sv *SignerVerifier, rekorEntry *models.LogEntryAnon) error {
+	var certs []*x509.Certificate
+	if len(sv.Cert) > 0 {
+		var err error
+		certs, err = cryptoutils.UnmarshalCertificatesFromPEM(append(append([]byte{}, sv.Cert...), sv.Chain...))
+		if err != nil {
+			return errors.Wrap(err, "parsing certificate")
+		}
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		return errors.Wrap(err, "getting public key")
+	}
+	b, err := cbundle.Marshal(payload, sig, certs, pub, rekorEntry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
@@ -16,12 +16,44 @@
 package sign
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/oci/signed"
+	"github.com/sigstore/cosign/pkg/oci/static"
+	"github.com/sigstore/cosign/pkg/providers"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
 // TestSignCmdLocalKeyAndSk verifies the SignCmd returns an error
@@ -37,9 +69,424 @@ func TestSignCmdLocalKeyAndSk(t *testing.T) {
 			Sk:       true,
 		},
 	} {
-		err := SignCmd(ctx, ko, options.RegistryOptions{}, nil, nil, "", false, "", "", "", false, false, "")
+		err := SignCmd(ctx, ko, options.RegistryOptions{}, nil, nil, "", false, "", "", "", "", false, false, "", "")
 		if (errors.Is(err, &options.KeyParseError{}) == false) {
 			t.Fatal("expected KeyParseError")
 		}
 	}
 }
+
+// TestSignCmdOCILayoutSingleImage verifies the SignCmd returns an error
+// if an OCI layout is requested for more than one image
+func TestSignCmdOCILayoutSingleImage(t *testing.T) {
+	ctx := context.Background()
+	ko := KeyOpts{KeyRef: "testLocalPath", PassFunc: generate.GetPass}
+
+	if err := SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{"a", "b"}, "", true, "", "", "", "", false, false, "", t.TempDir()); err == nil {
+		t.Error("expected error signing several images into one OCI layout")
+	}
+	if err := SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{"a"}, "", true, "", "", "", "", false, true, "", t.TempDir()); err == nil {
+		t.Error("expected error signing recursively into an OCI layout")
+	}
+}
+
+// TestSignCmdFakeRegistry signs an image held in a fake registry and
+// verifies the signature that was pushed back to it
+func TestSignCmdFakeRegistry(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	keys, err := cosign.GenerateKeyPair(passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKeyPath := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pubKeyPath := filepath.Join(td, "cosign.pub")
+	if err := os.WriteFile(pubKeyPath, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := random.Image(300 /* bytes */, 3 /* layers */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference("registry.example.com/repo:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := cremote.NewFakeRegistry()
+	if err := reg.Add(ref, signed.Image(img)); err != nil {
+		t.Fatal(err)
+	}
+
+	ko := KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc, SignatureAnnotations: map[string]string{"run-id": "42"}}
+	if err := signCmd(ctx, reg, reg, ko, options.RegistryOptions{}, nil, []string{ref.String()}, "", true, "", "", "", "", false, false, "", "", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := sigs.PublicKeyFromKeyRef(ctx, pubKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	co := &cosign.CheckOpts{Puller: reg, SigVerifier: verifier}
+	verified, _, err := cosign.VerifyImageSignatures(ctx, ref.Context().Digest(h.String()), co)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verified) != 1 {
+		t.Errorf("got %d verified signatures, want 1", len(verified))
+	}
+
+	co.RequiredSignatureAnnotations = map[string]string{"run-id": "42"}
+	if _, _, err := cosign.VerifyImageSignatures(ctx, ref.Context().Digest(h.String()), co); err != nil {
+		t.Errorf("verifying the required signature annotation: %v", err)
+	}
+	co.RequiredSignatureAnnotations = map[string]string{"run-id": "43"}
+	if _, _, err := cosign.VerifyImageSignatures(ctx, ref.Context().Digest(h.String()), co); err == nil {
+		t.Error("expected verification to fail for a mismatched signature annotation")
+	}
+	co.RequiredSignatureAnnotations = nil
+
+	// The signature was not uploaded to Rekor, so there is no bundle to verify offline.
+	co.Offline = true
+	if _, _, err := cosign.VerifyImageSignatures(ctx, ref.Context().Digest(h.String()), co); err == nil {
+		t.Error("expected offline verification of a signature without a bundle to fail")
+	}
+}
+
+// TestSignCmdRSAPSSTlog verifies that signatures from an rsa-pss key are not
+// uploaded to Rekor, which would reject them as PKCS #1 v1.5 signatures
+func TestSignCmdUnsupportedTlogKeys(t *testing.T) {
+	os.Setenv(options.ExperimentalEnv, "1")
+	defer os.Unsetenv(options.ExperimentalEnv)
+
+	ctx := context.Background()
+
+	rekor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to the transparency log: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer rekor.Close()
+
+	for keyType, want := range map[string]error{
+		cosign.RSAPSSKeyType:  cosign.ErrRSAPSSTlog,
+		cosign.ED25519KeyType: cosign.ErrED25519Tlog,
+	} {
+		td := t.TempDir()
+		passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+		keys, err := cosign.GenerateKeyPairWithType(keyType, passFunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privKeyPath := filepath.Join(td, "cosign.key")
+		if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := random.Image(300 /* bytes */, 3 /* layers */)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := name.ParseReference("registry.example.com/repo:latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reg := cremote.NewFakeRegistry()
+		if err := reg.Add(ref, signed.Image(img)); err != nil {
+			t.Fatal(err)
+		}
+
+		ko := KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc, RekorURL: rekor.URL}
+		err = signCmd(ctx, reg, reg, ko, options.RegistryOptions{}, nil, []string{ref.String()}, "", true, "", "", "", "", true, false, "", "", 1)
+		if !errors.Is(err, want) {
+			t.Errorf("%s: signCmd() = %v, want %v", keyType, err, want)
+		}
+
+		payloadPath := filepath.Join(td, "payload")
+		if err := os.WriteFile(payloadPath, []byte("payload"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err = SignBlobCmd(ctx, ko, options.RegistryOptions{}, payloadPath, false, "", "", "", "", 0)
+		if !errors.Is(err, want) {
+			t.Errorf("%s: SignBlobCmd() = %v, want %v", keyType, err, want)
+		}
+	}
+}
+
+// TestSignBlobCmdKeyBundle verifies that a bundle for a blob signed with a key
+// names the key
+func TestSignBlobCmdKeyBundle(t *testing.T) {
+	t.Setenv(options.ExperimentalEnv, "")
+	td := t.TempDir()
+	passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	keys, err := cosign.GenerateKeyPair(passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKeyPath := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	payloadPath := filepath.Join(td, "payload")
+	if err := os.WriteFile(payloadPath, []byte("payload"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ko := KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc, BundlePath: filepath.Join(td, "bundle")}
+	if _, err := SignBlobCmd(context.Background(), ko, options.RegistryOptions{}, payloadPath, true, "", "", "", "", 0); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(ko.BundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb, err := bundle.ParseSigstoreBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(keys.PublicBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hint, err := bundle.PublicKeyHint(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk := sb.VerificationMaterial.PublicKey; pk == nil || pk.Hint != hint {
+		t.Errorf("publicKey = %+v, want hint %s", pk, hint)
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	tags := []string{"latest", "v1.0.0", "v1.1.0", "sha256-abcd.sig", "sha256-abcd.att", "sha256-abcd.sbom", "dev"}
+	for _, tc := range []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"latest", "v1.0.0", "v1.1.0", "dev"}},
+		{`^v1\.`, []string{"v1.0.0", "v1.1.0"}},
+		{"^nope$", nil},
+	} {
+		got := filterTags(tags, regexp.MustCompile(tc.filter))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("filterTags(%q) = %v, want %v", tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestUniqueDigests(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := name.NewRepository(u.Host + "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, tags := range [][]string{{"v1", "latest"}, {"v2"}} {
+		img, err := random.Image(128, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tag := range tags {
+			if err := remote.Write(repo.Tag(tag), img); err != nil {
+				t.Fatal(err)
+			}
+		}
+		h, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, repo.Digest(h.String()).String())
+	}
+
+	got, err := uniqueDigests(repo, []string{"v1", "latest", "v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueDigests() = %v, want %v", got, want)
+	}
+	if _, err := uniqueDigests(repo, []string{"missing"}); err == nil {
+		t.Error("expected an error for a missing tag")
+	}
+}
+
+func TestPayloadHash(t *testing.T) {
+	// echo -n "payload" | sha256sum
+	want := "sha256:239f59ed55e737c77147cf55ad0c1b030b6d7ee748a7426952f9b852d5a935e5"
+	if got := payloadHash([]byte("payload")); got != want {
+		t.Errorf("payloadHash() = %s, want %s", got, want)
+	}
+}
+
+func TestWriteSignaturePEM(t *testing.T) {
+	certPEM := []byte("-----BEGIN CERTIFICATE-----\nMA==\n-----END CERTIFICATE-----\n")
+	for _, cert := range [][]byte{nil, certPEM} {
+		path := filepath.Join(t.TempDir(), "sig.pem")
+		if err := writeSignaturePEM(path, base64.StdEncoding.EncodeToString([]byte("signature")), cert); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, rest := pem.Decode(b)
+		if block == nil || block.Type != cosign.SignaturePemType || string(block.Bytes) != "signature" {
+			t.Errorf("got PEM block %+v, want a %s block holding the signature", block, cosign.SignaturePemType)
+		}
+		if !bytes.Equal(rest, cert) {
+			t.Errorf("got %q after the signature, want %q", rest, cert)
+		}
+	}
+}
+
+func TestAppendAuditLog(t *testing.T) {
+	digest, err := name.NewDigest("example.com/app@sha256:" + strings.Repeat("a", 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := static.NewSignature([]byte("payload"), "c2lnbmF0dXJl", static.WithBundle(&bundle.RekorBundle{
+		Payload: bundle.RekorPayload{LogID: "c0ffee", LogIndex: 7},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ko := KeyOpts{KeyRef: "cosign.key"}
+	appendAuditLog(path, digest, sig, ko)
+	appendAuditLog(path, digest, sig, ko)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want the audit log to be appended to", len(lines))
+	}
+	var e auditLogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Image != "example.com/app" || e.Digest != digest.DigestStr() || e.SignerIdentity != "cosign.key" ||
+		e.RekorLogID != "c0ffee" || e.RekorLogIndex == nil || *e.RekorLogIndex != 7 || e.Timestamp.IsZero() {
+		t.Errorf("unexpected audit log entry %+v", e)
+	}
+
+	// A failure to write the audit log is not fatal.
+	appendAuditLog(t.TempDir(), digest, sig, ko)
+}
+
+type fakeProvider struct {
+	enabled *bool
+	token   string
+}
+
+func (f *fakeProvider) Enabled(context.Context) bool { return *f.enabled }
+
+func (f *fakeProvider) Provide(context.Context, string) (string, error) { return f.token, nil }
+
+func TestAmbientTokenPriority(t *testing.T) {
+	enabled := true
+	t.Cleanup(func() {
+		// The providers stay registered, so keep them out of later tests.
+		enabled = false
+		ambientProvidersOnce, ambientProviders = sync.Once{}, nil
+	})
+	ambientProvidersOnce, ambientProviders = sync.Once{}, nil
+
+	// The lower-priority provider sorts first by name.
+	providers.RegisterWithPriority("a-ambient-test-fallback", &fakeProvider{enabled: &enabled, token: "fallback"}, providers.DefaultPriority+1)
+	providers.RegisterWithPriority("z-ambient-test-preferred", &fakeProvider{enabled: &enabled, token: "preferred"}, -1)
+
+	tok, err := ambientToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "preferred" {
+		t.Errorf("ambientToken() = %q, want the token of the highest priority provider", tok)
+	}
+}
+
+// certExpiringAt returns a PEM encoded self-signed certificate valid until notAfter.
+func certExpiringAt(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-10 * time.Minute),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSignerSourceRenewsExpiringCert(t *testing.T) {
+	start := time.Now()
+	now := start
+	first := &SignerVerifier{Cert: certExpiringAt(t, start.Add(10*time.Minute))}
+	renewals := 0
+	closed := 0
+	s := &signerSource{
+		sv:  first,
+		all: []*SignerVerifier{first},
+		renew: func(context.Context) (*SignerVerifier, error) {
+			renewals++
+			return &SignerVerifier{Cert: certExpiringAt(t, now.Add(10*time.Minute)), close: func() { closed++ }}, nil
+		},
+		now: func() time.Time { return now },
+	}
+
+	if sv, err := s.get(context.Background()); err != nil || sv != first || renewals != 0 {
+		t.Fatalf("get() = %v, %v with %d renewals, want the first signer", sv, err, renewals)
+	}
+	// Within keylessCertRefreshMargin of expiry, a new certificate is fetched.
+	now = start.Add(10*time.Minute - keylessCertRefreshMargin/2)
+	second, err := s.get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first || renewals != 1 {
+		t.Fatalf("get() near expiry returned the first signer, %d renewals", renewals)
+	}
+	if sv, _ := s.get(context.Background()); sv != second || renewals != 1 {
+		t.Errorf("get() renewed a fresh certificate")
+	}
+	s.Close()
+	if closed != 1 {
+		t.Errorf("Close() closed %d renewed signers, want 1", closed)
+	}
+
+	// Without ambient credentials the signer is never renewed.
+	s = &signerSource{sv: first, all: []*SignerVerifier{first}, now: func() time.Time { return start.Add(time.Hour) }}
+	if sv, err := s.get(context.Background()); err != nil || sv != first {
+		t.Errorf("get() = %v, %v, want the only signer", sv, err)
+	}
+}
@@ -52,7 +52,13 @@ func SignBlob() *cobra.Command {
   cosign sign-blob --key gcpkms://projects/[PROJECT]/locations/global/keyRings/[KEYRING]/cryptoKeys/[KEY] <FILE>
 
   # sign a blob with a key pair stored in Hashicorp Vault
-  cosign sign-blob --key hashivault://[KEY] <FILE>`,
+  cosign sign-blob --key hashivault://[KEY] <FILE>
+
+  # sign a blob with Google sign-in (experimental), saving the signature and certificate
+  COSIGN_EXPERIMENTAL=1 cosign sign-blob --signature <FILE>.sig --certificate <FILE>.pem <FILE>
+
+  # sign a blob with Google sign-in (experimental), saving everything needed to verify it in a bundle
+  COSIGN_EXPERIMENTAL=1 cosign sign-blob --bundle <FILE>.sigstore <FILE>`,
 		Args: cobra.MinimumNArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// A key file is required unless we're in experimental mode!
@@ -76,6 +82,7 @@ func SignBlob() *cobra.Command {
 				OIDCIssuer:               o.OIDC.Issuer,
 				OIDCClientID:             o.OIDC.ClientID,
 				OIDCClientSecret:         o.OIDC.ClientSecret,
+				BundlePath:               o.BundlePath,
 			}
 			for _, blob := range args {
 				// TODO: remove when the output flag has been deprecated
@@ -83,7 +90,8 @@ func SignBlob() *cobra.Command {
 					fmt.Fprintln(os.Stderr, "WARNING: the '--output' flag is deprecated and will be removed in the future. Use '--output-signature'")
 					o.OutputSignature = o.Output
 				}
-				if _, err := sign.SignBlobCmd(cmd.Context(), ko, o.Registry, blob, o.Base64Output, o.OutputSignature, o.OutputCertificate, o.Timeout); err != nil {
+				if _, err := sign.SignBlobCmd(cmd.Context(), ko, o.Registry, blob, o.Base64Output, o.OutputSignature, o.OutputCertificate,
+					o.Signature, o.Certificate, o.Timeout); err != nil {
 					return errors.Wrapf(err, "signing %s", blob)
 				}
 			}
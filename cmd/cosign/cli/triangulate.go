@@ -28,14 +28,17 @@ func Triangulate() *cobra.Command {
 	o := &options.TriangulateOptions{}
 
 	cmd := &cobra.Command{
-		Use:     "triangulate",
-		Short:   "Outputs the located cosign image reference. This is the location cosign stores the specified artifact type.",
-		Example: "  cosign triangulate <IMAGE>",
+		Use:   "triangulate",
+		Short: "Outputs the located cosign image reference. This is the location cosign stores the specified artifact type.",
+		Example: `  cosign triangulate <IMAGE>
+
+  # check that the signature image exists in the registry
+  cosign triangulate --check <IMAGE>`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return flag.ErrHelp
 			}
-			return triangulate.MungeCmd(cmd.Context(), o.Registry, args[0], o.Type)
+			return triangulate.MungeCmd(cmd.Context(), o.Registry, args[0], o.Type, o.Check)
 		},
 	}
 
@@ -18,15 +18,20 @@ package triangulate
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/pkg/errors"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/pkg/cosign"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
 )
 
-func MungeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef string, attachmentType string) error {
+func MungeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef string, attachmentType string, check bool) error {
 	ref, err := name.ParseReference(imageRef)
 	if err != nil {
 		return err
@@ -53,5 +58,31 @@ func MungeCmd(ctx context.Context, regOpts options.RegistryOptions, imageRef str
 	}
 
 	fmt.Println(dstRef.Name())
+	if !check {
+		return nil
+	}
+
+	desc, err := headRef(dstRef, regOpts.GetRegistryClientOpts(ctx)...)
+	if err != nil {
+		return errors.Wrapf(err, "checking %s", dstRef.Name())
+	}
+	if desc == nil {
+		fmt.Fprintf(os.Stderr, "No %s found at %s\n", attachmentType, dstRef.Name())
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Found %s at %s with digest %s\n", attachmentType, dstRef.Name(), desc.Digest)
 	return nil
 }
+
+// headRef issues a HEAD request for ref, returning a nil descriptor if the registry has nothing stored there.
+func headRef(ref name.Reference, opts ...remote.Option) (*v1.Descriptor, error) {
+	desc, err := remote.Head(ref, opts...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return desc, nil
+}
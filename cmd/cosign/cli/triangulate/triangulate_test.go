@@ -0,0 +1,73 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triangulate
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestHeadRef(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	present, err := name.NewTag(fmt.Sprintf("%s/repo:present", u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(128, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(present, img); err != nil {
+		t.Fatal(err)
+	}
+	wantDigest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := headRef(present)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc == nil || desc.Digest != wantDigest {
+		t.Errorf("headRef(%s) = %v, want digest %s", present, desc, wantDigest)
+	}
+
+	missing, err := name.NewTag(fmt.Sprintf("%s/repo:missing", u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err = headRef(missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc != nil {
+		t.Errorf("headRef(%s) = %v, want nil", missing, desc)
+	}
+}
@@ -56,7 +56,10 @@ func uploadBlob() *cobra.Command {
   cosign upload blob -f foo:MYOS/MYPLATFORM <IMAGE>
 
   # upload two blobs named foo-darwin and foo-linux to the location specified by <IMAGE>, setting the os fields
-  cosign upload blob -f foo-darwin:darwin -f foo-linux:linux <IMAGE>`,
+  cosign upload blob -f foo-darwin:darwin -f foo-linux:linux <IMAGE>
+
+  # upload a Helm chart, setting the media type of its layer
+  cosign upload blob -f mychart-0.1.0.tgz --media-type application/vnd.cncf.helm.chart.content.v1.tar+gzip <IMAGE>`,
 		Args: cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if len(o.Files.Files) < 1 {
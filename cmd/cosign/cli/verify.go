@@ -31,7 +31,11 @@ func Verify() *cobra.Command {
 		Use:   "verify",
 		Short: "Verify a signature on the supplied container image",
 		Long: `Verify signature and annotations on an image by checking the claims
-against the transparency log.`,
+against the transparency log.
+
+For a private Sigstore deployment, --tuf-mirror and --tuf-root point cosign at
+its TUF repository and trusted root. Together they replace initializing the
+TUF cache separately or setting SIGSTORE_ROOT_FILE to the deployment's Fulcio root.`,
 		Example: `  cosign verify --key <key path>|<key url>|<kms uri> <image uri> [<image uri> ...]
 
   # verify cosign claims and signing certificates on the image
@@ -40,12 +44,24 @@ against the transparency log.`,
   # verify multiple images
   cosign verify <IMAGE_1> <IMAGE_2> ...
 
+  # verify multiple images, up to four at a time
+  cosign verify --max-workers 4 <IMAGE_1> <IMAGE_2> ...
+
+  # verify every image listed in a file, one per line; the exit status is the number of images that failed
+  cosign verify --key cosign.pub --images-file images.txt
+
   # additionally verify specified annotations
   cosign verify -a key1=val1 -a key2=val2 <IMAGE>
 
+  # additionally require an annotation set with 'cosign sign --annotation' on the signature
+  cosign verify --key cosign.pub --require-annotation pipeline-run=1234 <IMAGE>
+
   # (experimental) additionally, verify with the transparency log
   COSIGN_EXPERIMENTAL=1 cosign verify <IMAGE>
 
+  # (experimental) verify transparency log inclusion from the signatures' Rekor bundles, without contacting Rekor
+  COSIGN_EXPERIMENTAL=1 cosign verify --offline <IMAGE>
+
   # verify image with an on-disk public key
   cosign verify --key cosign.pub <IMAGE>
 
@@ -72,14 +88,38 @@ against the transparency log.`,
   cosign verify --key gitlab://[OWNER]/[PROJECT_NAME] <IMAGE>
 
   # verify image with public key stored in GitLab with project id
-  cosign verify --key gitlab://[PROJECT_ID] <IMAGE>`,
+  cosign verify --key gitlab://[PROJECT_ID] <IMAGE>
 
-		Args: cobra.MinimumNArgs(1),
+  # verify image against the TUF root of a private Sigstore deployment
+  COSIGN_EXPERIMENTAL=1 cosign verify --tuf-mirror https://tuf.example.com --tuf-root root.json <IMAGE>
+
+  # verify image and write the verified signatures as a JSON array to a file
+  cosign verify --key cosign.pub --output-format json --output-file verification.json <IMAGE>`,
+
+		Args: func(cmd *cobra.Command, args []string) error {
+			if o.ImagesFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			images := args
+			if o.ImagesFile != "" {
+				fromFile, err := verify.ReadImagesFile(o.ImagesFile)
+				if err != nil {
+					return err
+				}
+				images = append(images, fromFile...)
+			}
+
 			annotations, err := o.AnnotationsMap()
 			if err != nil {
 				return err
 			}
+			requiredAnnotations, err := options.ParseSignatureAnnotations(o.RequireAnnotations)
+			if err != nil {
+				return err
+			}
 
 			hashAlgorithm, err := o.SignatureDigest.HashAlgorithm()
 			if err != nil {
@@ -87,23 +127,36 @@ against the transparency log.`,
 			}
 
 			v := verify.VerifyCommand{
-				RegistryOptions: o.Registry,
-				CheckClaims:     o.CheckClaims,
-				KeyRef:          o.Key,
-				CertRef:         o.Cert,
-				CertEmail:       o.CertEmail,
-				Sk:              o.SecurityKey.Use,
-				Slot:            o.SecurityKey.Slot,
-				Output:          o.Output,
-				RekorURL:        o.Rekor.URL,
-				Attachment:      o.Attachment,
-				Annotations:     annotations,
-				HashAlgorithm:   hashAlgorithm,
-				SignatureRef:    o.SignatureRef,
-				LocalImage:      o.LocalImage,
+				RegistryOptions:      o.Registry,
+				CheckClaims:          o.CheckClaims,
+				KeyRef:               o.Key,
+				CertRef:              o.Cert,
+				CertEmail:            o.CertEmail,
+				CertOidcIssuerRegexp: o.CertOidcIssuerRegexp,
+				TSACertChain:         o.TSACertChain,
+				TUFRoot:              o.TUFRoot,
+				TUFMirror:            o.TUFMirror,
+				Offline:              o.Offline,
+				MaxWorkers:           o.MaxWorkers,
+				Sk:                   o.SecurityKey.Use,
+				Slot:                 o.SecurityKey.Slot,
+				Output:               o.Output,
+				OutputFormat:         o.OutputFormat,
+				RekorURL:             o.Rekor.URL,
+				Attachment:           o.Attachment,
+				Annotations:          annotations,
+				RequiredAnnotations:  requiredAnnotations,
+				HashAlgorithm:        hashAlgorithm,
+				SignatureRef:         o.SignatureRef,
+				LocalImage:           o.LocalImage,
+				SearchRekor:          o.SearchRekor,
+				Threshold:            o.Threshold,
+				AdditionalKeyRefs:    o.AdditionalKeys,
+				RequireIdentity:      o.RequireIdentity,
+				ExperimentalOCI2:     o.ExperimentalOCI2,
 			}
 
-			return v.Exec(cmd.Context(), args)
+			return v.Exec(cmd.Context(), images)
 		},
 	}
 
@@ -133,6 +186,9 @@ against the transparency log.`,
   # (experimental) additionally, verify with the transparency log
   COSIGN_EXPERIMENTAL=1 cosign verify-attestation <IMAGE>
 
+  # (experimental) only accept attestations signed by release workflows of any repository in an organization
+  COSIGN_EXPERIMENTAL=1 cosign verify-attestation --certificate-identity-regexp '^https://github\.com/myorg/[^/]+/\.github/workflows/release\.yml@' <IMAGE>
+
   # verify image with public key
   cosign verify-attestation --key cosign.pub <IMAGE>
 
@@ -168,6 +224,8 @@ against the transparency log.`,
 				PredicateType:   o.Predicate.Type,
 				Policies:        o.Policies,
 				LocalImage:      o.LocalImage,
+
+				CertIdentityRegexp: o.CertIdentityRegexp,
 			}
 			return v.Exec(cmd.Context(), args)
 		},
@@ -226,8 +284,53 @@ The blob may be specified as a path to a file or - for stdin.`,
 
   # Verify a signature against a certificate
   cosign verify-blob --cert <cert> --signature $sig <blob>
+
+  # Verify a blob against a Sigstore bundle written by another Sigstore client
+  cosign verify-blob --bundle artifact.sigstore <blob>
 `,
 
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ko := sign.KeyOpts{
+				KeyRef:     o.Key,
+				Sk:         o.SecurityKey.Use,
+				Slot:       o.SecurityKey.Slot,
+				RekorURL:   o.Rekor.URL,
+				BundlePath: o.BundlePath,
+			}
+			if err := verify.VerifyBlobCmd(cmd.Context(), ko, o.Cert, o.Signature, args[0]); err != nil {
+				return errors.Wrapf(err, "verifying blob %s", args)
+			}
+			return nil
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func VerifyBlobAttestation() *cobra.Command {
+	o := &options.VerifyBlobAttestationOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify-blob-attestation",
+		Short: "Verify an attestation on the supplied blob",
+		Long: `Verify a signed in-toto attestation of a blob stored in a registry, checking that one
+of the attestation's subjects is the blob.
+
+The attestation file may hold several DSSE envelopes, one per line. Use --predicate-type
+to only verify the attestations of that predicate type.`,
+		Example: `  cosign verify-blob-attestation (--key <key path>|<key url>|<kms uri>|--cert <cert>) --signature <attestation> <blob uri>
+
+  # Verify the attestation of a blob with a public key
+  cosign verify-blob-attestation --key cosign.pub --signature attestation.json <blob uri>
+
+  # Verify the attestation of a blob with a Fulcio certificate
+  cosign verify-blob-attestation --cert signing.crt --signature attestation.json <blob uri>
+
+  # Verify only the SLSA provenance among several attestations
+  cosign verify-blob-attestation --key cosign.pub --signature attestations.jsonl --predicate-type slsaprovenance <blob uri>`,
+
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ko := sign.KeyOpts{
@@ -236,8 +339,8 @@ The blob may be specified as a path to a file or - for stdin.`,
 				Slot:     o.SecurityKey.Slot,
 				RekorURL: o.Rekor.URL,
 			}
-			if err := verify.VerifyBlobCmd(cmd.Context(), ko, o.Cert, o.Signature, args[0]); err != nil {
-				return errors.Wrapf(err, "verifying blob %s", args)
+			if err := verify.VerifyBlobAttestationCmd(cmd.Context(), ko, o.Registry, o.Cert, o.Signature, o.PredicateType, args[0]); err != nil {
+				return errors.Wrapf(err, "verifying blob attestation %s", args[0])
 			}
 			return nil
 		},
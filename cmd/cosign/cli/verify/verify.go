@@ -18,13 +18,16 @@ package verify
 import (
 	"context"
 	"crypto"
-	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/pkg/errors"
@@ -35,8 +38,11 @@ import (
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/sigstore/cosign/pkg/blob"
 	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/pkg/cosign/pivkey"
 	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
+	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/cosign/tuf"
 	"github.com/sigstore/cosign/pkg/oci"
 	sigs "github.com/sigstore/cosign/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
@@ -48,19 +54,32 @@ import (
 // nolint
 type VerifyCommand struct {
 	options.RegistryOptions
-	CheckClaims   bool
-	KeyRef        string
-	CertRef       string
-	CertEmail     string
-	Sk            bool
-	Slot          string
-	Output        string
-	RekorURL      string
-	Attachment    string
-	Annotations   sigs.AnnotationsMap
-	SignatureRef  string
-	HashAlgorithm crypto.Hash
-	LocalImage    bool
+	CheckClaims          bool
+	KeyRef               string
+	CertRef              string
+	CertEmail            string
+	CertOidcIssuerRegexp string
+	Sk                   bool
+	Slot                 string
+	Output               string
+	OutputFormat         string
+	RekorURL             string
+	Attachment           string
+	Annotations          sigs.AnnotationsMap
+	RequiredAnnotations  map[string]string
+	SignatureRef         string
+	HashAlgorithm        crypto.Hash
+	LocalImage           bool
+	TSACertChain         string
+	TUFRoot              string
+	TUFMirror            string
+	Offline              bool
+	MaxWorkers           int
+	SearchRekor          bool
+	Threshold            int
+	AdditionalKeyRefs    []string
+	RequireIdentity      bool
+	ExperimentalOCI2     bool
 }
 
 // Exec runs the verification command
@@ -76,6 +95,12 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 		return flag.ErrHelp
 	}
 
+	switch c.OutputFormat {
+	case "json", "":
+	default:
+		return fmt.Errorf("unsupported output format %q, expected json", c.OutputFormat)
+	}
+
 	// always default to sha256 if the algorithm hasn't been explicitly set
 	if c.HashAlgorithm == 0 {
 		c.HashAlgorithm = crypto.SHA256
@@ -89,23 +114,55 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 		return errors.Wrap(err, "constructing client options")
 	}
 	co := &cosign.CheckOpts{
-		Annotations:        c.Annotations.Annotations,
-		RegistryClientOpts: ociremoteOpts,
-		CertEmail:          c.CertEmail,
-		SignatureRef:       c.SignatureRef,
+		Annotations:                  c.Annotations.Annotations,
+		RequiredSignatureAnnotations: c.RequiredAnnotations,
+		RegistryClientOpts:           ociremoteOpts,
+		CertEmail:                    c.CertEmail,
+		SignatureRef:                 c.SignatureRef,
+		VerifySCT:                    true,
+		SignatureThreshold:           c.Threshold,
+	}
+	if c.ExperimentalOCI2 {
+		co.Puller = cremote.Referrers
+	}
+	if c.CertOidcIssuerRegexp != "" {
+		co.OIDCIssuerRegexp, err = regexp.Compile(c.CertOidcIssuerRegexp)
+		if err != nil {
+			return errors.Wrap(err, "compiling certificate OIDC issuer regexp")
+		}
 	}
 	if c.CheckClaims {
 		co.ClaimVerifier = cosign.SimpleClaimVerifier
+	} else {
+		// Only the presence of a valid signature matters, so stop at the first one.
+		co.FirstMatch = true
 	}
+	// Trust the Fulcio roots and log keys of a private TUF repository, without
+	// touching the local TUF cache.
+	useTUF := c.TUFRoot != "" || c.TUFMirror != ""
+	if useTUF {
+		if err := loadTUFTrust(ctx, c.TUFRoot, c.TUFMirror, co); err != nil {
+			return errors.Wrap(err, "loading TUF root")
+		}
+	}
+	if c.TSACertChain != "" {
+		co.TSACerts, err = loadCertPoolFromFileOrURL(c.TSACertChain)
+		if err != nil {
+			return errors.Wrap(err, "loading timestamp authority certificate chain")
+		}
+	}
+	co.Offline = c.Offline
 	if options.EnableExperimental() {
-		if c.RekorURL != "" {
+		if c.RekorURL != "" && !c.Offline {
 			rekorClient, err := rekor.NewClient(c.RekorURL)
 			if err != nil {
 				return errors.Wrap(err, "creating Rekor client")
 			}
 			co.RekorClient = rekorClient
 		}
-		co.RootCerts = fulcio.GetRoots()
+		if !useTUF {
+			co.RootCerts = fulcio.GetRoots()
+		}
 	}
 	keyRef := c.KeyRef
 	certRef := c.CertRef
@@ -137,44 +194,213 @@ func (c *VerifyCommand) Exec(ctx context.Context, images []string) (err error) {
 		if err != nil {
 			return err
 		}
-		pubKey, err = signature.LoadECDSAVerifier(cert.PublicKey.(*ecdsa.PublicKey), crypto.SHA256)
+		pubKey, err = signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
 		if err != nil {
 			return err
 		}
 	}
 	co.SigVerifier = pubKey
+	if len(c.AdditionalKeyRefs) > 0 && keyRef == "" {
+		return errors.New("--additional-key requires --key")
+	}
+	// A key passed more than once is loaded once.
+	seenKeyRefs := map[string]bool{keyRef: true}
+	for _, ref := range c.AdditionalKeyRefs {
+		if seenKeyRefs[ref] {
+			continue
+		}
+		seenKeyRefs[ref] = true
+		v, err := sigs.PublicKeyFromKeyRefWithHashAlgo(ctx, ref, c.HashAlgorithm)
+		if err != nil {
+			return errors.Wrapf(err, "loading public key %s", ref)
+		}
+		if pkcs11Key, ok := v.(*pkcs11key.Key); ok {
+			defer pkcs11Key.Close()
+		}
+		co.AdditionalSigVerifiers = append(co.AdditionalSigVerifiers, v)
+	}
+
+	// Searching Rekor needs a client even when attached signatures are not
+	// checked against the log.
+	searchOpts := *co
+	if c.SearchRekor && searchOpts.RekorClient == nil {
+		if c.Offline {
+			return errors.New("--search-rekor cannot be used with --offline")
+		}
+		rekorClient, err := rekor.NewClient(c.RekorURL)
+		if err != nil {
+			return errors.Wrap(err, "creating Rekor client")
+		}
+		searchOpts.RekorClient = rekorClient
+	}
 
-	for _, img := range images {
+	results := verifyImages(images, c.MaxWorkers, func(img string) verifyResult {
 		if c.LocalImage {
 			verified, bundleVerified, err := cosign.VerifyLocalImageSignatures(ctx, img, co)
-			if err != nil {
-				return err
-			}
-			PrintVerificationHeader(img, co, bundleVerified)
-			PrintVerification(img, verified, c.Output)
-		} else {
-			ref, err := name.ParseReference(img)
-			if err != nil {
-				return errors.Wrap(err, "parsing reference")
-			}
-			ref, err = sign.GetAttachedImageRef(ref, c.Attachment, ociremoteOpts...)
-			if err != nil {
-				return errors.Wrapf(err, "resolving attachment type %s for image %s", c.Attachment, img)
-			}
+			return verifyResult{name: img, verified: verified, bundleVerified: bundleVerified, err: err}
+		}
+		ref, err := name.ParseReference(img)
+		if err != nil {
+			return verifyResult{err: errors.Wrap(err, "parsing reference")}
+		}
+		ref, err = sign.GetAttachedImageRef(ref, c.Attachment, ociremoteOpts...)
+		if err != nil {
+			return verifyResult{err: errors.Wrapf(err, "resolving attachment type %s for image %s", c.Attachment, img)}
+		}
+		co := co
+		if c.RequireIdentity {
+			// Each image is checked against its own reference.
+			imgOpts := *co
+			imgOpts.ContainerIdentity = img
+			co = &imgOpts
+		}
+		verified, bundleVerified, err := cosign.VerifyImageSignatures(ctx, ref, co)
+		if c.SearchRekor && errors.Is(err, cosign.ErrNoSignatures) {
+			// Signatures found in Rekor are verified against the log directly.
+			verified, err = cosign.VerifyImageSignaturesInRekor(ctx, ref, &searchOpts)
+			bundleVerified = false
+		}
+		return verifyResult{name: ref.Name(), verified: verified, bundleVerified: bundleVerified, err: err}
+	})
 
-			verified, bundleVerified, err := cosign.VerifyImageSignatures(ctx, ref, co)
-			if err != nil {
-				return err
+	var structured []VerificationOutput
+	var failed []string
+	for i, r := range results {
+		if r.err != nil {
+			// A single image fails with its own error; with several, every
+			// image is verified and the failures are reported together.
+			if len(results) == 1 {
+				return r.err
 			}
-
-			PrintVerificationHeader(ref.Name(), co, bundleVerified)
-			PrintVerification(ref.Name(), verified, c.Output)
+			fmt.Fprintf(os.Stderr, "Verification failed for %s: %v\n", images[i], r.err)
+			failed = append(failed, images[i])
+			continue
+		}
+		PrintVerificationHeader(r.name, co, r.bundleVerified)
+		if c.OutputFormat == "json" {
+			structured = append(structured, verificationOutputs(r.name, r.verified, co.RootCerts)...)
+			continue
 		}
+		PrintVerification(r.name, r.verified, c.Output, co.RootCerts)
 	}
 
+	if c.OutputFormat == "json" {
+		b, err := json.Marshal(structured)
+		if err != nil {
+			return errors.Wrap(err, "generating the output")
+		}
+		fmt.Printf("\n%s\n", string(b))
+	}
+	if len(failed) > 0 {
+		return &FailedImagesError{Failed: failed, Total: len(images)}
+	}
 	return nil
 }
 
+// FailedImagesError is returned when some of several images fail verification.
+type FailedImagesError struct {
+	Failed []string
+	Total  int
+}
+
+func (e *FailedImagesError) Error() string {
+	return fmt.Sprintf("%d of %d images failed verification: %s", len(e.Failed), e.Total, strings.Join(e.Failed, ", "))
+}
+
+// ExitCode is the status cosign exits with: the number of images that failed,
+// capped at 125 so that it can't be mistaken for a shell's own statuses.
+func (e *FailedImagesError) ExitCode() int {
+	if n := len(e.Failed); n < 125 {
+		return n
+	}
+	return 125
+}
+
+// ReadImagesFile reads image references from the file at path, one per line.
+// Blank lines and lines starting with # are skipped.
+func ReadImagesFile(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading images file")
+	}
+	var images []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		images = append(images, line)
+	}
+	return images, nil
+}
+
+// verifyResult is the outcome of verifying a single image.
+type verifyResult struct {
+	name           string
+	verified       []oci.Signature
+	bundleVerified bool
+	err            error
+}
+
+// verifyImages calls verify for each of images, running at most maxWorkers at once,
+// and returns the results in the order of images.
+func verifyImages(images []string, maxWorkers int, verify func(img string) verifyResult) []verifyResult {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+	results := make([]verifyResult, len(images))
+	sem := make(chan struct{}, maxWorkers)
+	var wg sync.WaitGroup
+	for i, img := range images {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, img string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = verify(img)
+		}(i, img)
+	}
+	wg.Wait()
+	return results
+}
+
+// VerificationOutput describes a verified signature in the --output-format json document.
+type VerificationOutput struct {
+	ImageRef         string              `json:"image_ref"`
+	Signature        string              `json:"signature"`
+	Certificate      string              `json:"certificate,omitempty"`
+	CertificateChain []string            `json:"certificate_chain,omitempty"`
+	RekorBundle      *bundle.RekorBundle `json:"rekor_bundle,omitempty"`
+	VerifiedAt       time.Time           `json:"verified_at"`
+}
+
+func verificationOutputs(imgRef string, verified []oci.Signature, roots *x509.CertPool) []VerificationOutput {
+	now := time.Now().UTC()
+	out := make([]VerificationOutput, 0, len(verified))
+	for _, sig := range verified {
+		vo := VerificationOutput{
+			ImageRef:   imgRef,
+			VerifiedAt: now,
+		}
+		if b64sig, err := sig.Base64Signature(); err == nil {
+			vo.Signature = b64sig
+		}
+		if cert, err := sig.Cert(); err == nil && cert != nil {
+			if pemBytes, err := cryptoutils.MarshalCertificateToPEM(cert); err == nil {
+				vo.Certificate = string(pemBytes)
+			}
+			vo.CertificateChain = certificateChain(cert, roots)
+		}
+		if rb, err := sig.Bundle(); err == nil && rb != nil {
+			vo.RekorBundle = rb
+		}
+		out = append(out, vo)
+	}
+	return out
+}
+
 func PrintVerificationHeader(imgRef string, co *cosign.CheckOpts, bundleVerified bool) {
 	fmt.Fprintf(os.Stderr, "\nVerification for %s --\n", imgRef)
 	fmt.Fprintln(os.Stderr, "The following checks were performed on each of these signatures:")
@@ -196,8 +422,30 @@ func PrintVerificationHeader(imgRef string, co *cosign.CheckOpts, bundleVerified
 	fmt.Fprintln(os.Stderr, "  - Any certificates were verified against the Fulcio roots.")
 }
 
-// PrintVerification logs details about the verification to stdout
-func PrintVerification(imgRef string, verified []oci.Signature, output string) {
+// certificateChain returns the chain cert was verified with against roots, PEM-encoded
+// from the leaf to the root, or nil if there are no roots or it does not verify.
+func certificateChain(cert *x509.Certificate, roots *x509.CertPool) []string {
+	if roots == nil {
+		return nil
+	}
+	chain, err := cosign.TrustedChain(cert, roots)
+	if err != nil {
+		return nil
+	}
+	pems := make([]string, 0, len(chain))
+	for _, c := range chain {
+		p, err := cryptoutils.MarshalCertificateToPEM(c)
+		if err != nil {
+			return nil
+		}
+		pems = append(pems, string(p))
+	}
+	return pems
+}
+
+// PrintVerification logs details about the verification to stdout. Certificates are
+// verified against roots again to include their chain in the JSON output.
+func PrintVerification(imgRef string, verified []oci.Signature, output string, roots *x509.CertPool) {
 	switch output {
 	case "text":
 		for _, sig := range verified {
@@ -239,6 +487,9 @@ func PrintVerification(imgRef string, verified []oci.Signature, output string) {
 				if issuerURL := sigs.CertIssuerExtension(cert); issuerURL != "" {
 					ss.Optional["Issuer"] = issuerURL
 				}
+				if chain := certificateChain(cert, roots); chain != nil {
+					ss.Optional["certificate_chain"] = chain
+				}
 			}
 			if bundle, err := sig.Bundle(); err == nil && bundle != nil {
 				if ss.Optional == nil {
@@ -282,3 +533,73 @@ func loadCertFromFileOrURL(path string) (*x509.Certificate, error) {
 	}
 	return certs[0], nil
 }
+
+func loadCertPoolFromFileOrURL(path string) (*x509.CertPool, error) {
+	pems, err := blob.LoadFileOrURL(path)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pems)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certs found in pem file")
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// loadTUFTrust sets the Fulcio roots, CT log keys and Rekor keys of co to those of
+// the TUF repository at mirror, starting from the trusted root at rootRef or the
+// embedded one. The repository is fetched into memory, leaving the local TUF
+// cache untouched.
+func loadTUFTrust(ctx context.Context, rootRef, mirror string, co *cosign.CheckOpts) error {
+	var rootBytes []byte
+	if rootRef != "" {
+		var err error
+		if rootBytes, err = blob.LoadFileOrURL(rootRef); err != nil {
+			return err
+		}
+	}
+	t, err := tuf.NewFromConfigWithContext(ctx, tuf.TUFConfig{
+		Mirror:    mirror,
+		RootBytes: rootBytes,
+		Options:   &tuf.TUFOptions{ClientOptions: []tuf.ClientOption{tuf.WithInMemoryStore()}},
+	})
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	root, err := t.TrustedRoot()
+	if err != nil {
+		return err
+	}
+
+	co.RootCerts = x509.NewCertPool()
+	for _, ca := range root.CertificateAuthorities {
+		if !co.RootCerts.AppendCertsFromPEM(ca.Target) {
+			return errors.New("parsing Fulcio root certificate")
+		}
+	}
+	co.CTLogPubKeys = nil
+	for _, ctlog := range root.Ctlogs {
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey(ctlog.Target)
+		if err != nil {
+			return errors.Wrap(err, "parsing CT log public key")
+		}
+		co.CTLogPubKeys = append(co.CTLogPubKeys, pub)
+	}
+	co.RekorPubKeys = nil
+	for _, tlog := range root.Tlogs {
+		pub, err := cosign.PemToECDSAKey(tlog.Target)
+		if err != nil {
+			return errors.Wrap(err, "parsing Rekor public key")
+		}
+		co.RekorPubKeys = append(co.RekorPubKeys, pub)
+	}
+	return nil
+}
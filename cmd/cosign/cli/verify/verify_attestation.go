@@ -23,10 +23,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/pkg/errors"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
 	"github.com/sigstore/cosign/pkg/cosign/rego"
 	"github.com/sigstore/cosign/pkg/oci"
@@ -54,6 +56,8 @@ type VerifyAttestationCommand struct {
 	PredicateType string
 	Policies      []string
 	LocalImage    bool
+
+	CertIdentityRegexp string
 }
 
 // Exec runs the verification command
@@ -72,10 +76,17 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 	}
 	co := &cosign.CheckOpts{
 		RegistryClientOpts: ociremoteOpts,
+		VerifySCT:          true,
 	}
 	if c.CheckClaims {
 		co.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
 	}
+	if c.CertIdentityRegexp != "" {
+		co.CertIdentityRegexp, err = regexp.Compile(c.CertIdentityRegexp)
+		if err != nil {
+			return errors.Wrap(err, "parsing certificate identity regexp")
+		}
+	}
 	if options.EnableExperimental() {
 		if c.RekorURL != "" {
 			rekorClient, err := rekor.NewClient(c.RekorURL)
@@ -222,6 +233,15 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 				if err != nil {
 					return fmt.Errorf("error when generating SPDXStatement: %w", err)
 				}
+			case options.PredicateCycloneDX:
+				var cyclonedxStatement in_toto.Statement
+				if err := json.Unmarshal(decodedPayload, &cyclonedxStatement); err != nil {
+					return fmt.Errorf("unmarshal CycloneDX Statement: %w", err)
+				}
+				payload, err = json.Marshal(cyclonedxStatement)
+				if err != nil {
+					return fmt.Errorf("error when generating CycloneDX Statement: %w", err)
+				}
 			}
 
 			if len(cuePolicies) > 0 {
@@ -251,9 +271,72 @@ func (c *VerifyAttestationCommand) Exec(ctx context.Context, images []string) (e
 
 		// TODO: add CUE validation report to `PrintVerificationHeader`.
 		PrintVerificationHeader(imageRef, co, bundleVerified)
-		// The attestations are always JSON, so use the raw "text" mode for outputting them instead of conversion
-		PrintVerification(imageRef, verified, "text")
+		if c.Output == "json" {
+			printAttestations(verified)
+		} else {
+			// The attestations are always JSON, so use the raw "text" mode for outputting them instead of conversion
+			PrintVerification(imageRef, verified, "text", co.RootCerts)
+		}
 	}
 
 	return nil
 }
+
+// attestationOutput is a DSSE envelope printed along with the type and body of
+// the in-toto predicate it carries.
+type attestationOutput struct {
+	PayloadType   string           `json:"payloadType"`
+	Payload       string           `json:"payload"`
+	Signatures    []dsse.Signature `json:"signatures"`
+	PredicateType string           `json:"predicateType,omitempty"`
+	Predicate     json.RawMessage  `json:"predicate,omitempty"`
+}
+
+// decodeAttestation decodes the in-toto statement in the DSSE envelope p.
+func decodeAttestation(p []byte) (*attestationOutput, error) {
+	var env dsse.Envelope
+	if err := json.Unmarshal(p, &env); err != nil {
+		return nil, errors.Wrap(err, "unmarshal DSSE envelope")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode 'payload'")
+	}
+	var statement struct {
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}
+	if err := json.Unmarshal(decoded, &statement); err != nil {
+		return nil, errors.Wrap(err, "unmarshal in-toto statement")
+	}
+	return &attestationOutput{
+		PayloadType:   env.PayloadType,
+		Payload:       env.Payload,
+		Signatures:    env.Signatures,
+		PredicateType: statement.PredicateType,
+		Predicate:     statement.Predicate,
+	}, nil
+}
+
+// printAttestations prints each verified attestation to stdout as a line of
+// JSON, with its predicate decoded.
+func printAttestations(verified []oci.Signature) {
+	for _, att := range verified {
+		p, err := att.Payload()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching payload: %v", err)
+			return
+		}
+		out, err := decodeAttestation(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error decoding attestation: %v", err)
+			return
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			fmt.Println("error when generating the output:", err.Error())
+			return
+		}
+		fmt.Println(string(b))
+	}
+}
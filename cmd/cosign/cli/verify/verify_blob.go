@@ -19,8 +19,7 @@ import (
 	"bytes"
 	"context"
 	"crypto"
-	"crypto/ecdsa"
-	_ "crypto/sha256" // for `crypto.SHA256`
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"fmt"
@@ -36,8 +35,10 @@ import (
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/sigstore/cosign/pkg/blob"
 	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/pkg/cosign/pivkey"
 	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
+	"github.com/sigstore/cosign/pkg/oci/static"
 	sigs "github.com/sigstore/cosign/pkg/signature"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/types"
@@ -58,6 +59,10 @@ func VerifyBlobCmd(ctx context.Context, ko sign.KeyOpts, certRef, sigRef, blobRe
 	var pubKey signature.Verifier
 	var cert *x509.Certificate
 
+	if ko.BundlePath != "" {
+		return verifySigstoreBundle(ctx, ko, blobRef)
+	}
+
 	if !options.OneOf(ko.KeyRef, ko.Sk, certRef) && !options.EnableExperimental() {
 		return &options.PubKeyParseError{}
 	}
@@ -98,7 +103,7 @@ func VerifyBlobCmd(ctx context.Context, ko sign.KeyOpts, certRef, sigRef, blobRe
 		if err != nil {
 			return err
 		}
-		pubKey, err = signature.LoadECDSAVerifier(cert.PublicKey.(*ecdsa.PublicKey), crypto.SHA256)
+		pubKey, err = signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
 		if err != nil {
 			return err
 		}
@@ -127,19 +132,19 @@ func VerifyBlobCmd(ctx context.Context, ko sign.KeyOpts, certRef, sigRef, blobRe
 			return err
 		}
 		cert = certs[0]
-		pubKey, err = signature.LoadECDSAVerifier(cert.PublicKey.(*ecdsa.PublicKey), crypto.SHA256)
+		pubKey, err = signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
 		if err != nil {
 			return err
 		}
 	}
 
 	// verify the signature
-	if err := pubKey.VerifySignature(bytes.NewReader([]byte(sig)), bytes.NewReader(blobBytes)); err != nil {
+	if err := report(ko, cosign.VerifyStepSignature, pubKey.VerifySignature(bytes.NewReader([]byte(sig)), bytes.NewReader(blobBytes))); err != nil {
 		return err
 	}
 
 	// verify the cert
-	if err := verifyCert(cert); err != nil {
+	if err := verifyCert(ko, cert); err != nil {
 		return err
 	}
 
@@ -152,6 +157,91 @@ func VerifyBlobCmd(ctx context.Context, ko sign.KeyOpts, certRef, sigRef, blobRe
 	return nil
 }
 
+// verifySigstoreBundle verifies the blob against a Sigstore bundle, as written by
+// sign-blob --bundle or another Sigstore client. The bundle carries the signature, the certificate
+// and the transparency log inclusion promise, so no network access is needed.
+func verifySigstoreBundle(ctx context.Context, ko sign.KeyOpts, blobRef string) error {
+	b, err := blob.LoadFileOrURL(ko.BundlePath)
+	if err != nil {
+		return err
+	}
+	sb, err := bundle.Unmarshal(b)
+	if err != nil {
+		return errors.Wrap(err, "parsing bundle")
+	}
+
+	blobBytes, err := payloadBytes(blobRef)
+	if err != nil {
+		return err
+	}
+	if sb.Digest == nil {
+		return errors.New("bundle does not record the SHA-256 digest of the blob")
+	}
+	if h := sha256.Sum256(blobBytes); !bytes.Equal(h[:], sb.Digest) {
+		return errors.New("blob does not match the digest in the bundle")
+	}
+
+	var pubKey signature.Verifier
+	var cert *x509.Certificate
+	// pub is the key the tlog entry must be for, when verifying with a key.
+	var pub crypto.PublicKey
+	if ko.KeyRef != "" {
+		pubKey, err = sigs.PublicKeyFromKeyRef(ctx, ko.KeyRef)
+		if err != nil {
+			return errors.Wrap(err, "loading public key")
+		}
+		if pub, err = pubKey.PublicKey(); err != nil {
+			return err
+		}
+	} else {
+		cert = sb.Certificate()
+		if cert == nil {
+			return errors.New("bundle does not contain a certificate, a key is required to verify it")
+		}
+		pubKey, err = signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := report(ko, cosign.VerifyStepSignature, pubKey.VerifySignature(bytes.NewReader(sb.Signature), bytes.NewReader(blobBytes))); err != nil {
+		return err
+	}
+	if err := verifyCert(ko, cert); err != nil {
+		return err
+	}
+
+	rb := sb.Rekor
+	if rb == nil {
+		return errors.New("bundle does not contain a tlog entry with an inclusion promise")
+	}
+	opts := []static.Option{static.WithBundle(rb)}
+	if cert != nil {
+		certPEM, err := cryptoutils.MarshalCertificateToPEM(cert)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, static.WithCertChain(certPEM, nil))
+	}
+	sig, err := static.NewSignature(blobBytes, base64.StdEncoding.EncodeToString(sb.Signature), opts...)
+	if err != nil {
+		return err
+	}
+	// The tlog entry must be for this signature and digest, made by the
+	// certificate or the key the bundle was verified with.
+	verified, err := cosign.VerifyBundleWithKey(ctx, sig, pub)
+	if err == nil && !verified {
+		err = errors.New("tlog entry in the bundle does not match the blob")
+	}
+	if err := reportBundle(ko, cert, err); err != nil {
+		return errors.Wrap(err, "verifying tlog entry")
+	}
+	fmt.Fprintf(os.Stderr, "tlog entry verified offline with index: %d\n", rb.Payload.LogIndex)
+
+	fmt.Fprintln(os.Stderr, "Verified OK")
+	return nil
+}
+
 // signatures returns the raw signature and the base64 encoded signature
 func signatures(sigRef string) (string, string, error) {
 	var targetSig []byte
@@ -196,11 +286,38 @@ func payloadBytes(blobRef string) ([]byte, error) {
 	return blobBytes, nil
 }
 
-func verifyCert(cert *x509.Certificate) error {
+// report passes the outcome of step to ko.VerifyHook, if set, and returns err.
+func report(ko sign.KeyOpts, step string, err error) error {
+	if ko.VerifyHook != nil {
+		detail := ""
+		if err != nil {
+			detail = err.Error()
+		}
+		ko.VerifyHook(step, err == nil, detail)
+	}
+	return err
+}
+
+// reportBundle reports the outcome of verifying the tlog entry in a bundle for a
+// signature made with cert, or with a key if cert is nil, and returns err.
+func reportBundle(ko sign.KeyOpts, cert *x509.Certificate, err error) error {
+	if errors.Is(err, cosign.ErrCertExpired) || errors.Is(err, cosign.ErrCertNotYetValid) {
+		return report(ko, cosign.VerifyStepCertificateExpiry, err)
+	}
+	if err := report(ko, cosign.VerifyStepRekorBundle, err); err != nil {
+		return err
+	}
+	if cert != nil {
+		return report(ko, cosign.VerifyStepCertificateExpiry, nil)
+	}
+	return nil
+}
+
+func verifyCert(ko sign.KeyOpts, cert *x509.Certificate) error {
 	if cert == nil {
 		return nil
 	}
-	if err := cosign.TrustedCert(cert, fulcio.GetRoots()); err != nil {
+	if err := report(ko, cosign.VerifyStepCertificateChain, cosign.TrustedCert(cert, fulcio.GetRoots())); err != nil {
 		return err
 	}
 	fmt.Fprintln(os.Stderr, "Certificate is trusted by Fulcio Root CA")
@@ -234,7 +351,7 @@ func verifyRekorEntry(ctx context.Context, ko sign.KeyOpts, pubKey signature.Ver
 		}
 	}
 	uuid, index, err := cosign.FindTlogEntry(ctx, rekorClient, b64sig, blobBytes, pubBytes)
-	if err != nil {
+	if err := report(ko, cosign.VerifyStepRekorInclusion, err); err != nil {
 		return err
 	}
 	fmt.Fprintf(os.Stderr, "tlog entry verified with uuid: %q index: %d\n", uuid, index)
@@ -247,7 +364,7 @@ func verifyRekorEntry(ctx context.Context, ko sign.KeyOpts, pubKey signature.Ver
 	if err != nil {
 		return err
 	}
-	return cosign.CheckExpiry(cert, time.Unix(*e.IntegratedTime, 0))
+	return report(ko, cosign.VerifyStepCertificateExpiry, cosign.CheckExpiry(cert, time.Unix(*e.IntegratedTime, 0)))
 }
 
 func extractCerts(e *models.LogEntryAnon) ([]*x509.Certificate, error) {
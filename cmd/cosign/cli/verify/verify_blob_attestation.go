@@ -0,0 +1,92 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/pivkey"
+	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+)
+
+// VerifyBlobAttestationCmd verifies the attestation at attestationRef against the
+// artifact stored in the registry at blobRef, with a key or with the certificate at
+// certRef. If predicateType is set, only attestations of that type are verified.
+func VerifyBlobAttestationCmd(ctx context.Context, ko sign.KeyOpts, regOpts options.RegistryOptions, certRef, attestationRef, predicateType, blobRef string) (err error) {
+	if !options.OneOf(ko.KeyRef, ko.Sk, certRef) {
+		return &options.KeyParseError{}
+	}
+	if attestationRef == "" {
+		return errors.New("--signature is required")
+	}
+
+	ociremoteOpts, err := regOpts.ClientOpts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "constructing client options")
+	}
+	co := &cosign.CheckOpts{
+		RegistryClientOpts: ociremoteOpts,
+		VerifyHook:         ko.VerifyHook,
+	}
+	if predicateType != "" {
+		co.PredicateType, err = options.ParsePredicateType(predicateType)
+		if err != nil {
+			return err
+		}
+	}
+
+	var cert *x509.Certificate
+	switch {
+	case ko.KeyRef != "":
+		co.SigVerifier, err = sigs.PublicKeyFromKeyRef(ctx, ko.KeyRef)
+		if err != nil {
+			return errors.Wrap(err, "loading public key")
+		}
+		if pkcs11Key, ok := co.SigVerifier.(*pkcs11key.Key); ok {
+			defer pkcs11Key.Close()
+		}
+	case ko.Sk:
+		sk, err := pivkey.GetKeyWithSlot(ko.Slot)
+		if err != nil {
+			return errors.Wrap(err, "opening piv token")
+		}
+		defer sk.Close()
+		co.SigVerifier, err = sk.Verifier()
+		if err != nil {
+			return errors.Wrap(err, "initializing piv token verifier")
+		}
+	default:
+		cert, err = loadCertFromFileOrURL(certRef)
+		if err != nil {
+			return errors.Wrap(err, "loading certificate")
+		}
+		co.RootCerts = fulcio.GetRoots()
+		co.RekorClient, err = rekor.NewClient(ko.RekorURL)
+		if err != nil {
+			return errors.Wrap(err, "creating Rekor client")
+		}
+	}
+
+	return cosign.VerifyBlobAttestation(ctx, co, blobRef, attestationRef, cert)
+}
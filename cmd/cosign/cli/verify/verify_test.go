@@ -0,0 +1,363 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	gotuf "github.com/theupdateframework/go-tuf"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/static"
+)
+
+func TestVerificationOutputs(t *testing.T) {
+	rb := &bundle.RekorBundle{
+		SignedEntryTimestamp: []byte("set"),
+		Payload: bundle.RekorPayload{
+			LogIndex: 42,
+		},
+	}
+	sig, err := static.NewSignature([]byte("payload"), "c2lnbmF0dXJl", static.WithBundle(rb))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := verificationOutputs("example.com/image@sha256:abcd", []oci.Signature{sig}, nil)
+	if len(out) != 1 {
+		t.Fatalf("expected 1 output, got %d", len(out))
+	}
+	got := out[0]
+	if got.ImageRef != "example.com/image@sha256:abcd" {
+		t.Errorf("image_ref = %q", got.ImageRef)
+	}
+	if got.Signature != "c2lnbmF0dXJl" {
+		t.Errorf("signature = %q", got.Signature)
+	}
+	if got.Certificate != "" {
+		t.Errorf("expected no certificate, got %q", got.Certificate)
+	}
+	if got.RekorBundle == nil || got.RekorBundle.Payload.LogIndex != 42 {
+		t.Errorf("unexpected rekor_bundle %v", got.RekorBundle)
+	}
+	if got.VerifiedAt.IsZero() {
+		t.Error("expected verified_at to be set")
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"image_ref", "signature", "rekor_bundle", "verified_at"} {
+		if _, ok := fields[0][k]; !ok {
+			t.Errorf("missing %q in %s", k, b)
+		}
+	}
+}
+
+func TestVerifyCommandOutputFormat(t *testing.T) {
+	c := VerifyCommand{KeyRef: "cosign.pub", OutputFormat: "yaml"}
+	if err := c.Exec(context.Background(), []string{"example.com/image"}); err == nil {
+		t.Error("Exec() with an unsupported output format succeeded")
+	}
+}
+
+func TestCertificateChain(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, rootKey.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, leafKey.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	chain := certificateChain(leaf, roots)
+	if len(chain) != 2 {
+		t.Fatalf("certificateChain() returned %d certificates, want 2", len(chain))
+	}
+	for i, want := range []*x509.Certificate{leaf, root} {
+		p, err := cryptoutils.MarshalCertificateToPEM(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chain[i] != string(p) {
+			t.Errorf("chain[%d] = %s, want %s", i, chain[i], p)
+		}
+	}
+
+	if chain := certificateChain(leaf, x509.NewCertPool()); chain != nil {
+		t.Errorf("certificateChain() with untrusted roots = %v, want nil", chain)
+	}
+	if chain := certificateChain(leaf, nil); chain != nil {
+		t.Errorf("certificateChain() without roots = %v, want nil", chain)
+	}
+}
+
+func TestDecodeAttestation(t *testing.T) {
+	statement := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"cosign.sigstore.dev/attestation/v1","subject":[],"predicate":{"Data":"foo"}}`
+	env := fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":%q,"signatures":[{"keyid":"","sig":"c2ln"}]}`,
+		base64.StdEncoding.EncodeToString([]byte(statement)))
+
+	got, err := decodeAttestation([]byte(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PredicateType != "cosign.sigstore.dev/attestation/v1" {
+		t.Errorf("PredicateType = %q", got.PredicateType)
+	}
+	if string(got.Predicate) != `{"Data":"foo"}` {
+		t.Errorf("Predicate = %s", got.Predicate)
+	}
+	if got.PayloadType != "application/vnd.in-toto+json" || len(got.Signatures) != 1 || got.Signatures[0].Sig != "c2ln" {
+		t.Errorf("envelope fields not preserved: %+v", got)
+	}
+
+	if _, err := decodeAttestation([]byte(`{"payload":"not base64!"}`)); err == nil {
+		t.Error("expected an error for an undecodable payload")
+	}
+}
+
+func TestVerifyImages(t *testing.T) {
+	images := make([]string, 8)
+	for i := range images {
+		images[i] = fmt.Sprintf("example.com/image%d", i)
+	}
+
+	for _, maxWorkers := range []int{0, 1, 3} {
+		var running, peak int32
+		results := verifyImages(images, maxWorkers, func(img string) verifyResult {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return verifyResult{name: img}
+		})
+
+		want := int32(maxWorkers)
+		if want < 1 {
+			want = 1
+		}
+		if peak != want {
+			t.Errorf("maxWorkers %d: peak concurrency = %d, want %d", maxWorkers, peak, want)
+		}
+		for i, r := range results {
+			if r.name != images[i] {
+				t.Errorf("maxWorkers %d: result %d = %q, want %q", maxWorkers, i, r.name, images[i])
+			}
+		}
+	}
+}
+
+func TestReadImagesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "images.txt")
+	contents := "# production images\ngcr.io/example/a:v1\n\n  gcr.io/example/b@sha256:abc  \n#gcr.io/example/c\n"
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadImagesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"gcr.io/example/a:v1", "gcr.io/example/b@sha256:abc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadImagesFile() = %v, want %v", got, want)
+	}
+
+	if _, err := ReadImagesFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestFailedImagesErrorExitCode(t *testing.T) {
+	err := &FailedImagesError{Failed: []string{"a", "b"}, Total: 3}
+	if got := err.ExitCode(); got != 2 {
+		t.Errorf("ExitCode() = %d, want 2", got)
+	}
+	if want := "2 of 3 images failed verification: a, b"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if got := (&FailedImagesError{Failed: make([]string, 300), Total: 300}).ExitCode(); got != 125 {
+		t.Errorf("ExitCode() = %d, want 125", got)
+	}
+}
+
+// newTUFMirror serves a TUF repository whose targets are a Fulcio root, a Rekor
+// key and a CT log key, and returns its URL and the path of its root.json.
+func newTUFMirror(t *testing.T) (string, string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := cryptoutils.MarshalCertificateToPEM(&x509.Certificate{Raw: der})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "staged", "targets"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := gotuf.NewRepo(gotuf.FileSystemStore(dir, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Init(false); err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range []string{"root", "targets", "snapshot", "timestamp"} {
+		if _, err := repo.GenKey(role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, target := range map[string]struct {
+		usage string
+		b     []byte
+	}{
+		"fulcio.crt.pem": {"Fulcio", certPEM},
+		"rekor.pub":      {"Rekor", pubPEM},
+		"ctfe.pub":       {"CTFE", pubPEM},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "staged", "targets", name), target.b, 0600); err != nil {
+			t.Fatal(err)
+		}
+		custom := json.RawMessage(fmt.Sprintf(`{"sigstore":{"usage":%q,"status":"Active"}}`, target.usage))
+		if err := repo.AddTarget(name, custom); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Timestamp(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewServer(http.FileServer(http.Dir(filepath.Join(dir, "repository"))))
+	t.Cleanup(s.Close)
+	return s.URL, filepath.Join(dir, "repository", "root.json")
+}
+
+func TestLoadTUFTrust(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("TUF_ROOT", cacheDir)
+	mirror, root := newTUFMirror(t)
+
+	co := &cosign.CheckOpts{}
+	if err := loadTUFTrust(context.Background(), root, mirror, co); err != nil {
+		t.Fatal(err)
+	}
+	if co.RootCerts == nil {
+		t.Error("expected the Fulcio root to be trusted")
+	}
+	if len(co.CTLogPubKeys) != 1 {
+		t.Errorf("got %d CT log keys, want 1", len(co.CTLogPubKeys))
+	}
+	if len(co.RekorPubKeys) != 1 {
+		t.Errorf("got %d Rekor keys, want 1", len(co.RekorPubKeys))
+	}
+
+	// The local TUF cache is left alone.
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the TUF cache to be untouched, found %d entries", len(entries))
+	}
+}
@@ -15,12 +15,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/sigstore/cosign/cmd/cosign/cli"
+	"github.com/sigstore/cosign/cmd/cosign/cli/verify"
 )
 
 func main() {
@@ -43,6 +45,12 @@ func main() {
 	}
 
 	if err := cli.New().Execute(); err != nil {
+		// verify reports how many of its images failed in the exit status.
+		var failed *verify.FailedImagesError
+		if errors.As(err, &failed) {
+			log.Printf("error during command execution: %v", err)
+			os.Exit(failed.ExitCode())
+		}
 		log.Fatalf("error during command execution: %v", err)
 	}
 }
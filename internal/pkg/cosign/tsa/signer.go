@@ -0,0 +1,84 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tsa
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sigstore/cosign/internal/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/tsa"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/mutate"
+	"github.com/sigstore/cosign/pkg/oci/static"
+)
+
+// signerWrapper calls a wrapped, inner signer then requests an RFC 3161 timestamp over the resulting
+// signature from a timestamp authority, and adds the token to the signature's annotations
+type signerWrapper struct {
+	inner cosign.Signer
+
+	tsaURL string
+}
+
+var _ cosign.Signer = (*signerWrapper)(nil)
+
+// Sign implements `cosign.Signer`
+func (ts *signerWrapper) Sign(ctx context.Context, payload io.Reader) (oci.Signature, crypto.PublicKey, error) {
+	sig, pub, err := ts.inner.Sign(ctx, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b64Sig, err := sig.Base64Signature()
+	if err != nil {
+		return nil, nil, err
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(b64Sig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := tsa.RequestTimestamp(ctx, ts.tsaURL, sigBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintln(os.Stderr, "timestamp obtained from", ts.tsaURL)
+
+	annotations, err := sig.Annotations()
+	if err != nil {
+		return nil, nil, err
+	}
+	annotations[static.RFC3161TimestampAnnotationKey] = base64.StdEncoding.EncodeToString(token)
+
+	newSig, err := mutate.Signature(sig, mutate.WithAnnotations(annotations))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newSig, pub, nil
+}
+
+// NewSigner returns a `cosign.Signer` which timestamps the signature with the timestamp authority at tsaURL
+func NewSigner(inner cosign.Signer, tsaURL string) cosign.Signer {
+	return &signerWrapper{
+		inner:  inner,
+		tsaURL: tsaURL,
+	}
+}
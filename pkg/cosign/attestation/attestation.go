@@ -35,6 +35,13 @@ const (
 
 	// CosignVulnProvenanceV01 specifies the type of VulnerabilityScan Predicate
 	CosignVulnProvenanceV01 = "cosign.sigstore.dev/attestation/vuln/v1"
+
+	// CycloneDXBOM specifies the type of a CycloneDX SBOM Predicate.
+	CycloneDXBOM = "https://cyclonedx.org/bom"
+
+	// HelmChartV01 specifies the type of the Predicate cosign sign
+	// --artifact-type helm attests a Helm chart's name and version with.
+	HelmChartV01 = "cosign.sigstore.dev/attestation/helm-chart/v1"
 )
 
 // CosignPredicate specifies the format of the Custom Predicate.
@@ -103,6 +110,8 @@ func GenerateStatement(opts GenerateOpts) (interface{}, error) {
 		return generateSLSAProvenanceStatement(predicate, opts.Digest, opts.Repo)
 	case "spdx":
 		return generateSPDXStatement(predicate, opts.Digest, opts.Repo)
+	case "cyclonedx":
+		return generateCycloneDXStatement(predicate, opts.Digest, opts.Repo)
 	case "link":
 		return generateLinkStatement(predicate, opts.Digest, opts.Repo)
 	case "vuln":
@@ -122,10 +131,7 @@ func generateVulnStatement(predicate []byte, digest string, repo string) (interf
 		return nil, err
 	}
 
-	return in_toto.Statement{
-		StatementHeader: generateStatementHeader(digest, repo, CosignVulnProvenanceV01),
-		Predicate:       vuln,
-	}, nil
+	return newStatement(digest, repo, CosignVulnProvenanceV01, vuln)
 }
 
 func timestamp(opts GenerateOpts) string {
@@ -158,16 +164,22 @@ func generateStatementHeader(digest, repo, predicateType string) in_toto.Stateme
 	}
 }
 
+// newStatement is NewStatement for the sha256 digest of an image in repo.
+func newStatement(digest, repo, predicateType string, predicate interface{}) (interface{}, error) {
+	st, err := NewStatement(repo+"@sha256:"+digest, predicateType, predicate)
+	if err != nil {
+		return nil, err
+	}
+	return *st, nil
+}
+
 func generateCustomStatement(rawPayload []byte, customType, digest, repo, timestamp string) (interface{}, error) {
 	payload, err := generateCustomPredicate(rawPayload, customType, timestamp)
 	if err != nil {
 		return nil, err
 	}
 
-	return in_toto.Statement{
-		StatementHeader: generateStatementHeader(digest, repo, customType),
-		Predicate:       payload,
-	}, nil
+	return newStatement(digest, repo, customType, payload)
 }
 
 func generateCustomPredicate(rawPayload []byte, customType, timestamp string) (interface{}, error) {
@@ -196,6 +208,9 @@ func generateSLSAProvenanceStatement(rawPayload []byte, digest string, repo stri
 	if err != nil {
 		return "", errors.Wrap(err, "unmarshal Provenance predicate")
 	}
+	if err := validatePredicate(slsa.PredicateSLSAProvenance, predicate); err != nil {
+		return nil, err
+	}
 	return in_toto.ProvenanceStatement{
 		StatementHeader: generateStatementHeader(digest, repo, slsa.PredicateSLSAProvenance),
 		Predicate:       predicate,
@@ -227,6 +242,14 @@ func generateSPDXStatement(rawPayload []byte, digest string, repo string) (inter
 	}, nil
 }
 
+func generateCycloneDXStatement(rawPayload []byte, digest string, repo string) (interface{}, error) {
+	var bom map[string]interface{}
+	if err := json.Unmarshal(rawPayload, &bom); err != nil {
+		return nil, errors.Wrap(err, "unmarshal CycloneDX BOM")
+	}
+	return newStatement(digest, repo, CycloneDXBOM, bom)
+}
+
 func checkRequiredJSONFields(rawPayload []byte, typ reflect.Type) error {
 	var tmp map[string]interface{}
 	if err := json.Unmarshal(rawPayload, &tmp); err != nil {
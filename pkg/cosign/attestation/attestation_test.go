@@ -0,0 +1,150 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attestation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/sigstore/cosign/pkg/types"
+)
+
+func TestGenerateCycloneDXStatement(t *testing.T) {
+	bom := `{"bomFormat":"CycloneDX","specVersion":"1.4","components":[]}`
+	got, err := GenerateStatement(GenerateOpts{
+		Predicate: strings.NewReader(bom),
+		Type:      "cyclonedx",
+		Digest:    "deadbeef",
+		Repo:      "example.com/repo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, ok := got.(in_toto.Statement)
+	if !ok {
+		t.Fatalf("expected in_toto.Statement, got %T", got)
+	}
+	if st.PredicateType != CycloneDXBOM {
+		t.Errorf("predicate type = %q, want %q", st.PredicateType, CycloneDXBOM)
+	}
+	if len(st.Subject) != 1 || st.Subject[0].Digest["sha256"] != "deadbeef" {
+		t.Errorf("unexpected subject %v", st.Subject)
+	}
+	if p, ok := st.Predicate.(map[string]interface{}); !ok || p["bomFormat"] != "CycloneDX" {
+		t.Errorf("unexpected predicate %v", st.Predicate)
+	}
+
+	if _, err := GenerateStatement(GenerateOpts{
+		Predicate: strings.NewReader("not json"),
+		Type:      "cyclonedx",
+	}); err == nil {
+		t.Error("expected error for a CycloneDX BOM that is not JSON, got nil")
+	}
+}
+
+func TestNewStatement(t *testing.T) {
+	st, err := NewStatement("example.com/repo@sha256:deadbeef", CycloneDXBOM, map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.4",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Subject) != 1 || st.Subject[0].Name != "example.com/repo" || st.Subject[0].Digest["sha256"] != "deadbeef" {
+		t.Errorf("unexpected subject %v", st.Subject)
+	}
+	if st.Type != in_toto.StatementInTotoV01 || st.PredicateType != CycloneDXBOM {
+		t.Errorf("unexpected header %+v", st.StatementHeader)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		subject       string
+		predicateType string
+		predicate     interface{}
+		wantErr       bool
+	}{{
+		name:          "CycloneDX BOM missing bomFormat",
+		subject:       "sha256:deadbeef",
+		predicateType: CycloneDXBOM,
+		predicate:     map[string]interface{}{"specVersion": "1.4"},
+		wantErr:       true,
+	}, {
+		name:          "SLSA provenance",
+		subject:       "sha256:deadbeef",
+		predicateType: slsa.PredicateSLSAProvenance,
+		predicate:     map[string]interface{}{"builder": map[string]interface{}{"id": "https://example.com/builder"}, "buildType": "https://example.com/type"},
+	}, {
+		name:          "SLSA provenance without a builder id",
+		subject:       "sha256:deadbeef",
+		predicateType: slsa.PredicateSLSAProvenance,
+		predicate:     map[string]interface{}{"builder": map[string]interface{}{}, "buildType": "https://example.com/type"},
+		wantErr:       true,
+	}, {
+		name:          "opaque SPDX document",
+		subject:       "sha256:deadbeef",
+		predicateType: in_toto.PredicateSPDX,
+		predicate:     CosignPredicate{Data: "SPDXVersion: SPDX-2.2"},
+	}, {
+		name:          "unknown predicate type",
+		subject:       "sha256:deadbeef",
+		predicateType: "https://example.com/custom",
+		predicate:     map[string]interface{}{"anything": true},
+	}, {
+		name:          "subject without an algorithm",
+		subject:       "example.com/repo@deadbeef",
+		predicateType: "https://example.com/custom",
+		wantErr:       true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewStatement(tc.subject, tc.predicateType, tc.predicate)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("NewStatement() err = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStatement(t *testing.T) {
+	st, err := NewStatement("example.com/repo@sha256:deadbeef", "https://example.com/custom", map[string]interface{}{"a": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := &dsse.Envelope{PayloadType: types.IntotoPayloadType, Payload: base64.StdEncoding.EncodeToString(b)}
+
+	got, err := ParseStatement(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PredicateType != st.PredicateType || got.Subject[0].Digest["sha256"] != "deadbeef" {
+		t.Errorf("ParseStatement() = %+v, want %+v", got, st)
+	}
+
+	env.PayloadType = "application/json"
+	if _, err := ParseStatement(env); err == nil {
+		t.Error("expected error for a non in-toto payload type")
+	}
+}
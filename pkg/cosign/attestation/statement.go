@@ -0,0 +1,187 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attestation
+
+import (
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"cuelang.org/go/cue/cuecontext"
+	cuejson "cuelang.org/go/encoding/json"
+	"cuelang.org/go/encoding/jsonschema"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/pkg/errors"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/sigstore/cosign/pkg/types"
+)
+
+//go:embed schemas
+var schemaFS embed.FS
+
+// predicateSchemas maps the predicate types NewStatement validates to their
+// JSON Schema in schemaFS.
+var predicateSchemas = map[string]string{
+	slsa.PredicateSLSAProvenance: "slsa_provenance_v0.2.json",
+	in_toto.PredicateSPDX:        "spdx.json",
+	CycloneDXBOM:                 "cyclonedx.json",
+}
+
+// NewStatement returns an in-toto statement about the artifact subjectDigest,
+// given as name@algorithm:hex or just algorithm:hex. If predicateType is one of
+// the known SLSA provenance, SPDX or CycloneDX types, predicate must satisfy its
+// schema.
+func NewStatement(subjectDigest, predicateType string, predicate interface{}) (*in_toto.Statement, error) {
+	subject, err := parseSubject(subjectDigest)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePredicate(predicateType, predicate); err != nil {
+		return nil, err
+	}
+	return &in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: predicateType,
+			Subject:       []in_toto.Subject{subject},
+		},
+		Predicate: predicate,
+	}, nil
+}
+
+// ParseStatement returns the in-toto statement carried by env. It does not check
+// env's signatures.
+func ParseStatement(env *dsse.Envelope) (*in_toto.Statement, error) {
+	if env.PayloadType != types.IntotoPayloadType {
+		return nil, fmt.Errorf("invalid payloadType %s on envelope. Expected %s", env.PayloadType, types.IntotoPayloadType)
+	}
+	b, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding envelope payload")
+	}
+	st := &in_toto.Statement{}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, errors.Wrap(err, "unmarshal in-toto statement")
+	}
+	if st.Type != in_toto.StatementInTotoV01 {
+		return nil, fmt.Errorf("unsupported statement type %q", st.Type)
+	}
+	return st, nil
+}
+
+func parseSubject(subjectDigest string) (in_toto.Subject, error) {
+	var name string
+	dgst := subjectDigest
+	if i := strings.LastIndex(subjectDigest, "@"); i >= 0 {
+		name, dgst = subjectDigest[:i], subjectDigest[i+1:]
+	}
+	parts := strings.SplitN(dgst, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return in_toto.Subject{}, fmt.Errorf("invalid subject digest %q, expected [name@]algorithm:hex", subjectDigest)
+	}
+	return in_toto.Subject{
+		Name:   name,
+		Digest: map[string]string{parts[0]: parts[1]},
+	}, nil
+}
+
+// validatePredicate checks predicate against the schema of predicateType, if
+// there is one.
+func validatePredicate(predicateType string, predicate interface{}) error {
+	schemaFile, ok := predicateSchemas[predicateType]
+	if !ok {
+		return nil
+	}
+	switch predicate.(type) {
+	case CosignPredicate, *CosignPredicate:
+		// cosign wraps predicates it can't parse, such as SPDX tag-value
+		// documents, as an opaque string.
+		return nil
+	}
+
+	schemaJSON, err := schemaFS.ReadFile(path.Join("schemas", schemaFile))
+	if err != nil {
+		return err
+	}
+	ctx := cuecontext.New()
+	schemaValue := ctx.CompileBytes(schemaJSON)
+	if err := schemaValue.Err(); err != nil {
+		return errors.Wrapf(err, "compiling schema %s", schemaFile)
+	}
+	f, err := jsonschema.Extract(schemaValue, &jsonschema.Config{})
+	if err != nil {
+		return errors.Wrapf(err, "extracting schema %s", schemaFile)
+	}
+	schema := ctx.BuildFile(f)
+	if err := schema.Err(); err != nil {
+		return errors.Wrapf(err, "building schema %s", schemaFile)
+	}
+
+	b, err := json.Marshal(predicate)
+	if err != nil {
+		return errors.Wrap(err, "marshal predicate")
+	}
+	if err := cuejson.Validate(b, schema); err != nil {
+		return errors.Wrapf(err, "predicate does not match the %s schema", predicateType)
+	}
+	// The CUE encoding of a JSON Schema does not reject data that omits required
+	// properties, so check those separately.
+	var s, data interface{}
+	if err := json.Unmarshal(schemaJSON, &s); err != nil {
+		return errors.Wrapf(err, "parsing schema %s", schemaFile)
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return errors.Wrap(err, "unmarshal predicate")
+	}
+	if err := checkRequired(s, data, ""); err != nil {
+		return errors.Wrapf(err, "predicate does not match the %s schema", predicateType)
+	}
+	return nil
+}
+
+// checkRequired checks that data has the properties schema requires, and so on
+// for the properties it has that schema describes.
+func checkRequired(schema, data interface{}, at string) error {
+	sm, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	dm, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	required, _ := sm["required"].([]interface{})
+	for _, r := range required {
+		name, _ := r.(string)
+		if _, ok := dm[name]; !ok {
+			return fmt.Errorf("required field %s%s missing", at, name)
+		}
+	}
+	properties, _ := sm["properties"].(map[string]interface{})
+	for name, ps := range properties {
+		if v, ok := dm[name]; ok {
+			if err := checkRequired(ps, v, at+name+"."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
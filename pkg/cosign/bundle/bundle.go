@@ -0,0 +1,107 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// Bundle is the decoded contents of a Sigstore bundle for a signed blob.
+type Bundle struct {
+	// Digest is the SHA-256 digest of the signed blob, if the bundle records one.
+	Digest []byte
+	// Signature is the signature over the blob.
+	Signature []byte
+	// Certificates are the signing certificate followed by its chain. They are
+	// empty when the blob was signed with a key.
+	Certificates []*x509.Certificate
+	// Rekor is the transparency log entry carrying an inclusion promise, or nil.
+	Rekor *RekorBundle
+	// InclusionProof is the Merkle inclusion proof of the Rekor entry, or nil
+	// if the bundle only carries the promise.
+	InclusionProof *InclusionProof
+}
+
+// InclusionProof proves that a transparency log entry is included in the log
+// tree of size TreeSize whose root hash is RootHash.
+type InclusionProof struct {
+	LogIndex int64
+	TreeSize int64
+	RootHash []byte
+	Hashes   [][]byte
+	// Checkpoint is the log's signed checkpoint for RootHash, or empty. Without
+	// it nothing ties RootHash to the log.
+	Checkpoint string
+}
+
+// Marshal returns the JSON Sigstore bundle for the signature sig over blob. certs
+// is the signing certificate followed by its chain, or empty if the blob was
+// signed with the key pub, and rekorEntry the transparency log entry for the
+// signature, if any.
+func Marshal(blob, sig []byte, certs []*x509.Certificate, pub crypto.PublicKey, rekorEntry *models.LogEntryAnon) ([]byte, error) {
+	var rb *RekorBundle
+	if rekorEntry != nil {
+		rb = EntryToBundle(rekorEntry)
+	}
+	sb, err := NewSigstoreBundle(blob, sig, certs, pub, rb)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sb)
+}
+
+// Unmarshal parses a JSON Sigstore bundle for a signed blob, as written by Marshal
+// or by the other Sigstore clients.
+func Unmarshal(data []byte) (*Bundle, error) {
+	sb, err := ParseSigstoreBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Signature:      sb.MessageSignature.Signature,
+		Rekor:          sb.RekorBundle(),
+		InclusionProof: sb.InclusionProof(),
+	}
+	if md := sb.MessageSignature.MessageDigest; md.Algorithm != "" {
+		if md.Algorithm != "SHA2_256" {
+			return nil, fmt.Errorf("unsupported message digest algorithm %s", md.Algorithm)
+		}
+		b.Digest = md.Digest
+	}
+	if chain := sb.VerificationMaterial.X509CertificateChain; chain != nil {
+		for _, c := range chain.Certificates {
+			cert, err := x509.ParseCertificate(c.RawBytes)
+			if err != nil {
+				return nil, fmt.Errorf("parsing bundle certificate: %w", err)
+			}
+			b.Certificates = append(b.Certificates, cert)
+		}
+	}
+	return b, nil
+}
+
+// Certificate returns the signing certificate, or nil if the blob was signed with a key.
+func (b *Bundle) Certificate() *x509.Certificate {
+	if len(b.Certificates) == 0 {
+		return nil
+	}
+	return b.Certificates[0]
+}
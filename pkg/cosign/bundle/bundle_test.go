@@ -0,0 +1,115 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	integratedTime, logIndex, logID := int64(1640000000), int64(42), "dead"
+	entry := &models.LogEntryAnon{
+		Body:           base64.StdEncoding.EncodeToString([]byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{}}`)),
+		IntegratedTime: &integratedTime,
+		LogIndex:       &logIndex,
+		LogID:          &logID,
+		Verification:   &models.LogEntryAnonVerification{SignedEntryTimestamp: []byte("set")},
+	}
+
+	data, err := Marshal([]byte("blob"), []byte("sig"), []*x509.Certificate{cert}, &key.PublicKey, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte("blob"))
+	if string(b.Digest) != string(digest[:]) {
+		t.Errorf("Digest = %x, want %x", b.Digest, digest)
+	}
+	if string(b.Signature) != "sig" {
+		t.Errorf("Signature = %q", b.Signature)
+	}
+	if c := b.Certificate(); c == nil || !c.Equal(cert) {
+		t.Errorf("Certificate() = %v, want %v", c, cert)
+	}
+	if b.Rekor == nil || b.Rekor.Payload.LogIndex != logIndex || string(b.Rekor.SignedEntryTimestamp) != "set" {
+		t.Errorf("Rekor = %+v", b.Rekor)
+	}
+	sb, err := ParseSigstoreBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.VerificationMaterial.PublicKey != nil {
+		t.Errorf("publicKey = %+v, want none alongside a certificate", sb.VerificationMaterial.PublicKey)
+	}
+
+	// Signed with a key and not uploaded.
+	data, err = Marshal([]byte("blob"), []byte("sig"), nil, &key.PublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb, err = ParseSigstoreBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hint, err := PublicKeyHint(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk := sb.VerificationMaterial.PublicKey; pk == nil || pk.Hint != hint {
+		t.Errorf("publicKey = %+v, want hint %s", pk, hint)
+	}
+	b, err = Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Certificate() != nil || b.Rekor != nil {
+		t.Errorf("expected no certificate or tlog entry, got %+v", b)
+	}
+}
@@ -0,0 +1,280 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SigstoreBundleMediaTypePrefix prefixes the media type of every version of the Sigstore bundle format.
+const SigstoreBundleMediaTypePrefix = "application/vnd.dev.sigstore.bundle"
+
+// SigstoreBundleMediaType is the media type of the bundles cosign writes.
+const SigstoreBundleMediaType = SigstoreBundleMediaTypePrefix + "+json;version=0.1"
+
+// SigstoreBundle is the `*.sigstore` bundle written by the other Sigstore clients for a
+// signed blob. Only the fields cosign verifies are modelled.
+type SigstoreBundle struct {
+	MediaType            string                       `json:"mediaType"`
+	VerificationMaterial SigstoreVerificationMaterial `json:"verificationMaterial"`
+	MessageSignature     *SigstoreMessageSignature    `json:"messageSignature,omitempty"`
+}
+
+type SigstoreVerificationMaterial struct {
+	PublicKey            *SigstorePublicKeyIdentifier `json:"publicKey,omitempty"`
+	X509CertificateChain *SigstoreCertificateChain    `json:"x509CertificateChain,omitempty"`
+	TlogEntries          []SigstoreTlogEntry          `json:"tlogEntries,omitempty"`
+}
+
+// SigstorePublicKeyIdentifier names the key a blob was signed with, when it was
+// not signed with a certificate. The key itself is distributed out of band.
+type SigstorePublicKeyIdentifier struct {
+	Hint string `json:"hint,omitempty"`
+}
+
+type SigstoreCertificateChain struct {
+	Certificates []SigstoreCertificate `json:"certificates"`
+}
+
+type SigstoreCertificate struct {
+	RawBytes []byte `json:"rawBytes"`
+}
+
+type SigstoreTlogEntry struct {
+	LogIndex          Int64String               `json:"logIndex"`
+	LogID             SigstoreLogID             `json:"logId"`
+	KindVersion       SigstoreKindVersion       `json:"kindVersion"`
+	IntegratedTime    Int64String               `json:"integratedTime"`
+	InclusionPromise  *SigstoreInclusionPromise `json:"inclusionPromise,omitempty"`
+	InclusionProof    *SigstoreInclusionProof   `json:"inclusionProof,omitempty"`
+	CanonicalizedBody []byte                    `json:"canonicalizedBody"`
+}
+
+type SigstoreLogID struct {
+	KeyID []byte `json:"keyId"`
+}
+
+type SigstoreKindVersion struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+type SigstoreInclusionPromise struct {
+	SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+}
+
+type SigstoreInclusionProof struct {
+	LogIndex Int64String `json:"logIndex"`
+	RootHash []byte      `json:"rootHash"`
+	TreeSize Int64String `json:"treeSize"`
+	Hashes   [][]byte    `json:"hashes"`
+	// Checkpoint is the signed checkpoint of the tree the proof is against.
+	Checkpoint *SigstoreCheckpoint `json:"checkpoint,omitempty"`
+}
+
+type SigstoreCheckpoint struct {
+	Envelope string `json:"envelope"`
+}
+
+type SigstoreMessageSignature struct {
+	MessageDigest SigstoreMessageDigest `json:"messageDigest"`
+	Signature     []byte                `json:"signature"`
+}
+
+type SigstoreMessageDigest struct {
+	Algorithm string `json:"algorithm"`
+	Digest    []byte `json:"digest"`
+}
+
+// Int64String is an int64 that is encoded as a JSON string, as protobuf's JSON
+// mapping does, but that also accepts a plain JSON number.
+type Int64String int64
+
+// MarshalJSON implements json.Marshaler.
+func (i Int64String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(i), 10))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *Int64String) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = Int64String(v)
+	return nil
+}
+
+// ParseSigstoreBundle parses a Sigstore bundle for a signed blob.
+func ParseSigstoreBundle(b []byte) (*SigstoreBundle, error) {
+	var sb SigstoreBundle
+	if err := json.Unmarshal(b, &sb); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(sb.MediaType, SigstoreBundleMediaTypePrefix) {
+		return nil, fmt.Errorf("unsupported bundle media type %q", sb.MediaType)
+	}
+	if sb.MessageSignature == nil {
+		return nil, errors.New("bundle does not contain a message signature")
+	}
+	return &sb, nil
+}
+
+// Certificate returns the signing certificate from the bundle, or nil if it
+// was signed with a key.
+func (sb *SigstoreBundle) Certificate() (*x509.Certificate, error) {
+	chain := sb.VerificationMaterial.X509CertificateChain
+	if chain == nil || len(chain.Certificates) == 0 {
+		return nil, nil
+	}
+	return x509.ParseCertificate(chain.Certificates[0].RawBytes)
+}
+
+// RekorBundle returns the first transparency log entry of the bundle that carries
+// an inclusion promise, in the form cosign attaches to signatures, or nil if
+// there is none.
+func (sb *SigstoreBundle) RekorBundle() *RekorBundle {
+	e := sb.promisedEntry()
+	if e == nil {
+		return nil
+	}
+	return &RekorBundle{
+		SignedEntryTimestamp: e.InclusionPromise.SignedEntryTimestamp,
+		Payload: RekorPayload{
+			Body:           base64.StdEncoding.EncodeToString(e.CanonicalizedBody),
+			IntegratedTime: int64(e.IntegratedTime),
+			LogIndex:       int64(e.LogIndex),
+			LogID:          hex.EncodeToString(e.LogID.KeyID),
+		},
+	}
+}
+
+// InclusionProof returns the inclusion proof of the entry returned by RekorBundle,
+// or nil if it has none.
+func (sb *SigstoreBundle) InclusionProof() *InclusionProof {
+	e := sb.promisedEntry()
+	if e == nil || e.InclusionProof == nil {
+		return nil
+	}
+	proof := &InclusionProof{
+		LogIndex: int64(e.InclusionProof.LogIndex),
+		TreeSize: int64(e.InclusionProof.TreeSize),
+		RootHash: e.InclusionProof.RootHash,
+		Hashes:   e.InclusionProof.Hashes,
+	}
+	if cp := e.InclusionProof.Checkpoint; cp != nil {
+		proof.Checkpoint = cp.Envelope
+	}
+	return proof
+}
+
+// promisedEntry returns the first transparency log entry that carries an inclusion promise.
+func (sb *SigstoreBundle) promisedEntry() *SigstoreTlogEntry {
+	for i, e := range sb.VerificationMaterial.TlogEntries {
+		if e.InclusionPromise != nil {
+			return &sb.VerificationMaterial.TlogEntries[i]
+		}
+	}
+	return nil
+}
+
+// NewSigstoreBundle returns a bundle for the signature sig over blob. certs is
+// the signing certificate followed by its chain, and is empty when the blob was
+// signed with the key pub, which the bundle then names; rb is the transparency
+// log entry, if the signature was uploaded.
+func NewSigstoreBundle(blob, sig []byte, certs []*x509.Certificate, pub crypto.PublicKey, rb *RekorBundle) (*SigstoreBundle, error) {
+	digest := sha256.Sum256(blob)
+	sb := &SigstoreBundle{
+		MediaType: SigstoreBundleMediaType,
+		MessageSignature: &SigstoreMessageSignature{
+			MessageDigest: SigstoreMessageDigest{Algorithm: "SHA2_256", Digest: digest[:]},
+			Signature:     sig,
+		},
+	}
+	if len(certs) > 0 {
+		chain := &SigstoreCertificateChain{}
+		for _, c := range certs {
+			chain.Certificates = append(chain.Certificates, SigstoreCertificate{RawBytes: c.Raw})
+		}
+		sb.VerificationMaterial.X509CertificateChain = chain
+	} else if pub != nil {
+		hint, err := PublicKeyHint(pub)
+		if err != nil {
+			return nil, err
+		}
+		sb.VerificationMaterial.PublicKey = &SigstorePublicKeyIdentifier{Hint: hint}
+	}
+	if rb != nil {
+		entry, err := tlogEntryFromRekorBundle(rb)
+		if err != nil {
+			return nil, err
+		}
+		sb.VerificationMaterial.TlogEntries = []SigstoreTlogEntry{entry}
+	}
+	return sb, nil
+}
+
+// PublicKeyHint returns the hint that identifies pub in a bundle: the base64
+// encoded SHA-256 digest of its DER encoding.
+func PublicKeyHint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshalling public key: %w", err)
+	}
+	digest := sha256.Sum256(der)
+	return base64.StdEncoding.EncodeToString(digest[:]), nil
+}
+
+// tlogEntryFromRekorBundle is the inverse of RekorBundle.
+func tlogEntryFromRekorBundle(rb *RekorBundle) (SigstoreTlogEntry, error) {
+	b64Body, ok := rb.Payload.Body.(string)
+	if !ok {
+		return SigstoreTlogEntry{}, fmt.Errorf("unexpected tlog entry body type %T", rb.Payload.Body)
+	}
+	body, err := base64.StdEncoding.DecodeString(b64Body)
+	if err != nil {
+		return SigstoreTlogEntry{}, fmt.Errorf("decoding tlog entry body: %w", err)
+	}
+	var kv struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal(body, &kv); err != nil {
+		return SigstoreTlogEntry{}, fmt.Errorf("parsing tlog entry body: %w", err)
+	}
+	logID, err := hex.DecodeString(rb.Payload.LogID)
+	if err != nil {
+		return SigstoreTlogEntry{}, fmt.Errorf("decoding tlog log ID: %w", err)
+	}
+	return SigstoreTlogEntry{
+		LogIndex:          Int64String(rb.Payload.LogIndex),
+		LogID:             SigstoreLogID{KeyID: logID},
+		KindVersion:       SigstoreKindVersion{Kind: kv.Kind, Version: kv.APIVersion},
+		IntegratedTime:    Int64String(rb.Payload.IntegratedTime),
+		InclusionPromise:  &SigstoreInclusionPromise{SignedEntryTimestamp: rb.SignedEntryTimestamp},
+		CanonicalizedBody: body,
+	}, nil
+}
@@ -0,0 +1,129 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSigstoreBundle(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+	// Integers are strings in protobuf's JSON mapping, but plain numbers are accepted too.
+	b := []byte(`{
+		"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.1",
+		"verificationMaterial": {
+			"tlogEntries": [{
+				"logIndex": "42",
+				"logId": {"keyId": "` + b64([]byte{0xde, 0xad}) + `"},
+				"kindVersion": {"kind": "hashedrekord", "version": "0.0.1"},
+				"integratedTime": 1640000000,
+				"inclusionPromise": {"signedEntryTimestamp": "` + b64([]byte("set")) + `"},
+				"inclusionProof": {"logIndex": "42", "rootHash": "` + b64([]byte("root")) + `", "treeSize": "100", "hashes": ["` + b64([]byte("h")) + `"], "checkpoint": {"envelope": "note\n"}},
+				"canonicalizedBody": "` + b64([]byte("body")) + `"
+			}]
+		},
+		"messageSignature": {
+			"messageDigest": {"algorithm": "SHA2_256", "digest": "` + b64([]byte("digest")) + `"},
+			"signature": "` + b64([]byte("sig")) + `"
+		}
+	}`)
+
+	sb, err := ParseSigstoreBundle(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sb.MessageSignature.Signature) != "sig" {
+		t.Errorf("signature = %q", sb.MessageSignature.Signature)
+	}
+	cert, err := sb.Certificate()
+	if err != nil || cert != nil {
+		t.Errorf("Certificate() = %v, %v, want nil, nil", cert, err)
+	}
+
+	rb := sb.RekorBundle()
+	if rb == nil {
+		t.Fatal("expected a rekor bundle")
+	}
+	if string(rb.SignedEntryTimestamp) != "set" {
+		t.Errorf("SET = %q", rb.SignedEntryTimestamp)
+	}
+	want := RekorPayload{Body: b64([]byte("body")), IntegratedTime: 1640000000, LogIndex: 42, LogID: "dead"}
+	if rb.Payload != want {
+		t.Errorf("payload = %+v, want %+v", rb.Payload, want)
+	}
+
+	proof := sb.InclusionProof()
+	if proof == nil || proof.LogIndex != 42 || proof.TreeSize != 100 || string(proof.RootHash) != "root" || len(proof.Hashes) != 1 || proof.Checkpoint != "note\n" {
+		t.Errorf("InclusionProof() = %+v", proof)
+	}
+
+	out, err := json.Marshal(sb.VerificationMaterial.TlogEntries[0].LogIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"42"` {
+		t.Errorf("logIndex marshalled as %s", out)
+	}
+
+	for _, bad := range []string{
+		`{"mediaType": "application/json", "messageSignature": {}}`,
+		`{"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.1"}`,
+	} {
+		if _, err := ParseSigstoreBundle([]byte(bad)); err == nil {
+			t.Errorf("expected error parsing %s", bad)
+		}
+	}
+}
+
+func TestNewSigstoreBundle(t *testing.T) {
+	body := []byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{}}`)
+	rb := &RekorBundle{
+		SignedEntryTimestamp: []byte("set"),
+		Payload: RekorPayload{
+			Body:           base64.StdEncoding.EncodeToString(body),
+			IntegratedTime: 1640000000,
+			LogIndex:       42,
+			LogID:          "dead",
+		},
+	}
+
+	sb, err := NewSigstoreBundle([]byte("blob"), []byte("sig"), nil, nil, rb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSigstoreBundle(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.VerificationMaterial.TlogEntries[0].KindVersion; got != (SigstoreKindVersion{Kind: "hashedrekord", Version: "0.0.1"}) {
+		t.Errorf("kindVersion = %+v", got)
+	}
+	if got := parsed.RekorBundle(); got == nil || got.Payload != rb.Payload || string(got.SignedEntryTimestamp) != "set" {
+		t.Errorf("RekorBundle() = %+v, want %+v", got, rb)
+	}
+	if cert, err := parsed.Certificate(); err != nil || cert != nil {
+		t.Errorf("Certificate() = %v, %v, want nil, nil", cert, err)
+	}
+	if proof := parsed.InclusionProof(); proof != nil {
+		t.Errorf("InclusionProof() = %+v, want nil", proof)
+	}
+}
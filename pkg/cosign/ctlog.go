@@ -0,0 +1,321 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/cosign/tuf"
+)
+
+// This is the CT log public key target name, used when no target declares the ctfe usage.
+var ctPublicKeyStr = `ctfe.pub`
+
+// altCTLogPublicKeyLocation, if set, names a PEM or DER file holding the CT log
+// public key to use in place of the ones from TUF.
+const altCTLogPublicKeyLocation = "SIGSTORE_CT_LOG_PUBLIC_KEY_FILE"
+
+// GetCTLogPubs retrieves the certificate transparency log public keys from the
+// embedded or cached TUF root, or from SIGSTORE_CT_LOG_PUBLIC_KEY_FILE if set.
+func GetCTLogPubs(ctx context.Context) ([]crypto.PublicKey, error) {
+	if alt := os.Getenv(altCTLogPublicKeyLocation); alt != "" {
+		raw, err := os.ReadFile(alt)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading alternate CT log public key file")
+		}
+		pub, err := parseCTLogPub(raw)
+		if err != nil {
+			return nil, err
+		}
+		return []crypto.PublicKey{pub}, nil
+	}
+
+	t, err := tuf.NewFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+	targets, err := t.GetTargetsByMetaWithContext(ctx, tuf.CTFE, []string{ctPublicKeyStr})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting ctfe targets")
+	}
+	pubs := make([]crypto.PublicKey, 0, len(targets))
+	for _, target := range targets {
+		pub, err := parseCTLogPub(target.Target)
+		if err != nil {
+			return nil, err
+		}
+		pubs = append(pubs, pub)
+	}
+	return pubs, nil
+}
+
+// parseCTLogPub parses a PEM or DER encoded CT log public key.
+func parseCTLogPub(raw []byte) (crypto.PublicKey, error) {
+	der := raw
+	if block, _ := pem.Decode(raw); block != nil {
+		der = block.Bytes
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing CT log public key")
+	}
+	return pub, nil
+}
+
+// oidSCTList identifies the X.509 extension that embeds a list of SCTs (RFC 6962,
+// section 3.3).
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// TLS hash and signature algorithm identifiers used by SCTs (RFC 5246, section 7.4.1.4.1).
+const (
+	tlsHashSHA256     = 4
+	tlsSignatureRSA   = 1
+	tlsSignatureECDSA = 3
+)
+
+// sct is a version 1 Signed Certificate Timestamp (RFC 6962, section 3.2).
+type sct struct {
+	logID      [sha256.Size]byte
+	timestamp  uint64
+	extensions []byte
+	hashAlg    uint8
+	sigAlg     uint8
+	signature  []byte
+}
+
+// VerifyEmbeddedSCT verifies the Signed Certificate Timestamps embedded in cert,
+// which was issued by issuer, against the CT log public keys pubs. At least one
+// SCT must verify. If pubs is empty, the keys are retrieved with GetCTLogPubs.
+// Certificates without embedded SCTs are accepted, since Fulcio has not always
+// embedded them.
+//
+// This is implemented with the standard library rather than
+// certificate-transparency-go, which the Fulcio client and the webhook must not
+// depend on.
+func VerifyEmbeddedSCT(ctx context.Context, cert, issuer *x509.Certificate, pubs []crypto.PublicKey) error {
+	scts, err := embeddedSCTs(cert)
+	if err != nil {
+		return err
+	}
+	if len(scts) == 0 {
+		return nil
+	}
+	if len(pubs) == 0 {
+		if pubs, err = GetCTLogPubs(ctx); err != nil {
+			return errors.Wrap(err, "retrieving CT log public keys")
+		}
+	}
+	tbs, err := removeSCTList(cert.RawTBSCertificate)
+	if err != nil {
+		return err
+	}
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	for _, pub := range pubs {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return errors.Wrap(err, "marshalling CT log public key")
+		}
+		logID := sha256.Sum256(der)
+		for _, s := range scts {
+			if s.logID == logID && s.verify(pub, issuerKeyHash, tbs) {
+				return nil
+			}
+		}
+	}
+	return errors.New("none of the embedded SCTs verify against a trusted CT log key")
+}
+
+// HasEmbeddedSCT reports whether cert embeds at least one version 1 SCT.
+// VerifyEmbeddedSCT accepts certificates without any, so callers that require
+// one must check with HasEmbeddedSCT first.
+func HasEmbeddedSCT(cert *x509.Certificate) (bool, error) {
+	scts, err := embeddedSCTs(cert)
+	if err != nil {
+		return false, err
+	}
+	return len(scts) > 0, nil
+}
+
+// verify reports whether s is a valid signature by pub over the precertificate
+// entry for tbs, issued by the holder of the key that hashes to issuerKeyHash.
+func (s sct) verify(pub crypto.PublicKey, issuerKeyHash [sha256.Size]byte, tbs []byte) bool {
+	if s.hashAlg != tlsHashSHA256 || len(tbs) >= 1<<24 || len(s.extensions) >= 1<<16 {
+		return false
+	}
+	// digitally-signed struct of RFC 6962, section 3.2, for a precert_entry.
+	var input bytes.Buffer
+	input.WriteByte(0) // v1
+	input.WriteByte(0) // certificate_timestamp
+	_ = binary.Write(&input, binary.BigEndian, s.timestamp)
+	_ = binary.Write(&input, binary.BigEndian, uint16(1)) // precert_entry
+	input.Write(issuerKeyHash[:])
+	input.Write([]byte{byte(len(tbs) >> 16), byte(len(tbs) >> 8), byte(len(tbs))})
+	input.Write(tbs)
+	_ = binary.Write(&input, binary.BigEndian, uint16(len(s.extensions)))
+	input.Write(s.extensions)
+	digest := sha256.Sum256(input.Bytes())
+
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		return s.sigAlg == tlsSignatureECDSA && ecdsa.VerifyASN1(pub, digest[:], s.signature)
+	case *rsa.PublicKey:
+		return s.sigAlg == tlsSignatureRSA && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], s.signature) == nil
+	default:
+		return false
+	}
+}
+
+// embeddedSCTs returns the version 1 SCTs in cert's SCT list extension, if it has one.
+func embeddedSCTs(cert *x509.Certificate) ([]sct, error) {
+	var list []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSCTList) {
+			if rest, err := asn1.Unmarshal(ext.Value, &list); err != nil || len(rest) != 0 {
+				return nil, errors.New("malformed SCT list extension")
+			}
+		}
+	}
+	if list == nil {
+		return nil, nil
+	}
+
+	r := tlsReader(list)
+	entries, ok := r.vector16()
+	if !ok || len(r) != 0 {
+		return nil, errors.New("malformed SCT list")
+	}
+	var scts []sct
+	for len(entries) > 0 {
+		e, ok := entries.vector16()
+		if !ok {
+			return nil, errors.New("malformed SCT list")
+		}
+		version, ok := e.uint8()
+		if !ok {
+			return nil, errors.New("malformed SCT")
+		}
+		if version != 0 {
+			// Only v1 SCTs are defined; skip any others.
+			continue
+		}
+		var s sct
+		logID, ok1 := e.bytes(len(s.logID))
+		ts, ok2 := e.bytes(8)
+		exts, ok3 := e.vector16()
+		hashAlg, ok4 := e.uint8()
+		sigAlg, ok5 := e.uint8()
+		sig, ok6 := e.vector16()
+		if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 || len(e) != 0 {
+			return nil, errors.New("malformed SCT")
+		}
+		copy(s.logID[:], logID)
+		s.timestamp = binary.BigEndian.Uint64(ts)
+		s.extensions, s.hashAlg, s.sigAlg, s.signature = exts, hashAlg, sigAlg, sig
+		scts = append(scts, s)
+	}
+	return scts, nil
+}
+
+// removeSCTList returns the DER TBSCertificate tbs without its SCT list extension,
+// which is what the log signed when it issued the embedded SCTs.
+func removeSCTList(tbs []byte) ([]byte, error) {
+	var seq asn1.RawValue
+	if rest, err := asn1.Unmarshal(tbs, &seq); err != nil || len(rest) != 0 {
+		return nil, errors.New("malformed TBSCertificate")
+	}
+	var fields []byte
+	for rest := seq.Bytes; len(rest) > 0; {
+		var field asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &field); err != nil {
+			return nil, errors.Wrap(err, "parsing TBSCertificate")
+		}
+		// The extensions are the only [3] field of a TBSCertificate.
+		if field.Class != asn1.ClassContextSpecific || field.Tag != 3 {
+			fields = append(fields, field.FullBytes...)
+			continue
+		}
+		var exts []asn1.RawValue
+		if _, err := asn1.Unmarshal(field.Bytes, &exts); err != nil {
+			return nil, errors.Wrap(err, "parsing certificate extensions")
+		}
+		var kept []byte
+		for _, raw := range exts {
+			var ext pkix.Extension
+			if _, err := asn1.Unmarshal(raw.FullBytes, &ext); err != nil {
+				return nil, errors.Wrap(err, "parsing certificate extension")
+			}
+			if !ext.Id.Equal(oidSCTList) {
+				kept = append(kept, raw.FullBytes...)
+			}
+		}
+		extSeq, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: kept})
+		if err != nil {
+			return nil, err
+		}
+		field.Bytes, field.FullBytes = extSeq, nil
+		der, err := asn1.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, der...)
+	}
+	seq.Bytes, seq.FullBytes = fields, nil
+	return asn1.Marshal(seq)
+}
+
+// tlsReader reads the TLS presentation language encoding (RFC 5246, section 4) of SCTs.
+type tlsReader []byte
+
+func (r *tlsReader) bytes(n int) ([]byte, bool) {
+	if len(*r) < n {
+		return nil, false
+	}
+	b := (*r)[:n]
+	*r = (*r)[n:]
+	return b, true
+}
+
+func (r *tlsReader) uint8() (uint8, bool) {
+	b, ok := r.bytes(1)
+	if !ok {
+		return 0, false
+	}
+	return b[0], true
+}
+
+// vector16 reads a vector with a two byte length prefix.
+func (r *tlsReader) vector16() (tlsReader, bool) {
+	l, ok := r.bytes(2)
+	if !ok {
+		return nil, false
+	}
+	return r.bytes(int(binary.BigEndian.Uint16(l)))
+}
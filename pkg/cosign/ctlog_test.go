@@ -0,0 +1,181 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+)
+
+// signEmbeddedSCT returns the extension that embeds an SCT, signed by logKey, for the
+// certificate that tmpl and issuer produce.
+func signEmbeddedSCT(t *testing.T, tmpl, issuer *x509.Certificate, pub crypto.PublicKey, issuerKey, logKey *ecdsa.PrivateKey) pkix.Extension {
+	t.Helper()
+	// The SCT covers the certificate without its SCT list extension, which the CT
+	// library strips from a certificate carrying a placeholder one.
+	pre := *tmpl
+	pre.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT), Value: []byte{asn1.TagOctetString, 0}}}
+	der, err := x509.CreateCertificate(rand.Reader, &pre, issuer, pub, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := ctx509.ParseCertificate(der)
+	if ctx509.IsFatal(err) {
+		t.Fatal(err)
+	}
+	ctIssuer, err := ctx509.ParseCertificate(issuer.Raw)
+	if ctx509.IsFatal(err) {
+		t.Fatal(err)
+	}
+
+	timestamp := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	mtl, err := ct.MerkleTreeLeafForEmbeddedSCT([]*ctx509.Certificate{leaf, ctIssuer}, timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logDER, err := x509.MarshalPKIXPublicKey(&logKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sct := ct.SignedCertificateTimestamp{SCTVersion: ct.V1, LogID: ct.LogID{KeyID: sha256.Sum256(logDER)}, Timestamp: timestamp}
+	input, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{Leaf: *mtl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(input)
+	sig, err := ecdsa.SignASN1(rand.Reader, logKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sct.Signature = ct.DigitallySigned{
+		Algorithm: cttls.SignatureAndHashAlgorithm{Hash: cttls.SHA256, Signature: cttls.ECDSA},
+		Signature: sig,
+	}
+
+	list, err := x509util.MarshalSCTsIntoSCTList([]*ct.SignedCertificateTimestamp{&sct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawList, err := cttls.Marshal(*list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := asn1.Marshal(rawList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT), Value: value}
+}
+
+func TestVerifyEmbeddedSCT(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{"foo@example.com"},
+	}
+	plainDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := x509.ParseCertificate(plainDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTmpl.ExtraExtensions = []pkix.Extension{signEmbeddedSCT(t, leafTmpl, root, &leafKey.PublicKey, rootKey, logKey)}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := VerifyEmbeddedSCT(ctx, leaf, root, []crypto.PublicKey{&otherKey.PublicKey, &logKey.PublicKey}); err != nil {
+		t.Errorf("VerifyEmbeddedSCT() = %v", err)
+	}
+	if err := VerifyEmbeddedSCT(ctx, leaf, root, []crypto.PublicKey{&otherKey.PublicKey}); err == nil {
+		t.Error("expected error verifying an SCT against an untrusted log key")
+	}
+	if err := VerifyEmbeddedSCT(ctx, plain, root, []crypto.PublicKey{&otherKey.PublicKey}); err != nil {
+		t.Errorf("VerifyEmbeddedSCT() without embedded SCTs = %v", err)
+	}
+
+	co := &CheckOpts{RootCerts: roots, VerifySCT: true, CTLogPubKeys: []crypto.PublicKey{&otherKey.PublicKey}}
+	if _, err := validateAndUnpackCert(context.Background(), leaf, co); err == nil {
+		t.Error("expected validateAndUnpackCert() to reject an SCT from an untrusted log")
+	}
+	co.CTLogPubKeys = []crypto.PublicKey{&logKey.PublicKey}
+	if _, err := validateAndUnpackCert(context.Background(), leaf, co); err != nil {
+		t.Errorf("validateAndUnpackCert() = %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by verification, for callers to test with errors.Is.
+var (
+	// ErrNoSignatures means the image has no signatures to verify.
+	ErrNoSignatures = errors.New("no signatures found")
+	// ErrNoAttestations means the image has no attestations to verify.
+	ErrNoAttestations = errors.New("no attestations found")
+	// ErrNoMatchingSignatures means none of the image's signatures could be verified.
+	ErrNoMatchingSignatures = errors.New("no matching signatures")
+	// ErrNoMatchingAttestations means none of the image's attestations could be verified.
+	ErrNoMatchingAttestations = errors.New("no matching attestations")
+	// ErrCertExpired means the signing certificate expired before the signature was made.
+	ErrCertExpired = errors.New("certificate expired")
+	// ErrCertNotYetValid means the signing certificate was issued after the signature was made.
+	ErrCertNotYetValid = errors.New("certificate not yet valid")
+	// ErrIdentityMismatch means the signing certificate does not carry the expected
+	// email, identity or OIDC issuer.
+	ErrIdentityMismatch = errors.New("certificate identity mismatch")
+	// ErrRekorEntryNotFound means the signature has no entry in the transparency log.
+	ErrRekorEntryNotFound = errors.New("signature not found in transparency log")
+	// ErrThresholdNotMet means fewer of the image's signatures verified than
+	// CheckOpts.SignatureThreshold requires.
+	ErrThresholdNotMet = errors.New("signature threshold not met")
+)
+
+// verificationErrors is returned when none of an image's signatures or attestations
+// verify. It unwraps to err, and also matches any of the individual errors with errors.Is.
+type verificationErrors struct {
+	err  error
+	errs []error
+}
+
+func (e *verificationErrors) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%s:\n%s", e.err, strings.Join(msgs, "\n "))
+}
+
+func (e *verificationErrors) Unwrap() error {
+	return e.err
+}
+
+func (e *verificationErrors) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fulcio requests signing certificates from a Fulcio instance. The
+// version of Fulcio cosign currently builds against exposes its signing
+// certificate API over REST rather than gRPC; the request and response carry
+// the same information either way.
+package fulcio
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/fulcio/pkg/api"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// FulcioCertRequest builds a request for a signing certificate.
+type FulcioCertRequest struct {
+	client api.Client
+	proof  []byte
+}
+
+// NewFulcioCertRequest returns a request that will be sent with client.
+func NewFulcioCertRequest(client api.Client) *FulcioCertRequest {
+	return &FulcioCertRequest{client: client}
+}
+
+// WithProofOfPossession sets the signature over the token's subject, made with
+// the private key the certificate is requested for.
+func (r *FulcioCertRequest) WithProofOfPossession(proof []byte) *FulcioCertRequest {
+	r.proof = proof
+	return r
+}
+
+// Send requests a certificate for publicKey, authenticated with the OIDC token,
+// and returns Fulcio's response as is.
+func (r *FulcioCertRequest) Send(ctx context.Context, token string, publicKey crypto.PublicKey) (*api.CertificateResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var alg string
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey:
+		alg = "ecdsa"
+	case ed25519.PublicKey:
+		alg = "ed25519"
+	default:
+		return nil, errors.Errorf("unsupported public key type %T", publicKey)
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling public key")
+	}
+	return r.client.SigningCert(api.CertificateRequest{
+		PublicKey: api.Key{
+			Algorithm: alg,
+			Content:   pubBytes,
+		},
+		SignedEmailAddress: r.proof,
+	}, token)
+}
+
+// SigningCertificate is a certificate issued by Fulcio.
+type SigningCertificate struct {
+	// Leaf is the certificate issued for the public key.
+	Leaf *x509.Certificate
+	// Chain holds the certificates from Leaf's issuer up to the root.
+	Chain []*x509.Certificate
+	// SCT is the signed certificate timestamp for Leaf, if Fulcio returned one
+	// out of band rather than embedding it.
+	SCT []byte
+
+	// CertPEM and ChainPEM are Leaf and Chain as Fulcio returned them.
+	CertPEM  []byte
+	ChainPEM []byte
+}
+
+// RequestCertificate sends csr for publicKey, authenticated with the OIDC token,
+// and parses the certificate and chain Fulcio returns.
+func RequestCertificate(ctx context.Context, token string, publicKey crypto.PublicKey, csr *FulcioCertRequest) (*SigningCertificate, error) {
+	resp, err := csr.Send(ctx, token, publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "requesting signing certificate")
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(resp.CertPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing signing certificate")
+	}
+	if len(certs) != 1 {
+		return nil, errors.Errorf("expected a single signing certificate, got %d", len(certs))
+	}
+	var chain []*x509.Certificate
+	if len(resp.ChainPEM) > 0 {
+		if chain, err = cryptoutils.UnmarshalCertificatesFromPEM(resp.ChainPEM); err != nil {
+			return nil, errors.Wrap(err, "parsing certificate chain")
+		}
+	}
+	return &SigningCertificate{
+		Leaf:     certs[0],
+		Chain:    chain,
+		SCT:      resp.SCT,
+		CertPEM:  resp.CertPEM,
+		ChainPEM: resp.ChainPEM,
+	}, nil
+}
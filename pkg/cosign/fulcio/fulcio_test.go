@@ -0,0 +1,153 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fulcio
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/api"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+func newCert(t *testing.T, tmpl, parent *x509.Certificate, pub, priv interface{}) []byte {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRequestCertificate(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caPEM := newCert(t, caTmpl, caTmpl, caKey.Public(), caKey)
+	leafPEM := newCert(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		EmailAddresses: []string{"foo@example.com"},
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+	}, caTmpl, priv.Public(), caKey)
+	wantPub, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/signingCert") {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var cr api.CertificateRequest
+		if err := json.NewDecoder(r.Body).Decode(&cr); err != nil {
+			t.Fatal(err)
+		}
+		if cr.PublicKey.Algorithm != "ecdsa" || string(cr.PublicKey.Content) != string(wantPub) {
+			t.Errorf("unexpected public key in request: %+v", cr.PublicKey)
+		}
+		if string(cr.SignedEmailAddress) != "proof" {
+			t.Errorf("SignedEmailAddress = %q", cr.SignedEmailAddress)
+		}
+		w.Header().Set("SCT", base64.StdEncoding.EncodeToString([]byte("sct")))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(append(append([]byte{}, leafPEM...), caPEM...))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr := NewFulcioCertRequest(api.NewClient(u)).WithProofOfPossession([]byte("proof"))
+
+	got, err := RequestCertificate(context.Background(), "token", priv.Public(), csr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Leaf.EmailAddresses[0] != "foo@example.com" {
+		t.Errorf("Leaf = %v", got.Leaf.EmailAddresses)
+	}
+	if len(got.Chain) != 1 || got.Chain[0].Subject.CommonName != "sigstore" {
+		t.Errorf("Chain = %v", got.Chain)
+	}
+	if string(got.SCT) != "sct" {
+		t.Errorf("SCT = %q", got.SCT)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RequestCertificate(context.Background(), "token", rsaKey.Public(), csr); err == nil {
+		t.Error("expected an error for an unsupported key type")
+	}
+}
+
+func TestRequestCertificateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RequestCertificate(context.Background(), "token", priv.Public(), NewFulcioCertRequest(api.NewClient(u))); err == nil {
+		t.Error("expected an error")
+	}
+}
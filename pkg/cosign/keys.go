@@ -45,9 +45,24 @@ const (
 	ECPrivateKeyPemType = "EC PRIVATE KEY"
 	// PEM-encoded PKCS #8 RSA, ECDSA or ED25519 private key
 	PrivateKeyPemType = "PRIVATE KEY"
-	BundleKey         = static.BundleAnnotationKey
+	// PEM-encoded detached signature, as written by `cosign sign --output-signature-pem`
+	SignaturePemType = "COSIGN SIGNATURE"
+	BundleKey        = static.BundleAnnotationKey
 )
 
+// Key types accepted by GenerateKeyPairWithType.
+const (
+	ECDSAKeyType   = "ecdsa"
+	ED25519KeyType = "ed25519"
+	// RSAPSSKeyType is a 3072-bit RSA key that signs with RSASSA-PSS and SHA-256.
+	RSAPSSKeyType = "rsa-pss"
+)
+
+// ErrED25519Tlog is returned when a signature made with an ED25519 key would be
+// uploaded to the transparency log. Rekor only accepts signatures over a SHA-256
+// digest, which ED25519 cannot produce.
+var ErrED25519Tlog = errors.New("signatures from ed25519 keys cannot be uploaded to the transparency log, sign without COSIGN_EXPERIMENTAL to skip the upload")
+
 type PassFunc func(bool) ([]byte, error)
 
 type Keys struct {
@@ -99,7 +114,12 @@ func ImportKeyPair(keyPath string, pf PassFunc) (*KeysBytes, error) {
 	if err != nil {
 		return nil, err
 	}
+	return ImportKeyPairFromPEM(kb, pf)
+}
 
+// ImportKeyPairFromPEM encrypts the PEM-encoded RSA, ECDSA or ED25519 private key
+// in kb with the password from pf and returns it along with its public key.
+func ImportKeyPairFromPEM(kb []byte, pf PassFunc) (*KeysBytes, error) {
 	p, _ := pem.Decode(kb)
 	if p == nil {
 		return nil, fmt.Errorf("invalid pem block")
@@ -159,7 +179,28 @@ func marshalKeyPair(keypair Keys, pf PassFunc) (*KeysBytes, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "x509 encoding private key")
 	}
+	pubBytes, err := cryptoutils.MarshalPublicKeyToPEM(keypair.public)
+	if err != nil {
+		return nil, err
+	}
+	return encryptKeyPair(x509Encoded, pubBytes, pf)
+}
+
+// marshalRSAPSSKeyPair is like marshalKeyPair, but marks both keys for RSASSA-PSS.
+func marshalRSAPSSKeyPair(priv *rsa.PrivateKey, pf PassFunc) (*KeysBytes, error) {
+	x509Encoded, err := marshalRSAPSSPrivateKey(priv)
+	if err != nil {
+		return nil, errors.Wrap(err, "x509 encoding private key")
+	}
+	pubBytes, err := MarshalRSAPSSPublicKeyToPEM(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return encryptKeyPair(x509Encoded, pubBytes, pf)
+}
 
+// encryptKeyPair encrypts the PKCS #8 private key with the password from pf.
+func encryptKeyPair(x509Encoded, pubBytes []byte, pf PassFunc) (*KeysBytes, error) {
 	password, err := pf(true)
 	if err != nil {
 		return nil, err
@@ -176,12 +217,6 @@ func marshalKeyPair(keypair Keys, pf PassFunc) (*KeysBytes, error) {
 		Type:  CosignPrivateKeyPemType,
 	})
 
-	// Now do the public key
-	pubBytes, err := cryptoutils.MarshalPublicKeyToPEM(keypair.public)
-	if err != nil {
-		return nil, err
-	}
-
 	return &KeysBytes{
 		PrivateBytes: privBytes,
 		PublicBytes:  pubBytes,
@@ -190,7 +225,29 @@ func marshalKeyPair(keypair Keys, pf PassFunc) (*KeysBytes, error) {
 }
 
 func GenerateKeyPair(pf PassFunc) (*KeysBytes, error) {
-	priv, err := GeneratePrivateKey()
+	return GenerateKeyPairWithType(ECDSAKeyType, pf)
+}
+
+// GenerateKeyPairWithType generates a key pair of the given type, an ECDSA P-256
+// key for ECDSAKeyType, an ED25519 key for ED25519KeyType or an RSA key for
+// RSAPSSKeyType.
+func GenerateKeyPairWithType(keyType string, pf PassFunc) (*KeysBytes, error) {
+	var priv crypto.Signer
+	var err error
+	switch keyType {
+	case ECDSAKeyType:
+		priv, err = GeneratePrivateKey()
+	case ED25519KeyType:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	case RSAPSSKeyType:
+		rsaPriv, err := rsa.GenerateKey(rand.Reader, 3072)
+		if err != nil {
+			return nil, err
+		}
+		return marshalRSAPSSKeyPair(rsaPriv, pf)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q, expected (%s|%s|%s)", keyType, ECDSAKeyType, ED25519KeyType, RSAPSSKeyType)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -229,6 +286,12 @@ func LoadPrivateKey(key []byte, pass []byte) (signature.SignerVerifier, error) {
 		return nil, errors.Wrap(err, "decrypt")
 	}
 
+	if pssKey, ok, err := parseRSAPSSPrivateKey(x509Encoded); ok {
+		if err != nil {
+			return nil, err
+		}
+		return LoadRSAPSSSignerVerifier(pssKey)
+	}
 	pk, err := x509.ParsePKCS8PrivateKey(x509Encoded)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing private key")
@@ -238,7 +301,9 @@ func LoadPrivateKey(key []byte, pass []byte) (signature.SignerVerifier, error) {
 		return signature.LoadRSAPKCS1v15SignerVerifier(pk, crypto.SHA256)
 	case *ecdsa.PrivateKey:
 		return signature.LoadECDSASignerVerifier(pk, crypto.SHA256)
+	case ed25519.PrivateKey:
+		return signature.LoadED25519SignerVerifier(pk)
 	default:
-		return nil, errors.Wrap(err, "unsupported key type")
+		return nil, fmt.Errorf("unsupported key type %T", pk)
 	}
 }
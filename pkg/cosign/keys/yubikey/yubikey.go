@@ -0,0 +1,132 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yubikey provides a signature.SignerVerifier backed by a key held in
+// a PIV slot of a YubiKey. Hardware support requires cosign to be built with
+// the pivkey build tag and cgo; otherwise New returns an error.
+package yubikey
+
+import (
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/cosign/pivkey"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// DefaultSlot is the PIV slot used when no slot is configured.
+const DefaultSlot = "signature"
+
+type options struct {
+	slot string
+	pin  string
+}
+
+// Option configures a SignerVerifier.
+type Option func(*options)
+
+// WithSlot selects the PIV slot holding the key, either by name
+// (authentication|signature|card-authentication|key-management) or
+// by its key reference (9a|9c|9e|9d).
+func WithSlot(slot string) Option {
+	return func(o *options) {
+		o.slot = slot
+	}
+}
+
+// WithPIN sets the PIN used to unlock the key. If unset, the user is
+// prompted for the PIN on the terminal when the key is first used.
+func WithPIN(pin string) Option {
+	return func(o *options) {
+		o.pin = pin
+	}
+}
+
+// card is the part of *pivkey.Key that SignerVerifier uses.
+type card interface {
+	Authenticate(pin string)
+	SignerVerifier() (signature.SignerVerifier, error)
+	Certificate() (*x509.Certificate, error)
+	Attest() (*x509.Certificate, error)
+	GetAttestationCertificate() (*x509.Certificate, error)
+	Close()
+}
+
+// openCard opens the single attached YubiKey for the key in slot. Tests replace it.
+var openCard = func(slot string) (card, error) {
+	key, err := pivkey.GetKeyWithSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SignerVerifier signs and verifies with the key in a YubiKey PIV slot.
+// It must be closed to release the card.
+type SignerVerifier struct {
+	signature.SignerVerifier
+
+	key card
+}
+
+var _ signature.SignerVerifier = (*SignerVerifier)(nil)
+
+// New opens the single attached YubiKey and returns a SignerVerifier for the
+// key in the configured slot.
+func New(opts ...Option) (*SignerVerifier, error) {
+	o := &options{slot: DefaultSlot}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	key, err := openCard(o.slot)
+	if err != nil {
+		return nil, err
+	}
+	if o.pin != "" {
+		key.Authenticate(o.pin)
+	}
+	sv, err := key.SignerVerifier()
+	if err != nil {
+		key.Close()
+		return nil, errors.Wrapf(err, "loading key from slot %s", o.slot)
+	}
+	return &SignerVerifier{SignerVerifier: sv, key: key}, nil
+}
+
+// Certificate returns the certificate stored alongside the key in its slot.
+func (s *SignerVerifier) Certificate() (*x509.Certificate, error) {
+	return s.key.Certificate()
+}
+
+// Attestation returns the attestation certificate for the key's slot, signed by
+// the device, along with the device attestation certificate that signed it.
+func (s *SignerVerifier) Attestation() (slotCert, deviceCert *x509.Certificate, err error) {
+	slotCert, err = s.key.Attest()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "attesting slot")
+	}
+	deviceCert, err = s.key.GetAttestationCertificate()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "getting device attestation certificate")
+	}
+	return slotCert, deviceCert, nil
+}
+
+// Close releases the card.
+func (s *SignerVerifier) Close() {
+	s.key.Close()
+}
@@ -0,0 +1,217 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yubikey
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// fakeCard is a PIV card holding a software key.
+type fakeCard struct {
+	priv      *ecdsa.PrivateKey
+	cert      *x509.Certificate
+	pin       string
+	svErr     error
+	attestErr error
+	closed    bool
+}
+
+func newFakeCard(t *testing.T) *fakeCard {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "slot"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeCard{priv: priv, cert: cert}
+}
+
+func (c *fakeCard) Authenticate(pin string) { c.pin = pin }
+
+func (c *fakeCard) SignerVerifier() (signature.SignerVerifier, error) {
+	if c.svErr != nil {
+		return nil, c.svErr
+	}
+	return signature.LoadECDSASignerVerifier(c.priv, crypto.SHA256)
+}
+
+func (c *fakeCard) Certificate() (*x509.Certificate, error) { return c.cert, nil }
+
+func (c *fakeCard) Attest() (*x509.Certificate, error) {
+	if c.attestErr != nil {
+		return nil, c.attestErr
+	}
+	return c.cert, nil
+}
+
+func (c *fakeCard) GetAttestationCertificate() (*x509.Certificate, error) { return c.cert, nil }
+
+func (c *fakeCard) Close() { c.closed = true }
+
+// useCard makes New open c, recording the slot it asks for in slot.
+func useCard(t *testing.T, c *fakeCard, slot *string) {
+	t.Helper()
+	old := openCard
+	openCard = func(s string) (card, error) {
+		*slot = s
+		return c, nil
+	}
+	t.Cleanup(func() { openCard = old })
+}
+
+func TestNewSlot(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default", want: DefaultSlot},
+		{name: "by name", opts: []Option{WithSlot("authentication")}, want: "authentication"},
+		{name: "by key reference", opts: []Option{WithSlot("9e")}, want: "9e"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var slot string
+			useCard(t, newFakeCard(t), &slot)
+			sv, err := New(tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sv.Close()
+			if slot != tc.want {
+				t.Errorf("opened slot %q, want %q", slot, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewPIN(t *testing.T) {
+	var slot string
+	c := newFakeCard(t)
+	useCard(t, c, &slot)
+
+	sv, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv.Close()
+	if c.pin != "" {
+		t.Errorf("authenticated with %q without WithPIN; the PIN should be prompted for", c.pin)
+	}
+
+	sv, err = New(WithPIN("123456"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv.Close()
+	if c.pin != "123456" {
+		t.Errorf("authenticated with %q, want 123456", c.pin)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	old := openCard
+	t.Cleanup(func() { openCard = old })
+
+	openCard = func(string) (card, error) { return nil, errors.New("no card") }
+	if _, err := New(); err == nil {
+		t.Error("expected an error without a card")
+	}
+
+	c := newFakeCard(t)
+	c.svErr = errors.New("locked")
+	openCard = func(string) (card, error) { return c, nil }
+	if _, err := New(); err == nil {
+		t.Error("expected an error when the key can't be loaded")
+	}
+	if !c.closed {
+		t.Error("the card should be released when the key can't be loaded")
+	}
+}
+
+func TestSignerVerifierRoundTrip(t *testing.T) {
+	var slot string
+	c := newFakeCard(t)
+	useCard(t, c, &slot)
+	sv, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("hello")
+	sig, err := sv.SignMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.priv.PublicKey.Equal(pub) {
+		t.Error("PublicKey() is not the slot's key")
+	}
+	v, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Errorf("verifying with the exported public key: %v", err)
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("other"))); err == nil {
+		t.Error("verified a signature over another message")
+	}
+
+	cert, err := sv.Certificate()
+	if err != nil || cert != c.cert {
+		t.Errorf("Certificate() = %v, %v", cert, err)
+	}
+	slotCert, deviceCert, err := sv.Attestation()
+	if err != nil || slotCert == nil || deviceCert == nil {
+		t.Errorf("Attestation() = %v, %v, %v", slotCert, deviceCert, err)
+	}
+	c.attestErr = errors.New("not supported")
+	if _, _, err := sv.Attestation(); err == nil {
+		t.Error("expected an error when the slot can't be attested")
+	}
+
+	sv.Close()
+	if !c.closed {
+		t.Error("Close() did not release the card")
+	}
+}
@@ -16,12 +16,16 @@
 package cosign
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/rand"
 	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/stretchr/testify/require"
 )
 
@@ -290,6 +294,84 @@ func TestLoadECDSAPrivateKey(t *testing.T) {
 	}
 }
 
+func TestLoadED25519PrivateKey(t *testing.T) {
+	generated, err := GenerateKeyPairWithType(ED25519KeyType, pass("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportKeyPairFromPEM([]byte(ed25519key), pass("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keys := range []*KeysBytes{generated, imported} {
+		sv, err := LoadPrivateKey(keys.PrivateBytes, []byte("hello"))
+		if err != nil {
+			t.Fatalf("unexpected error loading key: %s", err)
+		}
+		payload := []byte("payload")
+		sig, err := sv.SignMessage(bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey(keys.PublicBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload)); err != nil {
+			t.Errorf("unexpected error verifying signature: %s", err)
+		}
+	}
+
+	if _, err := GenerateKeyPairWithType("dsa", pass("hello")); err == nil {
+		t.Error("expected error generating an unsupported key type")
+	}
+}
+
+func TestLoadRSAPSSPrivateKey(t *testing.T) {
+	keys, err := GenerateKeyPairWithType(RSAPSSKeyType, pass("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := LoadPrivateKey(keys.PrivateBytes, []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error loading key: %s", err)
+	}
+	if _, ok := sv.(*RSAPSSSignerVerifier); !ok {
+		t.Fatalf("LoadPrivateKey() = %T, want *RSAPSSSignerVerifier", sv)
+	}
+	payload := []byte("payload")
+	sig, err := sv.SignMessage(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := LoadPublicKeyVerifier(keys.PublicBytes, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := verifier.(*RSAPSSVerifier); !ok {
+		t.Fatalf("LoadPublicKeyVerifier() = %T, want *RSAPSSVerifier", verifier)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload)); err != nil {
+		t.Errorf("VerifySignature() = %v", err)
+	}
+
+	// The signature is not a PKCS #1 v1.5 signature.
+	pkcs1v15, err := signature.LoadVerifier(sv.(*RSAPSSSignerVerifier).publicKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkcs1v15.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload)); err == nil {
+		t.Error("expected a PKCS #1 v1.5 verifier to reject the PSS signature")
+	}
+}
+
 func TestImportPrivateKey(t *testing.T) {
 	testCases := []struct {
 		fileName string
@@ -381,6 +463,13 @@ func TestImportPrivateKey(t *testing.T) {
 			} else {
 				require.Equal(t, tc.expected.Error(), err.Error())
 			}
+
+			_, err = ImportKeyPairFromPEM([]byte(tc.pemData), pass("hello"))
+			if err == nil || tc.expected == nil {
+				require.Equal(t, tc.expected, err)
+			} else {
+				require.Equal(t, tc.expected.Error(), err.Error())
+			}
 		})
 	}
 }
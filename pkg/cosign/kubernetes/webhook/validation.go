@@ -18,7 +18,6 @@ package webhook
 import (
 	"context"
 	"crypto"
-	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -35,7 +34,7 @@ import (
 	"github.com/sigstore/sigstore/pkg/signature"
 )
 
-func valid(ctx context.Context, ref name.Reference, keys []*ecdsa.PublicKey, opts ...ociremote.Option) error {
+func valid(ctx context.Context, ref name.Reference, keys []crypto.PublicKey, opts ...ociremote.Option) error {
 	if len(keys) == 0 {
 		// If there are no keys, then verify against the fulcio root.
 		sps, err := validSignatures(ctx, ref, nil /* verifier */, opts...)
@@ -50,7 +49,7 @@ func valid(ctx context.Context, ref name.Reference, keys []*ecdsa.PublicKey, opt
 	// We return nil if ANY key matches
 	var lastErr error
 	for _, k := range keys {
-		verifier, err := signature.LoadECDSAVerifier(k, crypto.SHA256)
+		verifier, err := signature.LoadVerifier(k, crypto.SHA256)
 		if err != nil {
 			logging.FromContext(ctx).Errorf("error creating verifier: %v", err)
 			lastErr = err
@@ -84,8 +83,8 @@ func validSignatures(ctx context.Context, ref name.Reference, verifier signature
 	return sigs, err
 }
 
-func getKeys(ctx context.Context, cfg map[string][]byte) ([]*ecdsa.PublicKey, *apis.FieldError) {
-	keys := []*ecdsa.PublicKey{}
+func getKeys(ctx context.Context, cfg map[string][]byte) ([]crypto.PublicKey, *apis.FieldError) {
+	keys := []crypto.PublicKey{}
 	errs := []error{}
 
 	logging.FromContext(ctx).Debugf("Got public key: %v", cfg["cosign.pub"])
@@ -97,7 +96,7 @@ func getKeys(ctx context.Context, cfg map[string][]byte) ([]*ecdsa.PublicKey, *a
 		if err != nil {
 			errs = append(errs, err)
 		} else {
-			keys = append(keys, key.(*ecdsa.PublicKey))
+			keys = append(keys, key)
 		}
 	}
 	if keys == nil {
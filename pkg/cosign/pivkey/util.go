@@ -25,13 +25,13 @@ func SlotForName(slotName string) *piv.Slot {
 	switch slotName {
 	case "":
 		return &piv.SlotSignature
-	case "authentication":
+	case "authentication", "9a":
 		return &piv.SlotAuthentication
-	case "signature":
+	case "signature", "9c":
 		return &piv.SlotSignature
-	case "card-authentication":
+	case "card-authentication", "9e":
 		return &piv.SlotCardAuthentication
-	case "key-management":
+	case "key-management", "9d":
 		return &piv.SlotKeyManagement
 	default:
 		return nil
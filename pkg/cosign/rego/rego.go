@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/open-policy-agent/opa/rego"
@@ -66,3 +67,43 @@ func ValidateJSON(jsonBody []byte, entrypoints []string) []error {
 	}
 	return errs
 }
+
+// EvaluatePolicy evaluates the Rego policy in package cosign with the JSON
+// payload as input. It reports whether data.cosign.allow is true, and the
+// messages in data.cosign.deny, if the policy defines it, as the violations.
+func EvaluatePolicy(ctx context.Context, policy string, payload []byte) (bool, []string, error) {
+	var input interface{}
+	dec := json.NewDecoder(bytes.NewBuffer(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&input); err != nil {
+		return false, nil, err
+	}
+
+	rs, err := rego.New(
+		rego.Query("data.cosign"),
+		rego.Module("policy.rego", policy),
+		rego.Input(input)).Eval(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return false, nil, errors.New("policy does not define package cosign")
+	}
+	doc, ok := rs[0].Expressions[0].Value.(map[string]interface{})
+	if !ok {
+		return false, nil, fmt.Errorf("unexpected result %v evaluating policy", rs[0].Expressions[0].Value)
+	}
+
+	allowed, _ := doc["allow"].(bool)
+	var violations []string
+	if deny, ok := doc["deny"].([]interface{}); ok {
+		for _, v := range deny {
+			if s, ok := v.(string); ok {
+				violations = append(violations, s)
+			} else {
+				violations = append(violations, fmt.Sprint(v))
+			}
+		}
+	}
+	return allowed, violations, nil
+}
@@ -0,0 +1,61 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rego
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const builderPolicy = `
+package cosign
+
+default allow = false
+
+allow {
+	count(deny) == 0
+}
+
+deny[msg] {
+	input.predicate.builder.id != "trusted"
+	msg := sprintf("untrusted builder %s", [input.predicate.builder.id])
+}
+`
+
+func TestEvaluatePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	allowed, violations, err := EvaluatePolicy(ctx, builderPolicy, []byte(`{"predicate": {"builder": {"id": "trusted"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allowed || len(violations) != 0 {
+		t.Errorf("EvaluatePolicy() = %v, %v, want true, []", allowed, violations)
+	}
+
+	allowed, violations, err = EvaluatePolicy(ctx, builderPolicy, []byte(`{"predicate": {"builder": {"id": "other"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"untrusted builder other"}; allowed || !reflect.DeepEqual(violations, want) {
+		t.Errorf("EvaluatePolicy() = %v, %v, want false, %v", allowed, violations, want)
+	}
+
+	if _, _, err := EvaluatePolicy(ctx, "package other\n\nallow = true", []byte(`{}`)); err == nil {
+		t.Error("expected error for a policy outside package cosign")
+	}
+}
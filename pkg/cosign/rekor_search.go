@@ -0,0 +1,187 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+
+	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/static"
+)
+
+// VerifyImageSignaturesInRekor verifies signatures over the manifest of signedImgRef
+// that were recorded in the transparency log but never attached to the image. The
+// log is searched with the manifest digest as the artifact hash, and each
+// hashedrekord entry found is checked with the key or roots in co, as
+// VerifyImageSignatures checks a signature attached to the image. The payload of
+// each returned signature is the manifest.
+func VerifyImageSignaturesInRekor(ctx context.Context, signedImgRef name.Reference, co *CheckOpts) ([]oci.Signature, error) {
+	if co.RekorClient == nil {
+		return nil, errors.New("a Rekor client is required to search the transparency log")
+	}
+	if co.RootCerts == nil && co.SigVerifier == nil {
+		return nil, errors.New("one of verifier or root certs is required")
+	}
+
+	se, _, err := getSignedEntity(signedImgRef, co)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := se.(interface{ RawManifest() ([]byte, error) })
+	if !ok {
+		return nil, errors.New("must verify either an image index or image")
+	}
+	manifest, err := m.RawManifest()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching manifest")
+	}
+
+	uuids, err := FindTLogEntriesByPayload(ctx, co.RekorClient, manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "searching the transparency log")
+	}
+	if len(uuids) == 0 {
+		return nil, fmt.Errorf("%w in the transparency log", ErrNoSignatures)
+	}
+
+	var verified []oci.Signature
+	var validationErrs []error
+	// signers holds the distinct signers of verified.
+	signers := map[string]bool{}
+	for _, uuid := range uuids {
+		sig, signer, err := verifyRekorManifestSignature(ctx, uuid, manifest, co)
+		if err != nil {
+			validationErrs = append(validationErrs, errors.Wrapf(err, "entry %s", uuid))
+			continue
+		}
+		verified = append(verified, sig)
+		signers[signer] = true
+	}
+	if len(verified) == 0 {
+		return nil, &verificationErrors{err: ErrNoMatchingSignatures, errs: validationErrs}
+	}
+	if n := co.threshold(); len(signers) < n {
+		err := fmt.Errorf("%w: %d distinct signers in %d entries verified, %d required", ErrThresholdNotMet, len(signers), len(verified), n)
+		if len(validationErrs) == 0 {
+			return nil, err
+		}
+		return nil, &verificationErrors{err: err, errs: validationErrs}
+	}
+	return verified, nil
+}
+
+// verifyRekorManifestSignature verifies the log entry uuid as a signature over
+// manifest, and returns it with the signer it counts as toward
+// co.SignatureThreshold.
+func verifyRekorManifestSignature(ctx context.Context, uuid string, manifest []byte, co *CheckOpts) (oci.Signature, string, error) {
+	e, err := verifyTLogEntry(ctx, co.RekorClient, uuid)
+	if err != nil {
+		return nil, "", err
+	}
+	sig, pubKey, err := hashedrekordSignature(e)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var opts []static.Option
+	var signer string
+	if certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pubKey); err == nil && len(certs) > 0 {
+		if co.RootCerts == nil {
+			return nil, "", errors.New("entry is signed with a certificate but no root certificates were provided")
+		}
+		verifier, err := validateAndUnpackCert(ctx, certs[0], co)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := CheckExpiry(certs[0], time.Unix(*e.IntegratedTime, 0)); err != nil {
+			return nil, "", err
+		}
+		if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(manifest), options.WithContext(ctx)); err != nil {
+			return nil, "", err
+		}
+		signer = certSigner(certs[0])
+		opts = append(opts, static.WithCertChain(pubKey, nil))
+	} else if co.SigVerifier == nil {
+		return nil, "", errors.New("entry is signed with a key but no public key was provided")
+	} else {
+		signer, err = verifyRekorKeySignature(ctx, co, sig, manifest)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	opts = append(opts, static.WithBundle(cbundle.EntryToBundle(e)))
+	s, err := static.NewSignature(manifest, base64.StdEncoding.EncodeToString(sig), opts...)
+	return s, signer, err
+}
+
+// verifyRekorKeySignature verifies sig over manifest with co.SigVerifier or one of
+// co.AdditionalSigVerifiers, and returns the signer that key counts as.
+func verifyRekorKeySignature(ctx context.Context, co *CheckOpts, sig, manifest []byte) (string, error) {
+	var err error
+	for _, verifier := range append([]signature.Verifier{co.SigVerifier}, co.AdditionalSigVerifiers...) {
+		if err = verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(manifest), options.WithContext(ctx)); err == nil {
+			return keySigner(verifier, co.PKOpts...)
+		}
+	}
+	return "", err
+}
+
+// hashedrekordSignature returns the signature and the PEM-encoded public key or
+// certificate of the hashedrekord entry e.
+func hashedrekordSignature(e *models.LogEntryAnon) (sig, pubKey []byte, err error) {
+	body, ok := e.Body.(string)
+	if !ok {
+		return nil, nil, errors.New("unexpected tlog entry body")
+	}
+	bodyDecoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(bodyDecoded), runtime.JSONConsumer())
+	if err != nil {
+		return nil, nil, err
+	}
+	hrekord, ok := pe.(*models.Hashedrekord)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected tlog entry kind %q", pe.Kind())
+	}
+	specMarshal, err := json.Marshal(hrekord.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	var hrekordObj models.HashedrekordV001Schema
+	if err := json.Unmarshal(specMarshal, &hrekordObj); err != nil {
+		return nil, nil, err
+	}
+	if hrekordObj.Signature == nil || hrekordObj.Signature.PublicKey == nil {
+		return nil, nil, errors.New("tlog entry has no signature")
+	}
+	return hrekordObj.Signature.Content, hrekordObj.Signature.PublicKey.Content, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+func TestHashedrekordSignature(t *testing.T) {
+	entry := func(pe models.ProposedEntry) *models.LogEntryAnon {
+		b, err := json.Marshal(pe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &models.LogEntryAnon{Body: base64.StdEncoding.EncodeToString(b)}
+	}
+
+	e := entry(&models.Hashedrekord{
+		APIVersion: swag.String("0.0.1"),
+		Spec: models.HashedrekordV001Schema{
+			Data: &models.HashedrekordV001SchemaData{
+				Hash: &models.HashedrekordV001SchemaDataHash{
+					Algorithm: swag.String(models.HashedrekordV001SchemaDataHashAlgorithmSha256),
+					Value:     swag.String("abcd"),
+				},
+			},
+			Signature: &models.HashedrekordV001SchemaSignature{
+				Content: []byte("signature"),
+				PublicKey: &models.HashedrekordV001SchemaSignaturePublicKey{
+					Content: []byte("public key"),
+				},
+			},
+		},
+	})
+	sig, pub, err := hashedrekordSignature(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, []byte("signature")) || !bytes.Equal(pub, []byte("public key")) {
+		t.Errorf("hashedrekordSignature() = %q, %q", sig, pub)
+	}
+
+	e = entry(&models.Intoto{APIVersion: swag.String("0.0.1"), Spec: map[string]interface{}{}})
+	if _, _, err := hashedrekordSignature(e); err == nil {
+		t.Error("expected an error for an intoto entry")
+	}
+}
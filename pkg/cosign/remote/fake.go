@@ -0,0 +1,114 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/sigstore/cosign/pkg/oci"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+)
+
+// FakeRegistry is an in-memory Puller and Pusher for tests. Entities are
+// looked up by the exact reference they were added under, or by digest.
+// Writing signatures or attestations for an entity replaces what its digest
+// resolves to, so a later pull sees what was pushed.
+type FakeRegistry struct {
+	mu       sync.Mutex
+	entities map[string]oci.SignedEntity
+}
+
+var _ Puller = (*FakeRegistry)(nil)
+var _ Pusher = (*FakeRegistry)(nil)
+
+// NewFakeRegistry returns an empty FakeRegistry.
+func NewFakeRegistry() *FakeRegistry {
+	return &FakeRegistry{entities: map[string]oci.SignedEntity{}}
+}
+
+// Add makes se available at ref, and at its digest in ref's repository.
+func (f *FakeRegistry) Add(ref name.Reference, se oci.SignedEntity) error {
+	h, err := entityDigest(se)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entities[ref.String()] = se
+	f.entities[ref.Context().Digest(h.String()).String()] = se
+	return nil
+}
+
+// SignedEntity implements Puller
+func (f *FakeRegistry) SignedEntity(ref name.Reference, _ ...ociremote.Option) (oci.SignedEntity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	se, ok := f.entities[ref.String()]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", ref)
+	}
+	return se, nil
+}
+
+// ResolveDigest implements Puller
+func (f *FakeRegistry) ResolveDigest(ref name.Reference, opts ...ociremote.Option) (name.Digest, error) {
+	if d, ok := ref.(name.Digest); ok {
+		return d, nil
+	}
+	se, err := f.SignedEntity(ref, opts...)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	h, err := entityDigest(se)
+	if err != nil {
+		return name.Digest{}, err
+	}
+	return ref.Context().Digest(h.String()), nil
+}
+
+// WriteSignatures implements Pusher
+func (f *FakeRegistry) WriteSignatures(repo name.Repository, se oci.SignedEntity, _ ...ociremote.Option) error {
+	return f.write(repo, se)
+}
+
+// WriteAttestations implements Pusher
+func (f *FakeRegistry) WriteAttestations(repo name.Repository, se oci.SignedEntity, _ ...ociremote.Option) error {
+	return f.write(repo, se)
+}
+
+func (f *FakeRegistry) write(repo name.Repository, se oci.SignedEntity) error {
+	h, err := entityDigest(se)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entities[repo.Digest(h.String()).String()] = se
+	return nil
+}
+
+func entityDigest(se oci.SignedEntity) (v1.Hash, error) {
+	// Both of the SignedEntity types implement Digest()
+	d, ok := se.(interface{ Digest() (v1.Hash, error) })
+	if !ok {
+		return v1.Hash{}, fmt.Errorf("unsupported type: %T", se)
+	}
+	return d.Digest()
+}
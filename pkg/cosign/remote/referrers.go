@@ -0,0 +1,95 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/sigstore/cosign/pkg/oci"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+)
+
+// Referrers is the Puller and Pusher that stores signatures as OCI v1.1
+// referrers of the entities they sign, instead of under the signature tag.
+// Attestations are still stored under the attestation tag, and only the
+// signatures of the entity named by a reference, not its children, are
+// fetched as referrers.
+var Referrers = referrers{}
+
+type referrers struct{}
+
+var _ Puller = referrers{}
+var _ Pusher = referrers{}
+
+// SignedEntity implements Puller
+func (referrers) SignedEntity(ref name.Reference, opts ...ociremote.Option) (oci.SignedEntity, error) {
+	se, err := ociremote.SignedEntity(ref, opts...)
+	if err != nil {
+		return nil, err
+	}
+	h, err := se.(interface{ Digest() (v1.Hash, error) }).Digest()
+	if err != nil {
+		return nil, err
+	}
+	digest := ref.Context().Digest(h.String())
+	switch se := se.(type) {
+	case oci.SignedImage:
+		return &referrerImage{SignedImage: se, digest: digest, opts: opts}, nil
+	case oci.SignedImageIndex:
+		return &referrerIndex{SignedImageIndex: se, digest: digest, opts: opts}, nil
+	default:
+		return nil, fmt.Errorf("unsupported entity type %T", se)
+	}
+}
+
+// ResolveDigest implements Puller
+func (referrers) ResolveDigest(ref name.Reference, opts ...ociremote.Option) (name.Digest, error) {
+	return ociremote.ResolveDigest(ref, opts...)
+}
+
+// WriteSignatures implements Pusher
+func (referrers) WriteSignatures(repo name.Repository, se oci.SignedEntity, opts ...ociremote.Option) error {
+	return ociremote.WriteReferrerSignatures(repo, se, opts...)
+}
+
+// WriteAttestations implements Pusher
+func (referrers) WriteAttestations(repo name.Repository, se oci.SignedEntity, opts ...ociremote.Option) error {
+	return ociremote.WriteAttestations(repo, se, opts...)
+}
+
+type referrerImage struct {
+	oci.SignedImage
+	digest name.Digest
+	opts   []ociremote.Option
+}
+
+// Signatures implements oci.SignedEntity
+func (r *referrerImage) Signatures() (oci.Signatures, error) {
+	return ociremote.ReferrerSignatures(r.digest, r.opts...)
+}
+
+type referrerIndex struct {
+	oci.SignedImageIndex
+	digest name.Digest
+	opts   []ociremote.Option
+}
+
+// Signatures implements oci.SignedEntity
+func (r *referrerIndex) Signatures() (oci.Signatures, error) {
+	return ociremote.ReferrerSignatures(r.digest, r.opts...)
+}
@@ -0,0 +1,68 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/sigstore/cosign/pkg/oci"
+	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
+)
+
+// Puller fetches signed entities from a registry.
+type Puller interface {
+	// SignedEntity fetches the image or image index at ref.
+	SignedEntity(ref name.Reference, opts ...ociremote.Option) (oci.SignedEntity, error)
+	// ResolveDigest resolves ref to the digest of the entity it names.
+	ResolveDigest(ref name.Reference, opts ...ociremote.Option) (name.Digest, error)
+}
+
+// Pusher stores the signatures and attestations of signed entities in a registry.
+type Pusher interface {
+	// WriteSignatures publishes the signatures attached to se to repo.
+	WriteSignatures(repo name.Repository, se oci.SignedEntity, opts ...ociremote.Option) error
+	// WriteAttestations publishes the attestations attached to se to repo.
+	WriteAttestations(repo name.Repository, se oci.SignedEntity, opts ...ociremote.Option) error
+}
+
+// Registry is the Puller and Pusher that talks to real registries through
+// go-containerregistry.
+var Registry = registry{}
+
+type registry struct{}
+
+var _ Puller = registry{}
+var _ Pusher = registry{}
+
+// SignedEntity implements Puller
+func (registry) SignedEntity(ref name.Reference, opts ...ociremote.Option) (oci.SignedEntity, error) {
+	return ociremote.SignedEntity(ref, opts...)
+}
+
+// ResolveDigest implements Puller
+func (registry) ResolveDigest(ref name.Reference, opts ...ociremote.Option) (name.Digest, error) {
+	return ociremote.ResolveDigest(ref, opts...)
+}
+
+// WriteSignatures implements Pusher
+func (registry) WriteSignatures(repo name.Repository, se oci.SignedEntity, opts ...ociremote.Option) error {
+	return ociremote.WriteSignatures(repo, se, opts...)
+}
+
+// WriteAttestations implements Pusher
+func (registry) WriteAttestations(repo name.Repository, se oci.SignedEntity, opts ...ociremote.Option) error {
+	return ociremote.WriteAttestations(repo, se, opts...)
+}
@@ -17,15 +17,20 @@ package remote
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/cosign/pkg/cosign/attestation"
 	"github.com/sigstore/cosign/pkg/oci"
 	"github.com/sigstore/cosign/pkg/oci/mutate"
 	"github.com/sigstore/cosign/pkg/oci/static"
 	"github.com/sigstore/sigstore/pkg/signature"
+	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
 )
 
 // NewDupeDetector creates a new DupeDetector that looks for matching signatures that
@@ -34,8 +39,13 @@ func NewDupeDetector(v signature.Verifier) mutate.DupeDetector {
 	return &dd{verifier: v}
 }
 
-func NewReplaceOp(predicateURI string) mutate.ReplaceOp {
-	return &ro{predicateURI: predicateURI}
+// NewReplaceOp creates a new ReplaceOp that replaces the attestations of the
+// given predicate type made by the same signer with the new attestation. An
+// attestation has the same signer if its envelope verifies with v or, for
+// keyless attestations, if it verifies with a certificate for the same identity
+// and issuer as the new attestation's.
+func NewReplaceOp(predicateURI string, v signature.Verifier) mutate.ReplaceOp {
+	return &ro{predicateURI: predicateURI, verifier: v}
 }
 
 type dd struct {
@@ -44,6 +54,7 @@ type dd struct {
 
 type ro struct {
 	predicateURI string
+	verifier     signature.Verifier
 }
 
 var _ mutate.DupeDetector = (*dd)(nil)
@@ -110,47 +121,99 @@ LayerLoop:
 	return nil, nil
 }
 
+// Replace returns signatures with every attestation from the same signer whose in-toto
+// statement has the predicate type of the ReplaceOp removed, followed by o. Attestations
+// that can't be parsed are kept.
 func (r *ro) Replace(signatures oci.Signatures, o oci.Signature) (oci.Signatures, error) {
 	sigs, err := signatures.Get()
 	if err != nil {
 		return nil, err
 	}
+	newCert, err := o.Cert()
+	if err != nil {
+		return nil, err
+	}
 
 	ros := &replaceOCISignatures{Signatures: signatures}
+	for _, s := range sigs {
+		if r.replaces(s, newCert) {
+			fmt.Fprintf(os.Stderr, "Replacing existing attestation of predicate type %s\n", r.predicateURI)
+			continue
+		}
+		ros.attestations = append(ros.attestations, s)
+	}
+	ros.attestations = append(ros.attestations, o)
 
-	sigsCopy := make([]oci.Signature, 0, len(sigs))
-	sigsCopy = append(sigsCopy, o)
+	return ros, nil
+}
 
-	if len(sigs) == 0 {
-		ros.attestations = append(ros.attestations, sigsCopy...)
-		return ros, nil
+// replaces reports whether s is an attestation of the ReplaceOp's predicate type by
+// the signer of the new attestation, whose certificate is newCert if it has one.
+func (r *ro) replaces(s oci.Signature, newCert *x509.Certificate) bool {
+	p, err := s.Payload()
+	if err != nil {
+		return false
+	}
+	var env dsse.Envelope
+	if err := json.Unmarshal(p, &env); err != nil {
+		return false
+	}
+	st, err := attestation.ParseStatement(&env)
+	if err != nil || st.PredicateType != r.predicateURI {
+		return false
 	}
 
-	for _, s := range sigs {
-		var payloadData map[string]interface{}
+	if r.verifier != nil && verifyEnvelope(&env, r.verifier) {
+		return true
+	}
+	// Keyless attestations are each signed with a new key, so compare identities.
+	cert, err := s.Cert()
+	if err != nil || cert == nil || newCert == nil || !sameIdentity(cert, newCert) {
+		return false
+	}
+	v, err := signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
+	return err == nil && verifyEnvelope(&env, v)
+}
 
-		p, err := s.Payload()
-		if err != nil {
-			return nil, fmt.Errorf("could not get payload: %w", err)
-		}
+func verifyEnvelope(env *dsse.Envelope, v signature.Verifier) bool {
+	ev, err := dsse.NewEnvelopeVerifier(&sigdsse.VerifierAdapter{SignatureVerifier: v})
+	if err != nil {
+		return false
+	}
+	_, err = ev.Verify(env)
+	return err == nil
+}
 
-		err = json.Unmarshal(p, &payloadData)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal payload data: %w", err)
+// sameIdentity reports whether a and b were issued to the same identity by the same
+// OIDC issuer.
+func sameIdentity(a, b *x509.Certificate) bool {
+	if len(a.EmailAddresses) != len(b.EmailAddresses) || len(a.URIs) != len(b.URIs) {
+		return false
+	}
+	for i := range a.EmailAddresses {
+		if a.EmailAddresses[i] != b.EmailAddresses[i] {
+			return false
 		}
-
-		if r.predicateURI == payloadData["payloadType"] {
-			fmt.Fprintln(os.Stderr, "Attestation already present, not adding new one.")
-			continue
-		} else {
-			fmt.Fprintln(os.Stderr, "Attestation not found, adding new attestation.")
-			sigsCopy = append(sigsCopy, s)
+	}
+	for i := range a.URIs {
+		if a.URIs[i].String() != b.URIs[i].String() {
+			return false
 		}
 	}
+	if len(a.EmailAddresses)+len(a.URIs) == 0 {
+		return false
+	}
+	return bytes.Equal(oidcIssuer(a), oidcIssuer(b))
+}
 
-	ros.attestations = append(ros.attestations, sigsCopy...)
-
-	return ros, nil
+// oidcIssuer returns the value of the Fulcio OIDC issuer extension of cert, if any.
+func oidcIssuer(cert *x509.Certificate) []byte {
+	for _, ext := range cert.Extensions {
+		if ext.Id.String() == "1.3.6.1.4.1.57264.1.1" {
+			return ext.Value
+		}
+	}
+	return nil
 }
 
 type replaceOCISignatures struct {
@@ -0,0 +1,191 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/empty"
+	"github.com/sigstore/cosign/pkg/oci/mutate"
+	"github.com/sigstore/cosign/pkg/oci/static"
+	"github.com/sigstore/cosign/pkg/types"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
+)
+
+// mustAttestation returns an attestation of predicateType signed by sv, carrying
+// the certificate if there is one.
+func mustAttestation(t *testing.T, predicateType string, sv signature.SignerVerifier, cert *x509.Certificate) oci.Signature {
+	t.Helper()
+	st, err := json.Marshal(in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: predicateType,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := sigdsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(bytes.NewReader(st))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []static.Option{static.WithLayerMediaType(types.DssePayloadType)}
+	if cert != nil {
+		pem, err := cryptoutils.MarshalCertificateToPEM(cert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, static.WithCertChain(pem, nil))
+	}
+	att, err := static.NewAttestation(env, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return att
+}
+
+func newSignerVerifier(t *testing.T) (signature.SignerVerifier, *ecdsa.PrivateKey) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sv, priv
+}
+
+// keylessSigner returns a new key and a certificate for it issued to email by issuer.
+func keylessSigner(t *testing.T, email, issuer string) (signature.SignerVerifier, *x509.Certificate) {
+	t.Helper()
+	sv, priv := newSignerVerifier(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		EmailAddresses:  []string{email},
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(time.Minute),
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte(issuer)}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sv, cert
+}
+
+func TestReplaceOp(t *testing.T) {
+	sv, _ := newSignerVerifier(t)
+	other, _ := newSignerVerifier(t)
+	slsa := mustAttestation(t, "https://slsa.dev/provenance/v0.2", sv, nil)
+	spdx := mustAttestation(t, "https://spdx.dev/Document", sv, nil)
+	otherSLSA := mustAttestation(t, "https://slsa.dev/provenance/v0.2", other, nil)
+	garbage, err := static.NewAttestation([]byte("not an envelope"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := mutate.AppendSignatures(empty.Signatures(), slsa, spdx, otherSLSA, garbage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the attestation of the predicate type by the same signer is replaced;
+	// other signers' attestations and unparseable ones are kept.
+	newSLSA := mustAttestation(t, "https://slsa.dev/provenance/v0.2", sv, nil)
+	replaced, err := NewReplaceOp("https://slsa.dev/provenance/v0.2", sv).Replace(base, newSLSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := replaced.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 || got[0] != spdx || got[1] != otherSLSA || got[2] != garbage || got[3] != newSLSA {
+		t.Errorf("Replace() = %v, want [spdx, other slsa, garbage, new slsa]", got)
+	}
+
+	// Replacing a predicate type that isn't present only adds the new attestation.
+	vuln := mustAttestation(t, "cosign.sigstore.dev/attestation/vuln/v1", sv, nil)
+	replaced, err = NewReplaceOp("cosign.sigstore.dev/attestation/vuln/v1", sv).Replace(base, vuln)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = replaced.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[4] != vuln {
+		t.Errorf("Replace() = %v, want [slsa, spdx, other slsa, garbage, vuln]", got)
+	}
+}
+
+func TestReplaceOpKeyless(t *testing.T) {
+	const issuer = "https://accounts.example.com"
+	oldSV, oldCert := keylessSigner(t, "foo@example.com", issuer)
+	strangerSV, strangerCert := keylessSigner(t, "bar@example.com", issuer)
+	old := mustAttestation(t, "https://slsa.dev/provenance/v0.2", oldSV, oldCert)
+	stranger := mustAttestation(t, "https://slsa.dev/provenance/v0.2", strangerSV, strangerCert)
+	base, err := mutate.AppendSignatures(empty.Signatures(), old, stranger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new key, but the same identity: the old attestation is replaced.
+	newSV, newCert := keylessSigner(t, "foo@example.com", issuer)
+	att := mustAttestation(t, "https://slsa.dev/provenance/v0.2", newSV, newCert)
+	replaced, err := NewReplaceOp("https://slsa.dev/provenance/v0.2", newSV).Replace(base, att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := replaced.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != stranger || got[1] != att {
+		t.Errorf("Replace() = %v, want [stranger, new]", got)
+	}
+
+	// The same email from another issuer is a different identity.
+	otherSV, otherCert := keylessSigner(t, "foo@example.com", "https://other.example.com")
+	att = mustAttestation(t, "https://slsa.dev/provenance/v0.2", otherSV, otherCert)
+	replaced, err = NewReplaceOp("https://slsa.dev/provenance/v0.2", otherSV).Replace(base, att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err = replaced.Get(); err != nil || len(got) != 3 {
+		t.Errorf("Replace() = %v, %v, want all three attestations", got, err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// oidRSASSAPSS identifies an RSA key to be used only for RSASSA-PSS signatures (RFC 4055).
+// Keys generated with RSAPSSKeyType carry it in place of rsaEncryption, which is how
+// the signature scheme is told apart when a key is loaded.
+var oidRSASSAPSS = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+
+const publicKeyPemType = "PUBLIC KEY"
+
+// ErrRSAPSSTlog is returned when a signature made with an RSA-PSS key would be
+// uploaded to the transparency log. Rekor verifies RSA signatures as PKCS #1 v1.5
+// and would reject the entry.
+var ErrRSAPSSTlog = errors.New("signatures from rsa-pss keys cannot be uploaded to the transparency log")
+
+// rsaPSSOptions are the PSS parameters cosign signs and verifies with.
+var rsaPSSOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
+// RSAPSSVerifier verifies RSASSA-PSS signatures.
+type RSAPSSVerifier struct {
+	publicKey *rsa.PublicKey
+	hashFunc  crypto.Hash
+}
+
+var _ signature.Verifier = (*RSAPSSVerifier)(nil)
+
+// LoadRSAPSSVerifier returns a verifier of RSASSA-PSS signatures over messages hashed
+// with hashFunc.
+func LoadRSAPSSVerifier(pub *rsa.PublicKey, hashFunc crypto.Hash) (*RSAPSSVerifier, error) {
+	if pub == nil {
+		return nil, errors.New("invalid RSA public key specified")
+	}
+	if !hashFunc.Available() {
+		return nil, fmt.Errorf("hash function %v is not available", hashFunc)
+	}
+	return &RSAPSSVerifier{publicKey: pub, hashFunc: hashFunc}, nil
+}
+
+// PublicKey implements signature.PublicKeyProvider
+func (v *RSAPSSVerifier) PublicKey(_ ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return v.publicKey, nil
+}
+
+// VerifySignature implements signature.Verifier
+func (v *RSAPSSVerifier) VerifySignature(sig, message io.Reader, _ ...signature.VerifyOption) error {
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return errors.Wrap(err, "reading signature")
+	}
+	digest, err := v.digest(message)
+	if err != nil {
+		return err
+	}
+	return rsa.VerifyPSS(v.publicKey, v.hashFunc, digest, sigBytes, rsaPSSOptions)
+}
+
+func (v *RSAPSSVerifier) digest(message io.Reader) ([]byte, error) {
+	h := v.hashFunc.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, errors.Wrap(err, "hashing message")
+	}
+	return h.Sum(nil), nil
+}
+
+// RSAPSSSignerVerifier signs with RSASSA-PSS, using a salt as long as the hash.
+type RSAPSSSignerVerifier struct {
+	*RSAPSSVerifier
+	privateKey *rsa.PrivateKey
+}
+
+var _ signature.SignerVerifier = (*RSAPSSSignerVerifier)(nil)
+
+// LoadRSAPSSSignerVerifier returns a signer of RSASSA-PSS signatures over messages
+// hashed with SHA-256.
+func LoadRSAPSSSignerVerifier(priv *rsa.PrivateKey) (*RSAPSSSignerVerifier, error) {
+	if priv == nil {
+		return nil, errors.New("invalid RSA private key specified")
+	}
+	v, err := LoadRSAPSSVerifier(&priv.PublicKey, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &RSAPSSSignerVerifier{RSAPSSVerifier: v, privateKey: priv}, nil
+}
+
+// SignMessage implements signature.Signer
+func (s *RSAPSSSignerVerifier) SignMessage(message io.Reader, _ ...signature.SignOption) ([]byte, error) {
+	digest, err := s.digest(message)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.SignPSS(rand.Reader, s.privateKey, s.hashFunc, digest, rsaPSSOptions)
+}
+
+type pkixPublicKey struct {
+	Algo      pkix.AlgorithmIdentifier
+	BitString asn1.BitString
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// marshalRSAPSSPrivateKey returns the PKCS #8 encoding of priv, marked for RSASSA-PSS.
+func marshalRSAPSSPrivateKey(priv *rsa.PrivateKey) ([]byte, error) {
+	return asn1.Marshal(pkcs8PrivateKey{
+		Algo:       pkix.AlgorithmIdentifier{Algorithm: oidRSASSAPSS},
+		PrivateKey: x509.MarshalPKCS1PrivateKey(priv),
+	})
+}
+
+// parseRSAPSSPrivateKey returns the key in a PKCS #8 encoding, and whether the
+// encoding marked it for RSASSA-PSS. Other keys are left to x509.ParsePKCS8PrivateKey.
+func parseRSAPSSPrivateKey(der []byte) (*rsa.PrivateKey, bool, error) {
+	var k pkcs8PrivateKey
+	if _, err := asn1.Unmarshal(der, &k); err != nil || !k.Algo.Algorithm.Equal(oidRSASSAPSS) {
+		return nil, false, nil
+	}
+	priv, err := x509.ParsePKCS1PrivateKey(k.PrivateKey)
+	if err != nil {
+		return nil, true, errors.Wrap(err, "parsing RSA-PSS private key")
+	}
+	return priv, true, nil
+}
+
+// MarshalRSAPSSPublicKeyToPEM returns a PEM-encoded SubjectPublicKeyInfo for pub,
+// marked for RSASSA-PSS.
+func MarshalRSAPSSPublicKeyToPEM(pub *rsa.PublicKey) ([]byte, error) {
+	pkcs1 := x509.MarshalPKCS1PublicKey(pub)
+	der, err := asn1.Marshal(pkixPublicKey{
+		Algo:      pkix.AlgorithmIdentifier{Algorithm: oidRSASSAPSS},
+		BitString: asn1.BitString{Bytes: pkcs1, BitLength: 8 * len(pkcs1)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: publicKeyPemType, Bytes: der}), nil
+}
+
+// LoadPublicKeyVerifier returns a verifier for the PEM-encoded public key, using
+// hashAlgorithm. Keys marked for RSASSA-PSS get an RSAPSSVerifier; all others get
+// the verifier signature.LoadVerifier picks for them.
+func LoadPublicKeyVerifier(pemBytes []byte, hashAlgorithm crypto.Hash) (signature.Verifier, error) {
+	if p, _ := pem.Decode(bytes.TrimSpace(pemBytes)); p != nil && p.Type == publicKeyPemType {
+		var k pkixPublicKey
+		if _, err := asn1.Unmarshal(p.Bytes, &k); err == nil && k.Algo.Algorithm.Equal(oidRSASSAPSS) {
+			pub, err := x509.ParsePKCS1PublicKey(k.BitString.RightAlign())
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing RSA-PSS public key")
+			}
+			return LoadRSAPSSVerifier(pub, hashAlgorithm)
+		}
+	}
+	pubKey, err := cryptoutils.UnmarshalPEMToPublicKey(pemBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "pem to public key")
+	}
+	return signature.LoadVerifier(pubKey, hashAlgorithm)
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer provides a common interface over cosign's key-based signing
+// back-ends: cosign key files, KMS keys, PKCS11 tokens and YubiKeys.
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	signatureoptions "github.com/sigstore/sigstore/pkg/signature/options"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/keys/yubikey"
+	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+)
+
+// Signer signs payloads.
+type Signer interface {
+	// Sign returns the signature over payload and the PEM-encoded certificate
+	// for the signing key, if there is one.
+	Sign(ctx context.Context, payload []byte) (sig, cert []byte, err error)
+	// Close releases any token or connection held by the signer.
+	Close()
+}
+
+// KeySigner is a Signer backed by a private key.
+type KeySigner struct {
+	signature.SignerVerifier
+	// Cert is the PEM-encoded certificate for the key, or nil.
+	Cert  []byte
+	close func()
+}
+
+var _ Signer = (*KeySigner)(nil)
+
+// Sign implements Signer
+func (k *KeySigner) Sign(ctx context.Context, payload []byte) ([]byte, []byte, error) {
+	sig, err := k.SignMessage(bytes.NewReader(payload), signatureoptions.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, k.Cert, nil
+}
+
+// Close implements Signer
+func (k *KeySigner) Close() {
+	if k.close != nil {
+		k.close()
+	}
+}
+
+// FromSecurityKey returns a signer for the key in the given slot of the attached
+// YubiKey. The certificate in the same slot is used, if there is one.
+func FromSecurityKey(slot string) (*KeySigner, error) {
+	sk, err := yubikey.New(yubikey.WithSlot(slot))
+	if err != nil {
+		return nil, err
+	}
+
+	// With PIV, we assume the certificate is in the same slot on the PIV
+	// token as the private key. If it's not there, show a warning to the
+	// user.
+	certFromPIV, err := sk.Certificate()
+	var pemBytes []byte
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "warning: no x509 certificate retrieved from the PIV token")
+	} else {
+		pemBytes, err = cryptoutils.MarshalCertificateToPEM(certFromPIV)
+		if err != nil {
+			sk.Close()
+			return nil, err
+		}
+	}
+
+	return &KeySigner{
+		Cert:           pemBytes,
+		SignerVerifier: sk,
+		close:          sk.Close,
+	}, nil
+}
+
+// FromKeyRef returns a signer for a cosign key file, a KMS key, a PKCS11 token
+// or any other key reference cosign understands. For a PKCS11 token the
+// certificate stored with the key is used; otherwise certPath, if set, names
+// the certificate, which must be for the key.
+func FromKeyRef(ctx context.Context, keyRef, certPath string, pf cosign.PassFunc) (*KeySigner, error) {
+	k, err := sigs.SignerVerifierFromKeyRef(ctx, keyRef, pf)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading key")
+	}
+
+	// With PKCS11, we assume the certificate is in the same slot on the PKCS11
+	// token as the private key. If it's not there, show a warning to the
+	// user.
+	if pkcs11Key, ok := k.(*pkcs11key.Key); ok {
+		certFromPKCS11, _ := pkcs11Key.Certificate()
+		var pemBytes []byte
+		if certFromPKCS11 == nil {
+			fmt.Fprintln(os.Stderr, "warning: no x509 certificate retrieved from the PKCS11 token")
+		} else {
+			pemBytes, err = cryptoutils.MarshalCertificateToPEM(certFromPKCS11)
+			if err != nil {
+				pkcs11Key.Close()
+				return nil, err
+			}
+		}
+
+		return &KeySigner{
+			Cert:           pemBytes,
+			SignerVerifier: k,
+			close:          pkcs11Key.Close,
+		}, nil
+	}
+	certSigner := &KeySigner{
+		SignerVerifier: k,
+	}
+	if certPath == "" {
+		return certSigner, nil
+	}
+
+	certBytes, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read certificate")
+	}
+	// Handle PEM.
+	if bytes.HasPrefix(certBytes, []byte("-----")) {
+		decoded, _ := pem.Decode(certBytes)
+		if decoded.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("supplied PEM file is not a certificate: %s", certPath)
+		}
+		certBytes = decoded.Bytes
+	}
+	parsedCert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse x509 certificate")
+	}
+	pk, err := k.PublicKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "get public key")
+	}
+	switch kt := parsedCert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !kt.Equal(pk) {
+			return nil, errors.New("public key in certificate does not match that in the signing key")
+		}
+	case *rsa.PublicKey:
+		if !kt.Equal(pk) {
+			return nil, errors.New("public key in certificate does not match that in the signing key")
+		}
+	case ed25519.PublicKey:
+		if !kt.Equal(pk) {
+			return nil, errors.New("public key in certificate does not match that in the signing key")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T", parsedCert.PublicKey)
+	}
+	pemBytes, err := cryptoutils.MarshalCertificateToPEM(parsedCert)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling certificate to PEM")
+	}
+	certSigner.Cert = pemBytes
+	return certSigner, nil
+}
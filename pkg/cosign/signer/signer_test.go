@@ -0,0 +1,62 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+)
+
+func TestFromKeyRef(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+
+	passFunc := func(bool) ([]byte, error) { return []byte("hunter2"), nil }
+	keys, err := cosign.GenerateKeyPair(passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKeyPath := filepath.Join(td, "cosign.key")
+	if err := os.WriteFile(privKeyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := FromKeyRef(ctx, privKeyPath, "", passFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	payload := []byte("hello world")
+	sig, cert, err := s.Sign(ctx, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert != nil {
+		t.Errorf("Sign() returned a certificate for a key without one")
+	}
+	if err := s.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload)); err != nil {
+		t.Errorf("VerifySignature() = %v", err)
+	}
+
+	if _, err := FromKeyRef(ctx, privKeyPath, privKeyPath, passFunc); err == nil {
+		t.Error("expected an error for a --cert that is not a certificate")
+	}
+}
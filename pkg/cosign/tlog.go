@@ -24,10 +24,8 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
-	"github.com/google/trillian/merkle/logverifier"
-	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/pkg/errors"
-	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/cosign/transparency"
 	"github.com/sigstore/cosign/pkg/cosign/tuf"
 	"github.com/sigstore/rekor/pkg/generated/client/index"
 
@@ -187,7 +185,7 @@ func FindTlogEntry(ctx context.Context, rekorClient *client.Rekor, b64Sig string
 		return "", 0, errors.Wrap(err, "searching log query")
 	}
 	if len(resp.Payload) == 0 {
-		return "", 0, errors.New("signature not found in transparency log")
+		return "", 0, ErrRekorEntryNotFound
 	} else if len(resp.Payload) > 1 {
 		return "", 0, errors.New("multiple entries returned; this should not happen")
 	}
@@ -234,25 +232,7 @@ func verifyTLogEntry(ctx context.Context, rekorClient *client.Rekor, uuid string
 		return nil, errors.New("UUID value can not be extracted")
 	}
 	e := lep.Payload[params.EntryUUID]
-	if e.Verification == nil || e.Verification.InclusionProof == nil {
-		return nil, errors.New("inclusion proof not provided")
-	}
-
-	hashes := [][]byte{}
-	for _, h := range e.Verification.InclusionProof.Hashes {
-		hb, _ := hex.DecodeString(h)
-		hashes = append(hashes, hb)
-	}
-
-	rootHash, _ := hex.DecodeString(*e.Verification.InclusionProof.RootHash)
-	leafHash, _ := hex.DecodeString(params.EntryUUID)
-
-	v := logverifier.New(rfc6962.DefaultHasher)
-	if err := v.VerifyInclusionProof(*e.Verification.InclusionProof.LogIndex, *e.Verification.InclusionProof.TreeSize, hashes, rootHash, leafHash); err != nil {
-		return nil, errors.Wrap(err, "verifying inclusion proof")
-	}
-
-	// Verify rekor's signature over the SET.
+	// Verify the inclusion proof and rekor's signature over the SET.
 	resp, err := rekorClient.Pubkey.GetPublicKey(pubkey.NewGetPublicKeyParamsWithContext(ctx))
 	if err != nil {
 		return nil, errors.Wrap(err, "rekor public key")
@@ -261,15 +241,8 @@ func verifyTLogEntry(ctx context.Context, rekorClient *client.Rekor, uuid string
 	if err != nil {
 		return nil, errors.Wrap(err, "rekor public key pem to ecdsa")
 	}
-
-	payload := bundle.RekorPayload{
-		Body:           e.Body,
-		IntegratedTime: *e.IntegratedTime,
-		LogIndex:       *e.LogIndex,
-		LogID:          *e.LogID,
-	}
-	if err := VerifySET(payload, []byte(e.Verification.SignedEntryTimestamp), rekorPubKey); err != nil {
-		return nil, errors.Wrap(err, "verifying signedEntryTimestamp")
+	if err := transparency.VerifyInclusionProof(&e, rekorPubKey); err != nil {
+		return nil, err
 	}
 
 	return &e, nil
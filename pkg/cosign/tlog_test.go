@@ -0,0 +1,113 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/trillian/merkle/rfc6962"
+	rekor "github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+)
+
+// signedEntry returns the only entry of a log holding body, integrated at
+// integratedTime, with a SET from logKey.
+func signedEntry(t *testing.T, logKey *ecdsa.PrivateKey, body []byte, integratedTime int64) models.LogEntryAnon {
+	t.Helper()
+	b64Body := base64.StdEncoding.EncodeToString(body)
+	logIndex, treeSize := int64(0), int64(1)
+	logID := hex.EncodeToString(make([]byte, sha256.Size))
+	rootHash := hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(body))
+
+	contents, err := json.Marshal(bundle.RekorPayload{
+		Body:           b64Body,
+		IntegratedTime: integratedTime,
+		LogIndex:       logIndex,
+		LogID:          logID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalized, err := jsoncanonicalizer.Transform(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, logKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.LogEntryAnon{
+		Body:           b64Body,
+		IntegratedTime: &integratedTime,
+		LogIndex:       &logIndex,
+		LogID:          &logID,
+		Verification: &models.LogEntryAnonVerification{
+			SignedEntryTimestamp: strfmt.Base64(set),
+			InclusionProof: &models.InclusionProof{
+				LogIndex: &logIndex,
+				RootHash: &rootHash,
+				TreeSize: &treeSize,
+			},
+		},
+	}
+}
+
+// newFakeRekor serves entry as the answer to every search and lookup, along
+// with the public half of logKey, and returns a client for it.
+func newFakeRekor(t *testing.T, logKey *ecdsa.PrivateKey, entry models.LogEntryAnon) *client.Rekor {
+	t.Helper()
+	const uuid = "0000000000000000000000000000000000000000000000000000000000000001"
+	pemKey, err := cryptoutils.MarshalPublicKeyToPEM(&logKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/log/publicKey":
+			w.Header().Set("Content-Type", "application/x-pem-file")
+			w.Write(pemKey) //nolint: errcheck
+		case r.URL.Path == "/api/v1/log/entries/retrieve":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]map[string]models.LogEntryAnon{{uuid: entry}}) //nolint: errcheck
+		case strings.HasPrefix(r.URL.Path, "/api/v1/log/entries/"):
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]models.LogEntryAnon{uuid: entry}) //nolint: errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	c, err := rekor.GetRekorClient(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transparency
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+)
+
+// Checkpoint is a commitment by a transparency log to the root hash of its tree
+// at a given size, in the format of the Sigstore checkpoint spec.
+type Checkpoint struct {
+	// Origin identifies the log, e.g. "rekor.sigstore.dev - 2605736670972794746".
+	Origin string
+	// Size is the number of entries in the tree.
+	Size int64
+	// Hash is the root hash of the tree.
+	Hash []byte
+	// OtherContent holds any lines after the root hash.
+	OtherContent []string
+}
+
+// checkpointSignaturePrefix starts each signature line of a signed note.
+const checkpointSignaturePrefix = "— "
+
+// VerifyCheckpoint parses a signed checkpoint and verifies that pub signed it.
+// A signed checkpoint is a note, the checkpoint followed by a blank line and
+// one line per signature:
+//
+//	— <signer name> <base64(4-byte key hint || signature)>
+//
+// where the key hint is the start of the SHA-256 digest of the DER encoding of
+// the signer's public key.
+func VerifyCheckpoint(signed string, pub *ecdsa.PublicKey) (*Checkpoint, error) {
+	sep := strings.LastIndex(signed, "\n\n")
+	if sep < 0 {
+		return nil, errors.New("checkpoint has no signatures")
+	}
+	text, sigLines := signed[:sep+1], strings.Split(strings.TrimSuffix(signed[sep+2:], "\n"), "\n")
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling public key")
+	}
+	keyID := sha256.Sum256(der)
+	digest := sha256.Sum256([]byte(text))
+
+	verified := false
+	for _, line := range sigLines {
+		if !strings.HasPrefix(line, checkpointSignaturePrefix) {
+			return nil, errors.Errorf("malformed checkpoint signature line %q", line)
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, checkpointSignaturePrefix))
+		if len(fields) != 2 {
+			return nil, errors.Errorf("malformed checkpoint signature line %q", line)
+		}
+		sig, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(sig) < 5 {
+			return nil, errors.Errorf("malformed checkpoint signature line %q", line)
+		}
+		if !bytes.Equal(sig[:4], keyID[:4]) {
+			continue
+		}
+		if ecdsa.VerifyASN1(pub, digest[:], sig[4:]) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("checkpoint is not signed by the transparency log key")
+	}
+	return parseCheckpoint(text)
+}
+
+func parseCheckpoint(text string) (*Checkpoint, error) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) < 3 {
+		return nil, errors.New("checkpoint must have an origin, tree size and root hash")
+	}
+	c := &Checkpoint{Origin: lines[0], OtherContent: lines[3:]}
+	if c.Origin == "" {
+		return nil, errors.New("checkpoint has an empty origin")
+	}
+	size, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil || size < 0 {
+		return nil, errors.Errorf("invalid checkpoint tree size %q", lines[1])
+	}
+	c.Size = size
+	if c.Hash, err = base64.StdEncoding.DecodeString(lines[2]); err != nil {
+		return nil, errors.Wrap(err, "decoding checkpoint root hash")
+	}
+	return c, nil
+}
+
+// VerifyInclusion verifies that proof shows the entry with the given canonicalized
+// body in the tree c commits to.
+func (c *Checkpoint) VerifyInclusion(body []byte, proof *bundle.InclusionProof) error {
+	if proof.TreeSize != c.Size {
+		return errors.Errorf("inclusion proof is for a tree of size %d, checkpoint is for size %d", proof.TreeSize, c.Size)
+	}
+	if !bytes.Equal(proof.RootHash, c.Hash) {
+		return errors.New("inclusion proof root hash does not match the checkpoint")
+	}
+	return VerifyInclusion(body, proof)
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transparency verifies that entries are included in a Rekor
+// transparency log: the signed entry timestamps Rekor promises inclusion
+// with, the Merkle inclusion proofs it returns, and its signed checkpoints.
+package transparency
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/google/trillian/merkle/logverifier"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/pkg/errors"
+	"github.com/sigstore/rekor/pkg/generated/models"
+
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+)
+
+// VerifyInclusionProof verifies the transparency log entry returned by Rekor: that
+// rekorPubKey signed its signed entry timestamp, and that its inclusion proof
+// shows the entry's body in the log. Rekor's entries carry no signed checkpoint,
+// so the proof's root hash is taken as returned; proofs that come with one, as in
+// bundles, are verified with VerifyCheckpoint and Checkpoint.VerifyInclusion.
+func VerifyInclusionProof(entry *models.LogEntryAnon, rekorPubKey *ecdsa.PublicKey) error {
+	if entry.IntegratedTime == nil || entry.LogIndex == nil || entry.LogID == nil {
+		return errors.New("transparency log entry is missing its integrated time, log index or log ID")
+	}
+	if entry.Verification == nil || entry.Verification.InclusionProof == nil {
+		return errors.New("inclusion proof not provided")
+	}
+
+	payload := bundle.RekorPayload{
+		Body:           entry.Body,
+		IntegratedTime: *entry.IntegratedTime,
+		LogIndex:       *entry.LogIndex,
+		LogID:          *entry.LogID,
+	}
+	if err := VerifySET(payload, []byte(entry.Verification.SignedEntryTimestamp), rekorPubKey); err != nil {
+		return errors.Wrap(err, "verifying signedEntryTimestamp")
+	}
+
+	p := entry.Verification.InclusionProof
+	if p.LogIndex == nil || p.TreeSize == nil || p.RootHash == nil {
+		return errors.New("inclusion proof is missing its log index, tree size or root hash")
+	}
+	proof := &bundle.InclusionProof{
+		LogIndex: *p.LogIndex,
+		TreeSize: *p.TreeSize,
+	}
+	var err error
+	if proof.RootHash, err = hex.DecodeString(*p.RootHash); err != nil {
+		return errors.Wrap(err, "decoding inclusion proof root hash")
+	}
+	for _, h := range p.Hashes {
+		hb, err := hex.DecodeString(h)
+		if err != nil {
+			return errors.Wrap(err, "decoding inclusion proof hash")
+		}
+		proof.Hashes = append(proof.Hashes, hb)
+	}
+	body, err := EntryBody(entry.Body)
+	if err != nil {
+		return err
+	}
+	return VerifyInclusion(body, proof)
+}
+
+// VerifyInclusion verifies that proof shows the entry with the given canonicalized
+// body at the proof's index in the log.
+func VerifyInclusion(body []byte, proof *bundle.InclusionProof) error {
+	leafHash := rfc6962.DefaultHasher.HashLeaf(body)
+	v := logverifier.New(rfc6962.DefaultHasher)
+	if err := v.VerifyInclusionProof(proof.LogIndex, proof.TreeSize, proof.Hashes, proof.RootHash, leafHash); err != nil {
+		return errors.Wrap(err, "verifying inclusion proof")
+	}
+	return nil
+}
+
+// VerifySET verifies that pub signed the signed entry timestamp of the entry
+// described by payload.
+func VerifySET(payload bundle.RekorPayload, signature []byte, pub *ecdsa.PublicKey) error {
+	contents, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshaling")
+	}
+	canonicalized, err := jsoncanonicalizer.Transform(contents)
+	if err != nil {
+		return errors.Wrap(err, "canonicalizing")
+	}
+
+	// verify the SET against the public key
+	hash := sha256.Sum256(canonicalized)
+	if !ecdsa.VerifyASN1(pub, hash[:], signature) {
+		return errors.New("unable to verify")
+	}
+	return nil
+}
+
+// EntryBody decodes the base64 body of a transparency log entry.
+func EntryBody(body interface{}) ([]byte, error) {
+	b64Body, ok := body.(string)
+	if !ok {
+		return nil, errors.Errorf("unexpected transparency log entry body type %T", body)
+	}
+	b, err := base64.StdEncoding.DecodeString(b64Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding transparency log entry body")
+	}
+	return b, nil
+}
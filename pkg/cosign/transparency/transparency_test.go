@@ -0,0 +1,218 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transparency
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/sigstore/rekor/pkg/generated/models"
+
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+)
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// testEntry returns the first entry of a log holding two entries, signed by key.
+func testEntry(t *testing.T, key *ecdsa.PrivateKey) *models.LogEntryAnon {
+	t.Helper()
+	body := []byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{}}`)
+	other := []byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"other":true}}`)
+	h := rfc6962.DefaultHasher
+	sibling := h.HashLeaf(other)
+	root := h.HashChildren(h.HashLeaf(body), sibling)
+
+	b64Body := base64.StdEncoding.EncodeToString(body)
+	integratedTime, logIndex, treeSize := int64(1600000000), int64(0), int64(2)
+	logID := hex.EncodeToString(make([]byte, sha256.Size))
+	rootHash := hex.EncodeToString(root)
+
+	contents, err := json.Marshal(bundle.RekorPayload{
+		Body:           b64Body,
+		IntegratedTime: integratedTime,
+		LogIndex:       logIndex,
+		LogID:          logID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalized, err := jsoncanonicalizer.Transform(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &models.LogEntryAnon{
+		Body:           b64Body,
+		IntegratedTime: &integratedTime,
+		LogIndex:       &logIndex,
+		LogID:          &logID,
+		Verification: &models.LogEntryAnonVerification{
+			SignedEntryTimestamp: strfmt.Base64(set),
+			InclusionProof: &models.InclusionProof{
+				Hashes:   []string{hex.EncodeToString(sibling)},
+				LogIndex: &logIndex,
+				RootHash: &rootHash,
+				TreeSize: &treeSize,
+			},
+		},
+	}
+}
+
+func TestVerifyInclusionProof(t *testing.T) {
+	key := generateKey(t)
+	if err := VerifyInclusionProof(testEntry(t, key), &key.PublicKey); err != nil {
+		t.Fatalf("VerifyInclusionProof() = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*models.LogEntryAnon)
+	}{{
+		name: "tampered body",
+		mutate: func(e *models.LogEntryAnon) {
+			e.Body = base64.StdEncoding.EncodeToString([]byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"tampered":true}}`))
+		},
+	}, {
+		name: "tampered root hash",
+		mutate: func(e *models.LogEntryAnon) {
+			root := hex.EncodeToString(make([]byte, sha256.Size))
+			e.Verification.InclusionProof.RootHash = &root
+		},
+	}, {
+		name: "tampered proof hashes",
+		mutate: func(e *models.LogEntryAnon) {
+			e.Verification.InclusionProof.Hashes = []string{hex.EncodeToString(make([]byte, sha256.Size))}
+		},
+	}, {
+		name: "wrong index",
+		mutate: func(e *models.LogEntryAnon) {
+			idx := int64(1)
+			e.Verification.InclusionProof.LogIndex = &idx
+		},
+	}, {
+		name: "tampered integrated time",
+		mutate: func(e *models.LogEntryAnon) {
+			*e.IntegratedTime++
+		},
+	}, {
+		name: "no inclusion proof",
+		mutate: func(e *models.LogEntryAnon) {
+			e.Verification.InclusionProof = nil
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEntry(t, key)
+			tt.mutate(e)
+			if err := VerifyInclusionProof(e, &key.PublicKey); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if err := VerifyInclusionProof(testEntry(t, key), &generateKey(t).PublicKey); err == nil {
+		t.Error("expected an error verifying with the wrong key")
+	}
+}
+
+// signCheckpoint returns text signed as a note by key.
+func signCheckpoint(t *testing.T, key *ecdsa.PrivateKey, text string) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := sha256.Sum256(der)
+	digest := sha256.Sum256([]byte(text))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%s\n— rekor.sigstore.dev %s\n", text, base64.StdEncoding.EncodeToString(append(keyID[:4], sig...)))
+}
+
+func TestVerifyCheckpoint(t *testing.T) {
+	key := generateKey(t)
+	e := testEntry(t, key)
+	proof := e.Verification.InclusionProof
+	root, err := hex.DecodeString(*proof.RootHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := fmt.Sprintf("rekor.sigstore.dev - 1193050959916656506\n%d\n%s\nTimestamp: 1600000000\n", *proof.TreeSize, base64.StdEncoding.EncodeToString(root))
+
+	c, err := VerifyCheckpoint(signCheckpoint(t, key, text), &key.PublicKey)
+	if err != nil {
+		t.Fatalf("VerifyCheckpoint() = %v", err)
+	}
+	if c.Origin != "rekor.sigstore.dev - 1193050959916656506" || c.Size != 2 || len(c.OtherContent) != 1 {
+		t.Errorf("VerifyCheckpoint() = %+v", c)
+	}
+
+	body, err := EntryBody(e.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sibling, err := hex.DecodeString(proof.Hashes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip := &bundle.InclusionProof{LogIndex: 0, TreeSize: 2, RootHash: root, Hashes: [][]byte{sibling}}
+	if err := c.VerifyInclusion(body, ip); err != nil {
+		t.Errorf("VerifyInclusion() = %v", err)
+	}
+	if err := c.VerifyInclusion([]byte("tampered"), ip); err == nil {
+		t.Error("expected an error for a tampered body")
+	}
+	ip.TreeSize = 3
+	if err := c.VerifyInclusion(body, ip); err == nil {
+		t.Error("expected an error for a proof against another tree size")
+	}
+
+	if _, err := VerifyCheckpoint(signCheckpoint(t, generateKey(t), text), &key.PublicKey); err == nil {
+		t.Error("expected an error for a checkpoint signed by another key")
+	}
+	tampered := signCheckpoint(t, key, text)
+	tampered = "rekor.example.com" + tampered[len("rekor.sigstore.dev"):]
+	if _, err := VerifyCheckpoint(tampered, &key.PublicKey); err == nil {
+		t.Error("expected an error for a tampered checkpoint")
+	}
+	if _, err := VerifyCheckpoint(text, &key.PublicKey); err == nil {
+		t.Error("expected an error for an unsigned checkpoint")
+	}
+}
@@ -0,0 +1,90 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tsa requests and verifies RFC 3161 timestamp tokens.
+package tsa
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sassoftware/relic/lib/pkcs7"
+	"github.com/sassoftware/relic/lib/pkcs9"
+
+	// Register the other hashes a timestamp token may use.
+	_ "crypto/sha1" // nolint:gosec
+	_ "crypto/sha512"
+)
+
+const replyContentType = "application/timestamp-reply"
+
+// RequestTimestamp asks the timestamp authority at url for a timestamp token
+// over the SHA-256 digest of sig, and returns the DER encoded token.
+func RequestTimestamp(ctx context.Context, url string, sig []byte) ([]byte, error) {
+	digest := sha256.Sum256(sig)
+	msg, req, err := pkcs9.NewRequest(url, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("creating timestamp request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", replyContentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting timestamp: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading timestamp response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("timestamp authority returned %s", resp.Status)
+	}
+
+	// ParseResponse checks the status, the token's signature, the nonce and
+	// the message imprint.
+	token, err := msg.ParseResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	return token.Marshal()
+}
+
+// VerifyTimestamp checks that token is a timestamp over sig, signed by a
+// timestamping certificate that chains up to roots, and returns the time
+// the authority asserted.
+func VerifyTimestamp(token, sig []byte, roots *x509.CertPool) (time.Time, error) {
+	psd, err := pkcs7.Unmarshal(token)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing timestamp token: %w", err)
+	}
+	if !psd.Content.ContentInfo.ContentType.Equal(pkcs9.OidTSTInfo) {
+		return time.Time{}, fmt.Errorf("unexpected timestamp content type %v", psd.Content.ContentInfo.ContentType)
+	}
+	cs, err := pkcs9.Verify(psd, sig, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := cs.VerifyChain(roots, nil); err != nil {
+		return time.Time{}, fmt.Errorf("verifying timestamp authority certificate: %w", err)
+	}
+	return cs.SigningTime, nil
+}
@@ -0,0 +1,193 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tsa
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sassoftware/relic/lib/pkcs7"
+	"github.com/sassoftware/relic/lib/pkcs9"
+)
+
+type testTSA struct {
+	root *x509.Certificate
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	now  time.Time
+}
+
+func newTestTSA(t *testing.T) *testTSA {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test tsa root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test tsa"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testTSA{root: root, cert: cert, key: key, now: now}
+}
+
+func (ts *testTSA) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.root)
+	return pool
+}
+
+// issue signs a TSTInfo for the given imprint, the way a timestamp authority would.
+func (ts *testTSA) issue(mi pkcs9.MessageImprint, nonce *big.Int) (*pkcs7.ContentInfoSignedData, error) {
+	info, err := asn1.Marshal(pkcs9.TSTInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: mi,
+		SerialNumber:   big.NewInt(42),
+		GenTime: asn1.RawValue{
+			Tag:   asn1.TagGeneralizedTime,
+			Bytes: []byte(ts.now.Format("20060102150405") + ".25Z"),
+		},
+		Nonce: nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b := pkcs7.NewBuilder(ts.key, []*x509.Certificate{ts.cert}, crypto.SHA256)
+	if err := b.SetContent(pkcs9.OidTSTInfo, info); err != nil {
+		return nil, err
+	}
+	if err := b.AddAuthenticatedAttribute(pkcs7.OidAttributeSigningTime, ts.now); err != nil {
+		return nil, err
+	}
+	return b.Sign()
+}
+
+func (ts *testTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.Header.Get("Content-Type") != "application/timestamp-query" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var req pkcs9.TimeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil || !req.CertReq {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	token, err := ts.issue(req.MessageImprint, req.Nonce)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	resp, err := asn1.Marshal(pkcs9.TimeStampResp{
+		Status:         pkcs9.PKIStatusInfo{Status: pkcs9.StatusGranted},
+		TimeStampToken: *token,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", replyContentType)
+	w.Write(resp) //nolint: errcheck
+}
+
+func TestRequestAndVerifyTimestamp(t *testing.T) {
+	ts := newTestTSA(t)
+	s := httptest.NewServer(ts)
+	defer s.Close()
+
+	sig := []byte("signature")
+	token, err := RequestTimestamp(context.Background(), s.URL, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := VerifyTimestamp(token, sig, ts.roots())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ts.now.Add(250 * time.Millisecond); !got.Equal(want) {
+		t.Errorf("VerifyTimestamp() = %v, want %v", got, want)
+	}
+
+	if _, err := VerifyTimestamp(token, []byte("other signature"), ts.roots()); err == nil {
+		t.Error("expected error verifying a token against a different signature")
+	}
+	if _, err := VerifyTimestamp(token, sig, x509.NewCertPool()); err == nil {
+		t.Error("expected error verifying a token against an untrusted root")
+	}
+}
+
+func TestRequestTimestampRejected(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, _ := asn1.Marshal(pkcs9.TimeStampResp{
+			Status: pkcs9.PKIStatusInfo{Status: pkcs9.StatusRejection, StatusString: []string{"bad request"}},
+		})
+		w.Write(resp) //nolint: errcheck
+	}))
+	defer s.Close()
+
+	if _, err := RequestTimestamp(context.Background(), s.URL, []byte("signature")); err == nil {
+		t.Error("expected error for a rejected timestamp request")
+	}
+}
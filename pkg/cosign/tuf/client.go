@@ -18,14 +18,22 @@ package tuf
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -34,6 +42,7 @@ import (
 	tuf_leveldbstore "github.com/theupdateframework/go-tuf/client/leveldbstore"
 	"github.com/theupdateframework/go-tuf/data"
 	"github.com/theupdateframework/go-tuf/util"
+	"github.com/theupdateframework/go-tuf/verify"
 )
 
 const (
@@ -42,31 +51,274 @@ const (
 	SigstoreNoCache   = "SIGSTORE_NO_CACHE"
 )
 
+// ErrTargetNotFound is returned by GetTarget when the trusted targets metadata
+// does not list the requested target.
+var ErrTargetNotFound = errors.New("target not found")
+
+// ErrTargetTooLarge is returned when a target is larger than the client's
+// maximum target size.
+var ErrTargetTooLarge = errors.New("target exceeds the maximum target size")
+
+// ErrHashMismatch is returned by VerifyTargetIntegrity when data does not match
+// the hashes of the target in the trusted targets metadata.
+var ErrHashMismatch = errors.New("target hash mismatch")
+
+// DefaultMaxTargetSize is the largest target a client fetches unless
+// TUFOptions.MaxTargetSize says otherwise.
+const DefaultMaxTargetSize int64 = 50 << 20
+
+// targetFetchParallelism is how many targets are fetched at once after an update.
+var targetFetchParallelism = 8
+
+// UsageKind is the sigstore usage of a target, as recorded in its custom metadata.
+type UsageKind int
+
+const (
+	UnknownUsage UsageKind = iota
+	Fulcio
+	Rekor
+	CTFE
+)
+
+func (u *UsageKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "Fulcio":
+		*u = Fulcio
+	case "Rekor":
+		*u = Rekor
+	case "CTFE":
+		*u = CTFE
+	default:
+		return fmt.Errorf("error while unmarshalling, UsageKind=%v not valid", string(text))
+	}
+	return nil
+}
+
+// StatusKind is the sigstore status of a target, as recorded in its custom metadata.
+type StatusKind int
+
+const (
+	UnknownStatus StatusKind = iota
+	Active
+	Expired
+)
+
+func (s *StatusKind) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "Active":
+		*s = Active
+	case "Expired":
+		*s = Expired
+	default:
+		return fmt.Errorf("error while unmarshalling, StatusKind=%v not valid", string(text))
+	}
+	return nil
+}
+
+type customMetadata struct {
+	Usage  UsageKind  `json:"usage"`
+	Status StatusKind `json:"status"`
+}
+
+type sigstoreCustomMetadata struct {
+	Sigstore customMetadata `json:"sigstore"`
+}
+
+// ErrRemoteUnavailable is returned, wrapped, by NewFromEnv and New when the cached
+// metadata has expired and the remote repository could not be reached to refresh it.
+// Callers that would rather carry on with the cached metadata can set
+// TUFOptions.AllowStaleCache.
+var ErrRemoteUnavailable = errors.New("remote TUF repository unavailable")
+
+// TargetFile is the contents of a target along with its sigstore status.
+type TargetFile struct {
+	Target []byte
+	Status StatusKind
+}
+
+// TargetMeta describes a target in the trusted targets metadata.
+type TargetMeta struct {
+	Name   string
+	Size   int64
+	Hashes data.Hashes
+	// CustomMetadata is the target's sigstore metadata, or nil if it has none
+	// or it could not be parsed.
+	CustomMetadata *sigstoreCustomMetadata
+}
+
 type TUF struct {
-	client  *client.Client
+	client *client.Client
+	local  client.LocalStore
+	// remote returns the remote store to fetch with for a request's context.
+	remote  remoteSource
 	targets targetImpl
 	close   func() error
+	// closeOnce guards close so that Close can be called more than once.
+	closeOnce sync.Once
+	closeErr  error
+	// store is set when the client was created WithInMemoryStore.
+	store *inMemoryStore
+	// maxTargetSize is the largest target the client fetches or returns.
+	maxTargetSize int64
+	// metrics is set when the client was created WithMetricsRegisterer.
+	metrics *tufMetrics
+	// stale is set when the client was built from expired cached metadata
+	// because the remote could not be reached, see TUFOptions.AllowStaleCache.
+	stale bool
+}
+
+// Stale reports whether t was built from expired cached metadata because the
+// remote repository could not be reached to refresh it. Only clients created
+// with TUFOptions.AllowStaleCache can be stale.
+func (t *TUF) Stale() bool {
+	return t.stale
 }
 
 // We have to close the local storage passed into the tuf.Client object, but tuf.Client doesn't expose a
 // Close method. So we capture the method of the inner local storage and close that.
+// Close is safe to call more than once: later calls return the result of the first.
 func (t *TUF) Close() error {
-	if t.close != nil {
-		return t.close()
+	t.closeOnce.Do(func() {
+		if t.close != nil {
+			t.closeErr = t.close()
+		}
+	})
+	return t.closeErr
+}
+
+// TUFOptions configures a TUF client created with NewFromEnvWithOptions.
+type TUFOptions struct {
+	// ClientOptions configure how the remote repository is accessed.
+	ClientOptions []ClientOption
+
+	// RootRotationHandler, if set, is called synchronously after the trusted
+	// root has been replaced by a newer version fetched from the remote.
+	RootRotationHandler func(oldVersion, newVersion int)
+
+	// MaxTargetSize is the largest target, in bytes, that is fetched or
+	// returned; larger ones fail with ErrTargetTooLarge. It defaults to
+	// DefaultMaxTargetSize.
+	MaxTargetSize int64
+
+	// MaxCacheSizeBytes is how large the targets cached on disk may grow. Once
+	// it is exceeded, the least recently used targets are evicted and fetched
+	// again when next needed. The targets of the top-level targets role and the
+	// trusted root are never evicted, as they are needed to verify offline. It
+	// defaults to DefaultMaxCacheSizeBytes.
+	MaxCacheSizeBytes int64
+
+	// AllowStaleCache, if set, returns a client built from the cached metadata
+	// when it has expired and the remote repository could not be reached to
+	// refresh it, rather than failing with ErrRemoteUnavailable. No error is
+	// returned in that case; TUF.Stale reports it instead, so callers that
+	// need to know the metadata may be out of date must check it. The cached
+	// metadata has still been verified against the trusted root.
+	AllowStaleCache bool
+}
+
+func (o *TUFOptions) maxCacheSizeBytes() int64 {
+	if o.MaxCacheSizeBytes <= 0 {
+		return DefaultMaxCacheSizeBytes
 	}
-	return nil
+	return o.MaxCacheSizeBytes
 }
 
-func NewFromEnv(ctx context.Context) (*TUF, error) {
-	remote, err := GcsRemoteStore(ctx, DefaultRemoteRoot, nil, nil)
+func (o *TUFOptions) maxTargetSize() int64 {
+	if o.MaxTargetSize <= 0 {
+		return DefaultMaxTargetSize
+	}
+	return o.MaxTargetSize
+}
+
+func NewFromEnv(ctx context.Context, opts ...ClientOption) (*TUF, error) {
+	return NewFromEnvWithOptions(ctx, &TUFOptions{ClientOptions: opts})
+}
+
+func NewFromEnvWithOptions(ctx context.Context, opts *TUFOptions) (*TUF, error) {
+	return NewFromConfigWithContext(ctx, TUFConfig{
+		Root:    rootCacheDir(),
+		Mirror:  DefaultRemoteRoot,
+		NoCache: noCache(),
+		Options: opts,
+	})
+}
+
+// TUFConfig configures a TUF client created with NewFromConfig. It is the
+// structured equivalent of the TUF_ROOT and SIGSTORE_NO_CACHE environment
+// variables, which NewFromEnv reads into one.
+type TUFConfig struct {
+	// Root is the directory the metadata and targets are cached in. It
+	// defaults to ~/.sigstore/root.
+	Root string
+
+	// Mirror is the remote repository: a GCS bucket name or an HTTP(S) base
+	// URL. It defaults to DefaultRemoteRoot.
+	Mirror string
+
+	// NoCache keeps the targets in memory instead of writing them under Root.
+	NoCache bool
+
+	// RootBytes, if set, is the trusted root.json to start from when nothing is
+	// cached under Root, instead of the embedded one. A root supplied for
+	// DefaultRemoteRoot must share a threshold of root keys with the embedded root.
+	RootBytes []byte
+
+	// Options, if set, configures the client as for NewFromEnvWithOptions.
+	Options *TUFOptions
+}
+
+// NewFromConfig returns a TUF client configured by cfg rather than by the
+// environment. The metadata is updated from cfg.Mirror if it has expired.
+func NewFromConfig(cfg TUFConfig) (*TUF, error) {
+	return NewFromConfigWithContext(context.Background(), cfg)
+}
+
+// NewFromConfigWithContext is like NewFromConfig, but fetches from the remote
+// repository with ctx.
+func NewFromConfigWithContext(ctx context.Context, cfg TUFConfig) (*TUF, error) {
+	if cfg.Root == "" {
+		cfg.Root = defaultRootCacheDir()
+	}
+	if cfg.Mirror == "" {
+		cfg.Mirror = DefaultRemoteRoot
+	}
+	if cfg.Options == nil {
+		cfg.Options = &TUFOptions{}
+	}
+	if cfg.RootBytes != nil && cfg.Mirror == DefaultRemoteRoot {
+		if err := validateRootAgainstEmbedded(cfg.RootBytes); err != nil {
+			return nil, errors.Wrap(err, "validating trusted root")
+		}
+	}
+	o, err := makeClientOptions(cfg.Options.ClientOptions...)
 	if err != nil {
 		return nil, err
 	}
-	return New(ctx, remote, rootCacheDir())
+	remote, err := remoteFromMirror(ctx, cfg.Mirror, o)
+	if err != nil {
+		return nil, err
+	}
+	return newWithOptions(ctx, remote, cfg)
 }
 
 func New(ctx context.Context, remote client.RemoteStore, cacheRoot string) (*TUF, error) {
-	t := &TUF{}
+	return newWithOptions(ctx, fixedRemote(remote), TUFConfig{Root: cacheRoot, NoCache: noCache(), Options: &TUFOptions{}})
+}
+
+// newWithOptions creates a client that fetches from the remote stores returned
+// by remote, if it is set.
+func newWithOptions(ctx context.Context, remote remoteSource, cfg TUFConfig) (*TUF, error) {
+	opts := cfg.Options
+	cacheRoot := cfg.Root
+	o, err := makeClientOptions(opts.ClientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	t := &TUF{
+		maxTargetSize: opts.maxTargetSize(),
+		metrics:       o.metrics,
+		remote:        remote,
+	}
 	// WE SHOULD:
 	// FIRST RESPECT THE FILES ON DISK (BYOTUF)
 	// IF THEY'RE OUT OF DATE:
@@ -80,30 +332,59 @@ func New(ctx context.Context, remote client.RemoteStore, cacheRoot string) (*TUF
 
 	tufDB := filepath.Join(cacheRoot, "tuf.db")
 	var local client.LocalStore
-	var err error
+	var wal *walStore
 
-	_, statErr := os.Stat(tufDB)
+	inMemory := o.inMemory
+	var statErr error
+	if !inMemory {
+		_, statErr = os.Stat(tufDB)
+	}
 	switch {
+	case inMemory:
+		// Start from the embedded root and never touch the cache on disk.
+		t.store, err = newInMemoryStore(cfg.RootBytes)
+		if err != nil {
+			return nil, err
+		}
+		local = t.store
+		t.targets = t.store
 	case os.IsNotExist(statErr):
-		// There is no root at the location, try embedded
+		// There is no root at the location, try the configured root
+		if cfg.RootBytes != nil {
+			local, err = rootLocalStore(cfg.RootBytes)
+			if err != nil {
+				return nil, err
+			}
+			t.targets = newFileImpl(cacheRoot, cfg.NoCache, opts.maxCacheSizeBytes())
+			break
+		}
+		// and then embedded
 		local, err = embeddedLocalStore()
 		if err != nil {
 			return nil, err
 		}
-		t.targets = newEmbeddedImpl()
+		t.targets = newEmbeddedImpl(cacheRoot, cfg.NoCache, opts.maxCacheSizeBytes())
 	case statErr != nil:
 		// Some other error, bail
 		return nil, statErr
 	default:
 		// There is a root! Happy path.
-		local, err = localStore(tufDB)
+		cached, err := localStore(tufDB)
 		if err != nil {
 			return nil, err
 		}
-		t.targets = newFileImpl()
+		if err := recoverWAL(cached, cacheRoot); err != nil {
+			cached.Close()
+			return nil, err
+		}
+		wal = newWALStore(cached, cacheRoot)
+		local = wal
+		t.targets = newFileImpl(cacheRoot, cfg.NoCache, opts.maxCacheSizeBytes())
 	}
 
-	t.client = client.NewClient(local, remote)
+	t.local = local
+	t.client = t.clientFor(ctx)
+	pinTopLevelTargets(t.targets, t.client)
 	// Capture the Close method on the local storage object so we can close it.
 	t.close = local.Close
 	trustedMeta, err := local.GetMeta()
@@ -131,11 +412,43 @@ func New(ctx context.Context, remote client.RemoteStore, cacheRoot string) (*TUF
 		return nil, errors.Wrap(err, "bad trusted root")
 	}
 	if err := t.client.Init(rootKeys, rootThreshold); err != nil {
+		if isRemoteUnavailable(err) {
+			return t.remoteUnavailable(opts, err)
+		}
 		return nil, errors.Wrap(err, "unable to initialize client, local cache may be corrupt")
 	}
+	if wal != nil {
+		wal.begin()
+	}
 	if err := t.updateMetadataAndDownloadTargets(); err != nil {
+		if isRemoteUnavailable(err) {
+			return t.remoteUnavailable(opts, err)
+		}
 		return nil, errors.Wrap(err, "updating local metadata and targets")
 	}
+	if wal != nil {
+		if err := wal.commit(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.RootRotationHandler != nil {
+		oldVersion, err := rootVersion(trustedRoot)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing trusted root version")
+		}
+		updatedMeta, err := local.GetMeta()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting updated meta")
+		}
+		newVersion, err := rootVersion(updatedMeta["root.json"])
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing updated root version")
+		}
+		if newVersion > oldVersion {
+			opts.RootRotationHandler(oldVersion, newVersion)
+		}
+	}
 
 	return t, err
 }
@@ -153,46 +466,131 @@ func getRoot(meta map[string]json.RawMessage) (json.RawMessage, error) {
 	return trustedRoot, nil
 }
 
-func Initialize(remote client.RemoteStore, root []byte) error {
-	tufDB := filepath.Join(rootCacheDir(), "tuf.db")
-	local, err := localStore(tufDB)
+// Initialize fetches the TUF repository at mirror, which is either a GCS bucket
+// name or an HTTP(S) base URL, and writes it to the local cache. If root is nil,
+// the cached or embedded root is trusted. A root supplied for DefaultRemoteRoot
+// must share a threshold of root keys with the embedded root.
+func Initialize(ctx context.Context, mirror string, root io.Reader, opts ...ClientOption) error {
+	var rootBytes []byte
+	if root != nil {
+		var err error
+		if rootBytes, err = io.ReadAll(root); err != nil {
+			return errors.Wrap(err, "reading trusted root")
+		}
+		if mirror == DefaultRemoteRoot {
+			if err := validateRootAgainstEmbedded(rootBytes); err != nil {
+				return errors.Wrap(err, "validating trusted root")
+			}
+		}
+	}
+
+	o, err := makeClientOptions(opts...)
+	if err != nil {
+		return err
+	}
+	remote, err := remoteFromMirror(ctx, mirror, o)
 	if err != nil {
 		return err
 	}
-	defer local.Close()
 
-	if root == nil {
+	cacheRoot := rootCacheDir()
+	tufDB := filepath.Join(cacheRoot, "tuf.db")
+	cached, err := localStore(tufDB)
+	if err != nil {
+		return err
+	}
+	defer cached.Close()
+	if err := recoverWAL(cached, cacheRoot); err != nil {
+		return err
+	}
+	local := newWALStore(cached, cacheRoot)
+	local.begin()
+
+	if rootBytes == nil {
 		trustedMeta, err := local.GetMeta()
 		if err != nil {
 			return errors.Wrap(err, "getting trusted meta")
 		}
-		root, err = getRoot(trustedMeta)
+		rootBytes, err = getRoot(trustedMeta)
 		if err != nil {
 			return errors.Wrap(err, "getting trusted root")
 		}
 	}
-	rootKeys, rootThreshold, err := getRootKeys(root)
+	rootKeys, rootThreshold, err := getRootKeys(rootBytes)
 	if err != nil {
 		return errors.Wrap(err, "bad trusted root")
 	}
-	c := client.NewClient(local, remote)
+	c := client.NewClient(local, remote(ctx))
 	if err := c.Init(rootKeys, rootThreshold); err != nil {
 		return errors.Wrap(err, "initializing root")
 	}
-	if err := updateMetadataAndDownloadTargets(c, newFileImpl()); err != nil {
+	targets := newFileImpl(cacheRoot, noCache(), DefaultMaxCacheSizeBytes)
+	pinTopLevelTargets(targets, c)
+	if err := updateMetadataAndDownloadTargets(c, targets, DefaultMaxTargetSize); err != nil {
 		return errors.Wrap(err, "updating local metadata and targets")
 	}
-	return nil
+	return local.commit()
+}
+
+// InitializeFromBytes is like Initialize, but takes the trusted root as a byte slice.
+func InitializeFromBytes(ctx context.Context, mirror string, root []byte, opts ...ClientOption) error {
+	var r io.Reader
+	if root != nil {
+		r = bytes.NewReader(root)
+	}
+	return Initialize(ctx, mirror, r, opts...)
 }
 
+// DumpStore returns a copy of the metadata and targets held by a client created
+// WithInMemoryStore, keyed by metadata file name and by "targets/" followed by
+// the target name. It returns nil for clients backed by the on-disk cache.
+func (t *TUF) DumpStore() map[string][]byte {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.dump()
+}
+
+// GetRootVersion returns the version of the locally trusted root, without
+// contacting the remote repository.
+func (t *TUF) GetRootVersion() (int, error) {
+	trustedMeta, err := t.local.GetMeta()
+	if err != nil {
+		return 0, errors.Wrap(err, "getting trusted meta")
+	}
+	trustedRoot, err := getRoot(trustedMeta)
+	if err != nil {
+		return 0, errors.Wrap(err, "getting trusted root")
+	}
+	return rootVersion(trustedRoot)
+}
+
+// GetTarget returns the target name, fetching it from the remote repository if
+// it is not cached.
 func (t *TUF) GetTarget(name string) ([]byte, error) {
+	return t.GetTargetWithContext(context.Background(), name)
+}
+
+// GetTargetWithContext is like GetTarget, but fetches from the remote repository with ctx.
+func (t *TUF) GetTargetWithContext(ctx context.Context, name string) ([]byte, error) {
 	// Get valid target metadata. Does a local verification.
-	validMeta, err := t.client.Target(name)
+	validMeta, err := t.targetMeta(ctx, name)
 	if err != nil {
-		return nil, errors.Wrap(err, "error verifying local metadata; local cache may be corrupt")
+		return nil, err
 	}
 
+	if validMeta.Length > t.maxTargetSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes", ErrTargetTooLarge, name, validMeta.Length)
+	}
 	targetBytes, err := t.targets.Get(name)
+	switch {
+	case err == nil:
+		t.metrics.cacheHit()
+	case os.IsNotExist(err):
+		// The target was evicted from the cache; fetch it again.
+		t.metrics.cacheMiss()
+		targetBytes, err = t.refetchTarget(ctx, name)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +606,224 @@ func (t *TUF) GetTarget(name string) ([]byte, error) {
 	return targetBytes, nil
 }
 
+// VerifyTargetIntegrity checks that data, obtained from somewhere other than
+// the TUF repository, is the target name: its SHA-256 and SHA-512 hashes must
+// match those in the trusted targets metadata, which must list at least one of
+// them. The target itself is not fetched from the remote repository.
+func (t *TUF) VerifyTargetIntegrity(name string, data []byte) error {
+	return t.VerifyTargetIntegrityWithContext(context.Background(), name, data)
+}
+
+// VerifyTargetIntegrityWithContext is like VerifyTargetIntegrity, but fetches
+// any delegated targets metadata it needs from the remote repository with ctx.
+func (t *TUF) VerifyTargetIntegrityWithContext(ctx context.Context, name string, data []byte) error {
+	validMeta, err := t.targetMeta(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	sha256Sum := sha256.Sum256(data)
+	sha512Sum := sha512.Sum512(data)
+	computed := map[string][]byte{
+		"sha256": sha256Sum[:],
+		"sha512": sha512Sum[:],
+	}
+	checked := 0
+	for alg, sum := range computed {
+		want, ok := validMeta.Hashes[alg]
+		if !ok {
+			continue
+		}
+		if subtle.ConstantTimeCompare(want, sum) != 1 {
+			return fmt.Errorf("%w: %s %s is %x, want %x", ErrHashMismatch, name, alg, sum, []byte(want))
+		}
+		checked++
+	}
+	if checked == 0 {
+		return fmt.Errorf("%w: %s has no sha256 or sha512 hash in the targets metadata", ErrHashMismatch, name)
+	}
+	return nil
+}
+
+// targetMeta returns the trusted metadata of the target name, from the top-level
+// targets role or the roles it delegates to. The go-tuf client searches the
+// delegations, verifying each role it visits and caching it in the local store.
+func (t *TUF) targetMeta(ctx context.Context, name string) (data.TargetFileMeta, error) {
+	var meta data.TargetFileMeta
+	err := withWAL(t.local, func() error {
+		var err error
+		meta, err = t.clientFor(ctx).Target(name)
+		return err
+	})
+	if errors.As(err, &client.ErrNotFound{}) {
+		return data.TargetFileMeta{}, fmt.Errorf("%w: %s", ErrTargetNotFound, name)
+	} else if err != nil {
+		return data.TargetFileMeta{}, errors.Wrap(err, "error verifying local metadata; local cache may be corrupt")
+	}
+	return meta, nil
+}
+
+// clientFor returns a go-tuf client over the local store that fetches from the
+// remote repository with ctx. The go-tuf client reloads the trusted metadata
+// from the local store whenever it looks up a target, so clients created for
+// concurrent requests see the same metadata.
+func (t *TUF) clientFor(ctx context.Context) *client.Client {
+	var remote client.RemoteStore
+	if t.remote != nil {
+		remote = t.remote(ctx)
+	}
+	return client.NewClient(t.local, remote)
+}
+
+func (t *TUF) refetchTarget(ctx context.Context, name string) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := downloadRemoteTarget(name, t.clientFor(ctx), &buf, t.maxTargetSize); err != nil {
+		return nil, err
+	}
+	if err := t.targets.Set(name, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ListTargets returns the metadata of every target in the trusted targets metadata,
+// sorted by name. The targets themselves are not fetched.
+func (t *TUF) ListTargets() ([]TargetMeta, error) {
+	targets, err := t.client.Targets()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting targets")
+	}
+	metas := make([]TargetMeta, 0, len(targets))
+	for name, targetMeta := range targets {
+		tm := TargetMeta{
+			Name:   name,
+			Size:   targetMeta.Length,
+			Hashes: targetMeta.Hashes,
+		}
+		if targetMeta.Custom != nil {
+			var scm sigstoreCustomMetadata
+			if err := json.Unmarshal(*targetMeta.Custom, &scm); err == nil {
+				tm.CustomMetadata = &scm
+			}
+		}
+		metas = append(metas, tm)
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Name < metas[j].Name })
+	return metas, nil
+}
+
+// GetTargetsByMeta returns the targets whose custom metadata declares the given usage,
+// from the top-level targets role or the roles it delegates to. If no target declares
+// the usage, the fallback target names are returned instead. Expired targets are
+// included, so callers must check each target's Status; a warning is printed to
+// stderr for every expired target returned.
+func (t *TUF) GetTargetsByMeta(usage UsageKind, fallbacks []string) ([]TargetFile, error) {
+	return t.GetTargetsByMetaWithContext(context.Background(), usage, fallbacks)
+}
+
+// GetTargetsByMetaWithContext is like GetTargetsByMeta, but fetches from the
+// remote repository with ctx and stops as soon as it is done.
+func (t *TUF) GetTargetsByMetaWithContext(ctx context.Context, usage UsageKind, fallbacks []string) ([]TargetFile, error) {
+	return t.GetTargetsByMetaMultiWithContext(ctx, []UsageKind{usage}, fallbacks)
+}
+
+// GetTargetsByMetaMulti returns the targets whose custom metadata declares any of the
+// given usages, each target at most once. If no target declares one of the usages,
+// the fallback target names are returned instead. As with GetTargetsByMeta, expired
+// targets are included and a warning is printed for each.
+func (t *TUF) GetTargetsByMetaMulti(usages []UsageKind, fallbacks []string) ([]TargetFile, error) {
+	return t.GetTargetsByMetaMultiWithContext(context.Background(), usages, fallbacks)
+}
+
+// GetTargetsByMetaMultiWithContext is like GetTargetsByMetaMulti, but fetches from
+// the remote repository with ctx and stops as soon as it is done.
+func (t *TUF) GetTargetsByMetaMultiWithContext(ctx context.Context, usages []UsageKind, fallbacks []string) ([]TargetFile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	topLevel, err := t.client.Targets()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting targets")
+	}
+	targets := make(data.TargetFiles, len(topLevel))
+	for name, meta := range topLevel {
+		targets[name] = meta
+	}
+	// Targets the top-level role doesn't list may come from delegated roles.
+	delegatedNames, err := t.delegatedTargetNames(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting delegated targets")
+	}
+	for _, name := range delegatedNames {
+		if _, ok := targets[name]; ok {
+			continue
+		}
+		meta, err := t.targetMeta(ctx, name)
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting delegated targets")
+		}
+		targets[name] = meta
+	}
+	wanted := make(map[UsageKind]bool, len(usages))
+	for _, u := range usages {
+		wanted[u] = true
+	}
+
+	var matchedTargets []TargetFile
+	for name, targetMeta := range targets {
+		// Skip any targets that do not include custom metadata.
+		if targetMeta.Custom == nil {
+			continue
+		}
+		var scm sigstoreCustomMetadata
+		if err := json.Unmarshal(*targetMeta.Custom, &scm); err != nil {
+			fmt.Fprintf(os.Stderr, "**Warning** Custom metadata not configured properly for target %s, skipping target\n", name)
+			continue
+		}
+		if !wanted[scm.Sigstore.Usage] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		target, err := t.GetTargetWithContext(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting target %s by usage", name)
+		}
+		if scm.Sigstore.Status == Expired {
+			fmt.Fprintf(os.Stderr, "**Warning** Target %s has expired and should only be used to verify existing signatures\n", name)
+		}
+		matchedTargets = append(matchedTargets, TargetFile{Target: target, Status: scm.Sigstore.Status})
+	}
+	if len(matchedTargets) > 0 {
+		return matchedTargets, nil
+	}
+
+	seen := map[string]bool{}
+	for _, fallback := range fallbacks {
+		if seen[fallback] {
+			continue
+		}
+		seen[fallback] = true
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		target, err := t.GetTargetWithContext(ctx, fallback)
+		if errors.Is(err, ErrTargetNotFound) {
+			fmt.Fprintf(os.Stderr, "**Warning** Missing fallback target %s, skipping\n", fallback)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		matchedTargets = append(matchedTargets, TargetFile{Target: target, Status: Active})
+	}
+	if len(matchedTargets) == 0 {
+		return nil, fmt.Errorf("no matching targets by custom metadata, fallbacks not found: %s", strings.Join(fallbacks, ", "))
+	}
+	return matchedTargets, nil
+}
+
 func localStore(cacheRoot string) (client.LocalStore, error) {
 	local, err := tuf_leveldbstore.FileLocalStore(cacheRoot)
 	if err != nil {
@@ -216,8 +832,18 @@ func localStore(cacheRoot string) (client.LocalStore, error) {
 	return local, nil
 }
 
+// rootLocalStore returns an in-memory local store holding only rootBytes as the
+// trusted root, from which the rest of the metadata is fetched.
+func rootLocalStore(rootBytes []byte) (client.LocalStore, error) {
+	local := newSyncLocalStore()
+	if err := local.SetMeta("root.json", rootBytes); err != nil {
+		return nil, errors.Wrap(err, "setting local meta")
+	}
+	return local, nil
+}
+
 func embeddedLocalStore() (client.LocalStore, error) {
-	local := client.MemoryLocalStore()
+	local := newSyncLocalStore()
 	for _, mdFilename := range []string{"root.json", "targets.json", "snapshot.json", "timestamp.json"} {
 		b, err := embeddedRootRepo.ReadFile(path.Join("repository", mdFilename))
 		if err != nil {
@@ -230,6 +856,45 @@ func embeddedLocalStore() (client.LocalStore, error) {
 	return local, nil
 }
 
+// syncLocalStore is an in-memory local store that is safe for concurrent use,
+// as requests looking up targets in parallel may each store delegated metadata.
+type syncLocalStore struct {
+	mu   sync.RWMutex
+	meta map[string]json.RawMessage
+}
+
+func newSyncLocalStore() *syncLocalStore {
+	return &syncLocalStore{meta: map[string]json.RawMessage{}}
+}
+
+func (s *syncLocalStore) GetMeta() (map[string]json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	meta := make(map[string]json.RawMessage, len(s.meta))
+	for name, m := range s.meta {
+		meta[name] = m
+	}
+	return meta, nil
+}
+
+func (s *syncLocalStore) SetMeta(name string, meta json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta[name] = meta
+	return nil
+}
+
+func (s *syncLocalStore) DeleteMeta(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.meta, name)
+	return nil
+}
+
+func (s *syncLocalStore) Close() error {
+	return nil
+}
+
 //go:embed repository
 var embeddedRootRepo embed.FS
 
@@ -245,6 +910,18 @@ var isExpiredMetadata = func(metadata []byte) bool {
 	return time.Until(sm.Expires) <= 0
 }
 
+func rootVersion(rootBytes []byte) (int, error) {
+	s := &data.Signed{}
+	if err := json.Unmarshal(rootBytes, s); err != nil {
+		return 0, err
+	}
+	sm := &signedMeta{}
+	if err := json.Unmarshal(s.Signed, sm); err != nil {
+		return 0, err
+	}
+	return sm.Version, nil
+}
+
 type signedMeta struct {
 	Type    string    `json:"_type"`
 	Expires time.Time `json:"expires"`
@@ -265,25 +942,112 @@ func getRootKeys(rootFileBytes []byte) ([]*data.PublicKey, int, error) {
 	return rootKeys, rootThreshold, err
 }
 
+// validateRootAgainstEmbedded checks that rootBytes, a root supplied for the public
+// Sigstore repository, is signed by a threshold of the embedded root's root keys,
+// so that an arbitrary root can't be trusted in place of the Sigstore one.
+func validateRootAgainstEmbedded(rootBytes []byte) error {
+	embeddedBytes, err := embeddedRootRepo.ReadFile(path.Join("repository", "root.json"))
+	if err != nil {
+		return errors.Wrap(err, "reading embedded root")
+	}
+	rootKeys, threshold, err := getRootKeys(embeddedBytes)
+	if err != nil {
+		return errors.Wrap(err, "parsing embedded root")
+	}
+
+	db := verify.NewDB()
+	role := &data.Role{Threshold: threshold}
+	for _, k := range rootKeys {
+		for _, id := range k.IDs() {
+			if err := db.AddKey(id, k); err != nil {
+				return errors.Wrap(err, "adding embedded root key")
+			}
+			role.KeyIDs = append(role.KeyIDs, id)
+		}
+	}
+	if err := db.AddRole("root", role); err != nil {
+		return errors.Wrap(err, "adding embedded root role")
+	}
+
+	s := &data.Signed{}
+	if err := json.Unmarshal(rootBytes, s); err != nil {
+		return errors.Wrap(err, "parsing supplied root")
+	}
+	if err := db.VerifySignatures(s, "root"); err != nil {
+		return errors.Wrap(err, "supplied root is not signed by the embedded Sigstore root keys")
+	}
+	return nil
+}
+
+// isRemoteUnavailable reports whether err came from failing to fetch from the
+// remote, rather than from the fetched metadata failing verification.
+func isRemoteUnavailable(err error) bool {
+	var downloadErr client.ErrDownloadFailed
+	var missingErr client.ErrMissingRemoteMetadata
+	var urlErr *url.Error
+	return errors.As(err, &downloadErr) || errors.As(err, &missingErr) || errors.As(err, &urlErr)
+}
+
+// remoteUnavailable returns t, built from the cached metadata and marked stale,
+// if opts allow a stale cache, and closes it and fails with ErrRemoteUnavailable
+// otherwise.
+func (t *TUF) remoteUnavailable(opts *TUFOptions, err error) (*TUF, error) {
+	if opts.AllowStaleCache {
+		t.stale = true
+		return t, nil
+	}
+	t.Close()
+	return nil, fmt.Errorf("%w: %v", ErrRemoteUnavailable, err)
+}
+
 func (t *TUF) updateMetadataAndDownloadTargets() error {
-	return updateMetadataAndDownloadTargets(t.client, t.targets)
+	return updateMetadataAndDownloadTargets(t.client, t.targets, t.maxTargetSize)
 }
 
-func updateMetadataAndDownloadTargets(c *client.Client, t targetImpl) error {
+func updateMetadataAndDownloadTargets(c *client.Client, t targetImpl, maxTargetSize int64) error {
 	// Download updated targets and cache new metadata and targets in ${TUF_ROOT}.
 	targetFiles, err := c.Update()
 	if err != nil && !client.IsLatestSnapshot(err) {
 		return errors.Wrap(err, "updating tuf metadata")
 	}
 
+	names := make([]string, 0, len(targetFiles))
+	for name, meta := range targetFiles {
+		// Refuse to download targets that the metadata already says are too large.
+		if meta.Length > maxTargetSize {
+			return fmt.Errorf("%w: %s is %d bytes", ErrTargetTooLarge, name, meta.Length)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Once the metadata is verified the targets are independent of each other,
+	// so fetch them in parallel.
+	bufs := make([]bytes.Buffer, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, targetFetchParallelism)
+	for i, name := range names {
+		i, name := i, name
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = downloadRemoteTarget(name, c, &bufs[i], maxTargetSize)
+		}()
+	}
+	wg.Wait()
+
 	// Update the in-memory targets.
 	// If the cache directory is enabled, update that too.
-	for name := range targetFiles {
-		buf := bytes.Buffer{}
-		if err := downloadRemoteTarget(name, c, &buf); err != nil {
-			return err
+	for i, name := range names {
+		if errs[i] != nil {
+			return errs[i]
 		}
-		if err := t.Set(name, buf.Bytes()); err != nil {
+		if err := t.Set(name, bufs[i].Bytes()); err != nil {
 			return err
 		}
 	}
@@ -291,20 +1055,31 @@ func updateMetadataAndDownloadTargets(c *client.Client, t targetImpl) error {
 	return nil
 }
 
-func downloadRemoteTarget(name string, c *client.Client, w io.Writer) error {
-	dest := targetDestination{}
+func downloadRemoteTarget(name string, c *client.Client, w io.Writer, maxTargetSize int64) error {
+	dest := targetDestination{limit: maxTargetSize}
 	if err := c.Download(name, &dest); err != nil {
+		if dest.tooLarge {
+			return fmt.Errorf("%w: %s", ErrTargetTooLarge, name)
+		}
 		return errors.Wrap(err, "downloading target")
 	}
 	_, err := io.Copy(w, &dest.buf)
 	return err
 }
 
+// targetDestination buffers a downloaded target, failing the download as soon
+// as it is longer than limit bytes.
 type targetDestination struct {
-	buf bytes.Buffer
+	buf      bytes.Buffer
+	limit    int64
+	tooLarge bool
 }
 
 func (t *targetDestination) Write(b []byte) (int, error) {
+	if int64(t.buf.Len())+int64(len(b)) > t.limit {
+		t.tooLarge = true
+		return 0, ErrTargetTooLarge
+	}
 	return t.buf.Write(b)
 }
 
@@ -316,15 +1091,19 @@ func (t *targetDestination) Delete() error {
 func rootCacheDir() string {
 	rootDir := os.Getenv(TufRootEnv)
 	if rootDir == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			home = ""
-		}
-		return filepath.Join(home, ".sigstore", "root")
+		return defaultRootCacheDir()
 	}
 	return rootDir
 }
 
+func defaultRootCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = ""
+	}
+	return filepath.Join(home, ".sigstore", "root")
+}
+
 func cachedTargetsDir(cacheRoot string) string {
 	return filepath.Join(cacheRoot, "targets")
 }
@@ -350,6 +1129,85 @@ func (m *memoryCache) Set(p string, b []byte) error {
 	return nil
 }
 
+// inMemoryStore holds both the TUF metadata and the targets of a client created
+// WithInMemoryStore. Targets that were never downloaded are read from the
+// embedded repository.
+type inMemoryStore struct {
+	m sync.Map
+}
+
+const inMemoryTargetsPrefix = "targets/"
+
+var _ client.LocalStore = (*inMemoryStore)(nil)
+
+// newInMemoryStore returns a store holding the embedded metadata, or only
+// rootBytes as the trusted root if it is set.
+func newInMemoryStore(rootBytes []byte) (*inMemoryStore, error) {
+	s := &inMemoryStore{}
+	if rootBytes != nil {
+		s.m.Store("root.json", append([]byte(nil), rootBytes...))
+		return s, nil
+	}
+	for _, mdFilename := range []string{"root.json", "targets.json", "snapshot.json", "timestamp.json"} {
+		b, err := embeddedRootRepo.ReadFile(path.Join("repository", mdFilename))
+		if err != nil {
+			return nil, errors.Wrap(err, "reading embedded file")
+		}
+		s.m.Store(mdFilename, b)
+	}
+	return s, nil
+}
+
+// GetMeta implements client.LocalStore
+func (s *inMemoryStore) GetMeta() (map[string]json.RawMessage, error) {
+	meta := map[string]json.RawMessage{}
+	s.m.Range(func(k, v interface{}) bool {
+		if name := k.(string); !strings.HasPrefix(name, inMemoryTargetsPrefix) {
+			meta[name] = append(json.RawMessage(nil), v.([]byte)...)
+		}
+		return true
+	})
+	return meta, nil
+}
+
+// SetMeta implements client.LocalStore
+func (s *inMemoryStore) SetMeta(name string, meta json.RawMessage) error {
+	s.m.Store(name, append([]byte(nil), meta...))
+	return nil
+}
+
+// DeleteMeta implements client.LocalStore
+func (s *inMemoryStore) DeleteMeta(name string) error {
+	s.m.Delete(name)
+	return nil
+}
+
+// Close implements client.LocalStore
+func (s *inMemoryStore) Close() error {
+	return nil
+}
+
+func (s *inMemoryStore) Get(p string) ([]byte, error) {
+	if v, ok := s.m.Load(inMemoryTargetsPrefix + p); ok {
+		return append([]byte(nil), v.([]byte)...), nil
+	}
+	return (&embedded{}).Get(p)
+}
+
+func (s *inMemoryStore) Set(p string, b []byte) error {
+	s.m.Store(inMemoryTargetsPrefix+p, append([]byte(nil), b...))
+	return nil
+}
+
+func (s *inMemoryStore) dump() map[string][]byte {
+	out := map[string][]byte{}
+	s.m.Range(func(k, v interface{}) bool {
+		out[k.(string)] = append([]byte(nil), v.([]byte)...)
+		return true
+	})
+	return out
+}
+
 type embedded struct {
 	setImpl
 }
@@ -375,19 +1233,103 @@ type file struct {
 
 func (f *file) Get(p string) ([]byte, error) {
 	fp := filepath.Join(f.base, p)
-	return os.ReadFile(fp)
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := f.setImpl.(*diskCache); ok {
+		d.touch(p)
+	}
+	return b, nil
 }
 
 type diskCache struct {
 	base string
+	// maxSize is how large the cached targets may grow before the least
+	// recently used are evicted.
+	maxSize int64
+	// pinned, if set, reports the targets that are never evicted, as they are
+	// needed to verify offline.
+	pinned func(string) bool
+
+	mu sync.Mutex
+	// accessed holds the times targets were read since the access record was
+	// last written. It is only written along with a target, so reads need
+	// neither the lock on the directory nor a write.
+	accessed map[string]time.Time
+}
+
+// touch records that target p was used.
+func (d *diskCache) touch(p string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.accessed == nil {
+		d.accessed = map[string]time.Time{}
+	}
+	d.accessed[p] = time.Now()
+}
+
+func (d *diskCache) isPinned(name string) bool {
+	return name == trustedRootTarget || (d.pinned != nil && d.pinned(name))
 }
 
 func (d *diskCache) Set(p string, b []byte) error {
 	if err := os.MkdirAll(d.base, 0700); err != nil {
 		return errors.Wrap(err, "creating targets dir")
 	}
+	// Other cosign processes may be refreshing the same cache, so hold the
+	// lock while replacing the file and checking what landed on disk.
+	unlock, err := lockDir(d.base)
+	if err != nil {
+		return errors.Wrap(err, "locking targets dir")
+	}
+	defer unlock()
+
 	fp := filepath.Join(d.base, p)
-	return os.WriteFile(fp, b, 0600)
+	if err := atomicWriteFile(fp, b, 0600); err != nil {
+		return err
+	}
+	written, err := os.ReadFile(fp)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(written, b) {
+		return fmt.Errorf("cached target %s does not match the downloaded contents", p)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := updateLRU(d.base, p, time.Now(), d.accessed, d.maxSize, d.isPinned); err != nil {
+		return err
+	}
+	d.accessed = nil
+	return nil
+}
+
+// atomicWriteFile writes b to a temporary file next to fp and renames it into
+// place, so readers never observe a partially written file.
+func atomicWriteFile(fp string, b []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fp), "."+filepath.Base(fp)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, fp)
 }
 
 func noCache() bool {
@@ -398,23 +1340,48 @@ func noCache() bool {
 	return b
 }
 
-func newEmbeddedImpl() targetImpl {
+func newEmbeddedImpl(cacheRoot string, noCache bool, maxCacheSize int64) targetImpl {
 	e := &embedded{}
-	if noCache() {
+	if noCache {
 		e.setImpl = &memoryCache{}
 	} else {
-		e.setImpl = &diskCache{base: cachedTargetsDir(rootCacheDir())}
+		e.setImpl = &diskCache{base: cachedTargetsDir(cacheRoot), maxSize: maxCacheSize}
 	}
 	return e
 }
 
-func newFileImpl() targetImpl {
-	base := cachedTargetsDir(rootCacheDir())
+// pinTopLevelTargets keeps the targets listed by the top-level targets role of c
+// from being evicted from the disk cache of ti, if it has one, as they are needed
+// to verify offline.
+func pinTopLevelTargets(ti targetImpl, c *client.Client) {
+	var d *diskCache
+	switch ti := ti.(type) {
+	case *file:
+		d, _ = ti.setImpl.(*diskCache)
+	case *embedded:
+		d, _ = ti.setImpl.(*diskCache)
+	}
+	if d == nil {
+		return
+	}
+	d.pinned = func(name string) bool {
+		targets, err := c.Targets()
+		if err != nil {
+			// Evict nothing rather than something that may be needed.
+			return true
+		}
+		_, ok := targets[name]
+		return ok
+	}
+}
+
+func newFileImpl(cacheRoot string, noCache bool, maxCacheSize int64) targetImpl {
+	base := cachedTargetsDir(cacheRoot)
 	f := &file{base: base}
-	if noCache() {
+	if noCache {
 		f.setImpl = &memoryCache{}
 	} else {
-		f.setImpl = &diskCache{base: base}
+		f.setImpl = &diskCache{base: base, maxSize: maxCacheSize}
 	}
 	return f
 }
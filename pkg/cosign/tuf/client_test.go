@@ -16,9 +16,23 @@
 package tuf
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
+
+	"github.com/theupdateframework/go-tuf/client"
+	"github.com/theupdateframework/go-tuf/data"
+	"github.com/theupdateframework/go-tuf/util"
 )
 
 var targets = []string{
@@ -52,11 +66,7 @@ func TestNewFromEnv(t *testing.T) {
 	checkTargets(t, tuf)
 
 	// Now let's explicitly make a root.
-	remote, err := GcsRemoteStore(ctx, DefaultRemoteRoot, nil, nil)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if err := Initialize(remote, nil); err != nil {
+	if err := Initialize(ctx, DefaultRemoteRoot, nil); err != nil {
 		t.Error()
 	}
 	if l := dirLen(t, td); l == 0 {
@@ -72,6 +82,141 @@ func TestNewFromEnv(t *testing.T) {
 	tuf.Close()
 }
 
+func TestNewRemoteUnavailable(t *testing.T) {
+	ctx := context.Background()
+	forceExpiration(t, true)
+
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+	remote, err := client.HTTPRemoteStore(s.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	td := t.TempDir()
+	tuf, err := New(ctx, remote, td)
+	if !errors.Is(err, ErrRemoteUnavailable) {
+		t.Fatalf("expected ErrRemoteUnavailable, got %v", err)
+	}
+	if tuf != nil {
+		t.Fatal("expected no client without AllowStaleCache")
+	}
+
+	tuf, err = newWithOptions(ctx, fixedRemote(remote), TUFConfig{Root: td, Options: &TUFOptions{AllowStaleCache: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tuf.Close()
+	if !tuf.Stale() {
+		t.Error("expected a client built from the expired cache to be stale")
+	}
+	checkTargets(t, tuf)
+}
+
+func TestInitializeFromReader(t *testing.T) {
+	ctx := context.Background()
+	root, err := embeddedRootRepo.ReadFile(path.Join("repository", "root.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromBytes := t.TempDir()
+	t.Setenv("TUF_ROOT", fromBytes)
+	if err := InitializeFromBytes(ctx, DefaultRemoteRoot, root); err != nil {
+		t.Fatal(err)
+	}
+
+	fromReader := t.TempDir()
+	t.Setenv("TUF_ROOT", fromReader)
+	if err := Initialize(ctx, DefaultRemoteRoot, bytes.NewReader(root)); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, b := cachedMeta(t, fromBytes), cachedMeta(t, fromReader); !reflect.DeepEqual(a, b) {
+		t.Errorf("cached metadata differs between []byte and io.Reader roots")
+	}
+	for _, target := range targets {
+		a, err := os.ReadFile(filepath.Join(cachedTargetsDir(fromBytes), target))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(cachedTargetsDir(fromReader), target))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("cached target %s differs between []byte and io.Reader roots", target)
+		}
+	}
+}
+
+func cachedMeta(t *testing.T, cacheRoot string) map[string]json.RawMessage {
+	t.Helper()
+	local, err := localStore(filepath.Join(cacheRoot, "tuf.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	meta, err := local.GetMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return meta
+}
+
+func TestValidateRootAgainstEmbedded(t *testing.T) {
+	root, err := embeddedRootRepo.ReadFile(path.Join("repository", "root.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateRootAgainstEmbedded(root); err != nil {
+		t.Errorf("embedded root: %v", err)
+	}
+
+	rootWithKeys := func(ids ...string) []byte {
+		signed, _ := json.Marshal(map[string]interface{}{
+			"_type": "root",
+			"roles": map[string]*Role{"root": {KeyIDs: ids, Threshold: 1}},
+		})
+		b, _ := json.Marshal(map[string]interface{}{"signed": json.RawMessage(signed), "signatures": []interface{}{}})
+		return b
+	}
+	if err := validateRootAgainstEmbedded(rootWithKeys("not-a-sigstore-key")); err == nil {
+		t.Error("expected error for a root with none of the embedded root keys")
+	}
+
+	// Listing the embedded root keys is not enough, they must sign the root.
+	s := &data.Signed{}
+	if err := json.Unmarshal(root, s); err != nil {
+		t.Fatal(err)
+	}
+	r := &struct {
+		Roles map[string]*Role `json:"roles"`
+	}{}
+	if err := json.Unmarshal(s.Signed, r); err != nil {
+		t.Fatal(err)
+	}
+	role := r.Roles["root"]
+	if err := validateRootAgainstEmbedded(rootWithKeys(role.KeyIDs...)); err == nil {
+		t.Error("expected error for an unsigned root listing the embedded root keys")
+	}
+	unsigned := *s
+	unsigned.Signatures = nil
+	if b, _ := json.Marshal(unsigned); validateRootAgainstEmbedded(b) == nil {
+		t.Error("expected error for the embedded root without its signatures")
+	}
+	tampered := *s
+	tampered.Signed = append(json.RawMessage(`{"tampered":true,`), s.Signed[1:]...)
+	if b, _ := json.Marshal(tampered); validateRootAgainstEmbedded(b) == nil {
+		t.Error("expected error for a modified embedded root")
+	}
+
+	t.Setenv("TUF_ROOT", t.TempDir())
+	if err := InitializeFromBytes(context.Background(), DefaultRemoteRoot, rootWithKeys("not-a-sigstore-key")); err == nil {
+		t.Error("expected Initialize to reject a root that doesn't match the embedded root")
+	}
+}
+
 func TestNoCache(t *testing.T) {
 	ctx := context.Background()
 	// Once more with NO_CACHE
@@ -94,6 +239,76 @@ func TestNoCache(t *testing.T) {
 	}
 }
 
+func TestNewFromConfig(t *testing.T) {
+	ctx := context.Background()
+	// The environment is not consulted.
+	envRoot := t.TempDir()
+	t.Setenv("TUF_ROOT", envRoot)
+	t.Setenv("SIGSTORE_NO_CACHE", "false")
+	td := t.TempDir()
+
+	forceExpiration(t, true)
+
+	tuf, err := NewFromConfigWithContext(ctx, TUFConfig{Root: td, NoCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTargets(t, tuf)
+	tuf.Close()
+
+	if l := dirLen(t, td) + dirLen(t, envRoot); l != 0 {
+		t.Errorf("expected no filesystem writes, got %d entries", l)
+	}
+
+	if _, err := NewFromConfig(TUFConfig{Root: td, RootBytes: []byte("{}")}); err == nil {
+		t.Error("expected an error for a root that does not chain to the embedded root")
+	}
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TUF_ROOT", t.TempDir())
+
+	tuf, err := NewFromEnv(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := tuf.Close(); err != nil {
+			t.Errorf("Close() #%d = %v", i+1, err)
+		}
+	}
+}
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	// The cache settings are ignored for an in-memory store.
+	t.Setenv("SIGSTORE_NO_CACHE", "false")
+	td := t.TempDir()
+	t.Setenv("TUF_ROOT", td)
+
+	// Force expiration so we have some content to download
+	forceExpiration(t, true)
+
+	tuf, err := NewFromEnv(ctx, WithInMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTargets(t, tuf)
+	tuf.Close()
+
+	if l := dirLen(t, td); l != 0 {
+		t.Errorf("expected no filesystem writes, got %d entries", l)
+	}
+
+	store := tuf.DumpStore()
+	for _, name := range []string{"root.json", "targets.json", "snapshot.json", "timestamp.json"} {
+		if len(store[name]) == 0 {
+			t.Errorf("expected %s in the in-memory store", name)
+		}
+	}
+}
+
 func TestCache(t *testing.T) {
 	ctx := context.Background()
 	// Once more with NO_CACHE
@@ -132,6 +347,234 @@ func TestCache(t *testing.T) {
 	checkTargets(t, tuf)
 }
 
+func TestConcurrentNewFromEnv(t *testing.T) {
+	ctx := context.Background()
+	td := t.TempDir()
+	t.Setenv("TUF_ROOT", td)
+	t.Setenv("SIGSTORE_NO_CACHE", "false")
+
+	// Force expiration so every client downloads and caches the targets.
+	forceExpiration(t, true)
+
+	const n = 8
+	var wg sync.WaitGroup
+	clients := make([]*TUF, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i], errs[i] = NewFromEnv(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("client %d: %v", i, err)
+		}
+	}
+	defer func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}()
+
+	// Every cached target must match the verified metadata.
+	for _, target := range targets {
+		b, err := os.ReadFile(filepath.Join(cachedTargetsDir(td), target))
+		if err != nil {
+			t.Fatal(err)
+		}
+		validMeta, err := clients[0].client.Target(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		localMeta, err := util.GenerateTargetFileMeta(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := util.TargetFileMetaEqual(localMeta, validMeta); err != nil {
+			t.Errorf("corrupted cache for %s: %v", target, err)
+		}
+	}
+}
+
+func TestGetTargetsByMetaMulti(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TUF_ROOT", t.TempDir())
+	forceExpiration(t, false)
+
+	tuf, err := NewFromEnv(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tuf.Close()
+
+	// The embedded targets carry no custom metadata, so the fallbacks are used.
+	got, err := tuf.GetTargetsByMetaMulti([]UsageKind{Fulcio, Rekor}, []string{"fulcio.crt.pem", "rekor.pub", "rekor.pub", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 deduplicated targets, got %d", len(got))
+	}
+	for _, tf := range got {
+		if tf.Status != Active {
+			t.Errorf("expected fallback targets to be Active, got %v", tf.Status)
+		}
+	}
+
+	single, err := tuf.GetTargetsByMeta(CTFE, []string{"ctfe.pub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single) != 1 {
+		t.Errorf("expected 1 target, got %d", len(single))
+	}
+
+	if _, err := tuf.GetTargetsByMeta(Fulcio, []string{"missing"}); err == nil {
+		t.Error("expected error with no matching targets, got nil")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := tuf.GetTargetsByMetaWithContext(cancelled, CTFE, []string{"ctfe.pub"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListTargets(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TUF_ROOT", t.TempDir())
+	forceExpiration(t, false)
+
+	tuf, err := NewFromEnv(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tuf.Close()
+
+	metas, err := tuf.ListTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for i, tm := range metas {
+		names[tm.Name] = true
+		if i > 0 && metas[i-1].Name >= tm.Name {
+			t.Errorf("targets not sorted by name: %s before %s", metas[i-1].Name, tm.Name)
+		}
+		if tm.Size <= 0 || len(tm.Hashes["sha512"]) == 0 {
+			t.Errorf("target %s has size %d and hashes %v", tm.Name, tm.Size, tm.Hashes)
+		}
+		b, err := tuf.GetTarget(tm.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(b)) != tm.Size {
+			t.Errorf("target %s is %d bytes, metadata says %d", tm.Name, len(b), tm.Size)
+		}
+	}
+	for _, name := range []string{"fulcio.crt.pem", "rekor.pub", "ctfe.pub"} {
+		if !names[name] {
+			t.Errorf("ListTargets() is missing %s", name)
+		}
+	}
+}
+
+func TestVerifyTargetIntegrity(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TUF_ROOT", t.TempDir())
+	forceExpiration(t, false)
+
+	tuf, err := NewFromEnv(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tuf.Close()
+
+	b, err := tuf.GetTarget("rekor.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tuf.VerifyTargetIntegrity("rekor.pub", b); err != nil {
+		t.Errorf("VerifyTargetIntegrity() = %v", err)
+	}
+	tampered := append([]byte{}, b...)
+	tampered[0] ^= 0xff
+	if err := tuf.VerifyTargetIntegrity("rekor.pub", tampered); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("expected ErrHashMismatch, got %v", err)
+	}
+	if err := tuf.VerifyTargetIntegrity("not-a-target", b); !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("expected ErrTargetNotFound, got %v", err)
+	}
+}
+
+func TestMaxTargetSize(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TUF_ROOT", t.TempDir())
+	forceExpiration(t, false)
+
+	tuf, err := NewFromEnvWithOptions(ctx, &TUFOptions{MaxTargetSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tuf.Close()
+
+	if _, err := tuf.GetTarget("ctfe.pub"); !errors.Is(err, ErrTargetTooLarge) {
+		t.Errorf("expected ErrTargetTooLarge, got %v", err)
+	}
+	if _, err := tuf.GetTargetsByMeta(CTFE, []string{"ctfe.pub"}); !errors.Is(err, ErrTargetTooLarge) {
+		t.Errorf("expected ErrTargetTooLarge, got %v", err)
+	}
+}
+
+func TestTargetDestinationLimit(t *testing.T) {
+	dest := targetDestination{limit: 10}
+	if _, err := dest.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("writing up to the limit: %v", err)
+	}
+	if _, err := dest.Write([]byte("a")); !errors.Is(err, ErrTargetTooLarge) {
+		t.Errorf("expected ErrTargetTooLarge, got %v", err)
+	}
+	if !dest.tooLarge || dest.buf.Len() != 10 {
+		t.Errorf("buffered %d bytes past the limit, tooLarge = %v", dest.buf.Len()-10, dest.tooLarge)
+	}
+}
+
+func TestCustomMetadata(t *testing.T) {
+	var scm sigstoreCustomMetadata
+	if err := json.Unmarshal([]byte(`{"sigstore":{"usage":"Rekor","status":"Expired"}}`), &scm); err != nil {
+		t.Fatal(err)
+	}
+	if scm.Sigstore.Usage != Rekor || scm.Sigstore.Status != Expired {
+		t.Errorf("unexpected custom metadata %+v", scm)
+	}
+	if err := json.Unmarshal([]byte(`{"sigstore":{"usage":"Bogus"}}`), &scm); err == nil {
+		t.Error("expected error for unknown usage, got nil")
+	}
+}
+
+func TestGetTrustedRoot(t *testing.T) {
+	t.Setenv("TUF_ROOT", t.TempDir())
+	forceExpiration(t, false)
+
+	tr, err := GetTrustedRoot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.CertificateAuthorities) != 2 {
+		t.Errorf("expected 2 certificate authorities, got %d", len(tr.CertificateAuthorities))
+	}
+	if len(tr.Tlogs) != 1 {
+		t.Errorf("expected 1 transparency log, got %d", len(tr.Tlogs))
+	}
+	if len(tr.Ctlogs) != 1 {
+		t.Errorf("expected 1 certificate transparency log, got %d", len(tr.Ctlogs))
+	}
+}
+
 func checkTargets(t *testing.T, tuf *TUF) {
 	// Check the targets
 	t.Helper()
@@ -142,8 +585,8 @@ func checkTargets(t *testing.T, tuf *TUF) {
 	}
 
 	// An invalid target
-	if _, err := tuf.GetTarget("invalid"); err == nil {
-		t.Error("expected error reading target, got nil")
+	if _, err := tuf.GetTarget("invalid"); !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("expected ErrTargetNotFound reading target, got %v", err)
 	}
 }
 
@@ -156,7 +599,7 @@ func dirLen(t *testing.T, td string) int {
 	return len(de)
 }
 
-func forceExpiration(t *testing.T, expire bool) {
+func forceExpiration(t testing.TB, expire bool) {
 	oldIsExpiredMetadata := isExpiredMetadata
 	isExpiredMetadata = func(_ []byte) bool {
 		return expire
@@ -165,3 +608,80 @@ func forceExpiration(t *testing.T, expire bool) {
 		isExpiredMetadata = oldIsExpiredMetadata
 	})
 }
+
+// BenchmarkNewFromEnvExpired measures a cold start against the default remote,
+// which fetches the metadata and every target, fetching the targets one at a
+// time and in parallel.
+func BenchmarkNewFromEnvExpired(b *testing.B) {
+	ctx := context.Background()
+	forceExpiration(b, true)
+	b.Setenv("TUF_ROOT", b.TempDir())
+
+	for _, parallelism := range []int{1, targetFetchParallelism} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			old := targetFetchParallelism
+			targetFetchParallelism = parallelism
+			defer func() { targetFetchParallelism = old }()
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := os.Setenv("TUF_ROOT", b.TempDir()); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+
+				tuf, err := NewFromEnv(ctx)
+				if err != nil {
+					b.Fatal(err)
+				}
+				tuf.Close()
+			}
+		})
+	}
+}
+
+func TestGetRootVersion(t *testing.T) {
+	t.Setenv("TUF_ROOT", t.TempDir())
+	forceExpiration(t, false)
+
+	tuf, err := NewFromEnv(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tuf.Close()
+
+	b, err := embeddedRootRepo.ReadFile(path.Join("repository", "root.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := rootVersion(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tuf.GetRootVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("GetRootVersion() = %d, want %d", got, want)
+	}
+}
+
+func TestRootVersion(t *testing.T) {
+	for file, want := range map[string]int{"1.root.json": 1, "2.root.json": 2} {
+		b, err := embeddedRootRepo.ReadFile(path.Join("repository", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := rootVersion(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("rootVersion(%s) = %d, want %d", file, got, want)
+		}
+	}
+	if _, err := rootVersion([]byte("not json")); err == nil {
+		t.Error("expected error parsing invalid root, got nil")
+	}
+}
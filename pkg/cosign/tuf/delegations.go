@@ -0,0 +1,191 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/theupdateframework/go-tuf/data"
+	"github.com/theupdateframework/go-tuf/util"
+	"github.com/theupdateframework/go-tuf/verify"
+)
+
+// maxDelegations bounds how many delegated targets roles are visited when
+// listing targets, as the go-tuf client does when searching for one.
+const maxDelegations = 32
+
+// maxDelegatedMetaSize is the largest delegated targets metadata that is fetched
+// when the snapshot does not list its length.
+const maxDelegatedMetaSize int64 = 5 << 20
+
+// delegatedTargetNames returns the names of the targets listed by any role the
+// top-level targets role delegates to, for the paths each role is trusted for.
+// Which role's metadata applies to each name is decided by targetMeta.
+func (t *TUF) delegatedTargetNames(ctx context.Context) ([]string, error) {
+	meta, err := t.local.GetMeta()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting trusted meta")
+	}
+	top := &data.Targets{}
+	if err := unmarshalTrusted(meta, "targets.json", top); err != nil {
+		return nil, err
+	}
+	snapshot := &data.Snapshot{}
+	if err := unmarshalTrusted(meta, "snapshot.json", snapshot); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	stack := []*data.Delegations{top.Delegations}
+	for len(stack) > 0 && len(seen) < maxDelegations {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if d == nil {
+			continue
+		}
+		verifier, err := verify.NewDelegationsVerifier(d)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing delegations")
+		}
+		for i := range d.Roles {
+			role := &d.Roles[i]
+			if seen[role.Name] {
+				continue
+			}
+			seen[role.Name] = true
+			targets, err := t.loadDelegatedRole(ctx, meta, snapshot, role.Name, verifier)
+			if err != nil {
+				return nil, errors.Wrapf(err, "loading delegated role %s", role.Name)
+			}
+			for name := range targets.Targets {
+				if ok, _ := role.MatchesPath(name); ok {
+					names = append(names, name)
+				}
+			}
+			stack = append(stack, targets.Delegations)
+		}
+	}
+	return names, nil
+}
+
+// loadDelegatedRole returns the metadata of role at the version listed in the
+// trusted snapshot, verified against the keys of the role that delegated to it.
+// It comes from the local store if it is there, and is otherwise fetched from the
+// remote repository and stored, as the go-tuf client does when searching for a
+// target, so that it is only fetched once.
+func (t *TUF) loadDelegatedRole(ctx context.Context, meta map[string]json.RawMessage, snapshot *data.Snapshot, role string, verifier verify.DelegationsVerifier) (*data.Targets, error) {
+	fileName := role + ".json"
+	fileMeta, ok := snapshot.Meta[fileName]
+	if !ok {
+		return nil, fmt.Errorf("%s is not listed in the trusted snapshot", fileName)
+	}
+
+	raw, cached := meta[fileName]
+	if cached && matchesSnapshot(raw, fileMeta) != nil {
+		cached = false
+	}
+	if !cached {
+		if t.remote == nil {
+			return nil, fmt.Errorf("%s is not cached and there is no remote repository", fileName)
+		}
+		remotePath := fileName
+		if consistentSnapshot(meta) {
+			remotePath = util.VersionedPath(fileName, fileMeta.Version)
+		}
+		limit := fileMeta.Length
+		if limit <= 0 {
+			limit = maxDelegatedMetaSize
+		}
+		var err error
+		raw, err = readRemote(t.remote(ctx).GetMeta, remotePath, limit)
+		if err != nil {
+			return nil, err
+		}
+		if err := matchesSnapshot(raw, fileMeta); err != nil {
+			return nil, errors.Wrapf(err, "verifying %s", fileName)
+		}
+	}
+
+	targets := &data.Targets{}
+	if err := verifier.Unmarshal(raw, targets, role, fileMeta.Version); err != nil {
+		return nil, errors.Wrapf(err, "verifying %s", fileName)
+	}
+	if !cached {
+		if err := withWAL(t.local, func() error {
+			return t.local.SetMeta(fileName, raw)
+		}); err != nil {
+			return nil, errors.Wrapf(err, "caching %s", fileName)
+		}
+	}
+	return targets, nil
+}
+
+// matchesSnapshot checks raw against its length, hashes and version in the trusted snapshot.
+func matchesSnapshot(raw []byte, m data.SnapshotFileMeta) error {
+	actual, err := util.GenerateSnapshotFileMeta(bytes.NewReader(raw), m.HashAlgorithms()...)
+	if err != nil {
+		return err
+	}
+	return util.SnapshotFileMetaEqual(actual, m)
+}
+
+// readRemote reads name from the remote repository, failing if it is larger than limit.
+func readRemote(get func(string) (io.ReadCloser, int64, error), name string, limit int64) ([]byte, error) {
+	rc, _, err := get(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching %s", name)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", name)
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w: %s", ErrTargetTooLarge, name)
+	}
+	return b, nil
+}
+
+// unmarshalTrusted decodes the signed part of the metadata file name from the
+// local store, which the TUF client has already verified.
+func unmarshalTrusted(meta map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := meta[name]
+	if !ok {
+		return fmt.Errorf("no trusted %s", name)
+	}
+	var s data.Signed
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return errors.Wrapf(err, "parsing trusted %s", name)
+	}
+	if err := json.Unmarshal(s.Signed, v); err != nil {
+		return errors.Wrapf(err, "parsing trusted %s", name)
+	}
+	return nil
+}
+
+// consistentSnapshot reports whether the trusted root enables consistent snapshots.
+func consistentSnapshot(meta map[string]json.RawMessage) bool {
+	root := &data.Root{}
+	if err := unmarshalTrusted(meta, "root.json", root); err != nil {
+		return false
+	}
+	return root.ConsistentSnapshot
+}
@@ -0,0 +1,235 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/theupdateframework/go-tuf/client"
+	"github.com/theupdateframework/go-tuf/data"
+	"github.com/theupdateframework/go-tuf/pkg/keys"
+	"github.com/theupdateframework/go-tuf/sign"
+	"github.com/theupdateframework/go-tuf/util"
+)
+
+// countingRemote serves metadata from a map, counting each fetch.
+type countingRemote struct {
+	meta    map[string][]byte
+	mu      sync.Mutex
+	fetches int
+}
+
+func (r *countingRemote) GetMeta(name string) (io.ReadCloser, int64, error) {
+	b, ok := r.meta[name]
+	if !ok {
+		return nil, 0, client.ErrNotFound{File: name}
+	}
+	r.mu.Lock()
+	r.fetches++
+	r.mu.Unlock()
+	return io.NopCloser(bytes.NewReader(b)), int64(len(b)), nil
+}
+
+func (r *countingRemote) GetTarget(name string) (io.ReadCloser, int64, error) {
+	return nil, 0, client.ErrNotFound{File: name}
+}
+
+func newSigner(t *testing.T) keys.Signer {
+	t.Helper()
+	k, err := keys.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func signMeta(t *testing.T, v interface{}, k keys.Signer) []byte {
+	t.Helper()
+	s, err := sign.Marshal(v, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// newDelegatingTUF returns a client whose trusted targets delegate to the role
+// "a", which is trusted for "a*" and lists a.pem and b.pem. The role's metadata
+// is only available from the remote, and is signed by the delegated key if
+// validRole is set and by some other key otherwise.
+func newDelegatingTUF(t *testing.T, validRole bool) (*TUF, *countingRemote) {
+	t.Helper()
+	rootKey, roleKey := newSigner(t), newSigner(t)
+	roleSigner := roleKey
+	if !validRole {
+		roleSigner = newSigner(t)
+	}
+
+	root := data.NewRoot()
+	root.Version = 1
+	rootPub := rootKey.PublicData()
+	for _, id := range rootPub.IDs() {
+		root.Keys[id] = rootPub
+	}
+	for _, name := range []string{"root", "targets", "snapshot", "timestamp"} {
+		root.Roles[name] = &data.Role{KeyIDs: rootPub.IDs(), Threshold: 1}
+	}
+
+	role := data.NewTargets()
+	role.Version = 1
+	for _, name := range []string{"a.pem", "b.pem"} {
+		meta, err := util.GenerateTargetFileMeta(bytes.NewReader([]byte(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		role.Targets[name] = meta
+	}
+	roleJSON := signMeta(t, role, roleSigner)
+
+	rolePub := roleKey.PublicData()
+	targets := data.NewTargets()
+	targets.Version = 1
+	targets.Delegations = &data.Delegations{
+		Keys:  map[string]*data.PublicKey{rolePub.IDs()[0]: rolePub},
+		Roles: []data.DelegatedRole{{Name: "a", KeyIDs: rolePub.IDs(), Threshold: 1, Paths: []string{"a*"}}},
+	}
+	targetsJSON := signMeta(t, targets, rootKey)
+
+	snapshot := data.NewSnapshot()
+	snapshot.Version = 1
+	for name, b := range map[string][]byte{"targets.json": targetsJSON, "a.json": roleJSON} {
+		meta, err := util.GenerateSnapshotFileMeta(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.Meta[name] = meta
+	}
+	snapshotJSON := signMeta(t, snapshot, rootKey)
+
+	timestamp := data.NewTimestamp()
+	timestamp.Version = 1
+	meta, err := util.GenerateTimestampFileMeta(bytes.NewReader(snapshotJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp.Meta["snapshot.json"] = meta
+
+	local := newSyncLocalStore()
+	for name, b := range map[string][]byte{
+		"root.json":      signMeta(t, root, rootKey),
+		"targets.json":   targetsJSON,
+		"snapshot.json":  snapshotJSON,
+		"timestamp.json": signMeta(t, timestamp, rootKey),
+	} {
+		if err := local.SetMeta(name, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The root enables consistent snapshots, so the role is fetched by version.
+	remote := &countingRemote{meta: map[string][]byte{"1.a.json": roleJSON}}
+	return &TUF{
+		client:        client.NewClient(local, remote),
+		local:         local,
+		remote:        fixedRemote(remote),
+		maxTargetSize: DefaultMaxTargetSize,
+	}, remote
+}
+
+func TestDelegatedTargets(t *testing.T) {
+	tuf, remote := newDelegatingTUF(t, true)
+
+	names, err := tuf.delegatedTargetNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b.pem is listed by the role, but outside the paths it is trusted for.
+	if want := []string{"a.pem"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("delegatedTargetNames() = %v, want %v", names, want)
+	}
+	if remote.fetches != 1 {
+		t.Errorf("fetched the role %d times, want 1", remote.fetches)
+	}
+
+	// The verified role is cached, so neither listing again nor looking up
+	// its targets fetches it again.
+	if _, err := tuf.delegatedTargetNames(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := tuf.targetMeta(context.Background(), "a.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Length != int64(len("a.pem")) {
+		t.Errorf("targetMeta(a.pem) has length %d, want %d", meta.Length, len("a.pem"))
+	}
+	if remote.fetches != 1 {
+		t.Errorf("fetched the role %d times, want 1", remote.fetches)
+	}
+
+	if _, err := tuf.targetMeta(context.Background(), "b.pem"); !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("targetMeta(b.pem) = %v, want ErrTargetNotFound", err)
+	}
+}
+
+func TestDelegatedTargetsConcurrent(t *testing.T) {
+	tuf, _ := newDelegatingTUF(t, true)
+
+	// Lookups don't wait for each other, and each stores the role it fetches.
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tuf.targetMeta(context.Background(), "a.pem")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDelegatedTargetsInvalidRole(t *testing.T) {
+	tuf, _ := newDelegatingTUF(t, false)
+
+	if _, err := tuf.delegatedTargetNames(context.Background()); err == nil {
+		t.Error("expected a role signed by the wrong key to be rejected")
+	}
+	if _, err := tuf.targetMeta(context.Background(), "a.pem"); err == nil {
+		t.Error("expected a role signed by the wrong key to be rejected")
+	}
+	meta, err := tuf.local.GetMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := meta["a.json"]; ok {
+		t.Error("expected the rejected role not to be cached")
+	}
+}
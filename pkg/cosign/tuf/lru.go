@@ -0,0 +1,137 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// lruFile records when each cached target was last written or read. It is kept
+// in the targets directory, as a JSON object from target name to time.
+const lruFile = "lru.json"
+
+// DefaultMaxCacheSizeBytes is how large the cached targets may grow unless
+// TUFOptions.MaxCacheSizeBytes says otherwise.
+const DefaultMaxCacheSizeBytes int64 = 100 << 20
+
+// readLRU returns the access times recorded in dir. A missing or unreadable
+// record is treated as empty; targets without a time fall back to their
+// modification time.
+func readLRU(dir string) map[string]time.Time {
+	lru := map[string]time.Time{}
+	b, err := os.ReadFile(filepath.Join(dir, lruFile))
+	if err != nil {
+		return lru
+	}
+	if err := json.Unmarshal(b, &lru); err != nil {
+		return map[string]time.Time{}
+	}
+	return lru
+}
+
+func writeLRU(dir string, lru map[string]time.Time) error {
+	b, err := json.Marshal(lru)
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(filepath.Join(dir, lruFile), b, 0600)
+}
+
+// trustedRootTarget is the sigstore trusted root target, which is never evicted.
+const trustedRootTarget = "trusted_root.json"
+
+// updateLRU records that target p in dir was written at now, along with the
+// access times of other targets in accessed, then evicts the least recently used
+// other targets that are not pinned until the directory holds at most maxSize
+// bytes. The caller must hold the lock on dir.
+func updateLRU(dir, p string, now time.Time, accessed map[string]time.Time, maxSize int64, pinned func(string) bool) error {
+	lru := readLRU(dir)
+	for name, t := range accessed {
+		if t.After(lru[name]) {
+			lru[name] = t
+		}
+	}
+	lru[p] = now
+	if err := evictTargets(dir, p, maxSize, lru, pinned); err != nil {
+		return errors.Wrap(err, "evicting cached targets")
+	}
+	return writeLRU(dir, lru)
+}
+
+// evictTargets removes targets from dir, least recently used first and never keep
+// or a pinned target, until the files in dir total at most maxSize bytes. Evicted
+// targets are dropped from lru.
+func evictTargets(dir, keep string, maxSize int64, lru map[string]time.Time, pinned func(string) bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	type target struct {
+		name     string
+		size     int64
+		lastUsed time.Time
+	}
+	var total int64
+	var targets []target
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		total += info.Size()
+		// Skip the lock, the access record and temporary files.
+		if name := e.Name(); name != keep && name != lruFile && !strings.HasPrefix(name, ".") && !pinned(name) {
+			lastUsed, ok := lru[name]
+			if !ok {
+				lastUsed = info.ModTime()
+			}
+			targets = append(targets, target{name: name, size: info.Size(), lastUsed: lastUsed})
+		}
+	}
+	for name := range lru {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			delete(lru, name)
+		}
+	}
+	if total <= maxSize {
+		return nil
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].lastUsed.Before(targets[j].lastUsed) })
+	for _, t := range targets {
+		if total <= maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, t.name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		delete(lru, t.name)
+		total -= t.size
+	}
+	return nil
+}
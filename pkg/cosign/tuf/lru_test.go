@@ -0,0 +1,128 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func notPinned(string) bool { return false }
+
+func TestUpdateLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	td := t.TempDir()
+	now := time.Unix(1600000000, 0)
+	for i, name := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(td, name), make([]byte, 10), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := updateLRU(td, name, now.Add(time.Duration(i)*time.Minute), nil, 1<<20, notPinned); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The access record counts towards the size, so this only fits two targets.
+	if err := os.WriteFile(filepath.Join(td, "d"), make([]byte, 10), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(td, lruFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reading "a" since the last write makes "b" the least recently used.
+	accessed := map[string]time.Time{"a": now.Add(time.Hour)}
+	if err := updateLRU(td, "d", now.Add(2*time.Hour), accessed, info.Size()+20, notPinned); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"a": true, "b": false, "c": false, "d": true} {
+		if _, err := os.Stat(filepath.Join(td, name)); (err == nil) != want {
+			t.Errorf("%s present = %v, want %v", name, err == nil, want)
+		}
+	}
+	lru := readLRU(td)
+	if _, ok := lru["b"]; ok {
+		t.Error("evicted target b is still recorded")
+	}
+	if !lru["a"].Equal(now.Add(time.Hour)) {
+		t.Errorf("lru[a] = %v, want %v", lru["a"], now.Add(time.Hour))
+	}
+	if !lru["d"].Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("lru[d] = %v, want %v", lru["d"], now.Add(2*time.Hour))
+	}
+}
+
+func TestUpdateLRUKeepsNewTarget(t *testing.T) {
+	td := t.TempDir()
+	if err := os.WriteFile(filepath.Join(td, "big"), make([]byte, 100), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A target larger than the limit on its own is still kept.
+	if err := updateLRU(td, "big", time.Now(), nil, 10, notPinned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(td, "big")); err != nil {
+		t.Errorf("target just written was evicted: %v", err)
+	}
+}
+
+func TestDiskCacheKeepsPinnedTargets(t *testing.T) {
+	td := t.TempDir()
+	d := &diskCache{base: td, maxSize: 1, pinned: func(name string) bool { return name == "rekor.pub" }}
+	f := &file{base: td, setImpl: d}
+	for _, name := range []string{trustedRootTarget, "rekor.pub", "delegated.pem", "new.pem"} {
+		if err := f.Set(name, make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]bool{trustedRootTarget: true, "rekor.pub": true, "delegated.pem": false, "new.pem": true} {
+		if _, err := os.Stat(filepath.Join(td, name)); (err == nil) != want {
+			t.Errorf("%s present = %v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestDiskCacheGetDoesNotWrite(t *testing.T) {
+	td := t.TempDir()
+	f := &file{base: td, setImpl: &diskCache{base: td, maxSize: 1 << 20}}
+	if err := f.Set("a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	written := readLRU(td)["a"]
+	before, err := os.ReadFile(filepath.Join(td, lruFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(filepath.Join(td, lruFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("reading a target rewrote the access record")
+	}
+
+	// The read is recorded with the next write.
+	if err := f.Set("b", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if !readLRU(td)["a"].After(written) {
+		t.Error("expected the read of a to be recorded")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/theupdateframework/go-tuf/client"
+)
+
+// tufMetrics are the metrics recorded by a client created WithMetricsRegisterer.
+// All of its methods are no-ops on a nil *tufMetrics.
+type tufMetrics struct {
+	cacheHits     prometheus.Counter
+	cacheMisses   prometheus.Counter
+	fetchDuration *prometheus.HistogramVec
+}
+
+func newTUFMetrics(r prometheus.Registerer) (*tufMetrics, error) {
+	m := &tufMetrics{}
+	var ok bool
+	c, err := register(r, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tuf_cache_hits_total",
+		Help: "Number of TUF targets read from the local cache.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if m.cacheHits, ok = c.(prometheus.Counter); !ok {
+		return nil, errors.Errorf("tuf_cache_hits_total is already registered as a %T", c)
+	}
+	c, err = register(r, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tuf_cache_misses_total",
+		Help: "Number of TUF targets missing from the local cache and fetched again.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if m.cacheMisses, ok = c.(prometheus.Counter); !ok {
+		return nil, errors.Errorf("tuf_cache_misses_total is already registered as a %T", c)
+	}
+	c, err = register(r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tuf_remote_fetch_duration_seconds",
+		Help:    "Time taken to fetch TUF metadata and targets from the remote repository.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind"}))
+	if err != nil {
+		return nil, err
+	}
+	if m.fetchDuration, ok = c.(*prometheus.HistogramVec); !ok {
+		return nil, errors.Errorf("tuf_remote_fetch_duration_seconds is already registered as a %T", c)
+	}
+	return m, nil
+}
+
+// register registers c with r, returning the collector already registered in
+// its place if there is one, so that several clients can share a registerer.
+func register(r prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	if err := r.Register(c); err != nil {
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			return nil, err
+		}
+		return are.ExistingCollector, nil
+	}
+	return c, nil
+}
+
+func (m *tufMetrics) cacheHit() {
+	if m != nil {
+		m.cacheHits.Inc()
+	}
+}
+
+func (m *tufMetrics) cacheMiss() {
+	if m != nil {
+		m.cacheMisses.Inc()
+	}
+}
+
+// instrument returns remote, timing its fetches if metrics are enabled.
+func (m *tufMetrics) instrument(remote client.RemoteStore) client.RemoteStore {
+	if m == nil {
+		return remote
+	}
+	return &instrumentedRemoteStore{RemoteStore: remote, metrics: m}
+}
+
+// instrumentedRemoteStore records how long each fetch takes, up to the point
+// the response has been read and closed.
+type instrumentedRemoteStore struct {
+	client.RemoteStore
+	metrics *tufMetrics
+}
+
+func (s *instrumentedRemoteStore) GetMeta(name string) (io.ReadCloser, int64, error) {
+	start := time.Now()
+	rc, size, err := s.RemoteStore.GetMeta(name)
+	return s.timed("metadata", start, rc, err), size, err
+}
+
+func (s *instrumentedRemoteStore) GetTarget(path string) (io.ReadCloser, int64, error) {
+	start := time.Now()
+	rc, size, err := s.RemoteStore.GetTarget(path)
+	return s.timed("target", start, rc, err), size, err
+}
+
+func (s *instrumentedRemoteStore) timed(kind string, start time.Time, rc io.ReadCloser, err error) io.ReadCloser {
+	observe := func() {
+		s.metrics.fetchDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	}
+	if err != nil {
+		observe()
+		return rc
+	}
+	return &timedReadCloser{ReadCloser: rc, observe: observe}
+}
+
+type timedReadCloser struct {
+	io.ReadCloser
+	once    sync.Once
+	observe func()
+}
+
+func (t *timedReadCloser) Close() error {
+	t.once.Do(t.observe)
+	return t.ReadCloser.Close()
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/theupdateframework/go-tuf/client"
+)
+
+type fakeRemoteStore struct{}
+
+func (fakeRemoteStore) GetMeta(name string) (io.ReadCloser, int64, error) {
+	return io.NopCloser(strings.NewReader("{}")), 2, nil
+}
+
+func (fakeRemoteStore) GetTarget(path string) (io.ReadCloser, int64, error) {
+	return nil, 0, client.ErrNotFound{File: path}
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	o, err := makeClientOptions(WithMetricsRegisterer(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := o.metrics
+	if m == nil {
+		t.Fatal("WithMetricsRegisterer did not enable metrics")
+	}
+	// A second client on the same registerer shares the metrics.
+	other, err := makeClientOptions(WithMetricsRegisterer(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.metrics.cacheHits != m.cacheHits {
+		t.Error("clients sharing a registerer should share metrics")
+	}
+
+	m.cacheHit()
+	m.cacheHit()
+	m.cacheMiss()
+	if got := testutil.ToFloat64(m.cacheHits); got != 2 {
+		t.Errorf("tuf_cache_hits_total = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.cacheMisses); got != 1 {
+		t.Errorf("tuf_cache_misses_total = %v, want 1", got)
+	}
+
+	remote := m.instrument(fakeRemoteStore{})
+	rc, _, err := remote.GetMeta("root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(m.fetchDuration); n != 0 {
+		t.Errorf("observed %d fetches before the response was closed, want 0", n)
+	}
+	rc.Close()
+	rc.Close()
+	if _, _, err := remote.GetTarget("missing"); err == nil {
+		t.Fatal("expected an error for a missing target")
+	}
+	if n := testutil.CollectAndCount(m.fetchDuration); n != 2 {
+		t.Errorf("got %d fetch duration series, want metadata and target", n)
+	}
+
+	// Without a registerer, metrics are disabled and the remote is not wrapped.
+	var disabled *tufMetrics
+	disabled.cacheHit()
+	disabled.cacheMiss()
+	if _, ok := disabled.instrument(fakeRemoteStore{}).(fakeRemoteStore); !ok {
+		t.Error("instrument() should return the remote unchanged when metrics are disabled")
+	}
+}
+
+func TestMetricsConflict(t *testing.T) {
+	// Another collector under one of the names must fail client creation, not panic.
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "tuf_cache_hits_total", Help: "Not a counter."}))
+	if _, err := makeClientOptions(WithMetricsRegisterer(reg)); err == nil {
+		t.Error("expected an error registering over a different collector")
+	}
+	if _, err := NewFromConfig(TUFConfig{
+		Root:    t.TempDir(),
+		Mirror:  "http://127.0.0.1:0",
+		Options: &TUFOptions{ClientOptions: []ClientOption{WithMetricsRegisterer(reg)}},
+	}); err == nil {
+		t.Error("expected NewFromConfig to fail")
+	}
+}
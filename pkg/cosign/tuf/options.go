@@ -0,0 +1,225 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/theupdateframework/go-tuf/client"
+	"google.golang.org/api/option"
+)
+
+// ClientOption configures how the TUF client talks to the remote repository.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	httpTimeout time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	inMemory    bool
+	metrics     *tufMetrics
+	// err is the first error an option ran into, returned by makeClientOptions.
+	err error
+}
+
+// WithHTTPTimeout bounds the time spent on each request to the remote
+// repository, including any retries.
+func WithHTTPTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.httpTimeout = d
+	}
+}
+
+// WithRetryPolicy retries failed requests to the remote repository up to
+// maxAttempts times, waiting backoff before the first retry and doubling the
+// wait after each subsequent failure.
+func WithRetryPolicy(maxAttempts int, backoff time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.maxAttempts = maxAttempts
+		o.backoff = backoff
+	}
+}
+
+// WithInMemoryStore keeps the TUF metadata and targets in memory instead of in
+// the cache under TUF_ROOT, which is then neither read nor written, as if
+// SIGSTORE_NO_CACHE were set. It only affects NewFromEnv, NewFromConfig and
+// their variants; use TUF.DumpStore to inspect what was stored.
+func WithInMemoryStore() ClientOption {
+	return func(o *clientOptions) {
+		o.inMemory = true
+	}
+}
+
+// WithMetricsRegisterer records cache hits and misses and the duration of
+// remote fetches as Prometheus metrics registered with r: tuf_cache_hits_total,
+// tuf_cache_misses_total and tuf_remote_fetch_duration_seconds. Clients sharing
+// a registerer share these metrics. Creating the client fails if r rejects them,
+// for instance because another collector is registered under one of the names.
+func WithMetricsRegisterer(r prometheus.Registerer) ClientOption {
+	return func(o *clientOptions) {
+		m, err := newTUFMetrics(r)
+		if err != nil {
+			if o.err == nil {
+				o.err = errors.Wrap(err, "registering TUF metrics")
+			}
+			return
+		}
+		o.metrics = m
+	}
+}
+
+func makeClientOptions(opts ...ClientOption) (*clientOptions, error) {
+	o := &clientOptions{maxAttempts: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	return o, nil
+}
+
+// httpClient returns the HTTP client to use for remote fetches, or nil if the
+// defaults of the underlying remote store should be kept.
+func (o *clientOptions) httpClient() *http.Client {
+	if o.httpTimeout <= 0 && o.maxAttempts <= 1 {
+		return nil
+	}
+	var transport http.RoundTripper = http.DefaultTransport
+	if o.maxAttempts > 1 {
+		transport = &retryTransport{
+			base:        transport,
+			maxAttempts: o.maxAttempts,
+			backoff:     o.backoff,
+		}
+	}
+	return &http.Client{
+		Timeout:   o.httpTimeout,
+		Transport: transport,
+	}
+}
+
+// remoteSource returns a remote store whose fetches are bound to ctx. The
+// go-tuf client fetches through a RemoteStore, whose methods take no context, so
+// each TUF call that may fetch uses a client over a remote store of its own.
+type remoteSource func(ctx context.Context) client.RemoteStore
+
+// fixedRemote returns a remoteSource that always returns remote.
+func fixedRemote(remote client.RemoteStore) remoteSource {
+	if remote == nil {
+		return nil
+	}
+	return func(context.Context) client.RemoteStore { return remote }
+}
+
+// remoteFromMirror returns a remoteSource for a GCS bucket name or an HTTP(S)
+// base URL.
+func remoteFromMirror(ctx context.Context, mirror string, o *clientOptions) (remoteSource, error) {
+	hc := o.httpClient()
+	if _, parseErr := url.ParseRequestURI(mirror); parseErr == nil {
+		// Check the mirror here, so that creating a store for a request can't fail.
+		if _, err := client.HTTPRemoteStore(mirror, nil, nil); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) client.RemoteStore {
+			remote, _ := client.HTTPRemoteStore(mirror, nil, contextHTTPClient(ctx, hc))
+			return o.metrics.instrument(remote)
+		}, nil
+	}
+
+	var gcsClient *storage.Client
+	if hc != nil {
+		var err error
+		gcsClient, err = storage.NewClient(ctx, option.WithHTTPClient(hc))
+		if err != nil {
+			return nil, err
+		}
+	}
+	remote, err := GcsRemoteStore(ctx, mirror, nil, gcsClient)
+	if err != nil {
+		return nil, err
+	}
+	gcs := remote.(*gcsRemoteStore)
+	return func(ctx context.Context) client.RemoteStore {
+		bound := *gcs
+		bound.ctx = ctx
+		return o.metrics.instrument(&bound)
+	}, nil
+}
+
+// contextHTTPClient returns a copy of hc (or of the default client, if hc is
+// nil) whose requests are bound to ctx. The HTTP remote store issues its
+// requests without a context, so this is what lets cancelling ctx abort a fetch
+// that is in flight.
+func contextHTTPClient(ctx context.Context, hc *http.Client) *http.Client {
+	c := &http.Client{}
+	if hc != nil {
+		*c = *hc
+	}
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.Transport = &contextTransport{base: base, ctx: ctx}
+	return c
+}
+
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (c *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.base.RoundTrip(req.WithContext(c.ctx))
+}
+
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func (r *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := r.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := r.base.RoundTrip(req)
+		if attempt >= r.maxAttempts || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,201 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInitializeTimeout(t *testing.T) {
+	t.Setenv("TUF_ROOT", t.TempDir())
+
+	stop := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-stop:
+		case <-r.Context().Done():
+		}
+	}))
+	defer s.Close()
+	defer close(stop)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Initialize(context.Background(), s.URL, nil, WithHTTPTimeout(100*time.Millisecond))
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("expected error from stalled remote, got nil")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Initialize blocked on a stalled remote")
+	}
+}
+
+func TestInitializeContextCancel(t *testing.T) {
+	t.Setenv("TUF_ROOT", t.TempDir())
+
+	stop := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-stop:
+		case <-r.Context().Done():
+		}
+	}))
+	defer s.Close()
+	defer close(stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Initialize(ctx, s.URL, nil)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("expected error after cancelling the context, got nil")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Initialize ignored context cancellation")
+	}
+}
+
+func TestRemoteContextPerCall(t *testing.T) {
+	stop := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stall.json" {
+			select {
+			case <-stop:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte("{}")) //nolint: errcheck
+	}))
+	defer s.Close()
+	defer close(stop)
+
+	o, err := makeClientOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := remoteFromMirror(context.Background(), s.URL, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fetch is aborted when the context of the call making it is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := remote(ctx).GetMeta("stall.json")
+		errCh <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// Other calls fetch while it is in flight, and are not bound to its context.
+	rc, _, err := remote(context.Background()).GetMeta("ok.json")
+	if err != nil {
+		t.Fatalf("fetch alongside the stalled call returned: %v", err)
+	}
+	rc.Close()
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("expected error after cancelling the context, got nil")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("fetch ignored context cancellation")
+	}
+
+	rc, _, err = remote(context.Background()).GetMeta("ok.json")
+	if err != nil {
+		t.Fatalf("fetch after the cancelled call returned: %v", err)
+	}
+	rc.Close()
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		wantStatus  int
+		wantCalls   int32
+	}{{
+		name:        "retries until success",
+		maxAttempts: 5,
+		wantStatus:  http.StatusOK,
+		wantCalls:   3,
+	}, {
+		name:        "gives up after max attempts",
+		maxAttempts: 2,
+		wantStatus:  http.StatusServiceUnavailable,
+		wantCalls:   2,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			o, err := makeClientOptions(WithRetryPolicy(tc.maxAttempts, time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+			hc := o.httpClient()
+			resp, err := hc.Get(s.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.wantStatus)
+			}
+			if got := atomic.LoadInt32(&calls); got != tc.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDefaultClientOptions(t *testing.T) {
+	o, err := makeClientOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hc := o.httpClient(); hc != nil {
+		t.Errorf("expected no custom http client by default, got %v", hc)
+	}
+}
@@ -65,12 +65,13 @@ func (h *gcsRemoteStore) GetTarget(name string) (io.ReadCloser, int64, error) {
 }
 
 func (h *gcsRemoteStore) get(s string) (io.ReadCloser, int64, error) {
+	ctx := h.ctx
 	obj := h.client.Bucket(h.bucket).Object(s)
-	attrs, err := obj.Attrs(h.ctx)
+	attrs, err := obj.Attrs(ctx)
 	if err != nil {
 		return nil, 0, client.ErrNotFound{File: s}
 	}
-	rc, err := obj.NewReader(h.ctx)
+	rc, err := obj.NewReader(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
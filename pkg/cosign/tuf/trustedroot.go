@@ -0,0 +1,72 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Target names used before targets carried sigstore custom metadata.
+var (
+	fulcioFallbacks = []string{"fulcio.crt.pem", "fulcio_v1.crt.pem"}
+	rekorFallbacks  = []string{"rekor.pub"}
+	ctfeFallbacks   = []string{"ctfe.pub"}
+)
+
+// TrustedRoot is the set of sigstore trust material distributed through TUF.
+// It mirrors the shape of the TrustedRoot message from sigstore/protobuf-specs.
+type TrustedRoot struct {
+	// CertificateAuthorities are the PEM encoded Fulcio root certificates.
+	CertificateAuthorities []TargetFile
+	// Tlogs are the PEM encoded Rekor public keys.
+	Tlogs []TargetFile
+	// Ctlogs are the PEM encoded certificate transparency log public keys.
+	Ctlogs []TargetFile
+}
+
+// GetTrustedRoot returns the Fulcio, Rekor and CTFE targets of the TUF repository
+// configured by the environment, grouped by usage.
+func GetTrustedRoot(ctx context.Context) (*TrustedRoot, error) {
+	t, err := NewFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+	return t.TrustedRoot()
+}
+
+// TrustedRoot groups the Fulcio, Rekor and CTFE targets of t by usage.
+func (t *TUF) TrustedRoot() (*TrustedRoot, error) {
+	cas, err := t.GetTargetsByMeta(Fulcio, fulcioFallbacks)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting fulcio targets")
+	}
+	tlogs, err := t.GetTargetsByMeta(Rekor, rekorFallbacks)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting rekor targets")
+	}
+	ctlogs, err := t.GetTargetsByMeta(CTFE, ctfeFallbacks)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting ctfe targets")
+	}
+	return &TrustedRoot{
+		CertificateAuthorities: cas,
+		Tlogs:                  tlogs,
+		Ctlogs:                 ctlogs,
+	}, nil
+}
@@ -0,0 +1,185 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/theupdateframework/go-tuf/client"
+)
+
+// walFile is the write-ahead log of metadata updates, kept in the cache root
+// while an update is in progress. It holds one JSON walRecord per line.
+const walFile = "wal.json"
+
+// walRecord names a metadata file about to be written, along with its previous
+// contents, if there were any.
+type walRecord struct {
+	Meta string          `json:"meta"`
+	Prev json.RawMessage `json:"prev,omitempty"`
+}
+
+// walStore wraps the on-disk local store and logs each metadata file and its
+// previous contents before writing it, so that an update interrupted part way
+// through can be rolled back by recoverWAL instead of leaving a mix of old and
+// new metadata.
+//
+// Writes are grouped into transactions with begin and commit. Transactions may
+// overlap, for instance when a delegated role is loaded while an update is in
+// progress, so the log is only removed once every open transaction committed.
+type walStore struct {
+	client.LocalStore
+	path string
+
+	mu   sync.Mutex
+	open int
+}
+
+func newWALStore(local client.LocalStore, cacheRoot string) *walStore {
+	return &walStore{LocalStore: local, path: filepath.Join(cacheRoot, walFile)}
+}
+
+// SetMeta implements client.LocalStore
+func (w *walStore) SetMeta(name string, meta json.RawMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	trusted, err := w.LocalStore.GetMeta()
+	if err != nil {
+		return errors.Wrap(err, "getting trusted meta")
+	}
+	if err := w.logIntent(walRecord{Meta: name, Prev: trusted[name]}); err != nil {
+		return errors.Wrap(err, "writing TUF write-ahead log")
+	}
+	return w.LocalStore.SetMeta(name, meta)
+}
+
+func (w *walStore) logIntent(r walRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// begin starts a transaction, which must be ended with commit once every
+// metadata file it writes has been written.
+func (w *walStore) begin() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.open++
+}
+
+// commit ends a transaction started with begin, and removes the log when no
+// other transaction is still open.
+func (w *walStore) commit() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.open > 0 {
+		w.open--
+	}
+	if w.open > 0 {
+		return nil
+	}
+	if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing TUF write-ahead log")
+	}
+	return nil
+}
+
+// withWAL runs fn, which writes to local, in a transaction of its own if local
+// is a walStore. Each file fn writes must be valid on its own, as it is kept even
+// if fn fails.
+func withWAL(local client.LocalStore, fn func() error) error {
+	wal, ok := local.(*walStore)
+	if !ok {
+		return fn()
+	}
+	wal.begin()
+	err := fn()
+	if commitErr := wal.commit(); err == nil {
+		err = commitErr
+	}
+	return err
+}
+
+// recoverWAL rolls back an update to the cache at cacheRoot that was interrupted
+// before it committed, by restoring every metadata file named in its log to the
+// contents it had before the update, or deleting it if it had none. In
+// particular the trusted root.json is never lost, so the next update can verify
+// the files it fetches afresh.
+func recoverWAL(local client.LocalStore, cacheRoot string) error {
+	path := filepath.Join(cacheRoot, walFile)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "opening TUF write-ahead log")
+	}
+	defer f.Close()
+
+	// Only the first record of each file holds its contents from before the update.
+	var records []walRecord
+	seen := map[string]bool{}
+	br := bufio.NewReader(f)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, "reading TUF write-ahead log")
+		}
+		var r walRecord
+		// The last record may be torn if the process died while writing it, and
+		// then names a file that was never written.
+		if jsonErr := json.Unmarshal(line, &r); jsonErr == nil && r.Meta != "" && !seen[r.Meta] {
+			seen[r.Meta] = true
+			records = append(records, r)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	for _, r := range records {
+		if r.Prev != nil {
+			err = local.SetMeta(r.Meta, r.Prev)
+		} else {
+			err = local.DeleteMeta(r.Meta)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "rolling back %s", r.Meta)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return errors.Wrap(err, "removing TUF write-ahead log")
+	}
+	return nil
+}
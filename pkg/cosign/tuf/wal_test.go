@@ -0,0 +1,116 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theupdateframework/go-tuf/client"
+)
+
+func TestWALRecovery(t *testing.T) {
+	td := t.TempDir()
+	local := client.MemoryLocalStore()
+	for _, name := range []string{"root.json", "targets.json", "snapshot.json", "timestamp.json"} {
+		if err := local.SetMeta(name, json.RawMessage(`{"old":true}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An update that rotated the root, wrote the timestamp and snapshot and a
+	// new delegated role, then died.
+	wal := newWALStore(local, td)
+	wal.begin()
+	for _, name := range []string{"root.json", "timestamp.json", "snapshot.json", "snapshot.json", "role1.json"} {
+		if err := wal.SetMeta(name, json.RawMessage(`{"new":true}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A torn final record is ignored.
+	f, err := os.OpenFile(filepath.Join(td, walFile), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"meta":"targ`) //nolint: errcheck
+	f.Close()
+
+	if err := recoverWAL(local, td); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := local.GetMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"root.json", "targets.json", "snapshot.json", "timestamp.json"} {
+		if got := string(meta[name]); got != `{"old":true}` {
+			t.Errorf("%s = %q, want the contents from before the update", name, got)
+		}
+	}
+	if _, ok := meta["role1.json"]; ok {
+		t.Error("expected role1.json, which was new, to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(td, walFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the log to be removed, got %v", err)
+	}
+
+	// A committed update leaves nothing to roll back.
+	wal = newWALStore(local, td)
+	wal.begin()
+	if err := wal.SetMeta("timestamp.json", json.RawMessage(`{"new":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := recoverWAL(local, td); err != nil {
+		t.Fatal(err)
+	}
+	if meta, _ := local.GetMeta(); string(meta["timestamp.json"]) != `{"new":true}` {
+		t.Error("expected committed timestamp.json to be kept")
+	}
+}
+
+func TestWALOverlappingTransactions(t *testing.T) {
+	td := t.TempDir()
+	local := client.MemoryLocalStore()
+	wal := newWALStore(local, td)
+
+	// A delegated role loaded while an update is in progress commits without
+	// dropping the update's log.
+	wal.begin()
+	if err := wal.SetMeta("timestamp.json", json.RawMessage(`{"new":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	wal.begin()
+	if err := wal.SetMeta("role1.json", json.RawMessage(`{"new":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(td, walFile)); err != nil {
+		t.Fatalf("expected the log to be kept while the update is open, got %v", err)
+	}
+	if err := wal.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(td, walFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the log to be removed, got %v", err)
+	}
+}
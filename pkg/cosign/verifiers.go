@@ -16,15 +16,14 @@
 package cosign
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/pkg/errors"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 
+	"github.com/sigstore/cosign/pkg/cosign/attestation"
 	"github.com/sigstore/cosign/pkg/oci"
 	"github.com/sigstore/sigstore/pkg/signature/payload"
 )
@@ -66,15 +65,10 @@ func IntotoSubjectClaimVerifier(sig oci.Signature, imageDigest v1.Hash, _ map[st
 	if err := json.Unmarshal(p, &e); err != nil {
 		return err
 	}
-	stBytes, err := base64.StdEncoding.DecodeString(e.Payload)
+	st, err := attestation.ParseStatement(&e)
 	if err != nil {
 		return err
 	}
-
-	st := in_toto.Statement{}
-	if err := json.Unmarshal(stBytes, &st); err != nil {
-		return err
-	}
 	for _, subj := range st.StatementHeader.Subject {
 		dgst, ok := subj.Digest["sha256"]
 		if !ok {
@@ -21,26 +21,34 @@ import (
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/sigstore/cosign/pkg/cosign/attestation"
 	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/cosign/rego"
+	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
+	"github.com/sigstore/cosign/pkg/cosign/transparency"
 
 	"github.com/sigstore/cosign/pkg/blob"
 	"github.com/sigstore/cosign/pkg/oci/static"
 	"github.com/sigstore/cosign/pkg/types"
 
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/go-openapi/runtime"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/pkg/errors"
 
 	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/cosign/pkg/cosign/tsa"
 	"github.com/sigstore/cosign/pkg/oci"
 	"github.com/sigstore/cosign/pkg/oci/layout"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
@@ -53,21 +61,39 @@ import (
 	sigPayload "github.com/sigstore/sigstore/pkg/signature/payload"
 )
 
+// oidcIssuerOID is the certificate extension in which Fulcio records the OIDC issuer.
+var oidcIssuerOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
 // CheckOpts are the options for checking signatures.
 type CheckOpts struct {
 	// RegistryClientOpts are the options for interacting with the container registry.
 	RegistryClientOpts []ociremote.Option
+	// Puller, if set, fetches images and signatures in place of the container registry.
+	Puller cremote.Puller
 
 	// Annotations optionally specifies image signature annotations to verify.
 	Annotations map[string]interface{}
+	// RequiredSignatureAnnotations, if set, are annotations each signature layer must
+	// carry, with these values, for the signature to be valid.
+	RequiredSignatureAnnotations map[string]string
 	// ClaimVerifier, if provided, verifies claims present in the oci.Signature.
 	ClaimVerifier func(sig oci.Signature, imageDigest v1.Hash, annotations map[string]interface{}) error
 
 	// RekorClient, if set, is used to use to verify signatures and public keys.
 	RekorClient *client.Rekor
+	// Offline, if set, requires each signature to carry a Rekor bundle that verifies
+	// against the locally trusted Rekor public key, and never contacts Rekor.
+	Offline bool
+	// RekorPubKeys are the Rekor public keys bundles are verified against. If empty,
+	// the key is retrieved with GetRekorPub.
+	RekorPubKeys []*ecdsa.PublicKey
 
 	// SigVerifier is used to verify signatures.
 	SigVerifier signature.Verifier
+	// AdditionalSigVerifiers are further keys, besides SigVerifier, that image
+	// signatures may verify with. Each key counts as one signer toward
+	// SignatureThreshold.
+	AdditionalSigVerifiers []signature.Verifier
 	// PKOpts are the options provided to `SigVerifier.PublicKey()`.
 	PKOpts []signature.PublicKeyOption
 
@@ -75,13 +101,115 @@ type CheckOpts struct {
 	RootCerts *x509.CertPool
 	// CertEmail is the email expected for a certificate to be valid. The empty string means any certificate can be valid.
 	CertEmail string
+	// OIDCIssuerRegexp, if set, must match the OIDC issuer recorded in a certificate for it to be valid.
+	OIDCIssuerRegexp *regexp.Regexp
+	// CertIdentityRegexp, if set, must match one of the email or URI subject alternative
+	// names of a certificate for it to be valid.
+	CertIdentityRegexp *regexp.Regexp
+	// VerifySCT, if set, requires the SCTs embedded in a certificate to verify against a
+	// trusted CT log key. The command line enables it whenever certificates are verified.
+	VerifySCT bool
+	// CTLogPubKeys are the CT log public keys SCTs are verified against. If empty, they
+	// are retrieved with GetCTLogPubs.
+	CTLogPubKeys []crypto.PublicKey
 
 	// SignatureRef is the reference to the signature file
 	SignatureRef string
+
+	// PredicateType, if set, is the predicate type an attestation must have to be valid.
+	PredicateType string
+
+	// RegoPolicy, if set, is a Rego policy in package cosign that the in-toto statement of
+	// an attestation must satisfy, by making data.cosign.allow true, for it to be valid.
+	RegoPolicy string
+
+	// TSACerts, if set, are the roots an RFC 3161 timestamp on each signature must verify against.
+	TSACerts *x509.CertPool
+
+	// FirstMatch, if set, stops verification at the first signature that verifies,
+	// rather than returning every signature that does.
+	FirstMatch bool
+
+	// SignatureThreshold is how many distinct signers must have an image
+	// signature that verifies for verification to succeed. Each key counts as one
+	// signer, as does each certificate identity, that is its subject alternative
+	// names and OIDC issuer. Zero means one.
+	SignatureThreshold int
+
+	// ContainerIdentity, if set, is the image each signature's payload must name
+	// under ContainerIdentityKey, as signed by cosign sign --sign-container-identity.
+	ContainerIdentity string
+
+	// VerifyHook, if set, is called with the outcome of each check made on each
+	// signature or attestation, named by one of the VerifyStep constants. detail
+	// is the reason a check failed, and empty for checks that passed.
+	VerifyHook func(step string, passed bool, detail string)
+}
+
+// The verification steps reported to CheckOpts.VerifyHook.
+const (
+	VerifyStepSignatureAnnotations = "signature_annotations"
+	VerifyStepCertificateChain     = "certificate_chain"
+	VerifyStepSCT                  = "sct"
+	VerifyStepCertificateIdentity  = "certificate_identity"
+	VerifyStepOIDCIssuer           = "oidc_issuer"
+	VerifyStepSignature            = "signature"
+	VerifyStepTimestamp            = "rfc3161_timestamp"
+	VerifyStepClaims               = "claims"
+	VerifyStepContainerIdentity    = "container_identity"
+	VerifyStepRegoPolicy           = "rego_policy"
+	VerifyStepRekorBundle          = "rekor_bundle"
+	VerifyStepRekorInclusion       = "rekor_inclusion"
+	VerifyStepCertificateExpiry    = "certificate_expiry"
+)
+
+// report passes the outcome of step to co.VerifyHook, if set, and returns err.
+func (co *CheckOpts) report(step string, err error) error {
+	if co.VerifyHook != nil {
+		detail := ""
+		if err != nil {
+			detail = err.Error()
+		}
+		co.VerifyHook(step, err == nil, detail)
+	}
+	return err
 }
 
-func getSignedEntity(signedImgRef name.Reference, regClientOpts []ociremote.Option) (oci.SignedEntity, v1.Hash, error) {
-	se, err := ociremote.SignedEntity(signedImgRef, regClientOpts...)
+// reportBundle reports the outcome of verifyBundle for sig. A certificate that
+// was not valid when the entry was made fails the certificate_expiry step,
+// rather than rekor_bundle; nothing is reported if sig has no bundle.
+func (co *CheckOpts) reportBundle(sig oci.Signature, verified bool, err error) {
+	switch {
+	case errors.Is(err, ErrCertExpired) || errors.Is(err, ErrCertNotYetValid):
+		_ = co.report(VerifyStepCertificateExpiry, err)
+	case err != nil:
+		_ = co.report(VerifyStepRekorBundle, err)
+	case verified:
+		_ = co.report(VerifyStepRekorBundle, nil)
+		if cert, _ := sig.Cert(); cert != nil {
+			_ = co.report(VerifyStepCertificateExpiry, nil)
+		}
+	}
+}
+
+// threshold returns the number of distinct signers whose signatures must verify.
+func (co *CheckOpts) threshold() int {
+	if co.SignatureThreshold < 1 {
+		return 1
+	}
+	return co.SignatureThreshold
+}
+
+// puller returns the Puller to fetch images and signatures with.
+func (co *CheckOpts) puller() cremote.Puller {
+	if co.Puller != nil {
+		return co.Puller
+	}
+	return cremote.Registry
+}
+
+func getSignedEntity(signedImgRef name.Reference, co *CheckOpts) (oci.SignedEntity, v1.Hash, error) {
+	se, err := co.puller().SignedEntity(signedImgRef, co.RegistryClientOpts...)
 	if err != nil {
 		return nil, v1.Hash{}, err
 	}
@@ -136,16 +264,27 @@ func verifyOCIAttestation(_ context.Context, verifier signature.Verifier, att pa
 	return err
 }
 
-func validateAndUnpackCert(cert *x509.Certificate, co *CheckOpts) (signature.Verifier, error) {
-	verifier, err := signature.LoadECDSAVerifier(cert.PublicKey.(*ecdsa.PublicKey), crypto.SHA256)
+func validateAndUnpackCert(ctx context.Context, cert *x509.Certificate, co *CheckOpts) (signature.Verifier, error) {
+	verifier, err := signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid certificate found on signature")
 	}
 
 	// Now verify the cert, then the signature.
-	if err := TrustedCert(cert, co.RootCerts); err != nil {
+	chains, err := trustedChains(cert, co.RootCerts)
+	if err := co.report(VerifyStepCertificateChain, err); err != nil {
 		return nil, err
 	}
+	if co.VerifySCT {
+		// A chain of one means cert is itself a trusted root.
+		issuer := chains[0][0]
+		if len(chains[0]) > 1 {
+			issuer = chains[0][1]
+		}
+		if err := co.report(VerifyStepSCT, VerifyEmbeddedSCT(ctx, cert, issuer, co.CTLogPubKeys)); err != nil {
+			return nil, err
+		}
+	}
 	if co.CertEmail != "" {
 		emailVerified := false
 		for _, em := range cert.EmailAddresses {
@@ -155,13 +294,127 @@ func validateAndUnpackCert(cert *x509.Certificate, co *CheckOpts) (signature.Ver
 			}
 		}
 		if !emailVerified {
-			return nil, errors.New("expected email not found in certificate")
+			return nil, co.report(VerifyStepCertificateIdentity, fmt.Errorf("%w: expected email not found in certificate", ErrIdentityMismatch))
+		}
+	}
+	if co.OIDCIssuerRegexp != nil {
+		issuer := certOIDCIssuer(cert)
+		if !co.OIDCIssuerRegexp.MatchString(issuer) {
+			return nil, co.report(VerifyStepOIDCIssuer, fmt.Errorf("%w: OIDC issuer %q in certificate does not match %q", ErrIdentityMismatch, issuer, co.OIDCIssuerRegexp))
 		}
+		_ = co.report(VerifyStepOIDCIssuer, nil)
+	}
+	if co.CertIdentityRegexp != nil && !certIdentityMatches(cert, co.CertIdentityRegexp) {
+		return nil, co.report(VerifyStepCertificateIdentity, fmt.Errorf("%w: no identity in certificate matches %q", ErrIdentityMismatch, co.CertIdentityRegexp))
+	}
+	if co.CertEmail != "" || co.CertIdentityRegexp != nil {
+		_ = co.report(VerifyStepCertificateIdentity, nil)
 	}
 	return verifier, nil
 }
 
-func tlogValidatePublicKey(ctx context.Context, rekorClient *client.Rekor, pub crypto.PublicKey, sig oci.Signature) error {
+// verifyOCISignatureWithKeys verifies sig with co.SigVerifier or, failing that, the
+// first of co.AdditionalSigVerifiers it verifies with. It returns that key and the
+// signer it counts as toward co.SignatureThreshold.
+func verifyOCISignatureWithKeys(ctx context.Context, co *CheckOpts, sig oci.Signature) (signature.Verifier, string, error) {
+	var err error
+	for _, verifier := range append([]signature.Verifier{co.SigVerifier}, co.AdditionalSigVerifiers...) {
+		if err = verifyOCISignature(ctx, verifier, sig); err == nil {
+			signer, err := keySigner(verifier, co.PKOpts...)
+			return verifier, signer, err
+		}
+	}
+	return nil, "", err
+}
+
+// keySigner returns the signer a signature verified with v counts as toward
+// CheckOpts.SignatureThreshold: the digest of its public key, so that the same
+// key passed twice counts once.
+func keySigner(v signature.Verifier, opts ...signature.PublicKeyOption) (string, error) {
+	pub, err := v.PublicKey(opts...)
+	if err != nil {
+		return "", err
+	}
+	der, err := cryptoutils.MarshalPublicKeyToDER(pub)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("key sha256:%x", sha256.Sum256(der)), nil
+}
+
+// certSigner returns the signer a signature verified with cert counts as toward
+// CheckOpts.SignatureThreshold: its subject alternative names and OIDC issuer.
+func certSigner(cert *x509.Certificate) string {
+	identities := append([]string{}, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		identities = append(identities, u.String())
+	}
+	return fmt.Sprintf("%q issued by %q", identities, certOIDCIssuer(cert))
+}
+
+// certIdentityMatches reports whether re matches any email or URI subject alternative name of cert.
+func certIdentityMatches(cert *x509.Certificate, re *regexp.Regexp) bool {
+	for _, em := range cert.EmailAddresses {
+		if re.MatchString(em) {
+			return true
+		}
+	}
+	for _, u := range cert.URIs {
+		if re.MatchString(u.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// certOIDCIssuer returns the OIDC issuer Fulcio recorded in cert, or the empty string.
+func certOIDCIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidcIssuerOID) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// verifyRFC3161Timestamp checks the timestamp token attached to sig against roots,
+// and that any certificate on sig was valid at the timestamped time.
+func verifyRFC3161Timestamp(sig oci.Signature, roots *x509.CertPool) error {
+	annotations, err := sig.Annotations()
+	if err != nil {
+		return err
+	}
+	b64token, ok := annotations[static.RFC3161TimestampAnnotationKey]
+	if !ok {
+		return errors.New("no RFC 3161 timestamp found on signature")
+	}
+	token, err := base64.StdEncoding.DecodeString(b64token)
+	if err != nil {
+		return errors.Wrap(err, "decoding RFC 3161 timestamp")
+	}
+	b64sig, err := sig.Base64Signature()
+	if err != nil {
+		return err
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(b64sig)
+	if err != nil {
+		return err
+	}
+	ts, err := tsa.VerifyTimestamp(token, sigBytes, roots)
+	if err != nil {
+		return errors.Wrap(err, "verifying RFC 3161 timestamp")
+	}
+	cert, err := sig.Cert()
+	if err != nil {
+		return err
+	}
+	if cert != nil {
+		return CheckExpiry(cert, ts)
+	}
+	return nil
+}
+
+func tlogValidatePublicKey(ctx context.Context, co *CheckOpts, pub crypto.PublicKey, sig oci.Signature) error {
 	pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(pub)
 	if err != nil {
 		return err
@@ -174,11 +427,11 @@ func tlogValidatePublicKey(ctx context.Context, rekorClient *client.Rekor, pub c
 	if err != nil {
 		return err
 	}
-	_, _, err = FindTlogEntry(ctx, rekorClient, b64sig, payload, pemBytes)
-	return err
+	_, _, err = FindTlogEntry(ctx, co.RekorClient, b64sig, payload, pemBytes)
+	return co.report(VerifyStepRekorInclusion, err)
 }
 
-func tlogValidateCertificate(ctx context.Context, rekorClient *client.Rekor, sig oci.Signature) error {
+func tlogValidateCertificate(ctx context.Context, co *CheckOpts, sig oci.Signature) error {
 	cert, err := sig.Cert()
 	if err != nil {
 		return err
@@ -195,17 +448,17 @@ func tlogValidateCertificate(ctx context.Context, rekorClient *client.Rekor, sig
 	if err != nil {
 		return err
 	}
-	uuid, _, err := FindTlogEntry(ctx, rekorClient, b64sig, payload, pemBytes)
-	if err != nil {
+	uuid, _, err := FindTlogEntry(ctx, co.RekorClient, b64sig, payload, pemBytes)
+	if err := co.report(VerifyStepRekorInclusion, err); err != nil {
 		return err
 	}
 	// if we have a cert, we should check expiry
 	// The IntegratedTime verified in VerifyTlog
-	e, err := GetTlogEntry(ctx, rekorClient, uuid)
+	e, err := GetTlogEntry(ctx, co.RekorClient, uuid)
 	if err != nil {
 		return err
 	}
-	return CheckExpiry(cert, time.Unix(*e.IntegratedTime, 0))
+	return co.report(VerifyStepCertificateExpiry, CheckExpiry(cert, time.Unix(*e.IntegratedTime, 0)))
 }
 
 type fakeOCISignatures struct {
@@ -227,7 +480,7 @@ func VerifyImageSignatures(ctx context.Context, signedImgRef name.Reference, co
 
 	// TODO(mattmoor): We could implement recursive verification if we just wrapped
 	// most of the logic below here in a call to mutate.Map
-	se, h, err := getSignedEntity(signedImgRef, co.RegistryClientOpts)
+	se, h, err := getSignedEntity(signedImgRef, co)
 	if err != nil {
 		return nil, false, err
 	}
@@ -301,12 +554,26 @@ func verifySignatures(ctx context.Context, sigs oci.Signatures, h v1.Hash, co *C
 		return nil, false, err
 	}
 
-	validationErrs := []string{}
+	if len(sl) == 0 {
+		return nil, false, ErrNoSignatures
+	}
+
+	validationErrs := []error{}
+	// signers holds the distinct signers of checkedSignatures.
+	signers := map[string]bool{}
 
 	for _, sig := range sl {
+		var signer string
 		if err := func(sig oci.Signature) error {
-			verifier := co.SigVerifier
-			if verifier == nil {
+			if len(co.RequiredSignatureAnnotations) > 0 {
+				if err := co.report(VerifyStepSignatureAnnotations, checkSignatureAnnotations(sig, co.RequiredSignatureAnnotations)); err != nil {
+					return err
+				}
+			}
+
+			var verifier signature.Verifier
+			var sigErr error
+			if co.SigVerifier == nil {
 				// If we don't have a public key to check against, we can try a root cert.
 				cert, err := sig.Cert()
 				if err != nil {
@@ -315,51 +582,85 @@ func verifySignatures(ctx context.Context, sigs oci.Signatures, h v1.Hash, co *C
 				if cert == nil {
 					return errors.New("no certificate found on signature")
 				}
-				verifier, err = validateAndUnpackCert(cert, co)
+				verifier, err = validateAndUnpackCert(ctx, cert, co)
 				if err != nil {
 					return err
 				}
+				signer = certSigner(cert)
+				sigErr = verifyOCISignature(ctx, verifier, sig)
+			} else {
+				verifier, signer, sigErr = verifyOCISignatureWithKeys(ctx, co, sig)
 			}
-
-			if err := verifyOCISignature(ctx, verifier, sig); err != nil {
+			if err := co.report(VerifyStepSignature, sigErr); err != nil {
 				return err
 			}
 
+			if co.TSACerts != nil {
+				if err := co.report(VerifyStepTimestamp, verifyRFC3161Timestamp(sig, co.TSACerts)); err != nil {
+					return err
+				}
+			}
+
 			// We can't check annotations without claims, both require unmarshalling the payload.
 			if co.ClaimVerifier != nil {
-				if err := co.ClaimVerifier(sig, h, co.Annotations); err != nil {
+				if err := co.report(VerifyStepClaims, co.ClaimVerifier(sig, h, co.Annotations)); err != nil {
 					return err
 				}
 			}
 
-			verified, err := VerifyBundle(ctx, sig)
-			if err != nil && co.RekorClient == nil {
+			if co.ContainerIdentity != "" {
+				if err := co.report(VerifyStepContainerIdentity, checkContainerIdentity(sig, co.ContainerIdentity)); err != nil {
+					return err
+				}
+			}
+
+			var pub crypto.PublicKey
+			if co.SigVerifier != nil {
+				var err error
+				if pub, err = verifier.PublicKey(co.PKOpts...); err != nil {
+					return err
+				}
+			}
+
+			verified, err := verifyBundle(ctx, sig, pub, co.RekorPubKeys)
+			co.reportBundle(sig, verified, err)
+			if err != nil && (co.RekorClient == nil || co.Offline) {
 				return errors.Wrap(err, "unable to verify bundle")
 			}
 			bundleVerified = bundleVerified || verified
 
+			if !verified && co.Offline {
+				return errors.New("no Rekor bundle to verify offline")
+			}
 			if !verified && co.RekorClient != nil {
 				if co.SigVerifier != nil {
-					pub, err := co.SigVerifier.PublicKey(co.PKOpts...)
-					if err != nil {
-						return err
-					}
-					return tlogValidatePublicKey(ctx, co.RekorClient, pub, sig)
+					return tlogValidatePublicKey(ctx, co, pub, sig)
 				}
 
-				return tlogValidateCertificate(ctx, co.RekorClient, sig)
+				return tlogValidateCertificate(ctx, co, sig)
 			}
 			return nil
 		}(sig); err != nil {
-			validationErrs = append(validationErrs, err.Error())
+			validationErrs = append(validationErrs, err)
 			continue
 		}
 
 		// Phew, we made it.
 		checkedSignatures = append(checkedSignatures, sig)
+		signers[signer] = true
+		if co.FirstMatch && len(signers) >= co.threshold() {
+			break
+		}
 	}
 	if len(checkedSignatures) == 0 {
-		return nil, false, fmt.Errorf("no matching signatures:\n%s", strings.Join(validationErrs, "\n "))
+		return nil, false, &verificationErrors{err: ErrNoMatchingSignatures, errs: validationErrs}
+	}
+	if n := co.threshold(); len(signers) < n {
+		err := fmt.Errorf("%w: %d of %d signatures verified, from %d distinct signers, %d required", ErrThresholdNotMet, len(checkedSignatures), len(sl), len(signers), n)
+		if len(validationErrs) == 0 {
+			return nil, false, err
+		}
+		return nil, false, &verificationErrors{err: err, errs: validationErrs}
 	}
 	return checkedSignatures, bundleVerified, nil
 }
@@ -382,7 +683,7 @@ func loadSignatureFromFile(sigRef string, signedImgRef name.Reference, co *Check
 		b64sig = base64.StdEncoding.EncodeToString(targetSig)
 	}
 
-	digest, err := ociremote.ResolveDigest(signedImgRef, co.RegistryClientOpts...)
+	digest, err := co.puller().ResolveDigest(signedImgRef, co.RegistryClientOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -413,7 +714,7 @@ func VerifyImageAttestations(ctx context.Context, signedImgRef name.Reference, c
 	// TODO(mattmoor): We could implement recursive verification if we just wrapped
 	// most of the logic below here in a call to mutate.Map
 
-	se, h, err := getSignedEntity(signedImgRef, co.RegistryClientOpts)
+	se, h, err := getSignedEntity(signedImgRef, co)
 	if err != nil {
 		return nil, false, err
 	}
@@ -477,7 +778,11 @@ func verifyImageAttestations(ctx context.Context, atts oci.Signatures, h v1.Hash
 		return nil, false, err
 	}
 
-	validationErrs := []string{}
+	if len(sl) == 0 {
+		return nil, false, ErrNoAttestations
+	}
+
+	validationErrs := []error{}
 	for _, att := range sl {
 		if err := func(att oci.Signature) error {
 			verifier := co.SigVerifier
@@ -490,43 +795,57 @@ func verifyImageAttestations(ctx context.Context, atts oci.Signatures, h v1.Hash
 				if cert == nil {
 					return errors.New("no certificate found on attestation")
 				}
-				verifier, err = validateAndUnpackCert(cert, co)
+				verifier, err = validateAndUnpackCert(ctx, cert, co)
 				if err != nil {
 					return err
 				}
 			}
 
-			if err := verifyOCIAttestation(ctx, verifier, att); err != nil {
+			if err := co.report(VerifyStepSignature, verifyOCIAttestation(ctx, verifier, att)); err != nil {
 				return err
 			}
 
 			// We can't check annotations without claims, both require unmarshalling the payload.
 			if co.ClaimVerifier != nil {
-				if err := co.ClaimVerifier(att, h, co.Annotations); err != nil {
+				if err := co.report(VerifyStepClaims, co.ClaimVerifier(att, h, co.Annotations)); err != nil {
+					return err
+				}
+			}
+
+			if co.RegoPolicy != "" {
+				if err := co.report(VerifyStepRegoPolicy, checkRegoPolicy(ctx, co.RegoPolicy, att)); err != nil {
 					return err
 				}
 			}
 
-			verified, err := VerifyBundle(ctx, att)
-			if err != nil && co.RekorClient == nil {
+			var pub crypto.PublicKey
+			if co.SigVerifier != nil {
+				var err error
+				if pub, err = co.SigVerifier.PublicKey(co.PKOpts...); err != nil {
+					return err
+				}
+			}
+
+			verified, err := verifyBundle(ctx, att, pub, co.RekorPubKeys)
+			co.reportBundle(att, verified, err)
+			if err != nil && (co.RekorClient == nil || co.Offline) {
 				return errors.Wrap(err, "unable to verify bundle")
 			}
 			bundleVerified = bundleVerified || verified
 
+			if !verified && co.Offline {
+				return errors.New("no Rekor bundle to verify offline")
+			}
 			if !verified && co.RekorClient != nil {
 				if co.SigVerifier != nil {
-					pub, err := co.SigVerifier.PublicKey(co.PKOpts...)
-					if err != nil {
-						return err
-					}
-					return tlogValidatePublicKey(ctx, co.RekorClient, pub, att)
+					return tlogValidatePublicKey(ctx, co, pub, att)
 				}
 
-				return tlogValidateCertificate(ctx, co.RekorClient, att)
+				return tlogValidateCertificate(ctx, co, att)
 			}
 			return nil
 		}(att); err != nil {
-			validationErrs = append(validationErrs, err.Error())
+			validationErrs = append(validationErrs, err)
 			continue
 		}
 
@@ -534,28 +853,225 @@ func verifyImageAttestations(ctx context.Context, atts oci.Signatures, h v1.Hash
 		checkedAttestations = append(checkedAttestations, att)
 	}
 	if len(checkedAttestations) == 0 {
-		return nil, false, fmt.Errorf("no matching attestations:\n%s", strings.Join(validationErrs, "\n "))
+		return nil, false, &verificationErrors{err: ErrNoMatchingAttestations, errs: validationErrs}
 	}
 	return checkedAttestations, bundleVerified, nil
 }
 
+// checkRegoPolicy evaluates policy against the in-toto statement of att.
+func checkRegoPolicy(ctx context.Context, policy string, att oci.Signature) error {
+	payload, err := att.Payload()
+	if err != nil {
+		return err
+	}
+	env := ssldsse.Envelope{}
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return err
+	}
+	stBytes, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return err
+	}
+	allowed, violations, err := rego.EvaluatePolicy(ctx, policy, stBytes)
+	if err != nil {
+		return errors.Wrap(err, "evaluating policy")
+	}
+	if !allowed {
+		if len(violations) == 0 {
+			return errors.New("attestation is not allowed by policy")
+		}
+		return fmt.Errorf("attestation is not allowed by policy: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// VerifyBlobAttestation verifies the DSSE-wrapped in-toto attestation at attestationPath
+// and checks that one of its subjects is the artifact stored in the registry at blobRef.
+// The signature is checked with co.SigVerifier or, if that is nil, with the key in cert,
+// which must chain up to co.RootCerts and have been valid when the attestation was
+// integrated into the transparency log at co.RekorClient. The file may hold several
+// attestations, one envelope per line; if co.PredicateType is set, only those with that
+// predicate type are considered.
+func VerifyBlobAttestation(ctx context.Context, co *CheckOpts, blobRef string, attestationPath string, cert *x509.Certificate) error {
+	verifier := co.SigVerifier
+	var attOpts []static.Option
+	if verifier == nil {
+		if cert == nil {
+			return errors.New("a verifier or a certificate is required to verify a blob attestation")
+		}
+		if co.RekorClient == nil {
+			return errors.New("a transparency log is required to check the certificate was valid when the attestation was signed")
+		}
+		var err error
+		verifier, err = validateAndUnpackCert(ctx, cert, co)
+		if err != nil {
+			return err
+		}
+		pemCert, err := cryptoutils.MarshalCertificateToPEM(cert)
+		if err != nil {
+			return err
+		}
+		attOpts = append(attOpts, static.WithCertChain(pemCert, nil))
+	}
+
+	payload, err := blob.LoadFileOrURL(attestationPath)
+	if err != nil {
+		return errors.Wrap(err, "reading attestation")
+	}
+	envelopes, err := splitEnvelopes(payload)
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.ParseReference(blobRef)
+	if err != nil {
+		return errors.Wrap(err, "parsing reference")
+	}
+	digests, err := artifactDigests(ref, co)
+	if err != nil {
+		return errors.Wrapf(err, "fetching %s", blobRef)
+	}
+
+	var errs []error
+	for i, raw := range envelopes {
+		env := ssldsse.Envelope{}
+		if err := json.Unmarshal(raw, &env); err != nil {
+			errs = append(errs, errors.Wrapf(err, "parsing attestation %d", i+1))
+			continue
+		}
+		st, err := attestation.ParseStatement(&env)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "parsing attestation %d", i+1))
+			continue
+		}
+		if co.PredicateType != "" && st.PredicateType != co.PredicateType {
+			continue
+		}
+
+		att, err := static.NewAttestation(raw, attOpts...)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "parsing attestation %d", i+1))
+			continue
+		}
+		if err := co.report(VerifyStepSignature, verifyOCIAttestation(ctx, verifier, att)); err != nil {
+			errs = append(errs, errors.Wrapf(err, "verifying signature of attestation %d", i+1))
+			continue
+		}
+		if !subjectMatches(st.Subject, digests) {
+			errs = append(errs, fmt.Errorf("no subject of attestation %d matches %s", i+1, blobRef))
+			continue
+		}
+		if co.SigVerifier == nil {
+			if err := tlogValidateCertificate(ctx, co, att); err != nil {
+				errs = append(errs, errors.Wrapf(err, "checking attestation %d in the transparency log", i+1))
+				continue
+			}
+		}
+		return nil
+	}
+	switch {
+	case len(errs) == 1:
+		return errs[0]
+	case len(errs) > 1:
+		return &verificationErrors{err: ErrNoMatchingAttestations, errs: errs}
+	case co.PredicateType != "":
+		return fmt.Errorf("%w with predicate type %s", ErrNoAttestations, co.PredicateType)
+	default:
+		return ErrNoAttestations
+	}
+}
+
+// splitEnvelopes returns the DSSE envelopes in payload, which holds either a single
+// envelope or one envelope per line.
+func splitEnvelopes(payload []byte) ([][]byte, error) {
+	if json.Valid(payload) {
+		return [][]byte{payload}, nil
+	}
+	var envelopes [][]byte
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, errors.New("attestation is neither a DSSE envelope nor one envelope per line")
+		}
+		envelopes = append(envelopes, line)
+	}
+	return envelopes, nil
+}
+
+// subjectMatches reports whether any of subjects has a sha256 digest in digests.
+func subjectMatches(subjects []in_toto.Subject, digests map[string]bool) bool {
+	for _, subj := range subjects {
+		if dgst, ok := subj.Digest["sha256"]; ok && digests["sha256:"+dgst] {
+			return true
+		}
+	}
+	return false
+}
+
+// artifactDigests returns the manifest digest and the layer digests of the artifact at ref.
+func artifactDigests(ref name.Reference, co *CheckOpts) (map[string]bool, error) {
+	se, err := co.puller().SignedEntity(ref, co.RegistryClientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	img, ok := se.(oci.SignedImage)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an image", ref)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	digests := map[string]bool{h.String(): true}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range layers {
+		lh, err := l.Digest()
+		if err != nil {
+			return nil, err
+		}
+		digests[lh.String()] = true
+	}
+	return digests, nil
+}
+
 // CheckExpiry confirms the time provided is within the valid period of the cert
 func CheckExpiry(cert *x509.Certificate, it time.Time) error {
 	ft := func(t time.Time) string {
 		return t.Format(time.RFC3339)
 	}
 	if cert.NotAfter.Before(it) {
-		return fmt.Errorf("certificate expired before signatures were entered in log: %s is before %s",
-			ft(cert.NotAfter), ft(it))
+		return fmt.Errorf("%w before signatures were entered in log: %s is before %s",
+			ErrCertExpired, ft(cert.NotAfter), ft(it))
 	}
 	if cert.NotBefore.After(it) {
-		return fmt.Errorf("certificate was issued after signatures were entered in log: %s is after %s",
-			ft(cert.NotAfter), ft(it))
+		return fmt.Errorf("%w when signatures were entered in log: %s is after %s",
+			ErrCertNotYetValid, ft(cert.NotBefore), ft(it))
 	}
 	return nil
 }
 
+// VerifyBundle checks the bundle of sig against the Rekor public key, and that
+// its tlog entry was made for the payload, signature and certificate of sig.
 func VerifyBundle(ctx context.Context, sig oci.Signature) (bool, error) {
+	return verifyBundle(ctx, sig, nil, nil)
+}
+
+// VerifyBundleWithKey is VerifyBundle for a signature made with pub rather than
+// a certificate: the tlog entry in the bundle must also be for pub.
+func VerifyBundleWithKey(ctx context.Context, sig oci.Signature, pub crypto.PublicKey) (bool, error) {
+	return verifyBundle(ctx, sig, pub, nil)
+}
+
+// verifyBundle is VerifyBundle, verifying the bundle against any of rekorPubs. If
+// rekorPubs is empty, the key is retrieved with GetRekorPub. If sig has no
+// certificate and pub is not nil, the tlog entry must also be for pub.
+func verifyBundle(ctx context.Context, sig oci.Signature, pub crypto.PublicKey, rekorPubs []*ecdsa.PublicKey) (bool, error) {
 	bundle, err := sig.Bundle()
 	if err != nil {
 		return false, err
@@ -563,132 +1079,197 @@ func VerifyBundle(ctx context.Context, sig oci.Signature) (bool, error) {
 		return false, nil
 	}
 
-	pub, err := GetRekorPub(ctx)
-	if err != nil {
-		return false, errors.Wrap(err, "retrieving rekor public key")
+	if len(rekorPubs) == 0 {
+		pub, err := GetRekorPub(ctx)
+		if err != nil {
+			return false, errors.Wrap(err, "retrieving rekor public key")
+		}
+		rekorPubKey, err := PemToECDSAKey(pub)
+		if err != nil {
+			return false, errors.Wrap(err, "pem to ecdsa")
+		}
+		rekorPubs = []*ecdsa.PublicKey{rekorPubKey}
 	}
-
-	rekorPubKey, err := PemToECDSAKey(pub)
-	if err != nil {
-		return false, errors.Wrap(err, "pem to ecdsa")
+	for _, pub := range rekorPubs {
+		if err = VerifySET(bundle.Payload, bundle.SignedEntryTimestamp, pub); err == nil {
+			break
+		}
 	}
-
-	if err := VerifySET(bundle.Payload, bundle.SignedEntryTimestamp, rekorPubKey); err != nil {
+	if err != nil {
 		return false, err
 	}
 
 	cert, err := sig.Cert()
 	if err != nil {
 		return false, err
-	} else if cert == nil {
-		return true, nil
 	}
-
-	// verify the cert against the integrated time
-	if err := CheckExpiry(cert, time.Unix(bundle.Payload.IntegratedTime, 0)); err != nil {
-		return false, errors.Wrap(err, "checking expiry on cert")
+	if cert != nil {
+		// verify the cert against the integrated time
+		if err := CheckExpiry(cert, time.Unix(bundle.Payload.IntegratedTime, 0)); err != nil {
+			return false, errors.Wrap(err, "checking expiry on cert")
+		}
 	}
 
 	payload, err := sig.Payload()
 	if err != nil {
 		return false, errors.Wrap(err, "reading payload")
 	}
-	signature, err := sig.Base64Signature()
+	b64sig, err := sig.Base64Signature()
 	if err != nil {
 		return false, errors.Wrap(err, "reading base64signature")
 	}
+	signature, err := base64.StdEncoding.DecodeString(b64sig)
+	if err != nil {
+		return false, errors.Wrap(err, "decoding base64signature")
+	}
 
-	alg, bundlehash, err := bundleHash(bundle.Payload.Body.(string), signature)
-	h := sha256.Sum256(payload)
-	payloadHash := hex.EncodeToString(h[:])
-
-	if alg != "sha256" || bundlehash != payloadHash {
-		return false, errors.Wrap(err, "matching bundle to payload")
+	body, ok := bundle.Payload.Body.(string)
+	if !ok {
+		return false, errors.New("unexpected bundle body")
+	}
+	entry, err := parseBundleEntry(body)
+	if err != nil {
+		return false, errors.Wrap(err, "parsing bundle body")
+	}
+	if err := entry.matches(payload, signature, cert, pub); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
-func bundleHash(bundleBody, signature string) (string, string, error) {
-	var toto models.Intoto
-	var rekord models.Rekord
-	var hrekord models.Hashedrekord
-	var intotoObj models.IntotoV001Schema
-	var rekordObj models.RekordV001Schema
-	var hrekordObj models.HashedrekordV001Schema
+// bundleEntry is what a bundle's tlog entry records about the signature it
+// was created for.
+type bundleEntry struct {
+	hashAlgorithm, hash string
+	// signature is empty for intoto entries, whose body omits the envelope.
+	signature []byte
+	// publicKey is the PEM encoded key or certificate of the signer.
+	publicKey []byte
+}
 
+func parseBundleEntry(bundleBody string) (*bundleEntry, error) {
 	bodyDecoded, err := base64.StdEncoding.DecodeString(bundleBody)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(bodyDecoded), runtime.JSONConsumer())
+	if err != nil {
+		return nil, err
 	}
 
-	// The fact that there's no signature (or empty rather), implies
-	// that this is an Attestation that we're verifying.
-	if len(signature) == 0 {
-		err = json.Unmarshal(bodyDecoded, &toto)
-		if err != nil {
-			return "", "", err
+	var alg, hash *string
+	entry := &bundleEntry{}
+	switch e := pe.(type) {
+	case *models.Intoto:
+		var intotoObj models.IntotoV001Schema
+		if err := unmarshalSpec(e.Spec, &intotoObj); err != nil {
+			return nil, err
 		}
-
-		specMarshal, err := json.Marshal(toto.Spec)
-		if err != nil {
-			return "", "", err
+		if intotoObj.Content == nil || intotoObj.Content.Hash == nil || intotoObj.PublicKey == nil {
+			return nil, errors.New("incomplete intoto tlog entry")
 		}
-		err = json.Unmarshal(specMarshal, &intotoObj)
-		if err != nil {
-			return "", "", err
+		alg, hash = intotoObj.Content.Hash.Algorithm, intotoObj.Content.Hash.Value
+		entry.publicKey = *intotoObj.PublicKey
+	case *models.Rekord:
+		var rekordObj models.RekordV001Schema
+		if err := unmarshalSpec(e.Spec, &rekordObj); err != nil {
+			return nil, err
 		}
-
-		return *intotoObj.Content.Hash.Algorithm, *intotoObj.Content.Hash.Value, nil
-	}
-
-	if err := json.Unmarshal(bodyDecoded, &rekord); err == nil {
-		specMarshal, err := json.Marshal(rekord.Spec)
-		if err != nil {
-			return "", "", err
+		if rekordObj.Data == nil || rekordObj.Data.Hash == nil || rekordObj.Signature == nil || rekordObj.Signature.PublicKey == nil {
+			return nil, errors.New("incomplete rekord tlog entry")
 		}
-		err = json.Unmarshal(specMarshal, &rekordObj)
-		if err != nil {
-			return "", "", err
+		alg, hash = rekordObj.Data.Hash.Algorithm, rekordObj.Data.Hash.Value
+		entry.signature = rekordObj.Signature.Content
+		entry.publicKey = rekordObj.Signature.PublicKey.Content
+	case *models.Hashedrekord:
+		var hrekordObj models.HashedrekordV001Schema
+		if err := unmarshalSpec(e.Spec, &hrekordObj); err != nil {
+			return nil, err
 		}
-		return *rekordObj.Data.Hash.Algorithm, *rekordObj.Data.Hash.Value, nil
-	}
-
-	// Try hashedRekordObj
-	err = json.Unmarshal(bodyDecoded, &hrekord)
-	if err != nil {
-		return "", "", err
+		if hrekordObj.Data == nil || hrekordObj.Data.Hash == nil || hrekordObj.Signature == nil || hrekordObj.Signature.PublicKey == nil {
+			return nil, errors.New("incomplete hashedrekord tlog entry")
+		}
+		alg, hash = hrekordObj.Data.Hash.Algorithm, hrekordObj.Data.Hash.Value
+		entry.signature = hrekordObj.Signature.Content
+		entry.publicKey = hrekordObj.Signature.PublicKey.Content
+	default:
+		return nil, fmt.Errorf("unexpected tlog entry kind %q", pe.Kind())
 	}
-	specMarshal, err := json.Marshal(hrekord.Spec)
-	if err != nil {
-		return "", "", err
+	if alg == nil || hash == nil {
+		return nil, errors.New("tlog entry has no hash")
 	}
-	err = json.Unmarshal(specMarshal, &hrekordObj)
+	entry.hashAlgorithm, entry.hash = *alg, *hash
+	return entry, nil
+}
+
+// unmarshalSpec decodes the spec of a proposed entry into obj.
+func unmarshalSpec(spec interface{}, obj interface{}) error {
+	specMarshal, err := json.Marshal(spec)
 	if err != nil {
-		return "", "", err
+		return err
 	}
-	return *hrekordObj.Data.Hash.Algorithm, *hrekordObj.Data.Hash.Value, nil
+	return json.Unmarshal(specMarshal, obj)
 }
 
-func VerifySET(bundlePayload cbundle.RekorPayload, signature []byte, pub *ecdsa.PublicKey) error {
-	contents, err := json.Marshal(bundlePayload)
-	if err != nil {
-		return errors.Wrap(err, "marshaling")
+// matches returns an error unless the entry was made for payload and the
+// signature sig, by cert or, if cert is nil, by pub. A nil pub skips the
+// check of the signer's key.
+func (e *bundleEntry) matches(payload, sig []byte, cert *x509.Certificate, pub crypto.PublicKey) error {
+	h := sha256.Sum256(payload)
+	if e.hashAlgorithm != "sha256" || e.hash != hex.EncodeToString(h[:]) {
+		return errors.New("bundle does not match the payload")
 	}
-	canonicalized, err := jsoncanonicalizer.Transform(contents)
-	if err != nil {
-		return errors.Wrap(err, "canonicalizing")
+	if len(sig) > 0 && !bytes.Equal(e.signature, sig) {
+		return errors.New("bundle does not match the signature")
 	}
 
-	// verify the SET against the public key
-	hash := sha256.Sum256(canonicalized)
-	if !ecdsa.VerifyASN1(pub, hash[:], signature) {
-		return errors.New("unable to verify")
+	switch {
+	case cert != nil:
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(e.publicKey)
+		if err != nil || len(certs) == 0 || !certs[0].Equal(cert) {
+			return errors.New("bundle does not match the signing certificate")
+		}
+	case pub != nil:
+		entryPub, err := cryptoutils.UnmarshalPEMToPublicKey(e.publicKey)
+		if err != nil {
+			return errors.Wrap(err, "parsing bundle public key")
+		}
+		want, err := cryptoutils.MarshalPublicKeyToDER(pub)
+		if err != nil {
+			return err
+		}
+		got, err := cryptoutils.MarshalPublicKeyToDER(entryPub)
+		if err != nil || !bytes.Equal(got, want) {
+			return errors.New("bundle does not match the public key")
+		}
 	}
 	return nil
 }
 
+// VerifySET verifies that pub signed the signed entry timestamp of the entry
+// described by bundlePayload.
+func VerifySET(bundlePayload cbundle.RekorPayload, signature []byte, pub *ecdsa.PublicKey) error {
+	return transparency.VerifySET(bundlePayload, signature, pub)
+}
+
 func TrustedCert(cert *x509.Certificate, roots *x509.CertPool) error {
-	if _, err := cert.Verify(x509.VerifyOptions{
+	_, err := trustedChains(cert, roots)
+	return err
+}
+
+// TrustedChain verifies cert against roots as TrustedCert does, and returns the
+// verified chain from cert up to its root.
+func TrustedChain(cert *x509.Certificate, roots *x509.CertPool) ([]*x509.Certificate, error) {
+	chains, err := trustedChains(cert, roots)
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+// trustedChains verifies cert against roots as TrustedCert does, returning the verified chains.
+func trustedChains(cert *x509.Certificate, roots *x509.CertPool) ([][]*x509.Certificate, error) {
+	return cert.Verify(x509.VerifyOptions{
 		// THIS IS IMPORTANT: WE DO NOT CHECK TIMES HERE
 		// THE CERTIFICATE IS TREATED AS TRUSTED FOREVER
 		// WE CHECK THAT THE SIGNATURES WERE CREATED DURING THIS WINDOW
@@ -698,9 +1279,70 @@ func TrustedCert(cert *x509.Certificate, roots *x509.CertPool) error {
 			x509.ExtKeyUsage(x509.KeyUsageDigitalSignature),
 			x509.ExtKeyUsageCodeSigning,
 		},
-	}); err != nil {
+	})
+}
+
+// ContainerIdentityKey is the optional field of a signature payload in which the
+// signer names the image it meant to sign.
+const ContainerIdentityKey = "containerIdentity"
+
+// checkContainerIdentity returns an error unless the payload of sig names image
+// under ContainerIdentityKey.
+func checkContainerIdentity(sig oci.Signature, image string) error {
+	p, err := sig.Payload()
+	if err != nil {
+		return err
+	}
+	ss := &sigPayload.SimpleContainerImage{}
+	if err := json.Unmarshal(p, ss); err != nil {
 		return err
 	}
+	identity, ok := ss.Optional[ContainerIdentityKey].(string)
+	if !ok {
+		return fmt.Errorf("%w: signature payload has no %s", ErrIdentityMismatch, ContainerIdentityKey)
+	}
+	if !containerIdentityMatches(identity, image) {
+		return fmt.Errorf("%w: signed container identity %q does not match %q", ErrIdentityMismatch, identity, image)
+	}
+	return nil
+}
+
+// containerIdentityMatches reports whether identity names image. An identity without
+// a tag or digest matches any image in its repository.
+func containerIdentityMatches(identity, image string) bool {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return identity == image
+	}
+	if repo, err := name.NewRepository(identity); err == nil {
+		return repo.Name() == ref.Context().Name()
+	}
+	idRef, err := name.ParseReference(identity)
+	if err != nil {
+		return false
+	}
+	return idRef.Name() == ref.Name()
+}
+
+// checkSignatureAnnotations returns an error unless sig carries each of the required
+// annotations with the required value.
+func checkSignatureAnnotations(sig oci.Signature, required map[string]string) error {
+	if len(required) == 0 {
+		return nil
+	}
+	have, err := sig.Annotations()
+	if err != nil {
+		return err
+	}
+	for k, v := range required {
+		got, ok := have[k]
+		if !ok {
+			return fmt.Errorf("signature is missing required annotation %s", k)
+		}
+		if got != v {
+			return fmt.Errorf("signature annotation %s is %q, expected %q", k, got, v)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,144 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+)
+
+// For testing
+var cosignVerifySignatures = cosign.VerifyImageSignatures
+
+// AdmissionImage is an image referenced by the object in an AdmissionRequest.
+type AdmissionImage struct {
+	// Field is the path of the image in the object, e.g. "spec.containers[0].image".
+	Field string
+	// Image is the image reference as written in the object.
+	Image string
+}
+
+// VerifyAdmissionRequest verifies the signatures of every image in the pod spec
+// of the object req admits, with opts. Pods, CronJobs and the workload kinds
+// with a pod template (Deployments, Jobs and so on) are supported. As in the
+// cosign webhook, images must be referenced by digest, since a tag can move
+// once it is admitted. The error lists every image that failed.
+func VerifyAdmissionRequest(ctx context.Context, req *admissionv1.AdmissionRequest, opts *cosign.CheckOpts) error {
+	if opts == nil {
+		return errors.New("check options are required")
+	}
+	images, err := AdmissionRequestImages(req)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, img := range images {
+		if err := verifyAdmissionImage(ctx, img.Image, opts); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s: %v", img.Field, img.Image, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d images failed verification:\n%s", len(errs), len(images), strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func verifyAdmissionImage(ctx context.Context, image string, opts *cosign.CheckOpts) error {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return err
+	}
+	if _, ok := ref.(name.Digest); !ok {
+		return errors.New("must be an image digest")
+	}
+	_, _, err = cosignVerifySignatures(ctx, ref, opts)
+	return err
+}
+
+// AdmissionRequestImages returns the images in the pod spec of the object req
+// admits. It returns none when req deletes the object.
+func AdmissionRequestImages(req *admissionv1.AdmissionRequest) ([]AdmissionImage, error) {
+	if req.Operation == admissionv1.Delete || len(req.Object.Raw) == 0 {
+		return nil, nil
+	}
+
+	var spec *corev1.PodSpec
+	var field string
+	switch req.Kind.Kind {
+	case "Pod":
+		var pod corev1.Pod
+		if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+			return nil, errors.Wrap(err, "decoding Pod")
+		}
+		spec, field = &pod.Spec, "spec"
+	case "CronJob":
+		var cj struct {
+			Spec struct {
+				JobTemplate struct {
+					Spec struct {
+						Template corev1.PodTemplateSpec `json:"template"`
+					} `json:"spec"`
+				} `json:"jobTemplate"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(req.Object.Raw, &cj); err != nil {
+			return nil, errors.Wrap(err, "decoding CronJob")
+		}
+		spec, field = &cj.Spec.JobTemplate.Spec.Template.Spec, "spec.jobTemplate.spec.template.spec"
+	case "Deployment", "ReplicaSet", "StatefulSet", "DaemonSet", "Job", "ReplicationController":
+		var wp struct {
+			Spec struct {
+				Template *corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(req.Object.Raw, &wp); err != nil {
+			return nil, errors.Wrapf(err, "decoding %s", req.Kind.Kind)
+		}
+		if wp.Spec.Template == nil {
+			return nil, nil
+		}
+		spec, field = &wp.Spec.Template.Spec, "spec.template.spec"
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", req.Kind.Kind)
+	}
+
+	var images []AdmissionImage
+	add := func(kind string, i int, image string) {
+		images = append(images, AdmissionImage{
+			Field: fmt.Sprintf("%s.%s[%d].image", field, kind, i),
+			Image: image,
+		})
+	}
+	for i, c := range spec.InitContainers {
+		add("initContainers", i, c.Image)
+	}
+	for i, c := range spec.Containers {
+		add("containers", i, c.Image)
+	}
+	for i, c := range spec.EphemeralContainers {
+		add("ephemeralContainers", i, c.Image)
+	}
+	return images, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/oci"
+)
+
+const (
+	signedImage   = "gcr.io/example/signed@sha256:" + "1111111111111111111111111111111111111111111111111111111111111111"
+	unsignedImage = "gcr.io/example/unsigned@sha256:" + "2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func admissionRequest(kind, object string) *admissionv1.AdmissionRequest {
+	return &admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Kind:      metav1.GroupVersionKind{Kind: kind},
+		Object:    runtime.RawExtension{Raw: []byte(object)},
+	}
+}
+
+func TestVerifyAdmissionRequest(t *testing.T) {
+	cosignVerifySignatures = func(_ context.Context, ref name.Reference, _ *cosign.CheckOpts) ([]oci.Signature, bool, error) {
+		if ref.String() == signedImage {
+			return nil, false, nil
+		}
+		return nil, false, cosign.ErrNoMatchingSignatures
+	}
+	defer func() { cosignVerifySignatures = cosign.VerifyImageSignatures }()
+
+	tests := []struct {
+		name    string
+		req     *admissionv1.AdmissionRequest
+		wantErr []string
+	}{{
+		name: "signed pod",
+		req:  admissionRequest("Pod", `{"spec":{"initContainers":[{"image":"`+signedImage+`"}],"containers":[{"image":"`+signedImage+`"}]}}`),
+	}, {
+		name:    "unsigned container",
+		req:     admissionRequest("Pod", `{"spec":{"containers":[{"image":"`+signedImage+`"},{"image":"`+unsignedImage+`"}]}}`),
+		wantErr: []string{"1 of 2 images", "spec.containers[1].image", "no matching signatures"},
+	}, {
+		name:    "deployment with a tag",
+		req:     admissionRequest("Deployment", `{"spec":{"template":{"spec":{"containers":[{"image":"gcr.io/example/signed:latest"}]}}}}`),
+		wantErr: []string{"spec.template.spec.containers[0].image", "must be an image digest"},
+	}, {
+		name:    "cronjob",
+		req:     admissionRequest("CronJob", `{"spec":{"jobTemplate":{"spec":{"template":{"spec":{"initContainers":[{"image":"`+unsignedImage+`"}]}}}}}}`),
+		wantErr: []string{"spec.jobTemplate.spec.template.spec.initContainers[0].image"},
+	}, {
+		name: "delete",
+		req: &admissionv1.AdmissionRequest{
+			Operation: admissionv1.Delete,
+			Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+		},
+	}, {
+		name:    "unsupported kind",
+		req:     admissionRequest("ConfigMap", `{"data":{}}`),
+		wantErr: []string{"unsupported kind ConfigMap"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyAdmissionRequest(context.Background(), tt.req, &cosign.CheckOpts{})
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("VerifyAdmissionRequest() = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	if err := VerifyAdmissionRequest(context.Background(), admissionRequest("Pod", `{}`), nil); err == nil {
+		t.Error("expected an error without check options")
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	cjson "github.com/secure-systems-lab/go-securesystemslib/cjson"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// maxNotaryMetadataSize bounds the TUF metadata read from a Notary server.
+const maxNotaryMetadataSize = 10 << 20
+
+// notarySigned is a Notary v1 TUF metadata file: the signed role and the
+// signatures over its canonical JSON encoding.
+type notarySigned struct {
+	Signed     json.RawMessage `json:"signed"`
+	Signatures []struct {
+		KeyID  string `json:"keyid"`
+		Method string `json:"method"`
+		Sig    []byte `json:"sig"`
+	} `json:"signatures"`
+}
+
+type notaryKey struct {
+	KeyType string `json:"keytype"`
+	KeyVal  struct {
+		Public []byte `json:"public"`
+	} `json:"keyval"`
+}
+
+type notaryRole struct {
+	KeyIDs    []string `json:"keyids"`
+	Threshold int      `json:"threshold"`
+}
+
+type notaryRoot struct {
+	Type    string                `json:"_type"`
+	Expires time.Time             `json:"expires"`
+	Keys    map[string]notaryKey  `json:"keys"`
+	Roles   map[string]notaryRole `json:"roles"`
+}
+
+type notaryTargets struct {
+	Type    string    `json:"_type"`
+	Expires time.Time `json:"expires"`
+	Targets map[string]struct {
+		Hashes map[string][]byte `json:"hashes"`
+		Length int64             `json:"length"`
+	} `json:"targets"`
+}
+
+// VerifyDockerContentTrust verifies the Docker Content Trust signature on the tag
+// of imageRef, as published by the Notary v1 server at notaryServerURL, so that
+// images signed with DCT can be accepted while migrating to cosign.
+//
+// The root.json served for the image's repository is trusted on first use: its
+// self-signatures are checked, and it must not have expired. targets.json must
+// be signed by a threshold of the root's targets keys and list the tag. Only
+// the top-level targets role is consulted, not delegations such as
+// targets/releases, and the snapshot and timestamp roles are not checked.
+//
+// The result's Digest is the SHA-256 digest of the image manifest the tag was
+// signed for, which callers must compare with the image they are about to use.
+// Its Certificate is the certificate of a targets key that signed, if the key
+// is an X.509 certificate, as Notary's keys usually are.
+func VerifyDockerContentTrust(ctx context.Context, imageRef string, notaryServerURL string) (*VerificationResult, error) {
+	tag, err := name.NewTag(imageRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing image tag")
+	}
+	gun := dctGUN(tag.Context())
+	base := strings.TrimSuffix(notaryServerURL, "/") + "/v2/" + gun + "/_trust/tuf/"
+
+	rootMeta, err := fetchNotaryMetadata(ctx, base+"root.json")
+	if err != nil {
+		return nil, err
+	}
+	var root notaryRoot
+	if err := json.Unmarshal(rootMeta.Signed, &root); err != nil {
+		return nil, errors.Wrap(err, "parsing root.json")
+	}
+	if root.Type != "Root" {
+		return nil, fmt.Errorf("root.json has type %q", root.Type)
+	}
+	if _, err := verifyNotaryRole(rootMeta, root.Keys, root.Roles["root"]); err != nil {
+		return nil, errors.Wrap(err, "verifying root.json")
+	}
+	if time.Now().After(root.Expires) {
+		return nil, fmt.Errorf("root.json expired at %s", root.Expires)
+	}
+
+	targetsMeta, err := fetchNotaryMetadata(ctx, base+"targets.json")
+	if err != nil {
+		return nil, err
+	}
+	signer, err := verifyNotaryRole(targetsMeta, root.Keys, root.Roles["targets"])
+	if err != nil {
+		return nil, errors.Wrap(err, "verifying targets.json")
+	}
+	var targets notaryTargets
+	if err := json.Unmarshal(targetsMeta.Signed, &targets); err != nil {
+		return nil, errors.Wrap(err, "parsing targets.json")
+	}
+	if targets.Type != "Targets" {
+		return nil, fmt.Errorf("targets.json has type %q", targets.Type)
+	}
+	if time.Now().After(targets.Expires) {
+		return nil, fmt.Errorf("targets.json expired at %s", targets.Expires)
+	}
+
+	target, ok := targets.Targets[tag.TagStr()]
+	if !ok {
+		return nil, fmt.Errorf("no trust data for %s in %s", tag.TagStr(), gun)
+	}
+	digest, ok := target.Hashes["sha256"]
+	if !ok || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("trust data for %s has no sha256 hash", tag.TagStr())
+	}
+
+	res := &VerificationResult{Digest: digest}
+	if cert, err := notaryCertificate(root.Keys[signer]); err == nil {
+		res.Certificate = cert
+	}
+	return res, nil
+}
+
+// dctGUN returns the Globally Unique Name Notary stores the trust data for repo
+// under, which for Docker Hub is docker.io rather than index.docker.io.
+func dctGUN(repo name.Repository) string {
+	if repo.RegistryStr() == name.DefaultRegistry {
+		return "docker.io/" + repo.RepositoryStr()
+	}
+	return repo.Name()
+}
+
+func fetchNotaryMetadata(ctx context.Context, url string) (*notarySigned, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxNotaryMetadataSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", url)
+	}
+	var meta notarySigned
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", url)
+	}
+	return &meta, nil
+}
+
+// verifyNotaryRole checks that meta is signed by at least role.Threshold of the
+// role's keys, returning the ID of one of the keys that signed it.
+func verifyNotaryRole(meta *notarySigned, keys map[string]notaryKey, role notaryRole) (string, error) {
+	if role.Threshold < 1 {
+		return "", errors.New("role has no signing threshold")
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(meta.Signed, &decoded); err != nil {
+		return "", err
+	}
+	msg, err := cjson.EncodeCanonical(decoded)
+	if err != nil {
+		return "", errors.Wrap(err, "canonicalizing metadata")
+	}
+
+	roleKeys := make(map[string]bool, len(role.KeyIDs))
+	for _, id := range role.KeyIDs {
+		roleKeys[id] = true
+	}
+	verified := map[string]bool{}
+	var signer string
+	for _, sig := range meta.Signatures {
+		key, ok := keys[sig.KeyID]
+		if !roleKeys[sig.KeyID] || !ok || verified[sig.KeyID] {
+			continue
+		}
+		pub, err := notaryPublicKey(key)
+		if err != nil {
+			continue
+		}
+		if verifyNotarySignature(pub, sig.Method, msg, sig.Sig) == nil {
+			verified[sig.KeyID] = true
+			signer = sig.KeyID
+		}
+	}
+	if len(verified) < role.Threshold {
+		return "", fmt.Errorf("%d valid signatures, need %d", len(verified), role.Threshold)
+	}
+	return signer, nil
+}
+
+// notaryCertificate returns the certificate of an X.509 Notary key.
+func notaryCertificate(key notaryKey) (*x509.Certificate, error) {
+	if !strings.HasSuffix(key.KeyType, "-x509") {
+		return nil, fmt.Errorf("%s key is not a certificate", key.KeyType)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(key.KeyVal.Public)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate in key")
+	}
+	return certs[0], nil
+}
+
+func notaryPublicKey(key notaryKey) (crypto.PublicKey, error) {
+	switch key.KeyType {
+	case "ecdsa-x509", "rsa-x509":
+		cert, err := notaryCertificate(key)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "ecdsa", "rsa":
+		return x509.ParsePKIXPublicKey(key.KeyVal.Public)
+	case "ed25519":
+		if len(key.KeyVal.Public) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(key.KeyVal.Public), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", key.KeyType)
+	}
+}
+
+// verifyNotarySignature verifies sig over msg with the given Notary signing
+// method. Notary's ECDSA signatures are the raw concatenation of r and s.
+func verifyNotarySignature(pub crypto.PublicKey, method string, msg, sig []byte) error {
+	digest := sha256.Sum256(msg)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if method != "ecdsa" || len(sig)%2 != 0 {
+			return errors.New("invalid ecdsa signature")
+		}
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return errors.New("invalid ecdsa signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if method != "rsapss" {
+			return fmt.Errorf("unsupported signature method %q for an RSA key", method)
+		}
+		return rsa.VerifyPSS(k, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case ed25519.PublicKey:
+		if method != "ed25519" || !ed25519.Verify(k, msg, sig) {
+			return errors.New("invalid ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
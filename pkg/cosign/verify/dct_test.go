@@ -0,0 +1,164 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cjson "github.com/secure-systems-lab/go-securesystemslib/cjson"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// notaryKeyPair returns a Notary ecdsa-x509 key and the private key it certifies.
+func notaryKeyPair(t *testing.T) (map[string]interface{}, *ecdsa.PrivateKey) {
+	t.Helper()
+	k := generateKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "docker.io/library/test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, k.Public(), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]interface{}{"keytype": "ecdsa-x509", "keyval": map[string]interface{}{"private": nil, "public": p}}, k
+}
+
+// notarySign wraps signed in a Notary metadata file signed by k.
+func notarySign(t *testing.T, signed interface{}, keyID string, k *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	b, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := cjson.EncodeCanonical(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	out, err := json.Marshal(map[string]interface{}{
+		"signed":     signed,
+		"signatures": []map[string]interface{}{{"keyid": keyID, "method": "ecdsa", "sig": sig}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestVerifyDockerContentTrust(t *testing.T) {
+	rootKey, rootPriv := notaryKeyPair(t)
+	targetsKey, targetsPriv := notaryKeyPair(t)
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	root := map[string]interface{}{
+		"_type":   "Root",
+		"expires": expires,
+		"version": 1,
+		"keys":    map[string]interface{}{"root-key": rootKey, "targets-key": targetsKey},
+		"roles": map[string]interface{}{
+			"root":    map[string]interface{}{"keyids": []string{"root-key"}, "threshold": 1},
+			"targets": map[string]interface{}{"keyids": []string{"targets-key"}, "threshold": 1},
+		},
+	}
+	digest := sha256.Sum256([]byte("manifest"))
+	targets := map[string]interface{}{
+		"_type":   "Targets",
+		"expires": expires,
+		"version": 1,
+		"targets": map[string]interface{}{
+			"v1": map[string]interface{}{"hashes": map[string][]byte{"sha256": digest[:]}, "length": 8},
+		},
+	}
+
+	files := map[string][]byte{
+		"/v2/docker.io/library/test/_trust/tuf/root.json":    notarySign(t, root, "root-key", rootPriv),
+		"/v2/docker.io/library/test/_trust/tuf/targets.json": notarySign(t, targets, "targets-key", targetsPriv),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	res, err := VerifyDockerContentTrust(ctx, "test:v1", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Digest, digest[:]) {
+		t.Errorf("Digest = %x, want %x", res.Digest, digest)
+	}
+	if res.Certificate == nil || !res.Certificate.PublicKey.(*ecdsa.PublicKey).Equal(targetsPriv.Public()) {
+		t.Error("Certificate should be the targets key's certificate")
+	}
+
+	if _, err := VerifyDockerContentTrust(ctx, "test:v2", srv.URL); err == nil {
+		t.Error("expected an error for an unsigned tag")
+	}
+	if _, err := VerifyDockerContentTrust(ctx, "other:v1", srv.URL); err == nil {
+		t.Error("expected an error for a repository without trust data")
+	}
+
+	// targets.json signed by the root key instead of a targets key.
+	files["/v2/docker.io/library/test/_trust/tuf/targets.json"] = notarySign(t, targets, "root-key", rootPriv)
+	if _, err := VerifyDockerContentTrust(ctx, "test:v1", srv.URL); err == nil || !strings.Contains(err.Error(), "targets.json") {
+		t.Errorf("expected targets.json to fail verification, got %v", err)
+	}
+
+	// Tampering with the signed targets invalidates the signature.
+	signed := notarySign(t, targets, "targets-key", targetsPriv)
+	files["/v2/docker.io/library/test/_trust/tuf/targets.json"] = bytes.Replace(signed, []byte(`"length":8`), []byte(`"length":9`), 1)
+	if _, err := VerifyDockerContentTrust(ctx, "test:v1", srv.URL); err == nil {
+		t.Error("expected tampered targets.json to fail verification")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+)
+
+// CertificateAttributes are the parts of a signing certificate's identity that a
+// policy given to VerifyImageWithPolicy can match on. They are bound in the CEL
+// expression as issuer, subject and uris.
+type CertificateAttributes struct {
+	// Issuer is the OIDC issuer Fulcio recorded in the certificate.
+	Issuer string
+	// Subject is the certificate's first email address, or else its first URI.
+	Subject string
+	// URIs are the certificate's URI subject alternative names.
+	URIs []string
+}
+
+// CertificateAttributesFrom returns the attributes of cert.
+func CertificateAttributesFrom(cert *x509.Certificate) CertificateAttributes {
+	attrs := CertificateAttributes{
+		Issuer:  sigs.CertIssuerExtension(cert),
+		Subject: sigs.CertSubject(cert),
+	}
+	for _, u := range cert.URIs {
+		attrs.URIs = append(attrs.URIs, u.String())
+	}
+	return attrs
+}
+
+// VerifyImageWithPolicy verifies the signatures of ref with opts, and succeeds if the
+// certificate of at least one verified signature satisfies celExpr. celExpr is a CEL
+// expression evaluating to a bool over the variables issuer, subject and uris, for
+// example:
+//
+//	issuer == "https://token.actions.githubusercontent.com" &&
+//	  uris.exists(u, u.startsWith("https://github.com/sigstore/"))
+//
+// Signatures without a certificate never satisfy the policy.
+func VerifyImageWithPolicy(ctx context.Context, ref name.Reference, celExpr string, opts *cosign.CheckOpts) error {
+	if opts == nil {
+		return errors.New("check options are required")
+	}
+	prg, err := compilePolicy(celExpr)
+	if err != nil {
+		return err
+	}
+
+	// Every verified signature is a candidate, not only the first.
+	co := *opts
+	co.FirstMatch = false
+	verified, _, err := cosignVerifySignatures(ctx, ref, &co)
+	if err != nil {
+		return err
+	}
+	for _, sig := range verified {
+		cert, err := sig.Cert()
+		if err != nil || cert == nil {
+			continue
+		}
+		ok, err := evalPolicy(prg, CertificateAttributesFrom(cert))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no verified signature has a certificate satisfying %q", cosign.ErrIdentityMismatch, celExpr)
+}
+
+// compilePolicy compiles celExpr against the variables of CertificateAttributes.
+func compilePolicy(celExpr string) (cel.Program, error) {
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewVar("issuer", decls.String),
+		decls.NewVar("subject", decls.String),
+		decls.NewVar("uris", decls.NewListType(decls.String)),
+	))
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(celExpr)
+	if iss.Err() != nil {
+		return nil, errors.Wrap(iss.Err(), "compiling policy")
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling policy")
+	}
+	return prg, nil
+}
+
+// evalPolicy reports whether prg accepts attrs.
+func evalPolicy(prg cel.Program, attrs CertificateAttributes) (bool, error) {
+	uris := attrs.URIs
+	if uris == nil {
+		uris = []string{}
+	}
+	out, _, err := prg.Eval(map[string]interface{}{
+		"issuer":  attrs.Issuer,
+		"subject": attrs.Subject,
+		"uris":    uris,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "evaluating policy")
+	}
+	ok, isBool := out.Value().(bool)
+	if !isBool {
+		return false, fmt.Errorf("policy evaluated to %v, not a bool", out.Value())
+	}
+	return ok, nil
+}
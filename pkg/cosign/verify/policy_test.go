@@ -0,0 +1,102 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/static"
+)
+
+func TestEvalPolicy(t *testing.T) {
+	attrs := CertificateAttributes{
+		Issuer:  "https://token.actions.githubusercontent.com",
+		Subject: "https://github.com/sigstore/cosign/.github/workflows/release.yml@refs/heads/main",
+		URIs:    []string{"https://github.com/sigstore/cosign/.github/workflows/release.yml@refs/heads/main"},
+	}
+	tests := []struct {
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{expr: `issuer == "https://token.actions.githubusercontent.com"`, want: true},
+		{expr: `issuer == "https://accounts.google.com"`, want: false},
+		{expr: `uris.exists(u, u.startsWith("https://github.com/sigstore/"))`, want: true},
+		{expr: `subject.endsWith("@refs/heads/release")`, want: false},
+		{expr: `subject`, wantErr: true},
+	}
+	for _, tt := range tests {
+		prg, err := compilePolicy(tt.expr)
+		if err != nil {
+			t.Fatalf("compilePolicy(%s) = %v", tt.expr, err)
+		}
+		got, err := evalPolicy(prg, attrs)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("evalPolicy(%s) = %v, %v, want %v", tt.expr, got, err, tt.want)
+		}
+	}
+
+	if _, err := compilePolicy(`unknown == "x"`); err == nil {
+		t.Error("expected an error compiling a policy with an undeclared variable")
+	}
+}
+
+func TestVerifyImageWithPolicy(t *testing.T) {
+	blob := []byte("hello world")
+	b, _ := testBundle(t, blob)
+	leafPEM, err := cryptoutils.MarshalCertificateToPEM(b.Certificates[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	withCert, err := static.NewSignature(blob, "c2ln", static.WithCertChain(leafPEM, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutCert, err := static.NewSignature(blob, "c2ln")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotOpts *cosign.CheckOpts
+	cosignVerifySignatures = func(_ context.Context, _ name.Reference, co *cosign.CheckOpts) ([]oci.Signature, bool, error) {
+		gotOpts = co
+		return []oci.Signature{withoutCert, withCert}, false, nil
+	}
+	defer func() { cosignVerifySignatures = cosign.VerifyImageSignatures }()
+
+	ctx := context.Background()
+	ref := name.MustParseReference(signedImage)
+	co := &cosign.CheckOpts{FirstMatch: true, RootCerts: x509.NewCertPool()}
+	if err := VerifyImageWithPolicy(ctx, ref, `subject == "foo@example.com" && size(uris) == 0`, co); err != nil {
+		t.Errorf("VerifyImageWithPolicy() = %v", err)
+	}
+	if gotOpts.FirstMatch || !co.FirstMatch {
+		t.Error("expected every signature to be verified without changing the caller's options")
+	}
+	if err := VerifyImageWithPolicy(ctx, ref, `subject == "someone@example.com"`, co); !errors.Is(err, cosign.ErrIdentityMismatch) {
+		t.Errorf("VerifyImageWithPolicy() = %v, want ErrIdentityMismatch", err)
+	}
+	if err := VerifyImageWithPolicy(ctx, ref, `subject ==`, co); err == nil {
+		t.Error("expected an error for an invalid policy")
+	}
+}
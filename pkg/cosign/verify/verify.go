@@ -0,0 +1,266 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verify verifies Sigstore bundles entirely offline, against trust
+// material the caller has already loaded, and the images of objects admitted
+// to Kubernetes.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/cosign/transparency"
+	"github.com/sigstore/cosign/pkg/cosign/tuf"
+)
+
+// VerificationResult describes a bundle that VerifyBundle found to be valid.
+type VerificationResult struct {
+	// Certificate is the signing certificate.
+	Certificate *x509.Certificate
+	// Chain is the verified chain from Certificate up to a trusted root.
+	Chain []*x509.Certificate
+	// Digest is the SHA-256 digest of the signed blob. Callers must compare it
+	// with the digest of the artifact they expect to have been signed.
+	Digest []byte
+	// IntegratedTime is the time Rekor recorded the signature.
+	IntegratedTime time.Time
+	// LogIndex is the index of the signature's Rekor entry.
+	LogIndex int64
+	// InclusionVerified is true if the bundle's inclusion proof was verified
+	// against a checkpoint signed by the transparency log. Otherwise only the
+	// log's promise to include the entry was checked.
+	InclusionVerified bool
+}
+
+// VerifyBundle verifies b against root without making any network calls. It checks
+// that the signing certificate chains to one of the root's certificate authorities,
+// that it embeds an SCT signed by one of the root's CT logs, that the Rekor entry
+// is promised by one of the root's transparency logs, and that the entry, signature
+// and certificate all agree on the signed digest. If the bundle carries an inclusion
+// proof, it must come with a checkpoint signed by the same log, and the proof is
+// verified against the checkpoint's root hash.
+//
+// root is typically loaded once with tuf.GetTrustedRoot and shared between calls.
+func VerifyBundle(b *bundle.Bundle, root *tuf.TrustedRoot) (*VerificationResult, error) {
+	cert := b.Certificate()
+	if cert == nil {
+		return nil, errors.New("bundle has no signing certificate")
+	}
+	if len(b.Digest) == 0 {
+		return nil, errors.New("bundle has no message digest")
+	}
+	if b.Rekor == nil {
+		return nil, errors.New("bundle has no transparency log entry")
+	}
+
+	chain, err := verifyChain(cert, b.Certificates[1:], root.CertificateAuthorities)
+	if err != nil {
+		return nil, errors.Wrap(err, "verifying certificate chain")
+	}
+	if err := verifySCT(cert, chain[1], root.Ctlogs); err != nil {
+		return nil, errors.Wrap(err, "verifying SCT")
+	}
+	if err := verifyTlogEntry(b.Rekor, b.InclusionProof, root.Tlogs); err != nil {
+		return nil, errors.Wrap(err, "verifying transparency log entry")
+	}
+	integratedTime := time.Unix(b.Rekor.Payload.IntegratedTime, 0)
+	if err := cosign.CheckExpiry(cert, integratedTime); err != nil {
+		return nil, err
+	}
+	if err := verifySubject(b, cert); err != nil {
+		return nil, errors.Wrap(err, "verifying signature")
+	}
+
+	return &VerificationResult{
+		Certificate:    cert,
+		Chain:          chain,
+		Digest:         b.Digest,
+		IntegratedTime: integratedTime,
+		LogIndex:       b.Rekor.Payload.LogIndex,
+		// verifyTlogEntry rejects a proof it could not check against a checkpoint.
+		InclusionVerified: b.InclusionProof != nil,
+	}, nil
+}
+
+// verifyChain verifies cert up to one of the PEM encoded roots, returning the chain.
+func verifyChain(cert *x509.Certificate, intermediates []*x509.Certificate, cas []tuf.TargetFile) ([]*x509.Certificate, error) {
+	roots := x509.NewCertPool()
+	for i, ca := range cas {
+		if !roots.AppendCertsFromPEM(ca.Target) {
+			return nil, fmt.Errorf("parsing certificate authority %d", i)
+		}
+	}
+	pool := x509.NewCertPool()
+	for _, c := range intermediates {
+		pool.AddCert(c)
+	}
+	chains, err := cert.Verify(x509.VerifyOptions{
+		// As in cosign.TrustedCert, the certificate only has to have been valid
+		// when it was issued; CheckExpiry ties the signature to that window.
+		CurrentTime:   cert.NotBefore,
+		Roots:         roots,
+		Intermediates: pool,
+		KeyUsages: []x509.ExtKeyUsage{
+			x509.ExtKeyUsage(x509.KeyUsageDigitalSignature),
+			x509.ExtKeyUsageCodeSigning,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(chains[0]) < 2 {
+		return nil, errors.New("signing certificate is itself a trusted root")
+	}
+	return chains[0], nil
+}
+
+// verifySCT verifies the SCTs embedded in cert against the PEM encoded CT log keys.
+// Unlike cosign.VerifyEmbeddedSCT, it fails if cert has none.
+func verifySCT(cert, issuer *x509.Certificate, ctlogs []tuf.TargetFile) error {
+	ok, err := cosign.HasEmbeddedSCT(cert)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("certificate has no embedded SCT")
+	}
+	// cosign.VerifyEmbeddedSCT falls back to fetching the keys from TUF when
+	// it is given none, so refuse to call it without any.
+	if len(ctlogs) == 0 {
+		return errors.New("trusted root has no CT log keys")
+	}
+	pubs := make([]crypto.PublicKey, 0, len(ctlogs))
+	for i, t := range ctlogs {
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey(t.Target)
+		if err != nil {
+			return errors.Wrapf(err, "parsing CT log key %d", i)
+		}
+		pubs = append(pubs, pub)
+	}
+	return cosign.VerifyEmbeddedSCT(context.Background(), cert, issuer, pubs)
+}
+
+// verifyTlogEntry verifies the signed entry timestamp of rb with the transparency
+// log key whose ID it names and, if there is an inclusion proof, verifies it against
+// the checkpoint that comes with it, signed by the same key. A proof on its own
+// only recomputes its own root hash, so one without a checkpoint is rejected.
+func verifyTlogEntry(rb *bundle.RekorBundle, proof *bundle.InclusionProof, tlogs []tuf.TargetFile) error {
+	pub, err := tlogKey(rb.Payload.LogID, tlogs)
+	if err != nil {
+		return err
+	}
+	if err := transparency.VerifySET(rb.Payload, rb.SignedEntryTimestamp, pub); err != nil {
+		return err
+	}
+	if proof == nil {
+		return nil
+	}
+
+	if proof.LogIndex != rb.Payload.LogIndex {
+		return fmt.Errorf("inclusion proof is for log index %d, entry has %d", proof.LogIndex, rb.Payload.LogIndex)
+	}
+	if proof.Checkpoint == "" {
+		return errors.New("inclusion proof has no signed checkpoint")
+	}
+	checkpoint, err := transparency.VerifyCheckpoint(proof.Checkpoint, pub)
+	if err != nil {
+		return err
+	}
+	body, err := transparency.EntryBody(rb.Payload.Body)
+	if err != nil {
+		return err
+	}
+	return checkpoint.VerifyInclusion(body, proof)
+}
+
+// tlogKey returns the PEM encoded transparency log key with the given hex log ID,
+// which is the SHA-256 digest of the key's DER encoding.
+func tlogKey(logID string, tlogs []tuf.TargetFile) (*ecdsa.PublicKey, error) {
+	for i, t := range tlogs {
+		pub, err := cosign.PemToECDSAKey(t.Target)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing transparency log key %d", i)
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		id := sha256.Sum256(der)
+		if hex.EncodeToString(id[:]) == logID {
+			return pub, nil
+		}
+	}
+	return nil, fmt.Errorf("no trusted transparency log key with log ID %s", logID)
+}
+
+// verifySubject checks that the hashedrekord entry of b records b's digest, signature
+// and signing certificate, and that the signature is valid for the digest.
+func verifySubject(b *bundle.Bundle, cert *x509.Certificate) error {
+	body, err := transparency.EntryBody(b.Rekor.Payload.Body)
+	if err != nil {
+		return err
+	}
+	var hrekord models.Hashedrekord
+	if err := json.Unmarshal(body, &hrekord); err != nil {
+		return errors.Wrap(err, "parsing transparency log entry")
+	}
+	specMarshal, err := json.Marshal(hrekord.Spec)
+	if err != nil {
+		return err
+	}
+	var hrekordObj models.HashedrekordV001Schema
+	if err := json.Unmarshal(specMarshal, &hrekordObj); err != nil {
+		return errors.Wrap(err, "parsing hashedrekord entry")
+	}
+	if hrekordObj.Data == nil || hrekordObj.Data.Hash == nil || hrekordObj.Signature == nil || hrekordObj.Signature.PublicKey == nil {
+		return errors.New("incomplete hashedrekord entry")
+	}
+
+	if alg := hrekordObj.Data.Hash.Algorithm; alg == nil || *alg != models.HashedrekordV001SchemaDataHashAlgorithmSha256 {
+		return errors.New("transparency log entry is not for a SHA-256 digest")
+	}
+	if v := hrekordObj.Data.Hash.Value; v == nil || *v != hex.EncodeToString(b.Digest) {
+		return errors.New("bundle digest does not match the transparency log entry")
+	}
+	if !bytes.Equal(hrekordObj.Signature.Content, b.Signature) {
+		return errors.New("bundle signature does not match the transparency log entry")
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(hrekordObj.Signature.PublicKey.Content)
+	if err != nil || len(certs) == 0 || !certs[0].Equal(cert) {
+		return errors.New("bundle certificate does not match the transparency log entry")
+	}
+
+	verifier, err := signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	return verifier.VerifySignature(bytes.NewReader(b.Signature), nil, options.WithDigest(b.Digest))
+}
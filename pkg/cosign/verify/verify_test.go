@@ -0,0 +1,374 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/cosign/tuf"
+)
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func pemKey(t *testing.T, k *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	p, err := cryptoutils.MarshalPublicKeyToPEM(k.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// signEmbeddedSCT returns the extension that embeds an SCT, signed by logKey, for the
+// certificate that tmpl and issuer produce.
+func signEmbeddedSCT(t *testing.T, tmpl, issuer *x509.Certificate, pub crypto.PublicKey, issuerKey, logKey *ecdsa.PrivateKey) pkix.Extension {
+	t.Helper()
+	// The SCT covers the certificate without its SCT list extension, which the CT
+	// library strips from a certificate carrying a placeholder one.
+	pre := *tmpl
+	pre.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT), Value: []byte{asn1.TagOctetString, 0}}}
+	der, err := x509.CreateCertificate(rand.Reader, &pre, issuer, pub, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := ctx509.ParseCertificate(der)
+	if ctx509.IsFatal(err) {
+		t.Fatal(err)
+	}
+	ctIssuer, err := ctx509.ParseCertificate(issuer.Raw)
+	if ctx509.IsFatal(err) {
+		t.Fatal(err)
+	}
+
+	timestamp := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	mtl, err := ct.MerkleTreeLeafForEmbeddedSCT([]*ctx509.Certificate{leaf, ctIssuer}, timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logDER, err := x509.MarshalPKIXPublicKey(&logKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sct := ct.SignedCertificateTimestamp{SCTVersion: ct.V1, LogID: ct.LogID{KeyID: sha256.Sum256(logDER)}, Timestamp: timestamp}
+	input, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{Leaf: *mtl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(input)
+	sig, err := ecdsa.SignASN1(rand.Reader, logKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sct.Signature = ct.DigitallySigned{
+		Algorithm: cttls.SignatureAndHashAlgorithm{Hash: cttls.SHA256, Signature: cttls.ECDSA},
+		Signature: sig,
+	}
+
+	list, err := x509util.MarshalSCTsIntoSCTList([]*ct.SignedCertificateTimestamp{&sct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawList, err := cttls.Marshal(*list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := asn1.Marshal(rawList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT), Value: value}
+}
+
+// signCheckpoint returns a checkpoint for a tree of the given size and root hash,
+// signed as a note by key.
+func signCheckpoint(t *testing.T, key *ecdsa.PrivateKey, size int64, rootHash []byte) string {
+	t.Helper()
+	text := fmt.Sprintf("rekor.sigstore.dev - 0\n%d\n%s\n", size, base64.StdEncoding.EncodeToString(rootHash))
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := sha256.Sum256(der)
+	digest := sha256.Sum256([]byte(text))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%s\n— rekor.sigstore.dev %s\n", text, base64.StdEncoding.EncodeToString(append(keyID[:4], sig...)))
+}
+
+// testBundle returns a valid bundle for blob and the trusted root it verifies against.
+func testBundle(t *testing.T, blob []byte) (*bundle.Bundle, *tuf.TrustedRoot) {
+	t.Helper()
+	now := time.Now().Truncate(time.Second)
+
+	caKey := generateKey(t)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey := generateKey(t)
+	leafTmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		EmailAddresses: []string{"foo@example.com"},
+		NotBefore:      now.Add(-time.Minute),
+		NotAfter:       now.Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	ctKey := generateKey(t)
+	leafTmpl.ExtraExtensions = []pkix.Extension{signEmbeddedSCT(t, leafTmpl, ca, leafKey.Public(), caKey, ctKey)}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafPEM, err := cryptoutils.MarshalCertificateToPEM(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256(blob)
+	sig, err := ecdsa.SignASN1(rand.Reader, leafKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"data":{"hash":{"algorithm":"sha256","value":%q}},"signature":{"content":%q,"publicKey":{"content":%q}}}}`,
+		hex.EncodeToString(digest[:]), base64.StdEncoding.EncodeToString(sig), base64.StdEncoding.EncodeToString(leafPEM))
+
+	rekorKey := generateKey(t)
+	rekorDER, err := x509.MarshalPKIXPublicKey(rekorKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(rekorDER)
+	payload := bundle.RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString([]byte(body)),
+		IntegratedTime: now.Unix(),
+		LogIndex:       0,
+		LogID:          hex.EncodeToString(logID[:]),
+	}
+	contents, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalized, err := jsoncanonicalizer.Transform(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setDigest := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, rekorKey, setDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM, err := cryptoutils.MarshalCertificateToPEM(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafHash := rfc6962.DefaultHasher.HashLeaf([]byte(body))
+	b := &bundle.Bundle{
+		Digest:       digest[:],
+		Signature:    sig,
+		Certificates: []*x509.Certificate{leaf},
+		Rekor:        &bundle.RekorBundle{SignedEntryTimestamp: set, Payload: payload},
+		// A tree with a single leaf: the root hash is the leaf hash.
+		InclusionProof: &bundle.InclusionProof{
+			LogIndex:   0,
+			TreeSize:   1,
+			RootHash:   leafHash,
+			Checkpoint: signCheckpoint(t, rekorKey, 1, leafHash),
+		},
+	}
+	root := &tuf.TrustedRoot{
+		CertificateAuthorities: []tuf.TargetFile{{Target: caPEM}},
+		Tlogs:                  []tuf.TargetFile{{Target: pemKey(t, rekorKey)}},
+		Ctlogs:                 []tuf.TargetFile{{Target: pemKey(t, ctKey)}},
+	}
+	return b, root
+}
+
+func TestVerifySCTMissing(t *testing.T) {
+	key := generateKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Minute),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctlogs := []tuf.TargetFile{{Target: pemKey(t, generateKey(t))}}
+	if err := verifySCT(cert, cert, ctlogs); err == nil {
+		t.Error("verifySCT() accepted a certificate without an embedded SCT")
+	}
+}
+
+func TestVerifyBundle(t *testing.T) {
+	blob := []byte("hello world")
+	b, root := testBundle(t, blob)
+
+	res, err := VerifyBundle(b, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(blob); hex.EncodeToString(res.Digest) != hex.EncodeToString(want[:]) {
+		t.Errorf("Digest = %x, want %x", res.Digest, want)
+	}
+	if len(res.Chain) != 2 {
+		t.Errorf("len(Chain) = %d, want 2", len(res.Chain))
+	}
+	if res.IntegratedTime.Unix() != b.Rekor.Payload.IntegratedTime {
+		t.Errorf("IntegratedTime = %v, want %d", res.IntegratedTime, b.Rekor.Payload.IntegratedTime)
+	}
+	if !res.InclusionVerified {
+		t.Error("InclusionVerified = false, want true")
+	}
+
+	// Without a proof, only the promise is checked, and the result says so.
+	b.InclusionProof = nil
+	if res, err := VerifyBundle(b, root); err != nil || res.InclusionVerified {
+		t.Errorf("VerifyBundle() without a proof = %+v, %v", res, err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*bundle.Bundle, *tuf.TrustedRoot)
+	}{{
+		name: "untrusted root",
+		mutate: func(_ *bundle.Bundle, r *tuf.TrustedRoot) {
+			_, otherRoot := testBundle(t, blob)
+			r.CertificateAuthorities = otherRoot.CertificateAuthorities
+		},
+	}, {
+		name: "untrusted CT log",
+		mutate: func(_ *bundle.Bundle, r *tuf.TrustedRoot) {
+			r.Ctlogs = []tuf.TargetFile{{Target: pemKey(t, generateKey(t))}}
+		},
+	}, {
+		name: "no CT logs",
+		mutate: func(_ *bundle.Bundle, r *tuf.TrustedRoot) {
+			r.Ctlogs = nil
+		},
+	}, {
+		name: "unknown transparency log",
+		mutate: func(_ *bundle.Bundle, r *tuf.TrustedRoot) {
+			r.Tlogs = []tuf.TargetFile{{Target: pemKey(t, generateKey(t))}}
+		},
+	}, {
+		name: "bad SET",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.Rekor.Payload.IntegratedTime++
+		},
+	}, {
+		name: "bad inclusion proof",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.InclusionProof.RootHash = make([]byte, sha256.Size)
+		},
+	}, {
+		name: "inclusion proof without checkpoint",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.InclusionProof.Checkpoint = ""
+		},
+	}, {
+		name: "checkpoint from another key",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.InclusionProof.Checkpoint = signCheckpoint(t, generateKey(t), b.InclusionProof.TreeSize, b.InclusionProof.RootHash)
+		},
+	}, {
+		name: "digest mismatch",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			d := sha256.Sum256([]byte("something else"))
+			b.Digest = d[:]
+		},
+	}, {
+		name: "signature mismatch",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.Signature = []byte("not the signature")
+		},
+	}, {
+		name: "no certificate",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.Certificates = nil
+		},
+	}, {
+		name: "no tlog entry",
+		mutate: func(b *bundle.Bundle, _ *tuf.TrustedRoot) {
+			b.Rekor = nil
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, root := testBundle(t, blob)
+			tt.mutate(b, root)
+			if _, err := VerifyBundle(b, root); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
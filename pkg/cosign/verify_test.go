@@ -15,26 +15,54 @@
 package cosign
 
 import (
+	"bytes"
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/pkg/errors"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/static"
 	"github.com/sigstore/cosign/pkg/types"
+	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
+	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
 )
 
 type mockVerifier struct {
 	shouldErr bool
+	pub       crypto.PublicKey
 }
 
 func (m *mockVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
-	return nil, nil
+	return m.pub, nil
 }
 
 func (m *mockVerifier) VerifySignature(signature, message io.Reader, opts ...signature.VerifyOption) error {
@@ -46,6 +74,23 @@ func (m *mockVerifier) VerifySignature(signature, message io.Reader, opts ...sig
 
 var _ signature.Verifier = (*mockVerifier)(nil)
 
+// payloadVerifier accepts signatures over payload only.
+type payloadVerifier struct {
+	mockVerifier
+	payload string
+}
+
+func (p *payloadVerifier) VerifySignature(signature, message io.Reader, opts ...signature.VerifyOption) error {
+	b, err := io.ReadAll(message)
+	if err != nil {
+		return err
+	}
+	if string(b) != p.payload {
+		return errors.New("failure")
+	}
+	return nil
+}
+
 type mockAttestation struct {
 	payload interface{}
 }
@@ -89,3 +134,661 @@ func Test_verifyOCIAttestation(t *testing.T) {
 		t.Error("verifyOCIAttestation() expected invalid payload type error, got nil")
 	}
 }
+
+func TestVerifyBlobAttestation(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobRef := fmt.Sprintf("%s/blob:latest", u.Host)
+	ref, err := name.ParseReference(blobRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDigest, err := layers[0].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAttestation := func(t *testing.T, digest, predicateType string) string {
+		t.Helper()
+		stmt, err := json.Marshal(in_toto.Statement{
+			StatementHeader: in_toto.StatementHeader{
+				Type:          in_toto.StatementInTotoV01,
+				PredicateType: predicateType,
+				Subject: []in_toto.Subject{{
+					Name:   "blob",
+					Digest: map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")},
+				}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := json.Marshal(dsse.Envelope{
+			PayloadType: types.IntotoPayloadType,
+			Payload:     base64.StdEncoding.EncodeToString(stmt),
+			Signatures:  []dsse.Signature{{Sig: base64.StdEncoding.EncodeToString([]byte("foobar"))}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(t.TempDir(), "attestation.json")
+		if err := os.WriteFile(p, env, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name          string
+		digest        string
+		predicateType string
+		verifier      *mockVerifier
+		wantErr       bool
+	}{{
+		name:          "matching subject",
+		digest:        layerDigest.String(),
+		predicateType: in_toto.PredicateSPDX,
+		verifier:      &mockVerifier{},
+	}, {
+		name:          "mismatched subject",
+		digest:        "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		predicateType: in_toto.PredicateSPDX,
+		verifier:      &mockVerifier{},
+		wantErr:       true,
+	}, {
+		name:          "wrong predicate type",
+		digest:        layerDigest.String(),
+		predicateType: "https://example.com/other",
+		verifier:      &mockVerifier{},
+		wantErr:       true,
+	}, {
+		name:          "bad signature",
+		digest:        layerDigest.String(),
+		predicateType: in_toto.PredicateSPDX,
+		verifier:      &mockVerifier{shouldErr: true},
+		wantErr:       true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var failed []string
+			co := &CheckOpts{
+				SigVerifier:   tc.verifier,
+				PredicateType: in_toto.PredicateSPDX,
+				VerifyHook: func(step string, passed bool, _ string) {
+					if !passed {
+						failed = append(failed, step)
+					}
+				},
+			}
+			err := VerifyBlobAttestation(context.Background(), co, blobRef, writeAttestation(t, tc.digest, tc.predicateType), nil)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("VerifyBlobAttestation() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.verifier.shouldErr && !reflect.DeepEqual(failed, []string{VerifyStepSignature}) {
+				t.Errorf("VerifyHook failed steps = %v, want %q", failed, VerifyStepSignature)
+			}
+		})
+	}
+
+	// With several attestations in the file, only the one of the requested
+	// predicate type is verified.
+	var envelopes []byte
+	for _, pt := range []string{"https://example.com/other", in_toto.PredicateSPDX} {
+		env, err := os.ReadFile(writeAttestation(t, layerDigest.String(), pt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		envelopes = append(append(envelopes, env...), '\n')
+	}
+	multi := filepath.Join(t.TempDir(), "attestations.jsonl")
+	if err := os.WriteFile(multi, envelopes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	co := &CheckOpts{SigVerifier: &mockVerifier{}, PredicateType: in_toto.PredicateSPDX}
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, multi, nil); err != nil {
+		t.Errorf("VerifyBlobAttestation() with several attestations = %v", err)
+	}
+	co.PredicateType = in_toto.PredicateLinkV1
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, multi, nil); !errors.Is(err, ErrNoAttestations) {
+		t.Errorf("VerifyBlobAttestation() with no matching predicate type = %v, want ErrNoAttestations", err)
+	}
+
+	// An envelope that cannot be parsed does not stop the others from being verified.
+	withBad := filepath.Join(t.TempDir(), "attestations.jsonl")
+	if err := os.WriteFile(withBad, append([]byte("{}\n"), envelopes...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	co.PredicateType = in_toto.PredicateSPDX
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, withBad, nil); err != nil {
+		t.Errorf("VerifyBlobAttestation() with an unparseable attestation = %v", err)
+	}
+}
+
+func TestVerifyBlobAttestationCert(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobRef := fmt.Sprintf("%s/blob:latest", u.Host)
+	ref, err := name.ParseReference(blobRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{"foo@example.com"},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := json.Marshal(in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: in_toto.PredicateSPDX,
+			Subject: []in_toto.Subject{{
+				Name:   "blob",
+				Digest: map[string]string{"sha256": h.Hex},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(leafKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := sigdsse.WrapSigner(sv, types.IntotoPayloadType).SignMessage(bytes.NewReader(stmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attPath := filepath.Join(t.TempDir(), "attestation.json")
+	if err := os.WriteFile(attPath, env, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorAt := func(it time.Time) *client.Rekor {
+		return newFakeRekor(t, logKey, signedEntry(t, logKey, []byte(`{"kind":"intoto"}`), it.Unix()))
+	}
+
+	co := &CheckOpts{RootCerts: roots, CertEmail: "foo@example.com"}
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, attPath, leaf); err == nil {
+		t.Error("VerifyBlobAttestation() with a certificate and no transparency log succeeded")
+	}
+	co.RekorClient = rekorAt(time.Now())
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, attPath, leaf); err != nil {
+		t.Errorf("VerifyBlobAttestation() with a trusted certificate = %v", err)
+	}
+	co.RekorClient = rekorAt(time.Now().Add(2 * time.Hour))
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, attPath, leaf); err == nil {
+		t.Error("VerifyBlobAttestation() logged after the certificate expired succeeded")
+	}
+	co.RekorClient = rekorAt(time.Now())
+	co.CertEmail = "bar@example.com"
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, attPath, leaf); !errors.Is(err, ErrIdentityMismatch) {
+		t.Errorf("VerifyBlobAttestation() with a mismatched email = %v, want ErrIdentityMismatch", err)
+	}
+	co = &CheckOpts{RootCerts: x509.NewCertPool(), RekorClient: rekorAt(time.Now())}
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, attPath, leaf); err == nil {
+		t.Error("VerifyBlobAttestation() with an untrusted certificate succeeded")
+	}
+	if err := VerifyBlobAttestation(context.Background(), co, blobRef, attPath, nil); err == nil {
+		t.Error("VerifyBlobAttestation() without a verifier or certificate succeeded")
+	}
+}
+
+func TestValidateAndUnpackCertOIDCIssuer(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{"foo@example.com"},
+		URIs:           []*url.URL{{Scheme: "https", Host: "github.com", Path: "/acme/app/.github/workflows/release.yml@refs/heads/main"}},
+		ExtraExtensions: []pkix.Extension{{
+			Id:    oidcIssuerOID,
+			Value: []byte("https://token.actions.githubusercontent.com/acme"),
+		}},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		re       *regexp.Regexp
+		identity *regexp.Regexp
+		wantErr  bool
+	}{{
+		name: "no regexp",
+	}, {
+		name: "matching regexp",
+		re:   regexp.MustCompile(`^https://token\.actions\.githubusercontent\.com/.*$`),
+	}, {
+		name:    "non-matching regexp",
+		re:      regexp.MustCompile(`^https://accounts\.google\.com$`),
+		wantErr: true,
+	}, {
+		name:     "identity regexp matching URI",
+		identity: regexp.MustCompile(`^https://github\.com/acme/[^/]+/\.github/workflows/release\.yml@`),
+	}, {
+		name:     "identity regexp matching email",
+		identity: regexp.MustCompile(`@example\.com$`),
+	}, {
+		name:     "non-matching identity regexp",
+		identity: regexp.MustCompile(`^https://github\.com/other/`),
+		wantErr:  true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var steps []string
+			var failed []string
+			co := &CheckOpts{
+				RootCerts:          roots,
+				OIDCIssuerRegexp:   tc.re,
+				CertIdentityRegexp: tc.identity,
+				VerifyHook: func(step string, passed bool, detail string) {
+					steps = append(steps, step)
+					if !passed {
+						failed = append(failed, step)
+						if detail == "" {
+							t.Errorf("VerifyHook(%q) failed with no detail", step)
+						}
+					}
+				},
+			}
+			_, err := validateAndUnpackCert(context.Background(), leaf, co)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateAndUnpackCert() err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr && !errors.Is(err, ErrIdentityMismatch) {
+				t.Errorf("validateAndUnpackCert() err = %v, want ErrIdentityMismatch", err)
+			}
+			if len(steps) == 0 || steps[0] != VerifyStepCertificateChain {
+				t.Errorf("VerifyHook steps = %v, want %q first", steps, VerifyStepCertificateChain)
+			}
+			if (len(failed) != 0) != tc.wantErr || (tc.wantErr && failed[0] != steps[len(steps)-1]) {
+				t.Errorf("VerifyHook failed steps = %v of %v, wantErr %v", failed, steps, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerificationErrors(t *testing.T) {
+	cert := &x509.Certificate{
+		NotBefore: time.Unix(1000, 0),
+		NotAfter:  time.Unix(2000, 0),
+	}
+	if err := CheckExpiry(cert, time.Unix(3000, 0)); !errors.Is(err, ErrCertExpired) {
+		t.Errorf("CheckExpiry() = %v, want ErrCertExpired", err)
+	}
+	if err := CheckExpiry(cert, time.Unix(500, 0)); !errors.Is(err, ErrCertNotYetValid) {
+		t.Errorf("CheckExpiry() = %v, want ErrCertNotYetValid", err)
+	}
+
+	err := &verificationErrors{
+		err:  ErrNoMatchingSignatures,
+		errs: []error{errors.New("bad signature"), errors.Wrap(ErrRekorEntryNotFound, "checking tlog")},
+	}
+	for _, target := range []error{ErrNoMatchingSignatures, ErrRekorEntryNotFound} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(%v, %v) = false", err, target)
+		}
+	}
+	if errors.Is(err, ErrCertExpired) {
+		t.Errorf("errors.Is(%v, ErrCertExpired) = true", err)
+	}
+	if want := "no matching signatures:\nbad signature\n checking tlog: signature not found in transparency log"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	if _, _, err := verifySignatures(context.Background(), &fakeOCISignatures{}, v1.Hash{}, &CheckOpts{}); err != ErrNoSignatures {
+		t.Errorf("verifySignatures() = %v, want ErrNoSignatures", err)
+	}
+}
+
+func TestVerifySignaturesFirstMatch(t *testing.T) {
+	var sl []oci.Signature
+	for _, p := range []string{"first", "second"} {
+		sig, err := static.NewSignature([]byte(p), base64.StdEncoding.EncodeToString([]byte("sig")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sl = append(sl, sig)
+	}
+	sigs := &fakeOCISignatures{signatures: sl}
+	newKey := func() crypto.PublicKey {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return priv.Public()
+	}
+	key1, key2 := newKey(), newKey()
+
+	for _, tc := range []struct {
+		firstMatch bool
+		want       int
+	}{{false, 2}, {true, 1}} {
+		co := &CheckOpts{SigVerifier: &mockVerifier{pub: key1}, FirstMatch: tc.firstMatch}
+		verified, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(verified) != tc.want {
+			t.Errorf("FirstMatch = %v: got %d verified signatures, want %d", tc.firstMatch, len(verified), tc.want)
+		}
+	}
+
+	// FirstMatch keeps going until the threshold is met, counting each key once.
+	co := &CheckOpts{
+		SigVerifier:            &payloadVerifier{mockVerifier{pub: key1}, "first"},
+		AdditionalSigVerifiers: []signature.Verifier{&payloadVerifier{mockVerifier{pub: key2}, "second"}},
+		FirstMatch:             true,
+		SignatureThreshold:     2,
+	}
+	verified, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verified) != 2 {
+		t.Errorf("got %d verified signatures, want 2", len(verified))
+	}
+
+	co.SignatureThreshold = 3
+	if _, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co); !errors.Is(err, ErrThresholdNotMet) {
+		t.Errorf("verifySignatures() = %v, want ErrThresholdNotMet", err)
+	}
+
+	// Two signatures by the same key are one signer.
+	co = &CheckOpts{SigVerifier: &mockVerifier{pub: key1}, SignatureThreshold: 2}
+	if _, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co); !errors.Is(err, ErrThresholdNotMet) {
+		t.Errorf("verifySignatures() = %v, want ErrThresholdNotMet", err)
+	} else if !strings.Contains(err.Error(), "2 of 2 signatures verified, from 1 distinct signers, 2 required") {
+		t.Errorf("verifySignatures() = %v, want the number of verified signatures and signers", err)
+	}
+
+	// A key passed again as an additional key is still one signer.
+	co = &CheckOpts{
+		SigVerifier:            &payloadVerifier{mockVerifier{pub: key1}, "first"},
+		AdditionalSigVerifiers: []signature.Verifier{&payloadVerifier{mockVerifier{pub: key1}, "second"}},
+		SignatureThreshold:     2,
+	}
+	if _, _, err := verifySignatures(context.Background(), sigs, v1.Hash{}, co); !errors.Is(err, ErrThresholdNotMet) {
+		t.Errorf("verifySignatures() = %v, want ErrThresholdNotMet", err)
+	}
+}
+
+func TestCertSigner(t *testing.T) {
+	u, _ := url.Parse("https://github.com/foo/bar/.github/workflows/release.yml@refs/heads/main")
+	a := &x509.Certificate{EmailAddresses: []string{"foo@example.com"}}
+	b := &x509.Certificate{URIs: []*url.URL{u}}
+	if certSigner(a) == certSigner(b) {
+		t.Errorf("certificates with different identities are the same signer %s", certSigner(a))
+	}
+	if certSigner(a) != certSigner(&x509.Certificate{EmailAddresses: []string{"foo@example.com"}}) {
+		t.Error("certificates with the same identity are different signers")
+	}
+}
+
+func TestCheckContainerIdentity(t *testing.T) {
+	for _, tc := range []struct {
+		identity, image string
+		want            bool
+	}{
+		{"gcr.io/foo/bar", "gcr.io/foo/bar:latest", true},
+		{"gcr.io/foo/bar", "gcr.io/foo/bar@sha256:" + strings.Repeat("a", 64), true},
+		{"gcr.io/foo/bar:v1", "gcr.io/foo/bar:v1", true},
+		{"gcr.io/foo/bar:v1", "gcr.io/foo/bar:v2", false},
+		{"gcr.io/foo/bar", "gcr.io/foo/baz", false},
+		{"ubuntu", "index.docker.io/library/ubuntu:22.04", true},
+	} {
+		if got := containerIdentityMatches(tc.identity, tc.image); got != tc.want {
+			t.Errorf("containerIdentityMatches(%q, %q) = %v, want %v", tc.identity, tc.image, got, tc.want)
+		}
+	}
+
+	sig, err := static.NewSignature([]byte(`{"critical":{},"optional":{"containerIdentity":"gcr.io/foo/bar"}}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkContainerIdentity(sig, "gcr.io/foo/bar:latest"); err != nil {
+		t.Errorf("checkContainerIdentity() = %v", err)
+	}
+	if err := checkContainerIdentity(sig, "gcr.io/foo/other"); !errors.Is(err, ErrIdentityMismatch) {
+		t.Errorf("checkContainerIdentity() = %v, want ErrIdentityMismatch", err)
+	}
+	unnamed, err := static.NewSignature([]byte(`{"critical":{}}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkContainerIdentity(unnamed, "gcr.io/foo/bar"); !errors.Is(err, ErrIdentityMismatch) {
+		t.Errorf("checkContainerIdentity() = %v, want ErrIdentityMismatch", err)
+	}
+}
+
+func TestReportBundle(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(-time.Minute),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := static.NewSignature([]byte(`{"critical":{}}`), "", static.WithCertChain(certPEM, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := errors.Wrap(CheckExpiry(cert, time.Now()), "checking expiry on cert")
+
+	for _, tc := range []struct {
+		name     string
+		verified bool
+		err      error
+		want     []string
+	}{
+		{name: "expired certificate", err: expired, want: []string{"certificate_expiry:false"}},
+		{name: "bad entry", err: errors.New("bad SET"), want: []string{"rekor_bundle:false"}},
+		{name: "verified", verified: true, want: []string{"rekor_bundle:true", "certificate_expiry:true"}},
+		{name: "no bundle"},
+	} {
+		var got []string
+		co := &CheckOpts{VerifyHook: func(step string, passed bool, _ string) {
+			got = append(got, fmt.Sprintf("%s:%v", step, passed))
+		}}
+		co.reportBundle(sig, tc.verified, tc.err)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: reported %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestVerifyBundleKeyBinding(t *testing.T) {
+	ctx := context.Background()
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorPubs := []*ecdsa.PublicKey{&logKey.PublicKey}
+	sign := func(priv *ecdsa.PrivateKey, payload []byte) string {
+		sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := sv.SignMessage(bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemPub, err := cryptoutils.MarshalPublicKeyToPEM(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"critical":{}}`)
+	b64sig := sign(priv, payload)
+	pe, err := proposedEntry(b64sig, payload, pemPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(pe[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := signedEntry(t, logKey, body, time.Now().Unix())
+	bundled := func(payload []byte, b64sig string) oci.Signature {
+		sig, err := static.NewSignature(payload, b64sig, static.WithBundle(cbundle.EntryToBundle(&entry)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+
+	if ok, err := verifyBundle(ctx, bundled(payload, b64sig), &priv.PublicKey, rekorPubs); !ok || err != nil {
+		t.Fatalf("verifyBundle() = %v, %v", ok, err)
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPayload := []byte(`{"critical":{"other":true}}`)
+	for name, tc := range map[string]struct {
+		sig oci.Signature
+		pub crypto.PublicKey
+	}{
+		"other key":       {bundled(payload, b64sig), &other.PublicKey},
+		"other signature": {bundled(payload, sign(priv, payload)), &priv.PublicKey},
+		"other payload":   {bundled(otherPayload, sign(priv, otherPayload)), &priv.PublicKey},
+	} {
+		if ok, err := verifyBundle(ctx, tc.sig, tc.pub, rekorPubs); ok || err == nil {
+			t.Errorf("%s: verifyBundle() = %v, %v, want an error", name, ok, err)
+		}
+	}
+}
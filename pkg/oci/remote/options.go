@@ -16,6 +16,8 @@
 package remote
 
 import (
+	"context"
+	"net/http"
 	"os"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -43,6 +45,14 @@ type options struct {
 	TagPrefix         string
 	TargetRepository  name.Repository
 	ROpt              []remote.Option
+	// Keychain authenticates the requests that ROpt can't be used for, such
+	// as those to the OCI referrers API. If nil, authn.DefaultKeychain is used.
+	Keychain authn.Keychain
+	// Transport and Context are used for the same requests. They should match
+	// the remote.WithTransport and remote.WithContext options in ROpt, if any.
+	// If nil, http.DefaultTransport and context.Background() are used.
+	Transport http.RoundTripper
+	Context   context.Context
 
 	OriginalOptions []Option
 }
@@ -113,6 +123,30 @@ func WithRemoteOptions(opts ...remote.Option) Option {
 	}
 }
 
+// WithKeychain is a functional option for overriding the default keychain
+// used to authenticate requests to the OCI referrers API.
+func WithKeychain(kc authn.Keychain) Option {
+	return func(o *options) {
+		o.Keychain = kc
+	}
+}
+
+// WithTransport is a functional option for overriding the default transport
+// used for requests to the OCI referrers API.
+func WithTransport(t http.RoundTripper) Option {
+	return func(o *options) {
+		o.Transport = t
+	}
+}
+
+// WithContext is a functional option for setting the context of requests to
+// the OCI referrers API.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.Context = ctx
+	}
+}
+
 // WithTargetRepository is a functional option for overriding the default
 // target repository hosting the signature and attestation tags.
 func WithTargetRepository(repo name.Repository) Option {
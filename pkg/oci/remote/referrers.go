@@ -0,0 +1,371 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/oci"
+	"github.com/sigstore/cosign/pkg/oci/empty"
+	"github.com/sigstore/cosign/pkg/oci/mutate"
+)
+
+// SignatureArtifactType is the artifactType of the manifests holding cosign
+// signatures stored as OCI referrers.
+const SignatureArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+// referrerDescriptor is a v1.Descriptor with the OCI v1.1 artifactType field.
+type referrerDescriptor struct {
+	MediaType    types.MediaType   `json:"mediaType"`
+	Size         int64             `json:"size"`
+	Digest       v1.Hash           `json:"digest"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// referrerManifest is an OCI v1.1 image manifest with a subject.
+type referrerManifest struct {
+	SchemaVersion int64               `json:"schemaVersion"`
+	MediaType     types.MediaType     `json:"mediaType"`
+	ArtifactType  string              `json:"artifactType,omitempty"`
+	Config        v1.Descriptor       `json:"config"`
+	Layers        []v1.Descriptor     `json:"layers"`
+	Subject       *referrerDescriptor `json:"subject,omitempty"`
+	Annotations   map[string]string   `json:"annotations,omitempty"`
+}
+
+// referrersIndex is the image index returned by the referrers API, and stored
+// under the referrers fallback tag by registries that don't support it.
+type referrersIndex struct {
+	SchemaVersion int64                `json:"schemaVersion"`
+	MediaType     types.MediaType      `json:"mediaType"`
+	Manifests     []referrerDescriptor `json:"manifests"`
+}
+
+// rawManifest is a manifest to remote.Put as is.
+type rawManifest struct {
+	raw       []byte
+	mediaType types.MediaType
+}
+
+func (r rawManifest) RawManifest() ([]byte, error)        { return r.raw, nil }
+func (r rawManifest) MediaType() (types.MediaType, error) { return r.mediaType, nil }
+
+// WriteReferrerSignatures publishes the signatures attached to se into the
+// provided repository as a manifest whose subject is se, using the OCI v1.1
+// referrers API instead of the signature tag. On registries that don't support
+// the referrers API, the manifest is also added to the referrers fallback tag,
+// sha256-<digest>.
+func WriteReferrerSignatures(repo name.Repository, se oci.SignedEntity, opts ...Option) error {
+	o := makeOptions(repo, opts...)
+
+	sigs, err := se.Signatures()
+	if err != nil {
+		return err
+	}
+	subject, err := subjectDescriptor(se)
+	if err != nil {
+		return errors.Wrap(err, "describing subject")
+	}
+
+	layers, err := sigs.Layers()
+	if err != nil {
+		return err
+	}
+	for _, l := range layers {
+		if err := remote.WriteLayer(o.TargetRepository, l, o.ROpt...); err != nil {
+			return errors.Wrap(err, "writing signature layer")
+		}
+	}
+	m, err := sigs.Manifest()
+	if err != nil {
+		return err
+	}
+	cfg, err := sigs.RawConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := remote.WriteLayer(o.TargetRepository, static.NewLayer(cfg, m.Config.MediaType), o.ROpt...); err != nil {
+		return errors.Wrap(err, "writing signature config")
+	}
+
+	raw, err := json.Marshal(referrerManifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		ArtifactType:  SignatureArtifactType,
+		Config:        m.Config,
+		Layers:        m.Layers,
+		Subject:       subject,
+		Annotations:   m.Annotations,
+	})
+	if err != nil {
+		return err
+	}
+	h, size, err := v1.SHA256(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	if err := remote.Put(o.TargetRepository.Digest(h.String()), rawManifest{raw: raw, mediaType: types.OCIManifestSchema1}, o.ROpt...); err != nil {
+		return errors.Wrap(err, "writing referrer manifest")
+	}
+
+	_, supported, err := referrersFromAPI(o.TargetRepository, subject.Digest, o)
+	if err != nil {
+		return err
+	}
+	if supported {
+		return nil
+	}
+	return addToFallbackTag(o.TargetRepository, subject.Digest, referrerDescriptor{
+		MediaType:    types.OCIManifestSchema1,
+		Size:         size,
+		Digest:       h,
+		ArtifactType: SignatureArtifactType,
+	}, o)
+}
+
+// ReferrerSignatures fetches the signatures stored as OCI referrers of the
+// entity with digest d, from the referrers API or, if the registry doesn't
+// support it, the referrers fallback tag. If there are none, this returns an
+// empty oci.Signatures.
+func ReferrerSignatures(d name.Digest, opts ...Option) (oci.Signatures, error) {
+	o := makeOptions(d.Context(), opts...)
+	h, err := v1.NewHash(d.DigestStr())
+	if err != nil {
+		return nil, err
+	}
+
+	idx, supported, err := referrersFromAPI(o.TargetRepository, h, o)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		if idx, err = fallbackIndex(o.TargetRepository, h, o); err != nil {
+			return nil, err
+		}
+	}
+
+	var all []oci.Signature
+	seen := map[v1.Hash]bool{}
+	for _, desc := range idx.Manifests {
+		if desc.ArtifactType != SignatureArtifactType {
+			continue
+		}
+		img, err := remoteImage(o.TargetRepository.Digest(desc.Digest.String()), o.ROpt...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching referrer %s", desc.Digest)
+		}
+		got, err := (&sigs{Image: img}).Get()
+		if err != nil {
+			return nil, err
+		}
+		// Each signing writes a new referrer holding the signatures seen so far.
+		for _, sig := range got {
+			sd, err := sig.Digest()
+			if err != nil {
+				return nil, err
+			}
+			if !seen[sd] {
+				seen[sd] = true
+				all = append(all, sig)
+			}
+		}
+	}
+	return mutate.AppendSignatures(empty.Signatures(), all...)
+}
+
+func subjectDescriptor(se oci.SignedEntity) (*referrerDescriptor, error) {
+	m, ok := se.(interface {
+		RawManifest() ([]byte, error)
+		MediaType() (types.MediaType, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("unsupported entity type %T", se)
+	}
+	raw, err := m.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	mt, err := m.MediaType()
+	if err != nil {
+		return nil, err
+	}
+	h, size, err := v1.SHA256(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return &referrerDescriptor{MediaType: mt, Size: size, Digest: h}, nil
+}
+
+// referrersFromAPI lists the referrers of subject in repo with the referrers
+// API, following the Link header across pages, and reports whether the
+// registry supports it.
+func referrersFromAPI(repo name.Repository, subject v1.Hash, o *options) (*referrersIndex, bool, error) {
+	kc := o.Keychain
+	if kc == nil {
+		kc = authn.DefaultKeychain
+	}
+	auth, err := kc.Resolve(repo.Registry)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "resolving registry credentials")
+	}
+	base := o.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	tr, err := transport.New(repo.Registry, auth, base, []string{repo.Scope(transport.PullScope)})
+	if err != nil {
+		return nil, false, err
+	}
+	ctx := o.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c := &http.Client{Transport: tr}
+
+	u := &url.URL{
+		Scheme: repo.Registry.Scheme(),
+		Host:   repo.RegistryStr(),
+		Path:   fmt.Sprintf("/v2/%s/referrers/%s", repo.RepositoryStr(), subject),
+	}
+	idx := &referrersIndex{SchemaVersion: 2, MediaType: types.OCIImageIndex}
+	seen := map[string]bool{}
+	for u != nil {
+		if seen[u.String()] {
+			return nil, false, errors.Errorf("referrers pagination loops back to %s", u)
+		}
+		seen[u.String()] = true
+
+		page, next, found, err := referrersPage(ctx, c, u)
+		if err != nil {
+			return nil, false, err
+		}
+		if !found {
+			if len(seen) == 1 {
+				return nil, false, nil
+			}
+			return nil, false, errors.Errorf("referrers page %s not found", u)
+		}
+		idx.Manifests = append(idx.Manifests, page.Manifests...)
+		u = next
+	}
+	return idx, true, nil
+}
+
+// referrersPage fetches one page of referrers from u, returning the URL of
+// the next page, if any, and whether the page was found.
+func referrersPage(ctx context.Context, c *http.Client, u *url.URL) (*referrersIndex, *url.URL, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	req.Header.Set("Accept", string(types.OCIImageIndex))
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err, "listing referrers")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, false, nil
+	}
+	if err := transport.CheckError(resp, http.StatusOK); err != nil {
+		return nil, nil, false, err
+	}
+	var idx referrersIndex
+	if err := json.NewDecoder(resp.Body).Decode(&idx); err != nil {
+		return nil, nil, false, errors.Wrap(err, "decoding referrers")
+	}
+	next, err := nextPage(u, resp.Header.Get("Link"))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return &idx, next, true, nil
+}
+
+// nextPage returns the target of the rel="next" link in the Link header,
+// resolved against u, or nil if there is none.
+func nextPage(u *url.URL, link string) (*url.URL, error) {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if rel := strings.TrimSpace(p); rel == `rel="next"` || rel == "rel=next" {
+				next, err := u.Parse(strings.Trim(target, "<>"))
+				if err != nil {
+					return nil, errors.Wrap(err, "parsing referrers Link header")
+				}
+				return next, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func fallbackTag(repo name.Repository, subject v1.Hash) name.Tag {
+	return repo.Tag(strings.Replace(subject.String(), ":", "-", 1))
+}
+
+// fallbackIndex returns the index stored under the referrers fallback tag of
+// subject, or an empty index if there is none.
+func fallbackIndex(repo name.Repository, subject v1.Hash, o *options) (*referrersIndex, error) {
+	idx := &referrersIndex{SchemaVersion: 2, MediaType: types.OCIImageIndex}
+	desc, err := remote.Get(fallbackTag(repo, subject), o.ROpt...)
+	var te *transport.Error
+	if errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
+		return idx, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(desc.Manifest, idx); err != nil {
+		return nil, errors.Wrap(err, "decoding referrers fallback index")
+	}
+	return idx, nil
+}
+
+func addToFallbackTag(repo name.Repository, subject v1.Hash, desc referrerDescriptor, o *options) error {
+	idx, err := fallbackIndex(repo, subject, o)
+	if err != nil {
+		return err
+	}
+	for _, m := range idx.Manifests {
+		if m.Digest == desc.Digest {
+			return nil
+		}
+	}
+	idx.Manifests = append(idx.Manifests, desc)
+	raw, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return remote.Put(fallbackTag(repo, subject), rawManifest{raw: raw, mediaType: types.OCIImageIndex}, o.ROpt...)
+}
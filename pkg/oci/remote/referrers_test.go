@@ -0,0 +1,175 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/sigstore/cosign/pkg/oci/mutate"
+	"github.com/sigstore/cosign/pkg/oci/static"
+)
+
+func TestReferrerSignatures(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag(fmt.Sprintf("%s/repo:latest", u.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(128, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := ref.Context().Digest(h.String())
+
+	got, err := ReferrerSignatures(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sigs, err := got.Get(); err != nil || len(sigs) != 0 {
+		t.Fatalf("ReferrerSignatures() of an unsigned image = %v, %v", sigs, err)
+	}
+
+	for _, payload := range []string{"first", "second"} {
+		se, err := SignedEntity(digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := static.NewSignature([]byte(payload), "c2lnbmF0dXJl")
+		if err != nil {
+			t.Fatal(err)
+		}
+		se, err = mutate.AttachSignatureToEntity(se, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteReferrerSignatures(ref.Context(), se); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err = ReferrerSignatures(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := got.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 2 {
+		t.Fatalf("got %d signatures, want 2", len(sigs))
+	}
+
+	// The registry has no referrers API, so the signatures are listed under the
+	// fallback tag, and not under the signature tag.
+	if _, err := remote.Get(fallbackTag(ref.Context(), h)); err != nil {
+		t.Errorf("fetching the referrers fallback tag: %v", err)
+	}
+	sigTag, err := SignatureTag(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Get(sigTag); err == nil {
+		t.Error("signatures should not be written to the signature tag")
+	}
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestReferrersFromAPIPagination(t *testing.T) {
+	subject := v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"}
+	desc := func(i int) referrerDescriptor {
+		return referrerDescriptor{
+			MediaType:    types.OCIManifestSchema1,
+			Digest:       v1.Hash{Algorithm: "sha256", Hex: fmt.Sprintf("%064d", i)},
+			ArtifactType: SignatureArtifactType,
+		}
+	}
+	path := "/v2/repo/referrers/" + subject.String()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == path && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s?last=1>; rel="next"`, path))
+			json.NewEncoder(w).Encode(referrersIndex{Manifests: []referrerDescriptor{desc(1)}}) //nolint: errcheck
+		case r.URL.Path == path && r.URL.Query().Get("last") == "1":
+			json.NewEncoder(w).Encode(referrersIndex{Manifests: []referrerDescriptor{desc(2)}}) //nolint: errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := name.NewRepository(u.Host + "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := &countingTransport{}
+	idx, supported, err := referrersFromAPI(repo, subject, makeOptions(repo, WithTransport(tr)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !supported {
+		t.Fatal("referrers API should be reported as supported")
+	}
+	if len(idx.Manifests) != 2 || idx.Manifests[0].Digest != desc(1).Digest || idx.Manifests[1].Digest != desc(2).Digest {
+		t.Errorf("got manifests %+v, want both pages", idx.Manifests)
+	}
+	if tr.requests == 0 {
+		t.Error("the configured transport was not used")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := referrersFromAPI(repo, subject, makeOptions(repo, WithContext(ctx))); err == nil {
+		t.Error("expected an error with a cancelled context")
+	}
+}
@@ -32,6 +32,8 @@ const (
 	CertificateAnnotationKey = "dev.sigstore.cosign/certificate"
 	ChainAnnotationKey       = "dev.sigstore.cosign/chain"
 	BundleAnnotationKey      = "dev.sigstore.cosign/bundle"
+	// RFC3161TimestampAnnotationKey holds a base64 encoded RFC 3161 timestamp token over the signature.
+	RFC3161TimestampAnnotationKey = "dev.sigstore.cosign/rfc3161timestamp"
 )
 
 // NewSignature constructs a new oci.Signature from the provided options.
@@ -19,10 +19,12 @@ import (
 	"github.com/sigstore/cosign/pkg/providers"
 
 	// Link in all of the providers.
+	_ "github.com/sigstore/cosign/pkg/providers/azure"
 	_ "github.com/sigstore/cosign/pkg/providers/filesystem"
 	_ "github.com/sigstore/cosign/pkg/providers/github"
 	_ "github.com/sigstore/cosign/pkg/providers/google"
 	_ "github.com/sigstore/cosign/pkg/providers/spiffe"
+	_ "github.com/sigstore/cosign/pkg/providers/vault"
 )
 
 // Alias these methods, so that folks can import this to get all providers.
@@ -0,0 +1,102 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/providers"
+)
+
+func init() {
+	providers.Register("azure-managed-identity", &azureManagedIdentity{})
+}
+
+type azureManagedIdentity struct{}
+
+var _ providers.Interface = (*azureManagedIdentity)(nil)
+
+// imdsEndpoint is the Azure Instance Metadata Service.
+// This is a variable instead of a const to enable testing.
+var imdsEndpoint = "http://169.254.169.254"
+
+const (
+	imdsAPIVersion = "2018-02-01"
+	// imdsProbeTimeout bounds the request made by Enabled, since outside Azure
+	// the link-local IMDS address typically doesn't answer at all.
+	imdsProbeTimeout = time.Second
+)
+
+// Enabled implements providers.Interface
+func (ami *azureManagedIdentity) Enabled(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, imdsProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint+"/metadata/instance?api-version=2021-02-01", nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Metadata", "true")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// Provide implements providers.Interface
+func (ami *azureManagedIdentity) Provide(ctx context.Context, audience string) (string, error) {
+	q := url.Values{}
+	q.Set("api-version", imdsAPIVersion)
+	q.Set("resource", audience)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint+"/metadata/identity/oauth2/token?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("requesting Azure managed identity token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", errors.Wrap(err, "decoding Azure managed identity token response")
+	}
+	if payload.AccessToken == "" {
+		return "", errors.New("Azure managed identity token response did not contain a token")
+	}
+	return payload.AccessToken, nil
+}
@@ -0,0 +1,79 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func fakeIMDS(t *testing.T) {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/metadata/instance":
+			w.Write([]byte(`{}`)) //nolint: errcheck
+		case "/metadata/identity/oauth2/token":
+			if r.URL.Query().Get("api-version") != imdsAPIVersion {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"access_token": "token-for-` + r.URL.Query().Get("resource") + `", "token_type": "Bearer"}`)) //nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	old := imdsEndpoint
+	imdsEndpoint = s.URL
+	t.Cleanup(func() { imdsEndpoint = old })
+}
+
+func TestEnabled(t *testing.T) {
+	ami := &azureManagedIdentity{}
+	ctx := context.Background()
+
+	old := imdsEndpoint
+	imdsEndpoint = "http://127.0.0.1:0"
+	if ami.Enabled(ctx) {
+		t.Error("expected provider to be disabled without IMDS")
+	}
+	imdsEndpoint = old
+
+	fakeIMDS(t)
+	if !ami.Enabled(ctx) {
+		t.Error("expected provider to be enabled")
+	}
+}
+
+func TestProvide(t *testing.T) {
+	fakeIMDS(t)
+
+	tok, err := (&azureManagedIdentity{}).Provide(context.Background(), "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "token-for-sigstore" {
+		t.Errorf("Provide() = %q, want %q", tok, "token-for-sigstore")
+	}
+}
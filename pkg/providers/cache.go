@@ -0,0 +1,116 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheRefreshMargin is how long before a cached token expires that a provider
+// returned by WrapWithCache fetches a new one.
+const CacheRefreshMargin = 30 * time.Second
+
+type cachedToken struct {
+	token  string
+	expiry time.Time
+}
+
+type cachingProvider struct {
+	Interface
+
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+	now    func() time.Time
+}
+
+var _ ProviderWithExpiry = (*cachingProvider)(nil)
+
+// WrapWithCache returns a provider that keeps the tokens p furnishes in memory, per
+// audience, until CacheRefreshMargin before they expire. The expiry comes from p if
+// it implements ProviderWithExpiry, and otherwise from the token's exp claim; tokens
+// whose expiry is unknown are not cached.
+func WrapWithCache(p Interface) Interface {
+	if _, ok := p.(*cachingProvider); ok {
+		return p
+	}
+	return &cachingProvider{
+		Interface: p,
+		tokens:    make(map[string]cachedToken),
+		now:       time.Now,
+	}
+}
+
+// Provide implements Interface
+func (c *cachingProvider) Provide(ctx context.Context, audience string) (string, error) {
+	tok, _, err := c.ProvideWithExpiry(ctx, audience)
+	return tok, err
+}
+
+// ProvideWithExpiry implements ProviderWithExpiry
+func (c *cachingProvider) ProvideWithExpiry(ctx context.Context, audience string) (string, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.tokens[audience]; ok && c.now().Before(t.expiry.Add(-CacheRefreshMargin)) {
+		return t.token, t.expiry, nil
+	}
+
+	var tok string
+	var exp time.Time
+	var err error
+	if pe, ok := c.Interface.(ProviderWithExpiry); ok {
+		tok, exp, err = pe.ProvideWithExpiry(ctx, audience)
+	} else {
+		tok, err = c.Interface.Provide(ctx, audience)
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if exp.IsZero() {
+		exp = tokenExpiry(tok)
+	}
+	if exp.IsZero() {
+		delete(c.tokens, audience)
+	} else {
+		c.tokens[audience] = cachedToken{token: tok, expiry: exp}
+	}
+	return tok, exp, nil
+}
+
+// tokenExpiry returns the exp claim of the JWT tok, without verifying it, or the
+// zero time.Time if tok is not a JWT with an exp claim.
+func tokenExpiry(tok string) time.Time {
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0)
+}
@@ -0,0 +1,107 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// countingProvider furnishes a new JWT, expiring after ttl, on every call.
+type countingProvider struct {
+	calls int
+	ttl   time.Duration
+	now   time.Time
+}
+
+func (c *countingProvider) Enabled(context.Context) bool { return true }
+
+func (c *countingProvider) Provide(_ context.Context, audience string) (string, error) {
+	c.calls++
+	enc := base64.RawURLEncoding.EncodeToString
+	claims := fmt.Sprintf(`{"aud":%q,"exp":%d,"n":%d}`, audience, c.now.Add(c.ttl).Unix(), c.calls)
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(claims)) + ".sig", nil
+}
+
+func TestWrapWithCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1600000000, 0)
+	inner := &countingProvider{ttl: 5 * time.Minute, now: now}
+	p := WrapWithCache(inner)
+	p.(*cachingProvider).now = func() time.Time { return now }
+
+	if WrapWithCache(p) != p {
+		t.Error("WrapWithCache should not wrap a caching provider twice")
+	}
+
+	first, err := p.Provide(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.Provide(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second || inner.calls != 1 {
+		t.Errorf("got %d calls to the provider, want the cached token to be reused", inner.calls)
+	}
+	_, exp, err := p.(ProviderWithExpiry).ProvideWithExpiry(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(5 * time.Minute); !exp.Equal(want) {
+		t.Errorf("expiry = %v, want %v", exp, want)
+	}
+
+	if _, err := p.Provide(ctx, "other"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("got %d calls to the provider, want tokens cached per audience", inner.calls)
+	}
+
+	// Within CacheRefreshMargin of the expiry, a new token is fetched.
+	now = now.Add(5*time.Minute - CacheRefreshMargin)
+	inner.now = now
+	third, err := p.Provide(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first || inner.calls != 3 {
+		t.Errorf("got %d calls to the provider, want the expiring token to be refreshed", inner.calls)
+	}
+}
+
+func TestWrapWithCacheUnknownExpiry(t *testing.T) {
+	ctx := context.Background()
+	p := WrapWithCache(&fakeProvider{enabled: true, token: "not-a-jwt"})
+	if !p.Enabled(ctx) {
+		t.Error("Enabled() should be delegated to the wrapped provider")
+	}
+	tok, exp, err := p.(ProviderWithExpiry).ProvideWithExpiry(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "not-a-jwt" || !exp.IsZero() {
+		t.Errorf("ProvideWithExpiry() = %q, %v", tok, exp)
+	}
+	if n := len(p.(*cachingProvider).tokens); n != 0 {
+		t.Errorf("cached %d tokens of unknown expiry, want 0", n)
+	}
+}
@@ -18,8 +18,14 @@ package github
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/sigstore/cosign/pkg/providers"
 )
@@ -50,27 +56,45 @@ func (ga *githubActions) Enabled(ctx context.Context) bool {
 
 // Provide implements providers.Interface
 func (ga *githubActions) Provide(ctx context.Context, audience string) (string, error) {
-	url := os.Getenv(RequestURLEnvKey) + "&audience=" + audience
+	// The request URL already carries query parameters, and on GitHub Enterprise
+	// Server it points at the instance rather than github.com, so parse it
+	// instead of assuming its shape.
+	u, err := url.Parse(os.Getenv(RequestURLEnvKey))
+	if err != nil {
+		return "", errors.Wrap(err, "parsing "+RequestURLEnvKey)
+	}
+	if audience != "" {
+		q := u.Query()
+		q.Set("audience", audience)
+		u.RawQuery = q.Encode()
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return "", err
 	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv(RequestTokenEnvKey))
+	req.Header.Set("Accept", "application/json")
 
-	req.Header.Add("Authorization", "bearer "+os.Getenv(RequestTokenEnvKey))
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("requesting GitHub Actions ID token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
 	var payload struct {
 		Value string `json:"value"`
 	}
-
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&payload); err != nil {
-		return "", err
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", errors.Wrap(err, "decoding GitHub Actions ID token response")
+	}
+	if payload.Value == "" {
+		return "", errors.New("GitHub Actions ID token response did not contain a token")
 	}
 	return payload.Value, nil
 }
@@ -0,0 +1,87 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	ga := &githubActions{}
+	t.Setenv(RequestURLEnvKey, "")
+	t.Setenv(RequestTokenEnvKey, "")
+	if ga.Enabled(context.Background()) {
+		t.Error("expected provider to be disabled without the request variables")
+	}
+	t.Setenv(RequestURLEnvKey, "https://example.com/token")
+	if ga.Enabled(context.Background()) {
+		t.Error("expected provider to be disabled without a request token")
+	}
+	t.Setenv(RequestTokenEnvKey, "request-token")
+	if !ga.Enabled(context.Background()) {
+		t.Error("expected provider to be enabled")
+	}
+}
+
+func TestProvide(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer request-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2.0" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"count": 1, "value": "id-token-for-` + r.URL.Query().Get("audience") + `"}`)) //nolint: errcheck
+	}))
+	defer s.Close()
+
+	ga := &githubActions{}
+	ctx := context.Background()
+
+	// GitHub hands out a request URL that already has query parameters.
+	t.Setenv(RequestURLEnvKey, s.URL+"/_apis/distributedtask/token?api-version=2.0")
+	t.Setenv(RequestTokenEnvKey, "request-token")
+	tok, err := ga.Provide(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "id-token-for-sigstore" {
+		t.Errorf("Provide() = %q, want %q", tok, "id-token-for-sigstore")
+	}
+
+	t.Setenv(RequestTokenEnvKey, "wrong-token")
+	if _, err := ga.Provide(ctx, "sigstore"); err == nil {
+		t.Error("expected error when the request token is rejected")
+	}
+}
+
+func TestProvideEmptyToken(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`)) //nolint: errcheck
+	}))
+	defer s.Close()
+
+	t.Setenv(RequestURLEnvKey, s.URL)
+	t.Setenv(RequestTokenEnvKey, "request-token")
+	if _, err := (&githubActions{}).Provide(context.Background(), "sigstore"); err == nil {
+		t.Error("expected error when the response has no token")
+	}
+}
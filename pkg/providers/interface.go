@@ -19,12 +19,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
+// DefaultPriority is the priority of providers added with Register.
+const DefaultPriority = 100
+
 var (
 	m         sync.Mutex
 	providers = make(map[string]Interface)
+	// priorities holds the priority of each provider not at DefaultPriority.
+	priorities = make(map[string]int)
 )
 
 // Interface is what providers need to implement to participate in furnishing OIDC tokens.
@@ -36,8 +43,22 @@ type Interface interface {
 	Provide(ctx context.Context, audience string) (string, error)
 }
 
+// ProviderWithExpiry is implemented by providers that know when the tokens they furnish expire.
+type ProviderWithExpiry interface {
+	Interface
+
+	// ProvideWithExpiry returns an OIDC token scoped to the provided audience and the time it expires.
+	ProvideWithExpiry(ctx context.Context, audience string) (string, time.Time, error)
+}
+
 // Register is used by providers to participate in furnishing OIDC tokens.
 func Register(name string, p Interface) {
+	RegisterWithPriority(name, p, DefaultPriority)
+}
+
+// RegisterWithPriority registers p like Register. Enabled providers are tried in
+// ascending order of priority, and in order of name among equal priorities.
+func RegisterWithPriority(name string, p Interface, priority int) {
 	m.Lock()
 	defer m.Unlock()
 
@@ -45,6 +66,74 @@ func Register(name string, p Interface) {
 		panic(fmt.Sprintf("duplicate provider for name %q, %T and %T", name, prev, p))
 	}
 	providers[name] = p
+	if priority != DefaultPriority {
+		priorities[name] = priority
+	}
+}
+
+// Replace registers p under name in place of the provider registered there, keeping
+// its priority. It lets a program configure a provider that registers itself with
+// defaults, such as spiffe.
+func Replace(name string, p Interface) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := providers[name]; !ok {
+		return fmt.Errorf("no provider registered for name %q", name)
+	}
+	providers[name] = p
+	return nil
+}
+
+// orderedNames returns the names of the registered providers in the order they
+// are tried. The caller must hold m.
+func orderedNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	priority := func(name string) int {
+		if p, ok := priorities[name]; ok {
+			return p
+		}
+		return DefaultPriority
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if pi, pj := priority(names[i]), priority(names[j]); pi != pj {
+			return pi < pj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// ordered returns the registered providers in the order they are tried. The caller
+// must hold m.
+func ordered() []Interface {
+	names := orderedNames()
+	ps := make([]Interface, 0, len(names))
+	for _, name := range names {
+		ps = append(ps, providers[name])
+	}
+	return ps
+}
+
+// List returns the names of the registered providers in the order they are tried:
+// ascending priority, then name.
+func List() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	return orderedNames()
+}
+
+// Get returns the provider registered under name, if any.
+func Get(name string) (Interface, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	p, ok := providers[name]
+	return p, ok
 }
 
 // Enabled checks whether any of the registered providers are enabled in this execution context.
@@ -52,7 +141,7 @@ func Enabled(ctx context.Context) bool {
 	m.Lock()
 	defer m.Unlock()
 
-	for _, provider := range providers {
+	for _, provider := range ordered() {
 		if provider.Enabled(ctx) {
 			return true
 		}
@@ -60,14 +149,15 @@ func Enabled(ctx context.Context) bool {
 	return false
 }
 
-// Provide fetches an OIDC token from one of the active providers.
+// Provide fetches an OIDC token from the first active provider, in priority order,
+// that furnishes one.
 func Provide(ctx context.Context, audience string) (string, error) {
 	m.Lock()
 	defer m.Unlock()
 
 	var id string
 	var err error
-	for _, provider := range providers {
+	for _, provider := range ordered() {
 		if !provider.Enabled(ctx) {
 			continue
 		}
@@ -83,3 +173,33 @@ func Provide(ctx context.Context, audience string) (string, error) {
 	}
 	return id, err
 }
+
+// ProvideWithExpiry fetches an OIDC token from one of the active providers, along with
+// the time it expires. The expiry is the zero time.Time if the provider that furnished
+// the token does not implement ProviderWithExpiry.
+func ProvideWithExpiry(ctx context.Context, audience string) (string, time.Time, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var id string
+	var exp time.Time
+	var err error
+	for _, provider := range ordered() {
+		if !provider.Enabled(ctx) {
+			continue
+		}
+		if pe, ok := provider.(ProviderWithExpiry); ok {
+			id, exp, err = pe.ProvideWithExpiry(ctx, audience)
+		} else {
+			exp = time.Time{}
+			id, err = provider.Provide(ctx, audience)
+		}
+		if err == nil {
+			return id, exp, err
+		}
+	}
+	if err == nil {
+		err = errors.New("no providers are enabled, check providers.Enabled() before providers.ProvideWithExpiry()")
+	}
+	return id, exp, err
+}
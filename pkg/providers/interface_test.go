@@ -0,0 +1,154 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	enabled bool
+	token   string
+}
+
+func (f *fakeProvider) Enabled(context.Context) bool { return f.enabled }
+
+func (f *fakeProvider) Provide(context.Context, string) (string, error) { return f.token, nil }
+
+type fakeProviderWithExpiry struct {
+	fakeProvider
+	expiry time.Time
+}
+
+func (f *fakeProviderWithExpiry) ProvideWithExpiry(context.Context, string) (string, time.Time, error) {
+	return f.token, f.expiry, nil
+}
+
+var _ ProviderWithExpiry = (*fakeProviderWithExpiry)(nil)
+
+func TestProvideWithExpiry(t *testing.T) {
+	ctx := context.Background()
+	expiry := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		provider   Interface
+		wantExpiry time.Time
+	}{{
+		name:     "provider without expiry",
+		provider: &fakeProvider{enabled: true, token: "plain"},
+	}, {
+		name:       "provider with expiry",
+		provider:   &fakeProviderWithExpiry{fakeProvider: fakeProvider{enabled: true, token: "expiring"}, expiry: expiry},
+		wantExpiry: expiry,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m.Lock()
+			old := providers
+			providers = map[string]Interface{"fake": tc.provider}
+			m.Unlock()
+			t.Cleanup(func() {
+				m.Lock()
+				providers = old
+				m.Unlock()
+			})
+
+			tok, exp, err := ProvideWithExpiry(ctx, "sigstore")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := tc.provider.Provide(ctx, "sigstore")
+			if tok != want {
+				t.Errorf("token = %q, want %q", tok, want)
+			}
+			if !exp.Equal(tc.wantExpiry) {
+				t.Errorf("expiry = %v, want %v", exp, tc.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestListAndGet(t *testing.T) {
+	m.Lock()
+	old := providers
+	providers = map[string]Interface{}
+	m.Unlock()
+	t.Cleanup(func() {
+		m.Lock()
+		providers = old
+		m.Unlock()
+	})
+
+	spiffe := &fakeProvider{enabled: true, token: "spiffe"}
+	Register("spiffe", spiffe)
+	Register("google", &fakeProvider{})
+	Register("github", &fakeProvider{})
+
+	if got, want := List(), []string{"github", "google", "spiffe"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if p, ok := Get("spiffe"); !ok || p != spiffe {
+		t.Errorf("Get(spiffe) = %v, %v", p, ok)
+	}
+	if _, ok := Get("missing"); ok {
+		t.Error("Get(missing) found a provider")
+	}
+
+	configured := &fakeProvider{enabled: true, token: "configured"}
+	if err := Replace("spiffe", configured); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := Get("spiffe"); !ok || p != configured {
+		t.Errorf("Get(spiffe) after Replace = %v, %v", p, ok)
+	}
+	if err := Replace("missing", configured); err == nil {
+		t.Error("Replace(missing) should fail")
+	}
+}
+
+func TestRegisterWithPriority(t *testing.T) {
+	m.Lock()
+	oldProviders, oldPriorities := providers, priorities
+	providers, priorities = map[string]Interface{}, map[string]int{}
+	m.Unlock()
+	t.Cleanup(func() {
+		m.Lock()
+		providers, priorities = oldProviders, oldPriorities
+		m.Unlock()
+	})
+
+	Register("a-default", &fakeProvider{enabled: true, token: "default"})
+	RegisterWithPriority("b-preferred", &fakeProvider{enabled: true, token: "preferred"}, 10)
+	RegisterWithPriority("c-disabled", &fakeProvider{token: "disabled"}, 1)
+	RegisterWithPriority("d-fallback", &fakeProvider{enabled: true, token: "fallback"}, 200)
+
+	if got, want := List(), []string{"c-disabled", "b-preferred", "a-default", "d-fallback"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	for i := 0; i < 10; i++ {
+		tok, err := Provide(context.Background(), "sigstore")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != "preferred" {
+			t.Fatalf("Provide() = %q, want the enabled provider with the lowest priority", tok)
+		}
+	}
+}
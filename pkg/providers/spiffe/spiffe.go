@@ -17,7 +17,9 @@ package spiffe
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
 
@@ -25,35 +27,104 @@ import (
 	"github.com/spiffe/go-spiffe/v2/workloadapi"
 )
 
+// Name is the name the provider is registered under, with the socket path from
+// SPIFFE_SOCKET_PATH or the default. To use other options, replace it:
+//
+//	providers.Replace(spiffe.Name, spiffe.New(spiffe.WithSocketPath(path)))
+const Name = "spiffe"
+
 func init() {
-	providers.Register("spiffe", &spiffe{})
+	providers.Register(Name, New())
+}
+
+type spiffe struct {
+	socketPath  string
+	dialTimeout time.Duration
+}
+
+var _ providers.ProviderWithExpiry = (*spiffe)(nil)
+
+// Option configures the provider returned by New.
+type Option func(*spiffe)
+
+// WithSocketPath reads tokens from the SPIFFE workload API socket at path,
+// instead of the one named by SPIFFE_SOCKET_PATH or the default.
+func WithSocketPath(path string) Option {
+	return func(s *spiffe) {
+		s.socketPath = path
+	}
 }
 
-type spiffe struct{}
+// WithDialTimeout bounds the time spent connecting to the workload API and
+// fetching a token from it.
+func WithDialTimeout(d time.Duration) Option {
+	return func(s *spiffe) {
+		s.dialTimeout = d
+	}
+}
 
-var _ providers.Interface = (*spiffe)(nil)
+// New returns a SPIFFE provider configured by opts. The result also
+// implements providers.ProviderWithExpiry.
+func New(opts ...Option) providers.Interface {
+	s := &spiffe{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
 
 const (
-	// socketPath is the path to where we read an OIDC
-	// token from the spiffe.
-	// nolint
-	socketPath = "/tmp/spire-agent/public/api.sock"
+	// SocketPathEnvKey is the environment variable that overrides
+	// the path to where we read an OIDC token from the spiffe.
+	SocketPathEnvKey = "SPIFFE_SOCKET_PATH"
+
+	// defaultSocketPath is the path to where we read an OIDC
+	// token from the spiffe when SocketPathEnvKey is unset.
+	defaultSocketPath = "/tmp/spire-agent/public/api.sock"
 )
 
+// resolveSocketPath returns the path of the SPIFFE workload API socket.
+func resolveSocketPath() string {
+	if p := os.Getenv(SocketPathEnvKey); p != "" {
+		return p
+	}
+	return defaultSocketPath
+}
+
+// socket returns the path of the workload API socket this provider uses.
+func (ga *spiffe) socket() string {
+	if ga.socketPath != "" {
+		return ga.socketPath
+	}
+	return resolveSocketPath()
+}
+
 // Enabled implements providers.Interface
 func (ga *spiffe) Enabled(ctx context.Context) bool {
 	// If we can stat the file without error then this is enabled.
-	_, err := os.Stat(socketPath)
+	_, err := os.Stat(ga.socket())
 	return err == nil
 }
 
 // Provide implements providers.Interface
 func (ga *spiffe) Provide(ctx context.Context, audience string) (string, error) {
+	tok, _, err := ga.ProvideWithExpiry(ctx, audience)
+	return tok, err
+}
+
+// ProvideWithExpiry implements providers.ProviderWithExpiry
+func (ga *spiffe) ProvideWithExpiry(ctx context.Context, audience string) (string, time.Time, error) {
+	if ga.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ga.dialTimeout)
+		defer cancel()
+	}
+
 	// Creates a new Workload API client, connecting to provided socket path
 	// Environment variable `SPIFFE_ENDPOINT_SOCKET` is used as default
-	client, err := workloadapi.New(ctx, workloadapi.WithAddr("unix://"+socketPath))
+	client, err := workloadapi.New(ctx, workloadapi.WithAddr("unix://"+ga.socket()))
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 	defer client.Close()
 
@@ -61,8 +132,22 @@ func (ga *spiffe) Provide(ctx context.Context, audience string) (string, error)
 		Audience: audience,
 	})
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
+	}
+	if err := validateAudience(svid, audience); err != nil {
+		return "", time.Time{}, err
 	}
 
-	return svid.Marshal(), nil
+	return svid.Marshal(), svid.Expiry, nil
+}
+
+// validateAudience checks that svid was issued for the requested audience, in case
+// the SPIRE server ignored the one we asked for.
+func validateAudience(svid *jwtsvid.SVID, requested string) error {
+	for _, aud := range svid.Audience {
+		if aud == requested {
+			return nil
+		}
+	}
+	return fmt.Errorf("SPIFFE JWT SVID audience %v does not include the requested audience %q", svid.Audience, requested)
 }
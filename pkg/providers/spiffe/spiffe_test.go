@@ -0,0 +1,104 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+
+	"github.com/sigstore/cosign/pkg/providers"
+)
+
+func TestResolveSocketPath(t *testing.T) {
+	t.Setenv(SocketPathEnvKey, "")
+	if got := resolveSocketPath(); got != defaultSocketPath {
+		t.Errorf("resolveSocketPath() = %q, want %q", got, defaultSocketPath)
+	}
+
+	alt := filepath.Join(t.TempDir(), "api.sock")
+	t.Setenv(SocketPathEnvKey, alt)
+	if got := resolveSocketPath(); got != alt {
+		t.Errorf("resolveSocketPath() = %q, want %q", got, alt)
+	}
+
+	p := &spiffe{}
+	if p.Enabled(context.Background()) {
+		t.Error("expected provider to be disabled before the socket exists")
+	}
+	if err := os.WriteFile(alt, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Enabled(context.Background()) {
+		t.Error("expected provider to be enabled once the socket exists")
+	}
+}
+
+func TestWithSocketPath(t *testing.T) {
+	t.Setenv(SocketPathEnvKey, "")
+	alt := filepath.Join(t.TempDir(), "api.sock")
+	p := New(WithSocketPath(alt), WithDialTimeout(time.Second))
+
+	if p.Enabled(context.Background()) {
+		t.Error("expected provider to be disabled before the socket exists")
+	}
+	if err := os.WriteFile(alt, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Enabled(context.Background()) {
+		t.Error("expected provider to be enabled once the socket exists")
+	}
+	if _, ok := p.(providers.ProviderWithExpiry); !ok {
+		t.Error("expected provider to implement ProviderWithExpiry")
+	}
+}
+
+func TestReplaceDefault(t *testing.T) {
+	def, ok := providers.Get(Name)
+	if !ok {
+		t.Fatalf("%q is not registered", Name)
+	}
+	t.Cleanup(func() {
+		if err := providers.Replace(Name, def); err != nil {
+			t.Error(err)
+		}
+	})
+
+	configured := New(WithSocketPath(filepath.Join(t.TempDir(), "api.sock")))
+	if err := providers.Replace(Name, configured); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := providers.Get(Name); p != configured {
+		t.Error("providers.Get did not return the replacement")
+	}
+}
+
+func TestValidateAudience(t *testing.T) {
+	svid := &jwtsvid.SVID{Audience: []string{"other", "sigstore"}}
+	if err := validateAudience(svid, "sigstore"); err != nil {
+		t.Errorf("validateAudience() = %v", err)
+	}
+	if err := validateAudience(svid, "fulcio"); err == nil {
+		t.Error("expected an error for an audience the SVID was not issued for")
+	}
+	if err := validateAudience(&jwtsvid.SVID{}, "sigstore"); err == nil {
+		t.Error("expected an error for an SVID without an audience")
+	}
+}
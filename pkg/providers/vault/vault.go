@@ -0,0 +1,107 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sigstore/cosign/pkg/providers"
+)
+
+func init() {
+	providers.Register("vault", &vault{})
+}
+
+type vault struct{}
+
+var _ providers.Interface = (*vault)(nil)
+
+const (
+	AddrEnvKey    = "VAULT_ADDR"
+	TokenEnvKey   = "VAULT_TOKEN"
+	RoleEnvKey    = "VAULT_ROLE"
+	JWTPathEnvKey = "VAULT_JWT_PATH"
+)
+
+// path returns the Vault API path the token is read from: VAULT_JWT_PATH if set,
+// otherwise the identity token endpoint of VAULT_ROLE.
+func path() string {
+	if p := os.Getenv(JWTPathEnvKey); p != "" {
+		return strings.Trim(p, "/")
+	}
+	if role := os.Getenv(RoleEnvKey); role != "" {
+		return "identity/oidc/token/" + role
+	}
+	return ""
+}
+
+// Enabled implements providers.Interface
+func (v *vault) Enabled(ctx context.Context) bool {
+	// VAULT_ADDR alone is also set by users of the hashivault KMS, so
+	// additionally require somewhere to read the token from.
+	return os.Getenv(AddrEnvKey) != "" && path() != ""
+}
+
+// Provide implements providers.Interface
+//
+// Vault sets the audience of the tokens it issues from the configuration of
+// the role, so the role must be configured with the audience cosign asks for.
+func (v *vault) Provide(ctx context.Context, audience string) (string, error) {
+	u := strings.TrimRight(os.Getenv(AddrEnvKey), "/") + "/v1/" + path()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	if tok := os.Getenv(TokenEnvKey); tok != "" {
+		req.Header.Set("X-Vault-Token", tok)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("requesting Vault token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data struct {
+			Token    string `json:"token"`
+			ClientID string `json:"client_id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", errors.Wrap(err, "decoding Vault token response")
+	}
+	if payload.Data.Token == "" {
+		return "", errors.New("Vault token response did not contain a token")
+	}
+	if audience != "" && payload.Data.ClientID != "" && payload.Data.ClientID != audience {
+		return "", fmt.Errorf("Vault role issues tokens for audience %q, want %q", payload.Data.ClientID, audience)
+	}
+	return payload.Data.Token, nil
+}
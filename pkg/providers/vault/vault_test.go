@@ -0,0 +1,92 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	v := &vault{}
+	ctx := context.Background()
+
+	t.Setenv(AddrEnvKey, "")
+	t.Setenv(RoleEnvKey, "cosign")
+	if v.Enabled(ctx) {
+		t.Error("expected provider to be disabled without VAULT_ADDR")
+	}
+
+	t.Setenv(AddrEnvKey, "https://vault.example.com")
+	t.Setenv(RoleEnvKey, "")
+	t.Setenv(JWTPathEnvKey, "")
+	if v.Enabled(ctx) {
+		t.Error("expected provider to be disabled without a role or path")
+	}
+
+	t.Setenv(RoleEnvKey, "cosign")
+	if !v.Enabled(ctx) {
+		t.Error("expected provider to be enabled")
+	}
+}
+
+func TestProvide(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/identity/oidc/token/cosign":
+			w.Write([]byte(`{"data": {"token": "role-token", "client_id": "sigstore", "ttl": 3600}}`)) //nolint: errcheck
+		case "/v1/custom/jwt":
+			w.Write([]byte(`{"data": {"token": "path-token"}}`)) //nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	t.Setenv(AddrEnvKey, s.URL+"/")
+	t.Setenv(TokenEnvKey, "root")
+	t.Setenv(RoleEnvKey, "cosign")
+	t.Setenv(JWTPathEnvKey, "")
+
+	v := &vault{}
+	tok, err := v.Provide(ctx, "sigstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "role-token" {
+		t.Errorf("Provide() = %q, want %q", tok, "role-token")
+	}
+	if _, err := v.Provide(ctx, "other"); err == nil {
+		t.Error("expected error for a role issuing tokens for another audience")
+	}
+
+	t.Setenv(JWTPathEnvKey, "/custom/jwt")
+	if tok, err := v.Provide(ctx, "sigstore"); err != nil || tok != "path-token" {
+		t.Errorf("Provide() = %q, %v, want %q", tok, err, "path-token")
+	}
+
+	t.Setenv(TokenEnvKey, "wrong")
+	if _, err := v.Provide(ctx, "sigstore"); err == nil {
+		t.Error("expected error for a rejected Vault token")
+	}
+}
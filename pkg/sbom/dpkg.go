@@ -0,0 +1,64 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// parseDpkgStatus parses a dpkg status file, or one of the per-package files that
+// distroless images keep in status.d, and returns the installed packages.
+func parseDpkgStatus(r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	fields := map[string]string{}
+	flush := func() {
+		// Files in status.d have no Status field, everything in them is installed.
+		status, ok := fields["Status"]
+		installed := !ok || strings.HasSuffix(status, " installed")
+		if fields["Package"] != "" && installed {
+			pkgs = append(pkgs, Package{
+				Type:    "deb",
+				Name:    fields["Package"],
+				Version: fields["Version"],
+				Arch:    fields["Architecture"],
+			})
+		}
+		fields = map[string]string{}
+	}
+
+	s := bufio.NewScanner(r)
+	// Description fields can be long.
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			// Continuation of a multi-line field.
+		default:
+			if i := strings.Index(line, ":"); i > 0 {
+				fields[line[:i]] = strings.TrimSpace(line[i+1:])
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return pkgs, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Berkeley DB hash database layout, as used by rpm's Packages database.
+const (
+	bdbHashMagic        = 0x061561
+	bdbPageHeaderSize   = 26
+	bdbPageHashUnsorted = 2
+	bdbPageOverflow     = 7
+	bdbPageHash         = 13
+	bdbItemOffPage      = 3
+)
+
+// parseRPMDatabase returns the packages recorded in a Berkeley DB rpm database.
+func parseRPMDatabase(db []byte) ([]Package, error) {
+	blobs, err := bdbValues(db)
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []Package
+	for _, blob := range blobs {
+		pkg, err := parseRPMHeader(blob)
+		if err != nil {
+			return nil, err
+		}
+		// The keys imported with rpm --import are recorded as packages too.
+		if pkg.Name == "gpg-pubkey" {
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// bdbValues returns the values stored off-page in a Berkeley DB hash database.
+// rpm's header blobs are always too large to be stored on the hash pages.
+func bdbValues(db []byte) ([][]byte, error) {
+	if len(db) < 512 {
+		return nil, errors.New("truncated Berkeley DB metadata page")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	switch {
+	case binary.LittleEndian.Uint32(db[12:]) == bdbHashMagic:
+	case binary.BigEndian.Uint32(db[12:]) == bdbHashMagic:
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("not a Berkeley DB hash database")
+	}
+	pageSize := int(order.Uint32(db[20:]))
+	if pageSize < 512 || pageSize > 64*1024 {
+		return nil, fmt.Errorf("invalid Berkeley DB page size %d", pageSize)
+	}
+	numPages := len(db) / pageSize
+	page := func(n uint32) ([]byte, error) {
+		if n == 0 || int(n) >= numPages {
+			return nil, fmt.Errorf("Berkeley DB page %d out of range", n)
+		}
+		return db[int(n)*pageSize : int(n+1)*pageSize], nil
+	}
+
+	// overflow reassembles a value of length size stored on the chain of overflow pages starting at n.
+	overflow := func(n uint32, size uint32) ([]byte, error) {
+		// The chain can't hold more than every page of the file, so a larger
+		// size is corrupt and must not be allocated.
+		if int64(size) > int64(numPages)*int64(pageSize-bdbPageHeaderSize) {
+			return nil, fmt.Errorf("Berkeley DB overflow value of %d bytes is larger than the database", size)
+		}
+		value := make([]byte, 0, size)
+		for pages := 0; uint32(len(value)) < size; pages++ {
+			if pages >= numPages {
+				return nil, errors.New("Berkeley DB overflow page chain loops")
+			}
+			p, err := page(n)
+			if err != nil {
+				return nil, err
+			}
+			if p[25] != bdbPageOverflow {
+				return nil, fmt.Errorf("Berkeley DB page %d is not an overflow page", n)
+			}
+			// On overflow pages, the free area offset holds the length of the data on the page.
+			end := bdbPageHeaderSize + int(order.Uint16(p[22:]))
+			if end > pageSize {
+				return nil, fmt.Errorf("Berkeley DB overflow page %d is corrupt", n)
+			}
+			value = append(value, p[bdbPageHeaderSize:end]...)
+			n = order.Uint32(p[16:])
+			if n == 0 {
+				break
+			}
+		}
+		if uint32(len(value)) != size {
+			return nil, errors.New("Berkeley DB overflow value is truncated")
+		}
+		return value, nil
+	}
+
+	var values [][]byte
+	for n := 1; n < numPages; n++ {
+		p := db[n*pageSize : (n+1)*pageSize]
+		if t := p[25]; t != bdbPageHash && t != bdbPageHashUnsorted {
+			continue
+		}
+		entries := int(order.Uint16(p[20:]))
+		if bdbPageHeaderSize+2*entries > pageSize {
+			return nil, fmt.Errorf("Berkeley DB page %d is corrupt", n)
+		}
+		// Entries alternate between keys and values.
+		for i := 1; i < entries; i += 2 {
+			off := int(order.Uint16(p[bdbPageHeaderSize+2*i:]))
+			if off+12 > pageSize {
+				return nil, fmt.Errorf("Berkeley DB page %d is corrupt", n)
+			}
+			if p[off] != bdbItemOffPage {
+				continue
+			}
+			// An off-page item is its type, three bytes of padding, the first
+			// overflow page and the length of the value.
+			value, err := overflow(order.Uint32(p[off+4:]), order.Uint32(p[off+8:]))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// rpm header tags and types.
+const (
+	rpmTagName    = 1000
+	rpmTagVersion = 1001
+	rpmTagRelease = 1002
+	rpmTagEpoch   = 1003
+	rpmTagArch    = 1022
+
+	rpmTypeInt32  = 4
+	rpmTypeString = 6
+)
+
+// parseRPMHeader parses the header blob rpm stores for each installed package.
+func parseRPMHeader(blob []byte) (Package, error) {
+	if len(blob) < 8 {
+		return Package{}, errors.New("truncated rpm header")
+	}
+	indexCount := binary.BigEndian.Uint32(blob[0:])
+	dataLen := binary.BigEndian.Uint32(blob[4:])
+	if indexCount > 0xffff || dataLen > 256*1024*1024 || uint64(len(blob)) < 8+16*uint64(indexCount)+uint64(dataLen) {
+		return Package{}, errors.New("truncated rpm header")
+	}
+	data := blob[8+16*indexCount:][:dataLen]
+
+	var name, version, release, arch string
+	var epoch int64 = -1
+	for i := uint32(0); i < indexCount; i++ {
+		entry := blob[8+16*i:]
+		tag := binary.BigEndian.Uint32(entry[0:])
+		typ := binary.BigEndian.Uint32(entry[4:])
+		offset := binary.BigEndian.Uint32(entry[8:])
+		if offset >= dataLen {
+			continue
+		}
+		value := data[offset:]
+		switch {
+		case typ == rpmTypeString:
+			if end := bytes.IndexByte(value, 0); end >= 0 {
+				value = value[:end]
+			}
+			switch tag {
+			case rpmTagName:
+				name = string(value)
+			case rpmTagVersion:
+				version = string(value)
+			case rpmTagRelease:
+				release = string(value)
+			case rpmTagArch:
+				arch = string(value)
+			}
+		case typ == rpmTypeInt32 && tag == rpmTagEpoch && len(value) >= 4:
+			epoch = int64(binary.BigEndian.Uint32(value))
+		}
+	}
+	if name == "" {
+		return Package{}, errors.New("rpm header has no package name")
+	}
+
+	v := version
+	if release != "" {
+		v += "-" + release
+	}
+	if epoch > 0 {
+		v = strconv.FormatInt(epoch, 10) + ":" + v
+	}
+	return Package{Type: "rpm", Name: name, Version: v, Arch: arch}, nil
+}
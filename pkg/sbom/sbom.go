@@ -0,0 +1,140 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom generates software bills of materials for container images from
+// the package manager databases in their filesystems.
+package sbom
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+)
+
+// Package is a package installed in an image.
+type Package struct {
+	// Type is the purl type of the package manager that installed it, deb or rpm.
+	Type    string
+	Name    string
+	Version string
+	Arch    string
+}
+
+// Inventory is the set of packages installed in an image.
+type Inventory struct {
+	// Distro is the ID of the distribution from os-release, if any.
+	Distro   string
+	Packages []Package
+}
+
+const (
+	dpkgStatus    = "var/lib/dpkg/status"
+	dpkgStatusDir = "var/lib/dpkg/status.d/"
+)
+
+// rpmDatabases are the locations of the Berkeley DB rpm database.
+var rpmDatabases = map[string]bool{
+	"var/lib/rpm/Packages":          true,
+	"usr/lib/sysimage/rpm/Packages": true,
+}
+
+// unsupportedRPMDatabases are the rpm database formats that can't be read yet.
+var unsupportedRPMDatabases = map[string]bool{
+	"var/lib/rpm/rpmdb.sqlite":          true,
+	"usr/lib/sysimage/rpm/rpmdb.sqlite": true,
+	"var/lib/rpm/Packages.db":           true,
+	"usr/lib/sysimage/rpm/Packages.db":  true,
+}
+
+// Scan returns the packages installed in img, according to the dpkg and rpm
+// databases in its flattened filesystem.
+func Scan(img v1.Image) (*Inventory, error) {
+	rc := mutate.Extract(img)
+	defer rc.Close()
+
+	inv := &Inventory{}
+	var etcOSRelease, libOSRelease []byte
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading image filesystem")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimLeft(hdr.Name, "./"))
+
+		switch {
+		case name == "etc/os-release":
+			if etcOSRelease, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		case name == "usr/lib/os-release":
+			if libOSRelease, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		case name == dpkgStatus,
+			strings.HasPrefix(name, dpkgStatusDir) && !strings.HasSuffix(name, ".md5sums"):
+			pkgs, err := parseDpkgStatus(tr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing %s", name)
+			}
+			inv.Packages = append(inv.Packages, pkgs...)
+		case rpmDatabases[name]:
+			db, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			pkgs, err := parseRPMDatabase(db)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing %s", name)
+			}
+			inv.Packages = append(inv.Packages, pkgs...)
+		case unsupportedRPMDatabases[name]:
+			fmt.Fprintf(os.Stderr, "**Warning** Skipping rpm database %s, only Berkeley DB databases are supported\n", name)
+		}
+	}
+
+	// etc/os-release takes precedence, but is often a symlink to usr/lib/os-release.
+	osRelease := etcOSRelease
+	if osRelease == nil {
+		osRelease = libOSRelease
+	}
+	inv.Distro = osReleaseID(osRelease)
+	return inv, nil
+}
+
+// osReleaseID returns the ID field of an os-release file.
+func osReleaseID(b []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		if v := strings.TrimPrefix(s.Text(), "ID="); v != s.Text() {
+			return strings.Trim(v, `"'`)
+		}
+	}
+	return ""
+}
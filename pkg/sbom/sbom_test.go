@@ -0,0 +1,247 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const dpkgStatusFile = `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.31-13
+Description: GNU C Library
+ Contains the standard libraries.
+
+Package: removed
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: zlib1g
+Status: install ok installed
+Architecture: amd64
+Version: 1:1.2.11.dfsg-2
+`
+
+// rpmHeader returns an rpm header blob with the given string tags and epoch.
+func rpmHeader(strs map[uint32]string, epoch uint32) []byte {
+	var index, data bytes.Buffer
+	entry := func(tag, typ uint32, value []byte) {
+		for _, v := range []uint32{tag, typ, uint32(data.Len()), 1} {
+			binary.Write(&index, binary.BigEndian, v) //nolint: errcheck
+		}
+		data.Write(value)
+	}
+	// 1004 is the summary, which the tests use to make headers span overflow pages.
+	for _, tag := range []uint32{rpmTagName, rpmTagVersion, rpmTagRelease, rpmTagArch, 1004} {
+		entry(tag, rpmTypeString, append([]byte(strs[tag]), 0))
+	}
+	e := make([]byte, 4)
+	binary.BigEndian.PutUint32(e, epoch)
+	entry(rpmTagEpoch, rpmTypeInt32, e)
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(index.Len()/16)) //nolint: errcheck
+	binary.Write(&b, binary.BigEndian, uint32(data.Len()))     //nolint: errcheck
+	b.Write(index.Bytes())
+	b.Write(data.Bytes())
+	return b.Bytes()
+}
+
+// bdbHash returns a little endian Berkeley DB hash database holding values, each
+// stored on a chain of overflow pages.
+func bdbHash(values ...[]byte) []byte {
+	const pageSize = 512
+	le := binary.LittleEndian
+	var pages [][]byte
+	newPage := func(typ byte) []byte {
+		p := make([]byte, pageSize)
+		le.PutUint32(p[8:], uint32(len(pages)))
+		p[25] = typ
+		pages = append(pages, p)
+		return p
+	}
+
+	meta := newPage(8)
+	le.PutUint32(meta[12:], bdbHashMagic)
+	le.PutUint32(meta[20:], pageSize)
+
+	hash := newPage(bdbPageHash)
+	le.PutUint16(hash[20:], uint16(2*len(values)))
+	itemOff := pageSize
+	for i, v := range values {
+		// The key is an on-page item.
+		itemOff -= 8
+		copy(hash[itemOff:], []byte{1, 0, 0, 0, byte(i), 0, 0, 0})
+		le.PutUint16(hash[bdbPageHeaderSize+4*i:], uint16(itemOff))
+
+		// The value is split across overflow pages.
+		itemOff -= 12
+		hash[itemOff] = bdbItemOffPage
+		le.PutUint32(hash[itemOff+4:], uint32(len(pages)))
+		le.PutUint32(hash[itemOff+8:], uint32(len(v)))
+		le.PutUint16(hash[bdbPageHeaderSize+4*i+2:], uint16(itemOff))
+		for len(v) > 0 {
+			p := newPage(bdbPageOverflow)
+			n := copy(p[bdbPageHeaderSize:], v)
+			le.PutUint16(p[22:], uint16(n))
+			if v = v[n:]; len(v) > 0 {
+				le.PutUint32(p[16:], uint32(len(pages)))
+			}
+		}
+	}
+	return bytes.Join(pages, nil)
+}
+
+func TestParseDpkgStatus(t *testing.T) {
+	pkgs, err := parseDpkgStatus(strings.NewReader(dpkgStatusFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Package{
+		{Type: "deb", Name: "libc6", Version: "2.31-13", Arch: "amd64"},
+		{Type: "deb", Name: "zlib1g", Version: "1:1.2.11.dfsg-2", Arch: "amd64"},
+	}
+	if !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("parseDpkgStatus() = %+v, want %+v", pkgs, want)
+	}
+}
+
+func TestParseRPMDatabase(t *testing.T) {
+	db := bdbHash(
+		rpmHeader(map[uint32]string{rpmTagName: "bash", rpmTagVersion: "5.1.8", rpmTagRelease: "2.el9", rpmTagArch: "x86_64"}, 0),
+		rpmHeader(map[uint32]string{rpmTagName: "gpg-pubkey", rpmTagVersion: "fd431d51", rpmTagRelease: "4ae0493b"}, 0),
+		rpmHeader(map[uint32]string{rpmTagName: "openssl", rpmTagVersion: "3.0.1", rpmTagRelease: "5.el9", rpmTagArch: "x86_64", 1004: strings.Repeat("Utilities from the general purpose cryptography library. ", 20)}, 1),
+	)
+	pkgs, err := parseRPMDatabase(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Package{
+		{Type: "rpm", Name: "bash", Version: "5.1.8-2.el9", Arch: "x86_64"},
+		{Type: "rpm", Name: "openssl", Version: "1:3.0.1-5.el9", Arch: "x86_64"},
+	}
+	if !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("parseRPMDatabase() = %+v, want %+v", pkgs, want)
+	}
+
+	if _, err := parseRPMDatabase(make([]byte, 4096)); err == nil {
+		t.Error("expected error parsing a database without the hash magic")
+	}
+
+	// The off-page item of the first value holds its length 8 bytes in.
+	huge := bdbHash(rpmHeader(map[uint32]string{rpmTagName: "bash"}, 0))
+	off := binary.LittleEndian.Uint16(huge[512+bdbPageHeaderSize+2:])
+	binary.LittleEndian.PutUint32(huge[512+int(off)+8:], 0xffffffff)
+	if _, err := parseRPMDatabase(huge); err == nil || !strings.Contains(err.Error(), "larger than the database") {
+		t.Errorf("parseRPMDatabase() = %v, want an error for an overflow value larger than the database", err)
+	}
+}
+
+func layer(t *testing.T, files map[string]string) v1.Layer {
+	t.Helper()
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return static.NewLayer(b.Bytes(), types.DockerUncompressedLayer)
+}
+
+func TestScanAndSPDX(t *testing.T) {
+	img, err := mutate.AppendLayers(empty.Image,
+		layer(t, map[string]string{
+			"etc/os-release":      "NAME=\"Debian GNU/Linux\"\nID=debian\n",
+			"var/lib/dpkg/status": dpkgStatusFile,
+		}),
+		layer(t, map[string]string{
+			"./var/lib/dpkg/status.d/base-files":         "Package: base-files\nVersion: 11.1\nArchitecture: amd64\n",
+			"./var/lib/dpkg/status.d/base-files.md5sums": "d41d8cd98f00b204e9800998ecf8427e  etc/issue\n",
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := Scan(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Distro != "debian" {
+		t.Errorf("Distro = %q, want debian", inv.Distro)
+	}
+	if len(inv.Packages) != 3 {
+		t.Fatalf("found %d packages, want 3: %+v", len(inv.Packages), inv.Packages)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SPDX("example.com/app", digest, inv, time.Unix(1640000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.SPDXVersion != "SPDX-2.3" {
+		t.Errorf("spdxVersion = %q", doc.SPDXVersion)
+	}
+	image := doc.Packages[0]
+	if image.SPDXID != spdxImageID || image.Checksums[0].ChecksumValue != digest.Hex {
+		t.Errorf("image package = %+v, want checksum %s", image, digest.Hex)
+	}
+	if len(doc.Packages) != 4 || len(doc.Relationships) != 4 {
+		t.Errorf("got %d packages and %d relationships, want 4 of each", len(doc.Packages), len(doc.Relationships))
+	}
+
+	purls := map[string]bool{}
+	for _, p := range doc.Packages[1:] {
+		purls[p.ExternalRefs[0].ReferenceLocator] = true
+	}
+	for _, want := range []string{
+		"pkg:deb/debian/libc6@2.31-13?arch=amd64",
+		"pkg:deb/debian/zlib1g@1:1.2.11.dfsg-2?arch=amd64",
+		"pkg:deb/debian/base-files@11.1?arch=amd64",
+	} {
+		if !purls[want] {
+			t.Errorf("missing purl %s in %v", want, purls)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// spdxDocument is the subset of an SPDX 2.3 JSON document that SPDX writes.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const spdxImageID = "SPDXRef-Image"
+
+// SPDX returns an SPDX 2.3 JSON document describing the image repo@digest as
+// containing the packages of inv.
+func SPDX(repo string, digest v1.Hash, inv *Inventory, created time.Time) ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion: "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        repo + "@" + digest.String(),
+		// The namespace must be unique to this document's contents.
+		DocumentNamespace: fmt.Sprintf("https://sigstore.dev/cosign/spdx/%s/%s-%s-%d", url.PathEscape(repo), digest.Algorithm, digest.Hex, created.Unix()),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: cosign"},
+		},
+		Packages: []spdxPackage{{
+			Name:                  repo,
+			SPDXID:                spdxImageID,
+			VersionInfo:           digest.String(),
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: "CONTAINER",
+			Checksums: []spdxChecksum{{
+				Algorithm:     strings.ToUpper(digest.Algorithm),
+				ChecksumValue: digest.Hex,
+			}},
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxImageID,
+		}},
+	}
+
+	for i, pkg := range inv.Packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name:             pkg.Name,
+			SPDXID:           id,
+			VersionInfo:      pkg.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  purl(pkg, inv.Distro),
+			}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      spdxImageID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// purl returns the package URL of pkg, installed on the given distribution.
+func purl(pkg Package, distro string) string {
+	var b strings.Builder
+	b.WriteString("pkg:" + pkg.Type + "/")
+	if distro != "" {
+		b.WriteString(url.PathEscape(distro) + "/")
+	}
+	b.WriteString(url.PathEscape(pkg.Name))
+	if pkg.Version != "" {
+		b.WriteString("@" + url.PathEscape(pkg.Version))
+	}
+	if pkg.Arch != "" {
+		b.WriteString("?arch=" + url.QueryEscape(pkg.Arch))
+	}
+	return b.String()
+}
@@ -60,6 +60,7 @@ func (sg *SecureGet) Do(ctx context.Context) error {
 	co := &cosign.CheckOpts{
 		ClaimVerifier:      cosign.SimpleClaimVerifier,
 		RegistryClientOpts: []ociremote.Option{ociremote.WithRemoteOptions(opts...)},
+		VerifySCT:          true,
 	}
 	if _, ok := ref.(name.Tag); ok {
 		if sg.KeyRef == "" && !options.EnableExperimental() {
@@ -90,7 +91,7 @@ func (sg *SecureGet) Do(ctx context.Context) error {
 			return err
 		}
 		verify.PrintVerificationHeader(sg.ImageRef, co, bundleVerified)
-		verify.PrintVerification(sg.ImageRef, sp, "text")
+		verify.PrintVerification(sg.ImageRef, sp, "text", co.RootCerts)
 	}
 
 	// TODO(mattmoor): Depending on what this is, use the higher-level stuff.
@@ -31,6 +31,7 @@ import (
 	"github.com/sigstore/cosign/pkg/cosign/git/gitlab"
 	"github.com/sigstore/cosign/pkg/cosign/kubernetes"
 	"github.com/sigstore/cosign/pkg/cosign/pkcs11key"
+	"github.com/sigstore/cosign/pkg/signature/kms/gcpsm"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/kms"
@@ -57,12 +58,7 @@ func VerifierForKeyRef(ctx context.Context, keyRef string, hashAlgorithm crypto.
 	}
 
 	// PEM encoded file.
-	pubKey, err := cryptoutils.UnmarshalPEMToPublicKey(raw)
-	if err != nil {
-		return nil, errors.Wrap(err, "pem to public key")
-	}
-
-	return signature.LoadVerifier(pubKey, hashAlgorithm)
+	return cosign.LoadPublicKeyVerifier(raw, hashAlgorithm)
 }
 
 func loadKey(keyPath string, pf cosign.PassFunc) (signature.SignerVerifier, error) {
@@ -79,11 +75,7 @@ func loadKey(keyPath string, pf cosign.PassFunc) (signature.SignerVerifier, erro
 
 func loadPublicKey(raw []byte, hashAlgorithm crypto.Hash) (signature.Verifier, error) {
 	// PEM encoded file.
-	ed, err := cosign.PemToECDSAKey(raw)
-	if err != nil {
-		return nil, errors.Wrap(err, "pem to ecdsa")
-	}
-	return signature.LoadECDSAVerifier(ed, hashAlgorithm)
+	return cosign.LoadPublicKeyVerifier(raw, hashAlgorithm)
 }
 
 func SignerFromKeyRef(ctx context.Context, keyRef string, pf cosign.PassFunc) (signature.Signer, error) {
@@ -141,6 +133,8 @@ func SignerVerifierFromKeyRef(ctx context.Context, keyRef string, pf cosign.Pass
 		}
 
 		return cosign.LoadPrivateKey([]byte(pk), []byte(pass))
+	case strings.HasPrefix(keyRef, gcpsm.ReferenceScheme):
+		return gcpsm.SignerVerifier(ctx, keyRef)
 	}
 
 	sv, err := kms.Get(ctx, keyRef, crypto.SHA256)
@@ -205,6 +199,16 @@ func PublicKeyFromKeyRefWithHashAlgo(ctx context.Context, keyRef string, hashAlg
 		if len(pubKey) > 0 {
 			return loadPublicKey([]byte(pubKey), hashAlgorithm)
 		}
+	} else if strings.HasPrefix(keyRef, gcpsm.ReferenceScheme) {
+		sv, err := gcpsm.SignerVerifier(ctx, keyRef)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := sv.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		return signature.LoadVerifier(pub, hashAlgorithm)
 	}
 
 	return VerifierForKeyRef(ctx, keyRef, hashAlgorithm)
@@ -0,0 +1,139 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcpsm loads signing keys stored in Google Cloud Secret Manager.
+package gcpsm
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/secretmanager/v1"
+
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+const (
+	// ReferenceScheme prefixes references to keys in Secret Manager, which have the form
+	// secretmanager://projects/P/secrets/S/versions/V.
+	ReferenceScheme = "secretmanager://"
+
+	// PasswordLabel is the label on a secret that names another secret, in the same
+	// project, whose latest version holds the password of an encrypted cosign private
+	// key. Secrets without it hold an unencrypted PEM key.
+	PasswordLabel = "cosign-password-secret"
+)
+
+var (
+	versionRE = regexp.MustCompile(`^(projects/[^/]+)/secrets/[^/]+/versions/[^/]+$`)
+	// secretIDRE matches a secret ID, which label values are always a subset of.
+	secretIDRE = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// parseRef returns the secret version resource name referenced by ref.
+func parseRef(ref string) (string, error) {
+	name := strings.TrimPrefix(ref, ReferenceScheme)
+	if name == ref || !versionRE.MatchString(name) {
+		return "", fmt.Errorf("invalid secret manager reference %q, use %sprojects/P/secrets/S/versions/V", ref, ReferenceScheme)
+	}
+	return name, nil
+}
+
+// SignerVerifier returns a SignerVerifier for the private key stored in the Secret
+// Manager secret version referenced by ref, decrypting it with the password named
+// by the secret's PasswordLabel, if it has one.
+func SignerVerifier(ctx context.Context, ref string) (signature.SignerVerifier, error) {
+	name, err := parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := secretmanager.NewService(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating secret manager client")
+	}
+
+	key, err := accessVersion(ctx, svc, name)
+	if err != nil {
+		return nil, err
+	}
+
+	secretName := name[:strings.Index(name, "/versions/")]
+	secret, err := svc.Projects.Secrets.Get(secretName).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting secret %s", secretName)
+	}
+	if passwordSecret, ok := secret.Labels[PasswordLabel]; ok {
+		if !secretIDRE.MatchString(passwordSecret) {
+			return nil, fmt.Errorf("invalid %s label %q, use the ID of a secret in the same project", PasswordLabel, passwordSecret)
+		}
+		project := versionRE.FindStringSubmatch(name)[1]
+		pass, err := accessVersion(ctx, svc, fmt.Sprintf("%s/secrets/%s/versions/latest", project, passwordSecret))
+		if err != nil {
+			return nil, err
+		}
+		return cosign.LoadPrivateKey(key, pass)
+	}
+	return loadPEMKey(key)
+}
+
+func accessVersion(ctx context.Context, svc *secretmanager.Service, name string) ([]byte, error) {
+	resp, err := svc.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrapf(err, "accessing secret version %s", name)
+	}
+	if resp.Payload == nil {
+		return nil, fmt.Errorf("secret version %s has no payload", name)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding secret version %s", name)
+	}
+	return data, nil
+}
+
+// loadPEMKey loads an unencrypted PEM-encoded ECDSA private key, in either SEC 1
+// or PKCS #8 form.
+func loadPEMKey(key []byte) (signature.SignerVerifier, error) {
+	p, _ := pem.Decode(key)
+	if p == nil {
+		return nil, errors.New("invalid pem block")
+	}
+	var pk interface{}
+	var err error
+	switch p.Type {
+	case "EC PRIVATE KEY":
+		pk, err = x509.ParseECPrivateKey(p.Bytes)
+	case "PRIVATE KEY":
+		pk, err = x509.ParsePKCS8PrivateKey(p.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem type: %s", p.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing private key")
+	}
+	ecKey, ok := pk.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T, only ECDSA keys are supported", pk)
+	}
+	return signature.LoadECDSASignerVerifier(ecKey, crypto.SHA256)
+}
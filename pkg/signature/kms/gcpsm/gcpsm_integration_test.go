@@ -0,0 +1,63 @@
+//
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+// +build integration
+
+package gcpsm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testRef returns the secretmanager:// reference of a secret version holding a key,
+// readable with the application default credentials, or skips the test.
+func testRef(t *testing.T) string {
+	ref := os.Getenv("COSIGN_TEST_GCPSM_KEY")
+	if ref == "" {
+		t.Skip("COSIGN_TEST_GCPSM_KEY is not set")
+	}
+	return ref
+}
+
+func TestSignerVerifierIntegration(t *testing.T) {
+	sv, err := SignerVerifier(context.Background(), testRef(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("payload")
+	sig, err := sv.SignMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignerVerifierMissingVersionIntegration(t *testing.T) {
+	name, err := parseRef(testRef(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := ReferenceScheme + name[:strings.LastIndex(name, "/")] + "/versions/999999"
+	if _, err := SignerVerifier(context.Background(), missing); err == nil {
+		t.Errorf("expected error loading %s", missing)
+	}
+}
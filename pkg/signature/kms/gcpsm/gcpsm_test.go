@@ -0,0 +1,77 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpsm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	for ref, valid := range map[string]bool{
+		"secretmanager://projects/p/secrets/s/versions/1":      true,
+		"secretmanager://projects/p/secrets/s/versions/latest": true,
+		"secretmanager://projects/p/secrets/s":                 false,
+		"projects/p/secrets/s/versions/1":                      false,
+		"gcpkms://projects/p/secrets/s/versions/1":             false,
+	} {
+		name, err := parseRef(ref)
+		if valid && (err != nil || name != ref[len(ReferenceScheme):]) {
+			t.Errorf("parseRef(%q) = %q, %v", ref, name, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("parseRef(%q) succeeded, want error", ref)
+		}
+	}
+}
+
+func TestLoadPEMKey(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []*pem.Block{
+		{Type: "EC PRIVATE KEY", Bytes: sec1},
+		{Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		sv, err := loadPEMKey(pem.EncodeToMemory(b))
+		if err != nil {
+			t.Fatalf("loading %s: %v", b.Type, err)
+		}
+		pub, err := sv.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !priv.PublicKey.Equal(pub) {
+			t.Errorf("loading %s: public key mismatch", b.Type)
+		}
+	}
+
+	if _, err := loadPEMKey([]byte("not a key")); err == nil {
+		t.Error("expected error loading a non-PEM key")
+	}
+}
@@ -20,6 +20,8 @@ const (
 	SyftMediaType          = "application/vnd.syft+json"
 	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
 	SPDXMediaType          = "text/spdx"
+	SPDXJSONMediaType      = "application/spdx+json"
 	WasmLayerMediaType     = "application/vnd.wasm.content.layer.v1+wasm"
 	WasmConfigMediaType    = "application/vnd.wasm.config.v1+json"
+	HelmConfigMediaType    = "application/vnd.cncf.helm.config.v1+json"
 )
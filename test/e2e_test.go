@@ -22,7 +22,9 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -46,14 +48,17 @@ import (
 	"github.com/sigstore/cosign/cmd/cosign/cli"
 	"github.com/sigstore/cosign/cmd/cosign/cli/attach"
 	"github.com/sigstore/cosign/cmd/cosign/cli/attest"
+	"github.com/sigstore/cosign/cmd/cosign/cli/copy"
 	"github.com/sigstore/cosign/cmd/cosign/cli/download"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/publickey"
+	"github.com/sigstore/cosign/cmd/cosign/cli/rekor"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/sigstore/cosign/cmd/cosign/cli/upload"
 	cliverify "github.com/sigstore/cosign/cmd/cosign/cli/verify"
 	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/cosign/bundle"
 	"github.com/sigstore/cosign/pkg/cosign/kubernetes"
 	cremote "github.com/sigstore/cosign/pkg/cosign/remote"
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
@@ -124,7 +129,7 @@ func TestSignVerify(t *testing.T) {
 
 	// Now sign the image
 	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// Now verify and download should work!
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
@@ -135,7 +140,7 @@ func TestSignVerify(t *testing.T) {
 
 	// Sign the image with an annotation
 	annotations := map[string]interface{}{"foo": "bar"}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, annotations, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, annotations, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// It should match this time.
 	must(verify(pubKeyPath, imgName, true, map[string]interface{}{"foo": "bar"}, ""), t)
@@ -159,7 +164,7 @@ func TestSignVerifyClean(t *testing.T) {
 
 	// Now sign the image
 	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// Now verify and download should work!
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
@@ -188,7 +193,7 @@ func TestImportSignVerifyClean(t *testing.T) {
 
 	// Now sign the image
 	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// Now verify and download should work!
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
@@ -232,7 +237,7 @@ func TestAttestVerify(t *testing.T) {
 	// Now attest the image
 	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
 	must(attest.AttestCmd(ctx, ko, options.RegistryOptions{}, imgName, "", false, slsaAttestationPath, false,
-		"custom", false, ftime.Duration(30*time.Second)), t)
+		"custom", false, ftime.Duration(30*time.Second), 0, ""), t)
 
 	// Use cue to verify attestation
 	policyPath := filepath.Join(td, "policy.cue")
@@ -280,7 +285,7 @@ func TestBundle(t *testing.T) {
 	}
 
 	// Sign the image
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 	// Make sure verify works
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
 
@@ -310,14 +315,14 @@ func TestDuplicateSign(t *testing.T) {
 
 	// Now sign the image
 	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// Now verify and download should work!
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
 	must(download.SignatureCmd(ctx, options.RegistryOptions{}, imgName), t)
 
 	// Signing again should work just fine...
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	se, err := ociremote.SignedEntity(ref, ociremote.WithRemoteOptions(registryClientOpts(ctx)...))
 	must(err, t)
@@ -409,14 +414,14 @@ func TestMultipleSignatures(t *testing.T) {
 
 	// Now sign the image with one key
 	ko := sign.KeyOpts{KeyRef: priv1, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 	// Now verify should work with that one, but not the other
 	must(verify(pub1, imgName, true, nil, ""), t)
 	mustErr(verify(pub2, imgName, true, nil, ""), t)
 
 	// Now sign with the other key too
 	ko.KeyRef = priv2
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// Now verify should work with both
 	must(verify(pub1, imgName, true, nil, ""), t)
@@ -457,7 +462,7 @@ func TestSignBlob(t *testing.T) {
 		KeyRef:   privKeyPath1,
 		PassFunc: passFunc,
 	}
-	sig, err := sign.SignBlobCmd(ctx, ko, options.RegistryOptions{}, bp, true, "", "", time.Duration(30*time.Second))
+	sig, err := sign.SignBlobCmd(ctx, ko, options.RegistryOptions{}, bp, true, "", "", "", "", time.Duration(30*time.Second))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -466,6 +471,173 @@ func TestSignBlob(t *testing.T) {
 	mustErr(cliverify.VerifyBlobCmd(ctx, ko2, "", string(sig), bp), t)
 }
 
+func TestCopyAll(t *testing.T) {
+	repo, stop := reg(t)
+	defer stop()
+	td := t.TempDir()
+
+	srcImg := path.Join(repo, "cosign-e2e-copy-src")
+	_, _, cleanup := mkimage(t, srcImg)
+	defer cleanup()
+	dstImg := path.Join(repo, "cosign-e2e-copy-dst")
+
+	_, privKeyPath, pubKeyPath := keypair(t, td)
+	ctx := context.Background()
+
+	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{srcImg}, "", true, "", "", "", "", false, false, "", ""), t)
+
+	predicatePath := filepath.Join(td, "attestation.slsa.json")
+	if err := os.WriteFile(predicatePath, []byte(`{ "builder": { "id": "2" }, "recipe": {} }`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	must(attest.AttestCmd(ctx, ko, options.RegistryOptions{}, srcImg, "", false, predicatePath, false,
+		"custom", false, ftime.Duration(30*time.Second), 0, ""), t)
+
+	// The image has no SBOM, which --all skips.
+	must(copy.CopyCmd(ctx, options.RegistryOptions{}, srcImg, dstImg, false, true, false), t)
+	must(verify(pubKeyPath, dstImg, true, nil, ""), t)
+	verifyAttestation := cliverify.VerifyAttestationCommand{KeyRef: pubKeyPath, PredicateType: "custom"}
+	must(verifyAttestation.Exec(ctx, []string{dstImg}), t)
+
+	mustErr(copy.CopyCmd(ctx, options.RegistryOptions{}, srcImg, dstImg, true, true, false), t)
+}
+
+func TestSignToOCILayout(t *testing.T) {
+	repo, stop := reg(t)
+	defer stop()
+	td := t.TempDir()
+
+	imgName := path.Join(repo, "cosign-e2e-layout")
+	_, _, cleanup := mkimage(t, imgName)
+	defer cleanup()
+
+	_, privKeyPath, pubKeyPath := keypair(t, td)
+	ctx := context.Background()
+
+	// Sign into a layout; nothing should be pushed to the registry.
+	layoutDir := t.TempDir()
+	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", layoutDir), t)
+	mustErr(verify(pubKeyPath, imgName, true, nil, ""), t)
+	must(verifyLocal(pubKeyPath, layoutDir, true, nil, ""), t)
+
+	// Pushing the layout makes the signature available in the registry.
+	imgName2 := path.Join(repo, "cosign-e2e-layout-2")
+	must(cli.LoadCmd(ctx, options.LoadOptions{Directory: layoutDir}, imgName2), t)
+	must(verify(pubKeyPath, imgName2, true, nil, ""), t)
+}
+
+func TestVerifyBlobSigstoreBundle(t *testing.T) {
+	td1 := t.TempDir()
+	td2 := t.TempDir()
+	blob := []byte("someblob")
+	bp := filepath.Join(td1, "blob")
+	if err := os.WriteFile(bp, blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, privKeyPath, pubKeyPath1 := keypair(t, td1)
+	_, _, pubKeyPath2 := keypair(t, td2)
+
+	ctx := context.Background()
+	ko := sign.KeyOpts{
+		KeyRef:   privKeyPath,
+		PassFunc: passFunc,
+	}
+	b64sig, err := sign.SignBlobCmd(ctx, ko, options.RegistryOptions{}, bp, true, "", "", "", "", time.Duration(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(b64sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload the signature and write a bundle for it the way the other Sigstore clients do.
+	rClient, err := rekor.NewClient(rekorURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := cosign.TLogUpload(ctx, rClient, sig, blob, keys.PublicBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := base64.StdEncoding.DecodeString(entry.Body.(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID, err := hex.DecodeString(*entry.LogID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(blob)
+	sb := bundle.SigstoreBundle{
+		MediaType: "application/vnd.dev.sigstore.bundle+json;version=0.1",
+		VerificationMaterial: bundle.SigstoreVerificationMaterial{
+			TlogEntries: []bundle.SigstoreTlogEntry{{
+				LogIndex:          bundle.Int64String(*entry.LogIndex),
+				LogID:             bundle.SigstoreLogID{KeyID: logID},
+				KindVersion:       bundle.SigstoreKindVersion{Kind: "hashedrekord", Version: "0.0.1"},
+				IntegratedTime:    bundle.Int64String(*entry.IntegratedTime),
+				InclusionPromise:  &bundle.SigstoreInclusionPromise{SignedEntryTimestamp: entry.Verification.SignedEntryTimestamp},
+				CanonicalizedBody: body,
+			}},
+		},
+		MessageSignature: &bundle.SigstoreMessageSignature{
+			MessageDigest: bundle.SigstoreMessageDigest{Algorithm: "SHA2_256", Digest: digest[:]},
+			Signature:     sig,
+		},
+	}
+	b, err := json.Marshal(sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundlePath := filepath.Join(td1, "blob.sigstore")
+	if err := os.WriteFile(bundlePath, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	must(cliverify.VerifyBlobCmd(ctx, sign.KeyOpts{KeyRef: pubKeyPath1, BundlePath: bundlePath}, "", "", bp), t)
+	mustErr(cliverify.VerifyBlobCmd(ctx, sign.KeyOpts{KeyRef: pubKeyPath2, BundlePath: bundlePath}, "", "", bp), t)
+
+	otherBlob := filepath.Join(td1, "other")
+	if err := os.WriteFile(otherBlob, []byte("otherblob"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustErr(cliverify.VerifyBlobCmd(ctx, sign.KeyOpts{KeyRef: pubKeyPath1, BundlePath: bundlePath}, "", "", otherBlob), t)
+}
+
+func TestSignBlobBundle(t *testing.T) {
+	td := t.TempDir()
+	bp := filepath.Join(td, "blob")
+	if err := os.WriteFile(bp, []byte("someblob"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, privKeyPath, pubKeyPath := keypair(t, td)
+	ctx := context.Background()
+
+	// Upload to the tlog so the bundle carries an entry to verify offline.
+	defer setenv(t, options.ExperimentalEnv, "1")()
+	bundlePath := filepath.Join(td, "blob.sigstore")
+	ko := sign.KeyOpts{
+		KeyRef:     privKeyPath,
+		PassFunc:   passFunc,
+		RekorURL:   rekorURL,
+		BundlePath: bundlePath,
+	}
+	if _, err := sign.SignBlobCmd(ctx, ko, options.RegistryOptions{}, bp, true, "", "", "", "", time.Duration(30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	must(cliverify.VerifyBlobCmd(ctx, sign.KeyOpts{KeyRef: pubKeyPath, BundlePath: bundlePath}, "", "", bp), t)
+
+	// A key produces no certificate to write.
+	ko.BundlePath = ""
+	if _, err := sign.SignBlobCmd(ctx, ko, options.RegistryOptions{}, bp, true, "", "", "", filepath.Join(td, "blob.pem"), time.Duration(30*time.Second)); err == nil {
+		t.Error("expected error writing a certificate when signing with a key")
+	}
+}
+
 func TestGenerate(t *testing.T) {
 	repo, stop := reg(t)
 	defer stop()
@@ -750,7 +922,7 @@ func TestSaveLoad(t *testing.T) {
 			ctx := context.Background()
 			// Now sign the image and verify it
 			ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
-			must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+			must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 			must(verify(pubKeyPath, imgName, true, nil, ""), t)
 
 			// save the image to a temp dir
@@ -783,7 +955,7 @@ func TestSaveLoadAttestation(t *testing.T) {
 	ctx := context.Background()
 	// Now sign the image and verify it
 	ko := sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
 
 	// now, append an attestation to the image
@@ -796,7 +968,7 @@ func TestSaveLoadAttestation(t *testing.T) {
 	// Now attest the image
 	ko = sign.KeyOpts{KeyRef: privKeyPath, PassFunc: passFunc}
 	must(attest.AttestCmd(ctx, ko, options.RegistryOptions{}, imgName, "", false, slsaAttestationPath, false,
-		"custom", false, ftime.Duration(30*time.Second)), t)
+		"custom", false, ftime.Duration(30*time.Second), 0, ""), t)
 
 	// save the image to a temp dir
 	imageDir := t.TempDir()
@@ -834,7 +1006,7 @@ func TestAttachSBOM(t *testing.T) {
 	defer cleanup()
 
 	out := bytes.Buffer{}
-	_, err := download.SBOMCmd(ctx, options.RegistryOptions{}, img.Name(), &out)
+	_, err := download.SBOMCmd(ctx, options.RegistryOptions{}, options.SBOMFormatAuto, img.Name(), &out)
 	if err == nil {
 		t.Fatal("Expected error")
 	}
@@ -844,7 +1016,7 @@ func TestAttachSBOM(t *testing.T) {
 	// Upload it!
 	must(attach.SBOMCmd(ctx, options.RegistryOptions{}, "./testdata/bom-go-mod.spdx", "spdx", imgName), t)
 
-	sboms, err := download.SBOMCmd(ctx, options.RegistryOptions{}, imgName, &out)
+	sboms, err := download.SBOMCmd(ctx, options.RegistryOptions{}, options.SBOMFormatAuto, imgName, &out)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -872,7 +1044,7 @@ func TestAttachSBOM(t *testing.T) {
 
 	// Now sign the sbom with one key
 	ko1 := sign.KeyOpts{KeyRef: privKeyPath1, PassFunc: passFunc}
-	must(sign.SignCmd(ctx, ko1, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, "sbom"), t)
+	must(sign.SignCmd(ctx, ko1, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "sbom", ""), t)
 
 	// Now verify should work with that one, but not the other
 	must(verify(pubKeyPath1, imgName, true, nil, "sbom"), t)
@@ -910,7 +1082,7 @@ func TestTlog(t *testing.T) {
 		PassFunc: passFunc,
 		RekorURL: rekorURL,
 	}
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 
 	// Now verify should work!
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
@@ -922,7 +1094,7 @@ func TestTlog(t *testing.T) {
 	mustErr(verify(pubKeyPath, imgName, true, nil, ""), t)
 
 	// Sign again with the tlog env var on
-	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", false, false, ""), t)
+	must(sign.SignCmd(ctx, ko, options.RegistryOptions{}, nil, []string{imgName}, "", true, "", "", "", "", false, false, "", ""), t)
 	// And now verify works!
 	must(verify(pubKeyPath, imgName, true, nil, ""), t)
 }